@@ -23,16 +23,37 @@ package main
 import (
 	"context"
 	"log"
+	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	_ "github.com/juanjerrah/go_auth_api/docs" // swagger docs gerado automaticamente
 	"github.com/juanjerrah/go_auth_api/internal/config"
 	"github.com/juanjerrah/go_auth_api/internal/delivery/http/handlers"
+	"github.com/juanjerrah/go_auth_api/internal/domain/audit"
 	"github.com/juanjerrah/go_auth_api/internal/domain/auth"
+	"github.com/juanjerrah/go_auth_api/internal/domain/authz"
+	"github.com/juanjerrah/go_auth_api/internal/domain/device"
+	"github.com/juanjerrah/go_auth_api/internal/domain/group"
+	"github.com/juanjerrah/go_auth_api/internal/domain/loginalert"
+	"github.com/juanjerrah/go_auth_api/internal/domain/magiclink"
+	"github.com/juanjerrah/go_auth_api/internal/domain/mfa"
+	"github.com/juanjerrah/go_auth_api/internal/domain/oidc"
+	"github.com/juanjerrah/go_auth_api/internal/domain/organization"
+	"github.com/juanjerrah/go_auth_api/internal/domain/otp"
+	"github.com/juanjerrah/go_auth_api/internal/domain/policy"
+	"github.com/juanjerrah/go_auth_api/internal/domain/relation"
+	"github.com/juanjerrah/go_auth_api/internal/domain/risk"
+	"github.com/juanjerrah/go_auth_api/internal/domain/saml"
+	"github.com/juanjerrah/go_auth_api/internal/domain/scim"
 	"github.com/juanjerrah/go_auth_api/internal/domain/user"
+	"github.com/juanjerrah/go_auth_api/internal/domain/webauthn"
+	"github.com/juanjerrah/go_auth_api/internal/infrastructure/ldap"
+	"github.com/juanjerrah/go_auth_api/internal/infrastructure/mailer"
 	"github.com/juanjerrah/go_auth_api/internal/infrastructure/mongodb"
 	"github.com/juanjerrah/go_auth_api/internal/infrastructure/redis"
+	"github.com/juanjerrah/go_auth_api/internal/infrastructure/sms"
 	"github.com/juanjerrah/go_auth_api/internal/utils"
 	"github.com/juanjerrah/go_auth_api/pkg/middleware"
 	swaggerFiles "github.com/swaggo/files"
@@ -66,23 +87,218 @@ func main() {
 	// Initialize Infrastructure
 	mongoDB := mongodb.NewMongoDB(mongoClient, cfg.MongoDB.Database)
 	userRepo := mongodb.NewUserRepository(mongoDB.Database)
-	tokenRepo := redis.NewTokenRepository(redisClient)
+	tokenRepo := redis.NewTokenRepository(redisClient, cfg.JWTSecret)
+	auditRepo := mongodb.NewAuditRepository(mongoDB.Database)
+	orgRepo := mongodb.NewOrganizationRepository(mongoDB.Database)
+	membershipRepo := mongodb.NewMembershipRepository(mongoDB.Database)
+	invitationRepo := mongodb.NewInvitationRepository(mongoDB.Database)
+	groupRepo := mongodb.NewGroupRepository(mongoDB.Database)
+	permissionCache := redis.NewPermissionCache(redisClient, cfg.PermissionCacheTTL)
+	policyRepo := mongodb.NewPolicyRepository(mongoDB.Database)
+	relationRepo := mongodb.NewRelationRepository(mongoDB.Database)
+	namespaceRepo := mongodb.NewNamespaceRepository(mongoDB.Database)
+	oidcStateRepo := redis.NewOIDCStateRepository(redisClient)
+	samlRequestRepo := redis.NewSAMLRequestRepository(redisClient)
+	magicLinkRepo := redis.NewMagicLinkRepository(redisClient)
+	loginAlertRepo := redis.NewLoginAlertRepository(redisClient)
+	riskRepo := redis.NewRiskRepository(redisClient)
+	webauthnChallengeRepo := redis.NewWebAuthnChallengeRepository(redisClient)
+	mfaChallengeRepo := redis.NewMFAChallengeRepository(redisClient)
+	otpRepo := redis.NewOTPRepository(redisClient)
+
+	mailService, err := mailer.NewMailer(&cfg.Mailer)
+	if err != nil {
+		log.Fatal(err)
+	}
+	smsSender, err := sms.NewSender(&cfg.SMS)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Políticas de autorização embutidas e de arquivos; as do banco são carregadas pelo serviço
+	staticPolicies, err := policy.DefaultPolicies()
+	if err != nil {
+		log.Fatal(err)
+	}
+	filePolicies, err := policy.LoadFiles(cfg.PolicyFiles...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	staticPolicies = append(staticPolicies, filePolicies...)
 
 	// Initialize utilities
 	passwordHasher := utils.NewBcryptPasswordHasher(12)
 	jwtManager := auth.NewJWTManager(cfg.JWTSecret, cfg.TokenExpiresIn)
 	mongoUtils := utils.NewMongoUtils()
+	tokenGenerator := utils.NewSecureTokenGenerator(32)
+
+	// O diretório LDAP é opcional; sem URL, apenas contas locais
+	var directory user.DirectoryAuthenticator
+	if cfg.LDAP.URL != "" {
+		directory = ldap.NewAuthenticator(&cfg.LDAP)
+	}
 
 	// Initialize Services
-	userService := user.NewService(userRepo, passwordHasher, mongoUtils)
-	authService := auth.NewAuthService(tokenRepo)
+	auditService := audit.NewService(auditRepo)
+	groupService := group.NewService(groupRepo, userRepo, mongoUtils, auditService, permissionCache)
+	if cfg.Session.LimitPolicy != "reject" && cfg.Session.LimitPolicy != "evict_oldest" {
+		log.Fatalf("invalid SESSION_LIMIT_POLICY %q: expected reject or evict_oldest", cfg.Session.LimitPolicy)
+	}
+	sessionPolicies := auth.SessionPolicies{
+		Default: auth.SessionPolicy{
+			IdleTimeout: cfg.Session.IdleTimeout,
+			MaxLifetime: cfg.Session.MaxLifetime,
+			MaxSessions: cfg.Session.MaxSessions,
+		},
+		Roles:         map[user.Role]auth.SessionPolicy{},
+		TouchInterval: cfg.Session.TouchInterval,
+		EvictOldest:   cfg.Session.LimitPolicy == "evict_oldest",
+	}
+	for role, limits := range cfg.Session.Roles {
+		sessionPolicies.Roles[user.Role(role)] = auth.SessionPolicy(limits)
+	}
+	authService := auth.NewAuthService(tokenRepo, permissionCache, groupService, sessionPolicies)
+	// Sessões gravadas com o token na chave passam a usar o ID da sessão; as
+	// que falharem continuam inacessíveis até expirar
+	if migrated, err := tokenRepo.MigrateSessionKeys(context.Background()); err != nil {
+		log.Printf("Warning: failed to migrate session keys: %v", err)
+	} else if migrated > 0 {
+		log.Printf("Migrated %d session keys", migrated)
+	}
+	if cfg.Session.JanitorInterval > 0 {
+		go auth.RunSessionJanitor(context.Background(), tokenRepo, cfg.Session.JanitorInterval)
+	}
+	userService := user.NewService(userRepo, passwordHasher, mongoUtils, auditService, authService, directory)
+	orgService := organization.NewService(orgRepo, membershipRepo, userRepo, mongoUtils, auditService, authService)
+	policyService := policy.NewService(policyRepo, staticPolicies, userRepo, groupService, mongoUtils, auditService)
+	relationService := relation.NewService(relationRepo, namespaceRepo, auditService)
+	scimBaseURL := cfg.AppBaseURL + "/api/scim/v2"
+	scimService := scim.NewService(userRepo, userService, groupRepo, groupService, passwordHasher, tokenGenerator, mongoUtils, authService, auditService, scimBaseURL)
+	oidcProviders := make([]oidc.ProviderConfig, 0, len(cfg.OIDC.Providers))
+	for _, p := range cfg.OIDC.Providers {
+		oidcProviders = append(oidcProviders, oidc.ProviderConfig(p))
+	}
+	oidcService := oidc.NewService(oidcProviders, cfg.AppBaseURL+"/api/auth/oidc", oidcStateRepo, userService, tokenGenerator, &http.Client{Timeout: 10 * time.Second}, cfg.OIDC.StateTTL)
+	samlProviders := make([]saml.ProviderConfig, 0, len(cfg.SAML.Providers))
+	for _, p := range cfg.SAML.Providers {
+		certificates, err := saml.LoadCertificates(p.CertificateFiles...)
+		if err != nil {
+			log.Fatal(err)
+		}
+		samlProviders = append(samlProviders, saml.ProviderConfig{
+			Name:                p.Name,
+			DisplayName:         p.DisplayName,
+			IdPEntityID:         p.IdPEntityID,
+			IdPSSOURL:           p.IdPSSOURL,
+			Certificates:        certificates,
+			EmailAttribute:      p.EmailAttribute,
+			NameAttribute:       p.NameAttribute,
+			DepartmentAttribute: p.DepartmentAttribute,
+			RoleAttribute:       p.RoleAttribute,
+			AdminValues:         p.AdminValues,
+			EmailDomains:        p.EmailDomains,
+			AllowIdPInitiated:   p.AllowIdPInitiated,
+		})
+	}
+	samlService := saml.NewService(samlProviders, cfg.AppBaseURL+"/api/auth/saml", samlRequestRepo, userService, tokenGenerator, cfg.SAML.RequestTTL)
+	magicLinkURL := cfg.MagicLink.URL
+	if magicLinkURL == "" {
+		magicLinkURL = cfg.AppBaseURL + "/api/auth/magic-link/consume"
+	}
+	magicLinkService := magiclink.NewService(
+		magicLinkRepo, userRepo, mailService, tokenGenerator, auditService,
+		cfg.JWTSecret, magicLinkURL, cfg.MagicLink.TTL,
+		cfg.MagicLink.RateLimit, cfg.MagicLink.RateWindow,
+	)
+	webauthnService := webauthn.NewService(webauthn.Config{
+		RPID:        cfg.WebAuthn.RPID,
+		RPName:      cfg.WebAuthn.RPName,
+		Origins:     cfg.WebAuthn.Origins,
+		Attestation: cfg.WebAuthn.Attestation,
+		Timeout:     cfg.WebAuthn.Timeout,
+	}, webauthnChallengeRepo, userRepo, tokenGenerator, auditService)
+	mfaService := mfa.NewService(mfaChallengeRepo, tokenGenerator, cfg.MFA.ChallengeTTL)
+	otpService := otp.NewService(
+		otpRepo, userRepo, mailService, smsSender, auditService,
+		cfg.JWTSecret, cfg.OTP.Length, cfg.OTP.TTL, cfg.OTP.MaxAttempts,
+		cfg.OTP.RateLimit, cfg.OTP.RateWindow,
+	)
+	deviceService := device.NewService(userRepo, tokenGenerator, auditService, cfg.JWTSecret, cfg.MFA.TrustedDeviceTTL, cfg.MFA.TrustedDeviceLimit)
+	loginAlertURL := cfg.LoginAlert.URL
+	if loginAlertURL == "" {
+		loginAlertURL = cfg.AppBaseURL + "/api/auth/login-alerts/report"
+	}
+	loginAlertService := loginalert.NewService(
+		loginAlertRepo, userService, authService, mailService, tokenGenerator, auditService,
+		loginAlertURL, cfg.LoginAlert.TTL, cfg.LoginAlert.ResetTTL, cfg.LoginAlert.HistoryRetention,
+	)
+	// Sinais de risco; os que dependem de arquivos ou horário são opcionais
+	riskSignals := []risk.Signal{
+		risk.NewFailureVelocitySignal(riskRepo, cfg.Risk.Weights.Failures, int64(cfg.Risk.FailureLimit), cfg.Risk.FailureWindow),
+		risk.NewDeviceSignal(loginAlertService, cfg.Risk.Weights.NewDevice),
+	}
+	if cfg.Risk.GeoIPFile != "" {
+		geoDatabase, err := risk.LoadGeoDatabase(cfg.Risk.GeoIPFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		riskSignals = append(riskSignals, risk.NewImpossibleTravelSignal(riskRepo, geoDatabase, cfg.Risk.Weights.ImpossibleTravel, cfg.Risk.MaxTravelSpeed, cfg.LoginAlert.HistoryRetention))
+	}
+	if len(cfg.Risk.IPBlocklistFiles) > 0 {
+		ipList, err := risk.LoadIPLists(cfg.Risk.IPBlocklistFiles...)
+		if err != nil {
+			log.Fatal(err)
+		}
+		riskSignals = append(riskSignals, risk.NewIPListSignal(ipList, cfg.Risk.Weights.BadIP))
+	}
+	if cfg.Risk.QuietHours != "" {
+		quietHours, err := risk.NewQuietHoursSignal(cfg.Risk.QuietHours, cfg.Risk.Timezone, cfg.Risk.Weights.QuietHours)
+		if err != nil {
+			log.Fatal(err)
+		}
+		riskSignals = append(riskSignals, quietHours)
+	}
+	riskService := risk.NewService(riskSignals, auditService, cfg.Risk.MFAThreshold, cfg.Risk.BlockThreshold)
+	authzService := authz.NewService(jwtManager, authService, userService, policyService, relationService, cfg.AuthzCacheTTL)
+	invitationService := organization.NewInvitationService(
+		invitationRepo, orgRepo, membershipRepo, userRepo, userService,
+		mailService, tokenGenerator, mongoUtils, auditService,
+		cfg.AppBaseURL, cfg.InvitationExpiresIn,
+	)
+
+	// Sessão por cookie para front-ends web; sem ela, apenas o cabeçalho Authorization
+	var sessionCookies *middleware.SessionCookies
+	if cfg.SessionCookie.Enabled {
+		sameSite := middleware.ParseSameSite(cfg.SessionCookie.SameSite)
+		if sameSite == http.SameSiteNoneMode && !cfg.SessionCookie.Secure {
+			log.Fatal("SESSION_COOKIE_SAMESITE=none requires SESSION_COOKIE_SECURE=true")
+		}
+		sessionCookies = middleware.NewSessionCookies(cfg.SessionCookie.Name, cfg.SessionCookie.Domain, cfg.SessionCookie.Secure, sameSite, cfg.JWTSecret)
+	}
 
 	// Initialize Gin
 	router := gin.Default()
+	router.Use(middleware.RequestInfoMiddleware())
 
 	// Initialize Handlers
-	authHandler := handlers.NewAuthHandler(userService, jwtManager, authService)
-	userHandler := handlers.NewUserHandler(userService)
+	authHandler := handlers.NewAuthHandler(userService, jwtManager, authService, auditService, orgService, mfaService, webauthnService, otpService, deviceService, loginAlertService, riskService, sessionCookies)
+	userHandler := handlers.NewUserHandler(userService, policyService)
+	auditHandler := handlers.NewAuditHandler(auditService)
+	adminHandler := handlers.NewAdminHandler(userService, jwtManager, authService, auditService, cfg.ImpersonationExpiresIn)
+	orgHandler := handlers.NewOrganizationHandler(orgService)
+	invitationHandler := handlers.NewInvitationHandler(invitationService)
+	groupHandler := handlers.NewGroupHandler(groupService)
+	policyHandler := handlers.NewPolicyHandler(policyService)
+	relationHandler := handlers.NewRelationHandler(relationService)
+	authzHandler := handlers.NewAuthzHandler(authzService)
+	scimHandler := handlers.NewSCIMHandler(scimService, scimBaseURL)
+	oidcHandler := handlers.NewOIDCHandler(oidcService, jwtManager, authService, auditService, loginAlertService, sessionCookies)
+	samlHandler := handlers.NewSAMLHandler(samlService, jwtManager, authService, auditService, loginAlertService, sessionCookies)
+	magicLinkHandler := handlers.NewMagicLinkHandler(magicLinkService, jwtManager, authService, auditService, mfaService, deviceService, loginAlertService, riskService, sessionCookies)
+	webauthnHandler := handlers.NewWebAuthnHandler(webauthnService, jwtManager, authService, auditService, loginAlertService, riskService, sessionCookies)
+	otpHandler := handlers.NewOTPHandler(otpService)
+	deviceHandler := handlers.NewDeviceHandler(deviceService)
+	loginAlertHandler := handlers.NewLoginAlertHandler(loginAlertService)
 
 	// Routes
 	api := router.Group("/api")
@@ -90,12 +306,177 @@ func main() {
 		// Auth routes
 		api.POST("/auth/login", authHandler.Login)
 		api.POST("/auth/register", authHandler.Register)
+		api.POST("/invitations/accept", invitationHandler.AcceptInvitation)
+
+		// Second factor routes (challenge opened by the login)
+		api.POST("/auth/mfa/webauthn/options", authHandler.WebAuthnMFAOptions)
+		api.POST("/auth/mfa/webauthn/verify", authHandler.WebAuthnMFAVerify)
+		api.POST("/auth/mfa/otp/send", authHandler.OTPMFASend)
+		api.POST("/auth/mfa/otp/verify", authHandler.OTPMFAVerify)
+
+		// Passwordless login routes (passkeys)
+		api.POST("/auth/webauthn/login/options", webauthnHandler.LoginOptions)
+		api.POST("/auth/webauthn/login/verify", webauthnHandler.Login)
+
+		// Passwordless login routes (single-use links sent by email)
+		api.POST("/auth/magic-link", magicLinkHandler.RequestLink)
+		api.GET("/auth/magic-link/consume", magicLinkHandler.ConfirmLink)
+		api.POST("/auth/magic-link/consume", magicLinkHandler.ConsumeLink)
+
+		// Unrecognized login reports ("this wasn't me" link) and password reset
+		api.GET("/auth/login-alerts/report", loginAlertHandler.ConfirmReport)
+		api.POST("/auth/login-alerts/report", loginAlertHandler.Report)
+		api.POST("/auth/password/reset", loginAlertHandler.ResetPassword)
+
+		// Federated login routes (OpenID Connect)
+		api.GET("/auth/oidc/providers", oidcHandler.ListProviders)
+		api.GET("/auth/oidc/:provider/login", oidcHandler.Login)
+		api.GET("/auth/oidc/:provider/callback", oidcHandler.Callback)
+
+		// Federated login routes (SAML 2.0 service provider)
+		api.GET("/auth/saml/providers", samlHandler.ListProviders)
+		api.GET("/auth/saml/:provider/metadata", samlHandler.Metadata)
+		api.GET("/auth/saml/:provider/login", samlHandler.Login)
+		api.POST("/auth/saml/:provider/acs", samlHandler.AssertionConsumerService)
+
+		// SCIM 2.0 provisioning routes, authenticated by provisioning tokens
+		scimRoutes := api.Group("/scim/v2", middleware.SCIMAuthMiddleware(cfg.SCIMTokens))
+		{
+			scimRoutes.GET("/ServiceProviderConfig", scimHandler.ServiceProviderConfig)
+			scimRoutes.GET("/ResourceTypes", scimHandler.ListResourceTypes)
+			scimRoutes.GET("/ResourceTypes/:id", scimHandler.GetResourceType)
+			scimRoutes.GET("/Schemas", scimHandler.ListSchemas)
+			scimRoutes.GET("/Schemas/:id", scimHandler.GetSchema)
+			scimRoutes.POST("/Users", scimHandler.CreateUser)
+			scimRoutes.GET("/Users", scimHandler.ListUsers)
+			scimRoutes.GET("/Users/:id", scimHandler.GetUser)
+			scimRoutes.PUT("/Users/:id", scimHandler.ReplaceUser)
+			scimRoutes.PATCH("/Users/:id", scimHandler.PatchUser)
+			scimRoutes.DELETE("/Users/:id", scimHandler.DeleteUser)
+			scimRoutes.POST("/Groups", scimHandler.CreateGroup)
+			scimRoutes.GET("/Groups", scimHandler.ListGroups)
+			scimRoutes.GET("/Groups/:id", scimHandler.GetGroup)
+			scimRoutes.PUT("/Groups/:id", scimHandler.ReplaceGroup)
+			scimRoutes.PATCH("/Groups/:id", scimHandler.PatchGroup)
+			scimRoutes.DELETE("/Groups/:id", scimHandler.DeleteGroup)
+		}
 
 		// Protected routes
-		protected := api.Group("", middleware.AuthMiddleware(jwtManager, authService))
+		protected := api.Group("", middleware.AuthMiddleware(jwtManager, authService, sessionCookies))
 		{
-			// User routes
-			protected.PUT("/users/:id/password", userHandler.ChangePassword)
+			// Auth routes (cookie sessions pass the CSRF check in AuthMiddleware)
+			protected.POST("/auth/refresh", authHandler.RefreshToken)
+			protected.POST("/auth/logout", authHandler.Logout)
+			protected.POST("/auth/logout-all", middleware.DenyImpersonation(), authHandler.LogoutAll)
+			protected.POST("/auth/impersonation/stop", authHandler.StopImpersonation)
+			protected.POST("/auth/switch-organization", authHandler.SwitchOrganization)
+			protected.POST("/auth/reauthenticate/options", middleware.DenyImpersonation(), authHandler.ReauthenticationOptions)
+			protected.POST("/auth/reauthenticate", middleware.DenyImpersonation(), authHandler.Reauthenticate)
+
+			// User routes (sensitive changes require a recent authentication)
+			recentAuth := middleware.RequireRecentAuth(cfg.ReauthenticationMaxAge)
+			protected.PUT("/users/:id/password", middleware.DenyImpersonation(), recentAuth, userHandler.ChangePassword)
+			protected.PUT("/users/:id", middleware.DenyImpersonation(), recentAuth, middleware.PolicyMiddleware(policyService, "user:update", "user", userHandler.ResolveUser), userHandler.UpdateUser)
+			protected.DELETE("/users/:id", middleware.DenyImpersonation(), recentAuth, middleware.PolicyMiddleware(policyService, "user:delete", "user", userHandler.ResolveUser), userHandler.DeleteUser)
+			protected.GET("/users/me/activity", auditHandler.GetMyActivity)
+
+			// Passkey routes
+			protected.POST("/users/me/webauthn/register/options", middleware.DenyImpersonation(), recentAuth, webauthnHandler.RegistrationOptions)
+			protected.POST("/users/me/webauthn/register", middleware.DenyImpersonation(), recentAuth, webauthnHandler.Register)
+			protected.GET("/users/me/webauthn/credentials", webauthnHandler.ListCredentials)
+			protected.DELETE("/users/me/webauthn/credentials/:credentialId", middleware.DenyImpersonation(), recentAuth, webauthnHandler.RemoveCredential)
+
+			// One-time code routes (email and SMS second factors)
+			protected.POST("/users/me/otp/enroll", middleware.DenyImpersonation(), recentAuth, otpHandler.Enroll)
+			protected.POST("/users/me/otp/enroll/verify", middleware.DenyImpersonation(), recentAuth, otpHandler.VerifyEnrollment)
+			protected.GET("/users/me/otp/factors", otpHandler.ListFactors)
+			protected.DELETE("/users/me/otp/factors/:channel", middleware.DenyImpersonation(), recentAuth, otpHandler.RemoveFactor)
+
+			// Identity link routes (external OIDC and SAML accounts)
+			protected.POST("/users/me/identities/oidc/:provider", middleware.DenyImpersonation(), recentAuth, oidcHandler.Link)
+			protected.POST("/users/me/identities/saml/:provider", middleware.DenyImpersonation(), recentAuth, samlHandler.Link)
+
+			// Trusted device routes (browsers that skip the second factor)
+			protected.GET("/users/me/devices", deviceHandler.ListDevices)
+			protected.DELETE("/users/me/devices", middleware.DenyImpersonation(), deviceHandler.RevokeAllDevices)
+			protected.DELETE("/users/me/devices/:deviceId", middleware.DenyImpersonation(), deviceHandler.RevokeDevice)
+
+			// Organization routes
+			protected.POST("/orgs", orgHandler.CreateOrganization)
+			protected.GET("/orgs", orgHandler.ListMyOrganizations)
+
+			tenant := protected.Group("/orgs/:orgId", middleware.TenantMiddleware("orgId"))
+			{
+				tenant.GET("", orgHandler.GetOrganization)
+				tenant.GET("/members", orgHandler.ListMembers)
+				tenant.GET("/members/:userId", orgHandler.GetMember)
+
+				tenantAdmin := tenant.Group("", middleware.TenantRoleMiddleware(user.RoleAdmin))
+				{
+					tenantAdmin.POST("/members", orgHandler.AddMember)
+					tenantAdmin.PUT("/members/:userId", orgHandler.UpdateMemberRole)
+					tenantAdmin.DELETE("/members/:userId", orgHandler.RemoveMember)
+					tenantAdmin.POST("/invitations", invitationHandler.CreateInvitation)
+					tenantAdmin.GET("/invitations", invitationHandler.ListInvitations)
+					tenantAdmin.DELETE("/invitations/:invitationId", invitationHandler.RevokeInvitation)
+				}
+			}
+
+			// Relationship-based authorization routes
+			relations := protected.Group("/relations", middleware.PermissionMiddleware(auth.PermissionRelationRead))
+			{
+				relations.GET("/namespaces", relationHandler.ListNamespaces)
+				relations.GET("/tuples", relationHandler.ReadTuples)
+				relations.POST("/check", relationHandler.Check)
+				relations.POST("/expand", relationHandler.Expand)
+				relations.POST("/list-objects", relationHandler.ListObjects)
+
+				relationWrite := relations.Group("", middleware.PermissionMiddleware(auth.PermissionRelationWrite))
+				{
+					relationWrite.PUT("/namespaces/:namespace", relationHandler.PutNamespace)
+					relationWrite.DELETE("/namespaces/:namespace", relationHandler.DeleteNamespace)
+					relationWrite.POST("/tuples", relationHandler.WriteTuples)
+				}
+			}
+
+			// Authorization decision routes for other services
+			authzRoutes := protected.Group("/authz", middleware.PermissionMiddleware(auth.PermissionAuthzCheck))
+			{
+				authzRoutes.POST("/check", authzHandler.Check)
+				authzRoutes.POST("/check/batch", authzHandler.BatchCheck)
+			}
+
+			// Admin routes
+			admin := protected.Group("/admin", middleware.PermissionMiddleware(auth.PermissionAdminRead))
+			{
+				admin.GET("/users/:id", userHandler.GetUserByID)
+				admin.GET("/audit", auditHandler.ListEvents)
+				admin.GET("/audit/verify", auditHandler.VerifyChain)
+				admin.GET("/users/:id/groups", groupHandler.ListUserGroups)
+				admin.GET("/groups", groupHandler.ListGroups)
+				admin.GET("/groups/:groupId", groupHandler.GetGroup)
+				admin.GET("/groups/:groupId/members", groupHandler.ListMembers)
+				admin.GET("/policies", policyHandler.ListPolicies)
+				admin.GET("/policies/:policyId", policyHandler.GetPolicy)
+
+				// Uma sessão de personificação nunca altera dados administrativos
+				adminWrite := admin.Group("", middleware.PermissionMiddleware(auth.PermissionAdminWrite), middleware.DenyImpersonation())
+				{
+					adminWrite.POST("/users", adminHandler.CreateUser)
+					adminWrite.PUT("/users/:id/role", adminHandler.ChangeRole)
+					adminWrite.POST("/users/:id/logout", adminHandler.ForceLogout)
+					adminWrite.POST("/users/:id/impersonate", adminHandler.Impersonate)
+					adminWrite.POST("/users/:id/mfa/reset", adminHandler.ResetMFA)
+					adminWrite.POST("/groups", groupHandler.CreateGroup)
+					adminWrite.PUT("/groups/:groupId", groupHandler.UpdateGroup)
+					adminWrite.DELETE("/groups/:groupId", groupHandler.DeleteGroup)
+					adminWrite.POST("/groups/:groupId/members", groupHandler.AddMember)
+					adminWrite.DELETE("/groups/:groupId/members/:userId", groupHandler.RemoveMember)
+					adminWrite.POST("/policies", policyHandler.CreatePolicy)
+					adminWrite.PUT("/policies/:policyId", policyHandler.UpdatePolicy)
+					adminWrite.DELETE("/policies/:policyId", policyHandler.DeletePolicy)
+				}
+			}
 		}
 	}
 
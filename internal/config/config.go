@@ -4,16 +4,37 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 	"time"
-
 )
 
 type Config struct {
-	ServerPort     string
-	JWTSecret      string
-	TokenExpiresIn time.Duration
-	MongoDB        MongoDBConfig
-	Redis          RedisConfig
+	ServerPort             string
+	JWTSecret              string
+	TokenExpiresIn         time.Duration
+	ImpersonationExpiresIn time.Duration
+	ReauthenticationMaxAge time.Duration
+	InvitationExpiresIn    time.Duration
+	PermissionCacheTTL     time.Duration
+	AuthzCacheTTL          time.Duration
+	PolicyFiles            []string
+	SCIMTokens             []string
+	AppBaseURL             string
+	MongoDB                MongoDBConfig
+	Redis                  RedisConfig
+	Session                SessionConfig
+	SessionCookie          SessionCookieConfig
+	Mailer                 MailerConfig
+	LDAP                   LDAPConfig
+	OIDC                   OIDCConfig
+	SAML                   SAMLConfig
+	MagicLink              MagicLinkConfig
+	LoginAlert             LoginAlertConfig
+	Risk                   RiskConfig
+	WebAuthn               WebAuthnConfig
+	MFA                    MFAConfig
+	OTP                    OTPConfig
+	SMS                    SMSConfig
 }
 
 type MongoDBConfig struct {
@@ -30,15 +51,248 @@ type RedisConfig struct {
 	UseSSL   bool
 }
 
+type MailerConfig struct {
+	Driver   string
+	From     string
+	Host     string
+	Port     int
+	Username string
+	Password string
+}
+
+// LDAPConfig configura a autenticação em diretório. Com URL vazia, o
+// diretório fica desabilitado.
+type LDAPConfig struct {
+	URL                 string
+	StartTLS            bool
+	BindDN              string
+	BindPassword        string
+	BaseDN              string
+	EmailAttribute      string
+	NameAttribute       string
+	DepartmentAttribute string
+	GroupAttribute      string
+	AdminGroups         []string
+	Domains             []string
+	Timeout             time.Duration
+}
+
+// OIDCConfig lista os provedores OpenID Connect habilitados para login
+type OIDCConfig struct {
+	Providers []OIDCProviderConfig
+	StateTTL  time.Duration
+}
+
+type OIDCProviderConfig struct {
+	Name         string
+	DisplayName  string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	// TrustedEmailDomains são os domínios cujos e-mails são tratados como
+	// verificados mesmo sem a claim email_verified
+	TrustedEmailDomains []string
+}
+
+// SAMLConfig lista os provedores SAML habilitados para login
+type SAMLConfig struct {
+	Providers  []SAMLProviderConfig
+	RequestTTL time.Duration
+}
+
+type SAMLProviderConfig struct {
+	Name                string
+	DisplayName         string
+	IdPEntityID         string
+	IdPSSOURL           string
+	CertificateFiles    []string
+	EmailAttribute      string
+	NameAttribute       string
+	DepartmentAttribute string
+	RoleAttribute       string
+	AdminValues         []string
+	EmailDomains        []string
+	AllowIdPInitiated   bool
+}
+
+// SessionConfig limita a duração das sessões: IdleTimeout sem uso e
+// MaxLifetime desde o login, ambos limitados pela validade do token (zero usa
+// só a validade), e MaxSessions simultâneas por usuário (zero não limita).
+// Roles sobrescreve os limites por role e TouchInterval é o intervalo mínimo
+// entre as escritas que registram a atividade. LimitPolicy escolhe o que
+// acontece no limite de sessões: "reject" recusa o login e "evict_oldest"
+// encerra a sessão mais antiga. JanitorInterval é o intervalo entre as
+// reconciliações dos índices de sessões (zero desativa).
+type SessionConfig struct {
+	IdleTimeout     time.Duration
+	MaxLifetime     time.Duration
+	MaxSessions     int
+	TouchInterval   time.Duration
+	LimitPolicy     string
+	JanitorInterval time.Duration
+	Roles           map[string]SessionLimitsConfig
+}
+
+type SessionLimitsConfig struct {
+	IdleTimeout time.Duration
+	MaxLifetime time.Duration
+	MaxSessions int
+}
+
+// SessionCookieConfig ativa o modo de sessão por cookie para front-ends web:
+// o token vai em um cookie HttpOnly e as requisições que alteram estado
+// exigem o token CSRF. SameSite aceita strict, lax ou none (este exige Secure).
+type SessionCookieConfig struct {
+	Enabled  bool
+	Name     string
+	Domain   string
+	Secure   bool
+	SameSite string
+}
+
+// MagicLinkConfig configura o login por link enviado por e-mail. URL é a
+// página que recebe o token; vazia, usa a rota da própria API.
+type MagicLinkConfig struct {
+	URL        string
+	TTL        time.Duration
+	RateLimit  int
+	RateWindow time.Duration
+}
+
+// LoginAlertConfig configura os avisos de login de dispositivos ou redes
+// novos. Com URL vazia, o link "não fui eu" aponta para a própria API; o link
+// vale por TTL e a redefinição de senha liberada por ele, por ResetTTL.
+// Dispositivos e redes não vistos há mais de HistoryRetention voltam a ser
+// novos.
+type LoginAlertConfig struct {
+	URL              string
+	TTL              time.Duration
+	ResetTTL         time.Duration
+	HistoryRetention time.Duration
+}
+
+// RiskConfig configura a avaliação de risco do login. Pontuações a partir de
+// MFAThreshold exigem o segundo fator e a partir de BlockThreshold bloqueiam
+// a tentativa. Os sinais de viagem impossível, IPs maliciosos e horário só
+// são ativados quando GeoIPFile, IPBlocklistFiles e QuietHours são definidos.
+type RiskConfig struct {
+	MFAThreshold     int
+	BlockThreshold   int
+	FailureLimit     int
+	FailureWindow    time.Duration
+	MaxTravelSpeed   float64
+	QuietHours       string
+	Timezone         string
+	GeoIPFile        string
+	IPBlocklistFiles []string
+	Weights          RiskWeights
+}
+
+// RiskWeights é a pontuação de cada sinal de risco
+type RiskWeights struct {
+	Failures         int
+	NewDevice        int
+	ImpossibleTravel int
+	BadIP            int
+	QuietHours       int
+}
+
+// WebAuthnConfig identifica o relying party das passkeys. RPID é o domínio
+// registrado nas credenciais; Origins, as origens do front-end autorizadas.
+type WebAuthnConfig struct {
+	RPID        string
+	RPName      string
+	Origins     []string
+	Attestation string
+	Timeout     time.Duration
+}
+
+// MFAConfig configura o desafio de segundo fator aberto após o login e os
+// dispositivos confiáveis, que o dispensam por TrustedDeviceTTL. Cada usuário
+// mantém até TrustedDeviceLimit dispositivos.
+type MFAConfig struct {
+	ChallengeTTL       time.Duration
+	TrustedDeviceTTL   time.Duration
+	TrustedDeviceLimit int
+}
+
+// OTPConfig configura os códigos de uso único enviados por e-mail ou SMS.
+// Cada código aceita até MaxAttempts tentativas; cada usuário pode pedir até
+// RateLimit códigos por RateWindow.
+type OTPConfig struct {
+	Length      int
+	TTL         time.Duration
+	MaxAttempts int
+	RateLimit   int
+	RateWindow  time.Duration
+}
+
+// SMSConfig escolhe o envio de SMS: "log" escreve no log e "file" acrescenta
+// as mensagens a FilePath
+type SMSConfig struct {
+	Driver   string
+	FilePath string
+}
+
 func LoadConfig() *Config {
 	tokenExpiresIn, _ := strconv.Atoi(getEnv("TOKEN_EXPIRES_IN", "3600"))
+	impersonationExpiresIn, _ := strconv.Atoi(getEnv("IMPERSONATION_EXPIRES_IN", "900"))
+	reauthenticationMaxAge, _ := strconv.Atoi(getEnv("REAUTHENTICATION_MAX_AGE", "600"))
 	redisDB, _ := strconv.Atoi(getEnv("REDIS_DB", "0"))
 	redisUseSSL, _ := strconv.ParseBool(getEnv("REDIS_USE_SSL", "false"))
+	invitationExpiresIn, _ := strconv.Atoi(getEnv("INVITATION_EXPIRES_IN", "259200"))
+	permissionCacheTTL, _ := strconv.Atoi(getEnv("PERMISSION_CACHE_TTL", "300"))
+	authzCacheTTL, _ := strconv.Atoi(getEnv("AUTHZ_CACHE_TTL", "10"))
+	sessionIdleTimeout, _ := strconv.Atoi(getEnv("SESSION_IDLE_TIMEOUT", "1800"))
+	sessionMaxLifetime, _ := strconv.Atoi(getEnv("SESSION_MAX_LIFETIME", "0"))
+	sessionMaxSessions, _ := strconv.Atoi(getEnv("SESSION_MAX_SESSIONS", "0"))
+	sessionTouchInterval, _ := strconv.Atoi(getEnv("SESSION_TOUCH_INTERVAL", "60"))
+	sessionJanitorInterval, _ := strconv.Atoi(getEnv("SESSION_JANITOR_INTERVAL", "3600"))
+	sessionCookieEnabled, _ := strconv.ParseBool(getEnv("SESSION_COOKIE_ENABLED", "false"))
+	sessionCookieSecure, _ := strconv.ParseBool(getEnv("SESSION_COOKIE_SECURE", "true"))
+	smtpPort, _ := strconv.Atoi(getEnv("SMTP_PORT", "587"))
+	ldapStartTLS, _ := strconv.ParseBool(getEnv("LDAP_START_TLS", "false"))
+	oidcStateTTL, _ := strconv.Atoi(getEnv("OIDC_STATE_TTL", "600"))
+	samlRequestTTL, _ := strconv.Atoi(getEnv("SAML_REQUEST_TTL", "600"))
+	magicLinkTTL, _ := strconv.Atoi(getEnv("MAGIC_LINK_TTL", "900"))
+	magicLinkRateLimit, _ := strconv.Atoi(getEnv("MAGIC_LINK_RATE_LIMIT", "3"))
+	magicLinkRateWindow, _ := strconv.Atoi(getEnv("MAGIC_LINK_RATE_WINDOW", "3600"))
+	loginAlertTTL, _ := strconv.Atoi(getEnv("LOGIN_ALERT_TTL", "604800"))
+	passwordResetTTL, _ := strconv.Atoi(getEnv("PASSWORD_RESET_TTL", "3600"))
+	loginHistoryRetention, _ := strconv.Atoi(getEnv("LOGIN_HISTORY_RETENTION", "7776000"))
+	riskMFAThreshold, _ := strconv.Atoi(getEnv("RISK_MFA_THRESHOLD", "50"))
+	riskBlockThreshold, _ := strconv.Atoi(getEnv("RISK_BLOCK_THRESHOLD", "90"))
+	riskFailureLimit, _ := strconv.Atoi(getEnv("RISK_FAILURE_LIMIT", "5"))
+	riskFailureWindow, _ := strconv.Atoi(getEnv("RISK_FAILURE_WINDOW", "900"))
+	riskMaxTravelSpeed, _ := strconv.ParseFloat(getEnv("RISK_MAX_TRAVEL_SPEED", "900"), 64)
+	riskWeightFailures, _ := strconv.Atoi(getEnv("RISK_WEIGHT_FAILURES", "40"))
+	riskWeightNewDevice, _ := strconv.Atoi(getEnv("RISK_WEIGHT_NEW_DEVICE", "30"))
+	riskWeightImpossibleTravel, _ := strconv.Atoi(getEnv("RISK_WEIGHT_IMPOSSIBLE_TRAVEL", "60"))
+	riskWeightBadIP, _ := strconv.Atoi(getEnv("RISK_WEIGHT_BAD_IP", "100"))
+	riskWeightQuietHours, _ := strconv.Atoi(getEnv("RISK_WEIGHT_QUIET_HOURS", "10"))
+	webAuthnTimeout, _ := strconv.Atoi(getEnv("WEBAUTHN_TIMEOUT", "300"))
+	mfaChallengeTTL, _ := strconv.Atoi(getEnv("MFA_CHALLENGE_TTL", "300"))
+	trustedDeviceTTL, _ := strconv.Atoi(getEnv("MFA_TRUSTED_DEVICE_TTL", "2592000"))
+	trustedDeviceLimit, _ := strconv.Atoi(getEnv("MFA_TRUSTED_DEVICE_LIMIT", "10"))
+	otpLength, _ := strconv.Atoi(getEnv("OTP_LENGTH", "6"))
+	otpTTL, _ := strconv.Atoi(getEnv("OTP_TTL", "300"))
+	otpMaxAttempts, _ := strconv.Atoi(getEnv("OTP_MAX_ATTEMPTS", "5"))
+	otpRateLimit, _ := strconv.Atoi(getEnv("OTP_RATE_LIMIT", "5"))
+	otpRateWindow, _ := strconv.Atoi(getEnv("OTP_RATE_WINDOW", "3600"))
 
 	return &Config{
-		ServerPort:     getEnv("SERVER_PORT", "8080"),
-		JWTSecret:      getEnv("JWT_SECRET", "BxZryG/amKX+/czuY8C2Fqk1LjBohUfRDgwrYDbT8GI="),
-		TokenExpiresIn: time.Duration(tokenExpiresIn) * time.Second,
+		ServerPort:             getEnv("SERVER_PORT", "8080"),
+		JWTSecret:              getEnv("JWT_SECRET", "BxZryG/amKX+/czuY8C2Fqk1LjBohUfRDgwrYDbT8GI="),
+		TokenExpiresIn:         time.Duration(tokenExpiresIn) * time.Second,
+		ImpersonationExpiresIn: time.Duration(impersonationExpiresIn) * time.Second,
+		ReauthenticationMaxAge: time.Duration(reauthenticationMaxAge) * time.Second,
+		InvitationExpiresIn:    time.Duration(invitationExpiresIn) * time.Second,
+		PermissionCacheTTL:     time.Duration(permissionCacheTTL) * time.Second,
+		AuthzCacheTTL:          time.Duration(authzCacheTTL) * time.Second,
+		PolicyFiles:            splitList(getEnv("POLICY_FILES", "")),
+		SCIMTokens:             splitList(getEnv("SCIM_TOKENS", "")),
+		AppBaseURL:             getEnv("APP_BASE_URL", "http://localhost:8080"),
 		MongoDB: MongoDBConfig{
 			URI:      getEnv("MONGODB_URI", "mongodb://localhost:27017"),
 			Database: getEnv("MONGODB_DATABASE", "Users"),
@@ -51,7 +305,172 @@ func LoadConfig() *Config {
 			Timeout:  5 * time.Second,
 			UseSSL:   redisUseSSL,
 		},
+		Session: SessionConfig{
+			IdleTimeout:     time.Duration(sessionIdleTimeout) * time.Second,
+			MaxLifetime:     time.Duration(sessionMaxLifetime) * time.Second,
+			MaxSessions:     sessionMaxSessions,
+			TouchInterval:   time.Duration(sessionTouchInterval) * time.Second,
+			LimitPolicy:     strings.ToLower(getEnv("SESSION_LIMIT_POLICY", "reject")),
+			JanitorInterval: time.Duration(sessionJanitorInterval) * time.Second,
+			Roles:           loadSessionLimits(sessionIdleTimeout, sessionMaxLifetime, sessionMaxSessions),
+		},
+		SessionCookie: SessionCookieConfig{
+			Enabled:  sessionCookieEnabled,
+			Name:     getEnv("SESSION_COOKIE_NAME", "session"),
+			Domain:   getEnv("SESSION_COOKIE_DOMAIN", ""),
+			Secure:   sessionCookieSecure,
+			SameSite: strings.ToLower(getEnv("SESSION_COOKIE_SAMESITE", "lax")),
+		},
+		Mailer: MailerConfig{
+			Driver:   getEnv("MAILER_DRIVER", "log"),
+			From:     getEnv("MAILER_FROM", "no-reply@localhost"),
+			Host:     getEnv("SMTP_HOST", "localhost"),
+			Port:     smtpPort,
+			Username: getEnv("SMTP_USERNAME", ""),
+			Password: getEnv("SMTP_PASSWORD", ""),
+		},
+		LDAP: LDAPConfig{
+			URL:                 getEnv("LDAP_URL", ""),
+			StartTLS:            ldapStartTLS,
+			BindDN:              getEnv("LDAP_BIND_DN", ""),
+			BindPassword:        getEnv("LDAP_BIND_PASSWORD", ""),
+			BaseDN:              getEnv("LDAP_BASE_DN", ""),
+			EmailAttribute:      getEnv("LDAP_EMAIL_ATTRIBUTE", "mail"),
+			NameAttribute:       getEnv("LDAP_NAME_ATTRIBUTE", "cn"),
+			DepartmentAttribute: getEnv("LDAP_DEPARTMENT_ATTRIBUTE", "department"),
+			GroupAttribute:      getEnv("LDAP_GROUP_ATTRIBUTE", "memberOf"),
+			AdminGroups:         splitListBy(getEnv("LDAP_ADMIN_GROUPS", ""), ";"),
+			Domains:             splitList(getEnv("LDAP_DOMAINS", "")),
+			Timeout:             5 * time.Second,
+		},
+		OIDC: OIDCConfig{
+			Providers: loadOIDCProviders(splitList(getEnv("OIDC_PROVIDERS", ""))),
+			StateTTL:  time.Duration(oidcStateTTL) * time.Second,
+		},
+		SAML: SAMLConfig{
+			Providers:  loadSAMLProviders(splitList(getEnv("SAML_PROVIDERS", ""))),
+			RequestTTL: time.Duration(samlRequestTTL) * time.Second,
+		},
+		MagicLink: MagicLinkConfig{
+			URL:        getEnv("MAGIC_LINK_URL", ""),
+			TTL:        time.Duration(magicLinkTTL) * time.Second,
+			RateLimit:  magicLinkRateLimit,
+			RateWindow: time.Duration(magicLinkRateWindow) * time.Second,
+		},
+		LoginAlert: LoginAlertConfig{
+			URL:              getEnv("LOGIN_ALERT_URL", ""),
+			TTL:              time.Duration(loginAlertTTL) * time.Second,
+			ResetTTL:         time.Duration(passwordResetTTL) * time.Second,
+			HistoryRetention: time.Duration(loginHistoryRetention) * time.Second,
+		},
+		Risk: RiskConfig{
+			MFAThreshold:     riskMFAThreshold,
+			BlockThreshold:   riskBlockThreshold,
+			FailureLimit:     riskFailureLimit,
+			FailureWindow:    time.Duration(riskFailureWindow) * time.Second,
+			MaxTravelSpeed:   riskMaxTravelSpeed,
+			QuietHours:       getEnv("RISK_QUIET_HOURS", ""),
+			Timezone:         getEnv("RISK_TIMEZONE", "UTC"),
+			GeoIPFile:        getEnv("RISK_GEOIP_FILE", ""),
+			IPBlocklistFiles: splitList(getEnv("RISK_IP_BLOCKLIST_FILES", "")),
+			Weights: RiskWeights{
+				Failures:         riskWeightFailures,
+				NewDevice:        riskWeightNewDevice,
+				ImpossibleTravel: riskWeightImpossibleTravel,
+				BadIP:            riskWeightBadIP,
+				QuietHours:       riskWeightQuietHours,
+			},
+		},
+		WebAuthn: WebAuthnConfig{
+			RPID:        getEnv("WEBAUTHN_RP_ID", "localhost"),
+			RPName:      getEnv("WEBAUTHN_RP_NAME", "Go Auth API"),
+			Origins:     splitList(getEnv("WEBAUTHN_ORIGINS", "http://localhost:8080")),
+			Attestation: getEnv("WEBAUTHN_ATTESTATION", "none"),
+			Timeout:     time.Duration(webAuthnTimeout) * time.Second,
+		},
+		MFA: MFAConfig{
+			ChallengeTTL:       time.Duration(mfaChallengeTTL) * time.Second,
+			TrustedDeviceTTL:   time.Duration(trustedDeviceTTL) * time.Second,
+			TrustedDeviceLimit: trustedDeviceLimit,
+		},
+		OTP: OTPConfig{
+			Length:      otpLength,
+			TTL:         time.Duration(otpTTL) * time.Second,
+			MaxAttempts: otpMaxAttempts,
+			RateLimit:   otpRateLimit,
+			RateWindow:  time.Duration(otpRateWindow) * time.Second,
+		},
+		SMS: SMSConfig{
+			Driver:   getEnv("SMS_DRIVER", "log"),
+			FilePath: getEnv("SMS_FILE_PATH", "sms.log"),
+		},
+	}
+}
+
+// loadSessionLimits lê os limites de sessão de cada role das variáveis
+// SESSION_<ROLE>_IDLE_TIMEOUT, SESSION_<ROLE>_MAX_LIFETIME e
+// SESSION_<ROLE>_MAX_SESSIONS, com os limites globais como padrão
+func loadSessionLimits(idleTimeout, maxLifetime, maxSessions int) map[string]SessionLimitsConfig {
+	limits := map[string]SessionLimitsConfig{}
+	for _, role := range []string{"user", "admin"} {
+		prefix := "SESSION_" + strings.ToUpper(role) + "_"
+		roleIdleTimeout, _ := strconv.Atoi(getEnv(prefix+"IDLE_TIMEOUT", strconv.Itoa(idleTimeout)))
+		roleMaxLifetime, _ := strconv.Atoi(getEnv(prefix+"MAX_LIFETIME", strconv.Itoa(maxLifetime)))
+		roleMaxSessions, _ := strconv.Atoi(getEnv(prefix+"MAX_SESSIONS", strconv.Itoa(maxSessions)))
+		limits[role] = SessionLimitsConfig{
+			IdleTimeout: time.Duration(roleIdleTimeout) * time.Second,
+			MaxLifetime: time.Duration(roleMaxLifetime) * time.Second,
+			MaxSessions: roleMaxSessions,
+		}
 	}
+	return limits
+}
+
+// loadOIDCProviders lê a configuração de cada provedor das variáveis
+// OIDC_<NOME>_*
+func loadOIDCProviders(names []string) []OIDCProviderConfig {
+	providers := make([]OIDCProviderConfig, 0, len(names))
+	for _, name := range names {
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		if os.Getenv(prefix+"TRUST_EMAIL") != "" {
+			log.Printf("Warning: %sTRUST_EMAIL is no longer supported; list the provider's domains in %sTRUSTED_EMAIL_DOMAINS", prefix, prefix)
+		}
+		providers = append(providers, OIDCProviderConfig{
+			Name:                name,
+			DisplayName:         getEnv(prefix+"DISPLAY_NAME", name),
+			Issuer:              getEnv(prefix+"ISSUER", ""),
+			ClientID:            getEnv(prefix+"CLIENT_ID", ""),
+			ClientSecret:        getEnv(prefix+"CLIENT_SECRET", ""),
+			Scopes:              splitList(getEnv(prefix+"SCOPES", "openid,email,profile")),
+			TrustedEmailDomains: splitList(getEnv(prefix+"TRUSTED_EMAIL_DOMAINS", "")),
+		})
+	}
+	return providers
+}
+
+// loadSAMLProviders lê a configuração de cada provedor das variáveis
+// SAML_<NOME>_*
+func loadSAMLProviders(names []string) []SAMLProviderConfig {
+	providers := make([]SAMLProviderConfig, 0, len(names))
+	for _, name := range names {
+		prefix := "SAML_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		allowIdPInitiated, _ := strconv.ParseBool(getEnv(prefix+"ALLOW_IDP_INITIATED", "false"))
+		providers = append(providers, SAMLProviderConfig{
+			Name:                name,
+			DisplayName:         getEnv(prefix+"DISPLAY_NAME", name),
+			IdPEntityID:         getEnv(prefix+"IDP_ENTITY_ID", ""),
+			IdPSSOURL:           getEnv(prefix+"IDP_SSO_URL", ""),
+			CertificateFiles:    splitList(getEnv(prefix+"IDP_CERTIFICATES", "")),
+			EmailAttribute:      getEnv(prefix+"EMAIL_ATTRIBUTE", ""),
+			NameAttribute:       getEnv(prefix+"NAME_ATTRIBUTE", ""),
+			DepartmentAttribute: getEnv(prefix+"DEPARTMENT_ATTRIBUTE", ""),
+			RoleAttribute:       getEnv(prefix+"ROLE_ATTRIBUTE", ""),
+			AdminValues:         splitListBy(getEnv(prefix+"ADMIN_VALUES", ""), ";"),
+			EmailDomains:        splitList(getEnv(prefix+"EMAIL_DOMAINS", "")),
+			AllowIdPInitiated:   allowIdPInitiated,
+		})
+	}
+	return providers
 }
 
 func getEnv(key, defaultValue string) string {
@@ -62,3 +481,20 @@ func getEnv(key, defaultValue string) string {
 	}
 	return value
 }
+
+// splitList separa valores de uma variável de ambiente delimitados por vírgula
+func splitList(value string) []string {
+	return splitListBy(value, ",")
+}
+
+// splitListBy separa valores pelo delimitador informado, para listas cujos
+// itens contêm vírgulas (como DNs)
+func splitListBy(value, separator string) []string {
+	var items []string
+	for _, item := range strings.Split(value, separator) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/juanjerrah/go_auth_api/internal/domain/audit"
+	"github.com/juanjerrah/go_auth_api/internal/domain/auth"
+	"github.com/juanjerrah/go_auth_api/internal/domain/user"
+	"github.com/juanjerrah/go_auth_api/pkg/types"
+)
+
+type AdminHandler struct {
+	userService      user.Service
+	jwtManager       *auth.JWTManager
+	authService      auth.AuthService
+	auditService     audit.Service
+	impersonationTTL time.Duration
+}
+
+func NewAdminHandler(userService user.Service, jwtManager *auth.JWTManager, authService auth.AuthService, auditService audit.Service, impersonationTTL time.Duration) *AdminHandler {
+	return &AdminHandler{
+		userService:      userService,
+		jwtManager:       jwtManager,
+		authService:      authService,
+		auditService:     auditService,
+		impersonationTTL: impersonationTTL,
+	}
+}
+
+// CreateUser creates a user on behalf of an administrator
+// @Summary Create user
+// @Description Create a user account with any role
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body user.CreateUserRequest true "User data"
+// @Success 201 {object} user.UserResponse "User created successfully"
+// @Failure 400 {object} map[string]string "Invalid input data"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Insufficient permissions"
+// @Failure 409 {object} map[string]string "Email already in use"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/users [post]
+func (h *AdminHandler) CreateUser(c *gin.Context) {
+	var req user.CreateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userResponse, err := h.userService.CreateUser(c.Request.Context(), &req)
+	if err != nil {
+		switch err {
+		case user.ErrEmailAlreadyInUse:
+			c.JSON(http.StatusConflict, gin.H{"error": "Email already in use"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, userResponse)
+}
+
+// ChangeRole changes a user's role
+// @Summary Change user role
+// @Description Change a user's role and invalidate their sessions so the new permissions take effect
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param request body user.ChangeRoleRequest true "New role"
+// @Success 200 {object} map[string]string "Role changed successfully"
+// @Failure 400 {object} map[string]string "Invalid input data"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Insufficient permissions"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 409 {object} map[string]string "Cannot demote the last admin"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/users/{id}/role [put]
+func (h *AdminHandler) ChangeRole(c *gin.Context) {
+	var req user.ChangeRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.Param("id")
+	if err := h.userService.ChangeRole(c.Request.Context(), userID, req.Role); err != nil {
+		switch err {
+		case user.ErrUserNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		case user.ErrLastAdmin:
+			c.JSON(http.StatusConflict, gin.H{"error": "Cannot demote the last admin"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change role"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Role changed successfully"})
+}
+
+// ForceLogout invalidates every session of a user
+// @Summary Force logout
+// @Description Invalidate all active sessions of a user
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} map[string]string "User logged out"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Insufficient permissions"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/users/{id}/logout [post]
+func (h *AdminHandler) ForceLogout(c *gin.Context) {
+	userID := c.Param("id")
+	if err := h.userService.ForceLogout(c.Request.Context(), userID); err != nil {
+		switch err {
+		case user.ErrUserNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to logout user"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "User logged out from all devices"})
+}
+
+// ResetMFA removes every second factor of a user
+// @Summary Reset MFA
+// @Description Remove all second factors and trusted devices of a user who lost their authenticator and invalidate their sessions
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} map[string]string "MFA reset"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Insufficient permissions"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/users/{id}/mfa/reset [post]
+func (h *AdminHandler) ResetMFA(c *gin.Context) {
+	userID := c.Param("id")
+	if err := h.userService.ResetMFA(c.Request.Context(), userID); err != nil {
+		switch err {
+		case user.ErrUserNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset MFA"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "MFA reset successfully"})
+}
+
+// Impersonate issues a short-lived token to act as another user
+// @Summary Impersonate user
+// @Description Issue a short-lived token for the target user carrying the admin in the "act" claim. Sensitive operations are blocked while impersonating.
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} map[string]interface{} "Impersonation token"
+// @Failure 400 {object} map[string]string "Cannot impersonate yourself"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Insufficient permissions, target has administrative permissions or not allowed during impersonation"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/users/{id}/impersonate [post]
+func (h *AdminHandler) Impersonate(c *gin.Context) {
+	authContext, _ := c.Get("authContext")
+	authCtx := authContext.(*auth.AuthContext)
+
+	targetID := c.Param("id")
+	if targetID == authCtx.UserID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot impersonate yourself"})
+		return
+	}
+
+	target, err := h.userService.GetUserByID(c.Request.Context(), targetID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	permissions, err := h.authService.GetUserPermissions(c.Request.Context(), target.ID, types.Role(target.Role))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve permissions"})
+		return
+	}
+
+	// Personificar outro admin daria acesso irrestrito sem rastreabilidade
+	// direta. Vale para as permissões efetivas: um usuário comum que recebeu
+	// por um grupo permissões além das de sua role também é privilegiado.
+	if types.Role(target.Role) == user.RoleAdmin || hasPrivilegedPermission(permissions) {
+		h.recordAudit(c, &audit.Event{
+			Type:     audit.EventImpersonationStarted,
+			TargetID: target.ID,
+			Outcome:  audit.OutcomeFailure,
+			Reason:   "target has administrative permissions",
+		})
+		c.JSON(http.StatusForbidden, gin.H{"error": "Cannot impersonate another administrator"})
+		return
+	}
+
+	token, err := h.jwtManager.GenerateImpersonationToken(target.ID, target.Email, types.Role(target.Role), authCtx.UserID, h.impersonationTTL)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	impersonatedCtx := &types.AuthContext{
+		UserID:         target.ID,
+		Email:          target.Email,
+		Role:           types.Role(target.Role),
+		Permissions:    permissions,
+		ImpersonatorID: authCtx.UserID,
+	}
+
+	err = h.authService.StoreToken(c.Request.Context(), token, impersonatedCtx, h.impersonationTTL)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store session"})
+		return
+	}
+
+	h.recordAudit(c, &audit.Event{
+		Type:     audit.EventImpersonationStarted,
+		TargetID: target.ID,
+		Metadata: map[string]string{"expires_in": h.impersonationTTL.String()},
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Impersonation started",
+		"token":      token,
+		"expires_in": h.impersonationTTL.Seconds(),
+		"user":       target,
+	})
+}
+
+// hasPrivilegedPermission informa se as permissões vão além das de um usuário
+// comum, seja pela role ou por grupos
+func hasPrivilegedPermission(permissions []types.Permission) bool {
+	for _, permission := range permissions {
+		if !types.HasPermission(user.RoleUser, permission) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *AdminHandler) recordAudit(c *gin.Context, event *audit.Event) {
+	if err := h.auditService.Record(c.Request.Context(), event); err != nil {
+		log.Printf("Warning: failed to record audit event %s: %v", event.Type, err)
+	}
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/juanjerrah/go_auth_api/internal/domain/audit"
+	"github.com/juanjerrah/go_auth_api/internal/domain/auth"
+)
+
+type AuditHandler struct {
+	auditService audit.Service
+}
+
+func NewAuditHandler(auditService audit.Service) *AuditHandler {
+	return &AuditHandler{
+		auditService: auditService,
+	}
+}
+
+// ListEvents returns audit events matching the given filters
+// @Summary List audit events
+// @Description List security audit events, newest first, filtered by actor, target, type, outcome and time range
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param actor_id query string false "Actor user ID"
+// @Param target_id query string false "Target user ID"
+// @Param type query string false "Event type"
+// @Param outcome query string false "Outcome (success or failure)"
+// @Param from query string false "Start of time range (RFC3339)"
+// @Param to query string false "End of time range (RFC3339)"
+// @Param page query int false "Page number"
+// @Param limit query int false "Page size (max 100)"
+// @Success 200 {object} audit.ListEventsResponse "Audit events"
+// @Failure 400 {object} map[string]string "Invalid filters"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Insufficient permissions"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/audit [get]
+func (h *AuditHandler) ListEvents(c *gin.Context) {
+	var req audit.ListEventsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.auditService.List(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list audit events"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// VerifyChain checks the integrity of the audit hash chain
+// @Summary Verify audit chain
+// @Description Recompute the audit log hash chain and report the first broken entry, if any
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} audit.VerifyResponse "Verification result"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Insufficient permissions"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/audit/verify [get]
+func (h *AuditHandler) VerifyChain(c *gin.Context) {
+	response, err := h.auditService.VerifyChain(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify audit chain"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetMyActivity returns the current user's audit events
+// @Summary Get my activity
+// @Description List audit events where the authenticated user is the actor or the target
+// @Tags users
+// @Security BearerAuth
+// @Produce json
+// @Param type query string false "Event type"
+// @Param outcome query string false "Outcome (success or failure)"
+// @Param from query string false "Start of time range (RFC3339)"
+// @Param to query string false "End of time range (RFC3339)"
+// @Param page query int false "Page number"
+// @Param limit query int false "Page size (max 100)"
+// @Success 200 {object} audit.ListEventsResponse "User activity"
+// @Failure 400 {object} map[string]string "Invalid filters"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /users/me/activity [get]
+func (h *AuditHandler) GetMyActivity(c *gin.Context) {
+	authContext, _ := c.Get("authContext")
+	authCtx := authContext.(*auth.AuthContext)
+
+	var req audit.ListEventsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.auditService.ListForUser(c.Request.Context(), authCtx.UserID, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list activity"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
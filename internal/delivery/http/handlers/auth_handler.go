@@ -1,26 +1,56 @@
 package handlers
 
 import (
+	"errors"
+	"log"
 	"net/http"
+	"slices"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/juanjerrah/go_auth_api/internal/domain/audit"
 	"github.com/juanjerrah/go_auth_api/internal/domain/auth"
+	"github.com/juanjerrah/go_auth_api/internal/domain/device"
+	"github.com/juanjerrah/go_auth_api/internal/domain/loginalert"
+	"github.com/juanjerrah/go_auth_api/internal/domain/mfa"
+	"github.com/juanjerrah/go_auth_api/internal/domain/organization"
+	"github.com/juanjerrah/go_auth_api/internal/domain/otp"
+	"github.com/juanjerrah/go_auth_api/internal/domain/risk"
 	"github.com/juanjerrah/go_auth_api/internal/domain/user"
+	"github.com/juanjerrah/go_auth_api/internal/domain/webauthn"
+	"github.com/juanjerrah/go_auth_api/pkg/middleware"
 	"github.com/juanjerrah/go_auth_api/pkg/types"
 )
 
 type AuthHandler struct {
-	userService user.Service
-	jwtManager  *auth.JWTManager
-	authService auth.AuthService
+	userService     user.Service
+	jwtManager      *auth.JWTManager
+	authService     auth.AuthService
+	auditService    audit.Service
+	orgService      organization.Service
+	mfaService      mfa.Service
+	webauthnService webauthn.Service
+	otpService      otp.Service
+	deviceService   device.Service
+	alertService    loginalert.Service
+	riskService     risk.Service
+	cookies         *middleware.SessionCookies
 }
 
-func NewAuthHandler(userService user.Service, jwtManager *auth.JWTManager, authService auth.AuthService) *AuthHandler {
+func NewAuthHandler(userService user.Service, jwtManager *auth.JWTManager, authService auth.AuthService, auditService audit.Service, orgService organization.Service, mfaService mfa.Service, webauthnService webauthn.Service, otpService otp.Service, deviceService device.Service, alertService loginalert.Service, riskService risk.Service, cookies *middleware.SessionCookies) *AuthHandler {
 	return &AuthHandler{
-		userService: userService,
-		jwtManager:  jwtManager,
-		authService: authService,
+		userService:     userService,
+		jwtManager:      jwtManager,
+		authService:     authService,
+		auditService:    auditService,
+		orgService:      orgService,
+		mfaService:      mfaService,
+		webauthnService: webauthnService,
+		otpService:      otpService,
+		deviceService:   deviceService,
+		alertService:    alertService,
+		riskService:     riskService,
+		cookies:         cookies,
 	}
 }
 
@@ -33,6 +63,7 @@ func NewAuthHandler(userService user.Service, jwtManager *auth.JWTManager, authS
 // @Param request body user.CreateUserRequest true "User registration data"
 // @Success 201 {object} map[string]interface{} "User created successfully"
 // @Failure 400 {object} map[string]string "Invalid input data"
+// @Failure 403 {object} map[string]string "Admin role not allowed"
 // @Failure 409 {object} map[string]string "Email already in use"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /auth/register [post]
@@ -49,11 +80,19 @@ func (h *AuthHandler) Register(c *gin.Context) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role"})
 			return
 		}
+		// Administradores só podem ser criados por outro admin
+		if req.Role == user.RoleAdmin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin accounts can only be created by an administrator"})
+			return
+		}
 	} else {
 		// Definir role padrão como 'user'
 		req.Role = user.RoleUser
 	}
 
+	// O departamento é usado pelas políticas de autorização e só é definido por admins
+	req.Department = ""
+
 	userResponse, err := h.userService.CreateUser(c.Request.Context(), &req)
 	if err != nil {
 		switch err {
@@ -72,12 +111,20 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
+	permissions, err := h.authService.GetUserPermissions(c.Request.Context(), userResponse.ID, types.Role(userResponse.Role))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve permissions"})
+		return
+	}
+
 	// Armazenar token no Redis
 	authCtx := &types.AuthContext{
 		UserID:      userResponse.ID,
 		Email:       userResponse.Email,
 		Role:        types.Role(userResponse.Role),
-		Permissions: h.authService.GetUserPermissions(types.Role(userResponse.Role)),
+		Permissions: permissions,
+		AuthTime:    time.Now().UTC(),
+		AMR:         []string{types.AMRPassword},
 	}
 
 	err = h.authService.StoreToken(c.Request.Context(), token, authCtx, h.jwtManager.GetTokenDuration())
@@ -85,6 +132,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store session"})
 		return
 	}
+	h.cookies.Set(c, token, h.jwtManager.GetTokenDuration())
 
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "User registered successfully",
@@ -95,15 +143,18 @@ func (h *AuthHandler) Register(c *gin.Context) {
 
 // Login handles user authentication
 // @Summary Authenticate user
-// @Description Login with email and password
+// @Description Login with email and password. Users with a second factor enrolled receive an MFA challenge (mfa_required, mfa_token) instead of a token, unless the browser sends a valid trusted device cookie and the login is not considered risky. Risky logins of users without a second factor receive an MFA challenge with a code sent to the account email. Logins with a high risk score are blocked before the password is checked. In cookie session mode the session is also set in an HttpOnly cookie and the CSRF token to send in X-CSRF-Token is returned in the header of the same name and in a readable cookie
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param request body user.LoginRequest true "Login credentials"
-// @Success 200 {object} map[string]interface{} "Login successful"
+// @Success 200 {object} map[string]interface{} "Login successful or MFA challenge"
 // @Failure 400 {object} map[string]string "Invalid input data"
 // @Failure 401 {object} map[string]string "Invalid credentials"
+// @Failure 403 {object} map[string]string "Account disabled, password reset required, login blocked or not a member of this organization"
+// @Failure 409 {object} map[string]string "Maximum number of active sessions reached"
 // @Failure 500 {object} map[string]string "Internal server error"
+// @Failure 503 {object} map[string]string "Directory unavailable"
 // @Router /auth/login [post]
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req user.LoginRequest
@@ -112,55 +163,482 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
+	// Avaliar o risco antes de conferir a senha, para que uma tentativa
+	// bloqueada não revele se a senha estava correta
+	attempt := h.loginAttempt(c, req.Email)
+	assessment := h.riskService.Assess(c.Request.Context(), attempt)
+	if assessment.Decision == risk.DecisionBlock {
+		h.recordAudit(c, &audit.Event{
+			Type:     audit.EventLoginFailed,
+			ActorID:  attempt.UserID,
+			TargetID: attempt.UserID,
+			Outcome:  audit.OutcomeFailure,
+			Reason:   "blocked by risk policy",
+			Metadata: map[string]string{"email": req.Email},
+		})
+		c.JSON(http.StatusForbidden, gin.H{"error": "Login blocked due to suspicious activity"})
+		return
+	}
+
 	// Autenticar usuário
-	user, err := h.userService.Authenticate(c.Request.Context(), req.Email, req.Password)
+	usr, err := h.userService.Authenticate(c.Request.Context(), req.Email, req.Password)
 	if err != nil {
+		h.riskService.Record(c.Request.Context(), attempt, false)
+		h.recordAudit(c, &audit.Event{
+			Type:     audit.EventLoginFailed,
+			Outcome:  audit.OutcomeFailure,
+			Reason:   err.Error(),
+			Metadata: map[string]string{"email": req.Email},
+		})
+		switch err {
+		case user.ErrUserDisabled:
+			c.JSON(http.StatusForbidden, gin.H{"error": "Account is disabled"})
+			return
+		case user.ErrMustResetPassword:
+			c.JSON(http.StatusForbidden, gin.H{"error": "Password reset required"})
+			return
+		case user.ErrDirectoryUnavailable:
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Directory unavailable"})
+			return
+		}
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 		return
 	}
 
-	// Gerar token JWT
-	token, err := h.jwtManager.GenerateToken(user.ID.Hex(), user.Email, user.Role)
+	// Com segundo fator cadastrado, a senha apenas abre o desafio de MFA, a
+	// menos que o navegador seja um dispositivo confiável e o login não seja
+	// de risco
+	if len(h.mfaService.Methods(usr)) > 0 {
+		if trusted := trustedDevice(c, h.deviceService, usr); trusted != nil && assessment.Decision == risk.DecisionAllow {
+			h.completeLogin(c, usr, req.OrganizationID, []string{types.AMRPassword}, map[string]string{"trusted_device": trusted.ID})
+			return
+		}
+		challenge, err := h.mfaService.StartChallenge(c.Request.Context(), usr, req.OrganizationID, []string{types.AMRPassword})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start MFA challenge"})
+			return
+		}
+		c.JSON(http.StatusOK, challenge)
+		return
+	}
+
+	// Sem fator cadastrado, o login de risco só é concluído com um código
+	// enviado ao e-mail da conta
+	if assessment.Decision == risk.DecisionRequireMFA {
+		challenge, err := h.mfaService.StartAccountEmailChallenge(c.Request.Context(), usr, req.OrganizationID, []string{types.AMRPassword})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start MFA challenge"})
+			return
+		}
+		c.JSON(http.StatusOK, challenge)
+		return
+	}
+
+	h.completeLogin(c, usr, req.OrganizationID, []string{types.AMRPassword}, nil)
+}
+
+// WebAuthnMFAOptions starts the passkey step of a login
+// @Summary Start WebAuthn second factor
+// @Description Return the navigator.credentials.get() options for the MFA challenge opened by the login
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body mfa.WebAuthnOptionsRequest true "MFA challenge"
+// @Success 200 {object} webauthn.RequestOptions "Assertion options"
+// @Failure 400 {object} map[string]string "Invalid input data or method not enrolled"
+// @Failure 401 {object} map[string]string "Invalid or expired MFA challenge"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /auth/mfa/webauthn/options [post]
+func (h *AuthHandler) WebAuthnMFAOptions(c *gin.Context) {
+	var req mfa.WebAuthnOptionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	challenge, err := h.mfaService.Challenge(c.Request.Context(), req.MFAToken, mfa.MethodWebAuthn)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		h.handleMFAError(c, err)
+		return
+	}
+
+	options, err := h.webauthnService.BeginLogin(c.Request.Context(), challenge.UserID)
+	if err != nil {
+		h.handleMFAError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, options)
+}
+
+// WebAuthnMFAVerify completes a login with a passkey as second factor
+// @Summary Verify WebAuthn second factor
+// @Description Verify the passkey assertion for the MFA challenge and start the session. With remember_device, the browser receives a trusted device cookie that skips the second factor on the next logins
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body mfa.WebAuthnVerifyRequest true "MFA challenge and assertion"
+// @Success 200 {object} map[string]interface{} "Login successful"
+// @Failure 400 {object} map[string]string "Invalid input data or method not enrolled"
+// @Failure 401 {object} map[string]string "Verification failed"
+// @Failure 403 {object} map[string]string "Account disabled or not a member of this organization"
+// @Failure 409 {object} map[string]string "Maximum number of active sessions reached"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /auth/mfa/webauthn/verify [post]
+func (h *AuthHandler) WebAuthnMFAVerify(c *gin.Context) {
+	var req mfa.WebAuthnVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	challenge, err := h.mfaService.Challenge(c.Request.Context(), req.MFAToken, mfa.MethodWebAuthn)
+	if err != nil {
+		h.handleMFAError(c, err)
+		return
+	}
+
+	usr, err := h.webauthnService.FinishLogin(c.Request.Context(), challenge.UserID, &req.Credential)
+	if err == nil {
+		// O desafio só é consumido depois do fator verificado, permitindo nova tentativa
+		_, err = h.mfaService.Complete(c.Request.Context(), req.MFAToken)
+	}
+	if err != nil {
+		h.recordAudit(c, &audit.Event{
+			Type:     audit.EventLoginFailed,
+			ActorID:  challenge.UserID,
+			TargetID: challenge.UserID,
+			Outcome:  audit.OutcomeFailure,
+			Reason:   err.Error(),
+			Metadata: map[string]string{"mfa": mfa.MethodWebAuthn},
+		})
+		h.handleMFAError(c, err)
+		return
+	}
+
+	rememberDevice(c, h.deviceService, usr, &req.TrustRequest)
+	h.completeLogin(c, usr, challenge.OrganizationID, mfaAMR(challenge, mfa.MethodWebAuthn), map[string]string{"mfa": mfa.MethodWebAuthn})
+}
+
+// OTPMFASend sends a one-time code for the second step of a login
+// @Summary Send one-time code
+// @Description Send a one-time code to the email address or phone number enrolled for the MFA challenge opened by the login, or to the account email when a risky login of a user without a second factor opened it. A new code replaces the previous one
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body mfa.OTPSendRequest true "MFA challenge and channel"
+// @Success 200 {object} otp.SendResponse "Code sent"
+// @Failure 400 {object} map[string]string "Invalid input data or method not enrolled"
+// @Failure 401 {object} map[string]string "Invalid or expired MFA challenge"
+// @Failure 429 {object} map[string]string "Too many codes requested"
+// @Failure 502 {object} map[string]string "Failed to deliver the code"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /auth/mfa/otp/send [post]
+func (h *AuthHandler) OTPMFASend(c *gin.Context) {
+	var req mfa.OTPSendRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	challenge, err := h.mfaService.Challenge(c.Request.Context(), req.MFAToken, mfa.OTPMethod(req.Channel))
+	if err != nil {
+		h.handleMFAError(c, err)
+		return
+	}
+
+	var sent *otp.SendResponse
+	if challenge.AccountEmail {
+		sent, err = h.otpService.SendAccountCode(c.Request.Context(), challenge.UserID)
+	} else {
+		sent, err = h.otpService.SendLoginCode(c.Request.Context(), challenge.UserID, req.Channel)
+	}
+	if err != nil {
+		h.handleMFAError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, sent)
+}
+
+// OTPMFAVerify completes a login with a one-time code as second factor
+// @Summary Verify one-time code
+// @Description Verify the code sent for the MFA challenge and start the session. Each code accepts a limited number of attempts. With remember_device, the browser receives a trusted device cookie that skips the second factor on the next logins
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body mfa.OTPVerifyRequest true "MFA challenge and code"
+// @Success 200 {object} map[string]interface{} "Login successful"
+// @Failure 400 {object} map[string]string "Invalid input data or method not enrolled"
+// @Failure 401 {object} map[string]string "Invalid or expired code"
+// @Failure 403 {object} map[string]string "Account disabled or not a member of this organization"
+// @Failure 429 {object} map[string]string "Too many attempts"
+// @Failure 409 {object} map[string]string "Maximum number of active sessions reached"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /auth/mfa/otp/verify [post]
+func (h *AuthHandler) OTPMFAVerify(c *gin.Context) {
+	var req mfa.OTPVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	method := mfa.OTPMethod(req.Channel)
+	challenge, err := h.mfaService.Challenge(c.Request.Context(), req.MFAToken, method)
+	if err != nil {
+		h.handleMFAError(c, err)
+		return
+	}
+
+	var usr *user.User
+	if challenge.AccountEmail {
+		usr, err = h.otpService.VerifyAccountCode(c.Request.Context(), challenge.UserID, req.Code)
+	} else {
+		usr, err = h.otpService.VerifyLoginCode(c.Request.Context(), challenge.UserID, req.Channel, req.Code)
+	}
+	if err == nil {
+		_, err = h.mfaService.Complete(c.Request.Context(), req.MFAToken)
+	}
+	if err != nil {
+		h.recordAudit(c, &audit.Event{
+			Type:     audit.EventLoginFailed,
+			ActorID:  challenge.UserID,
+			TargetID: challenge.UserID,
+			Outcome:  audit.OutcomeFailure,
+			Reason:   err.Error(),
+			Metadata: map[string]string{"mfa": method},
+		})
+		h.handleMFAError(c, err)
+		return
+	}
+
+	rememberDevice(c, h.deviceService, usr, &req.TrustRequest)
+	h.completeLogin(c, usr, challenge.OrganizationID, mfaAMR(challenge, method), map[string]string{"mfa": method})
+}
+
+// ReauthenticationOptions prepares a second factor to confirm the session owner
+// @Summary Start reauthentication
+// @Description Return the passkey assertion options or send a one-time code to reauthenticate the current session
+// @Tags auth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body mfa.ReauthenticationOptionsRequest true "Reauthentication method"
+// @Success 200 {object} map[string]interface{} "Assertion options or code sent"
+// @Failure 400 {object} map[string]string "Invalid input data or method not enrolled"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Not allowed during impersonation"
+// @Failure 429 {object} map[string]string "Too many codes requested"
+// @Failure 502 {object} map[string]string "Failed to deliver the code"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /auth/reauthenticate/options [post]
+func (h *AuthHandler) ReauthenticationOptions(c *gin.Context) {
+	var req mfa.ReauthenticationOptionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	authContext, _ := c.Get("authContext")
+	authCtx := authContext.(*types.AuthContext)
+
+	if req.Method == mfa.MethodWebAuthn {
+		options, err := h.webauthnService.BeginLogin(c.Request.Context(), authCtx.UserID)
+		if err != nil {
+			h.handleMFAError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, options)
+		return
+	}
+
+	sent, err := h.otpService.SendLoginCode(c.Request.Context(), authCtx.UserID, mfa.OTPChannel(req.Method))
+	if err != nil {
+		h.handleMFAError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, sent)
+}
+
+// Reauthenticate confirms the session owner and refreshes the authentication time
+// @Summary Reauthenticate session
+// @Description Verify the password, a passkey or a one-time code again and mark the current session as recently authenticated, as required by sensitive operations
+// @Tags auth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body mfa.ReauthenticateRequest true "Method and proof"
+// @Success 200 {object} map[string]interface{} "Reauthenticated"
+// @Failure 400 {object} map[string]string "Invalid input data or method not enrolled"
+// @Failure 401 {object} map[string]string "Verification failed"
+// @Failure 403 {object} map[string]string "Account disabled, password reset required or not allowed during impersonation"
+// @Failure 429 {object} map[string]string "Too many attempts"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Failure 503 {object} map[string]string "Directory unavailable"
+// @Router /auth/reauthenticate [post]
+func (h *AuthHandler) Reauthenticate(c *gin.Context) {
+	var req mfa.ReauthenticateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Method == mfa.MethodWebAuthn && req.Credential == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Credential is required"})
+		return
+	}
+
+	authContext, _ := c.Get("authContext")
+	authCtx := authContext.(*types.AuthContext)
+
+	var err error
+	switch req.Method {
+	case mfa.MethodPassword:
+		err = h.verifyPassword(c, authCtx.UserID, req.Password)
+	case mfa.MethodWebAuthn:
+		_, err = h.webauthnService.FinishLogin(c.Request.Context(), authCtx.UserID, req.Credential)
+	default:
+		_, err = h.otpService.VerifyLoginCode(c.Request.Context(), authCtx.UserID, mfa.OTPChannel(req.Method), req.Code)
+	}
+	if err != nil {
+		h.recordAudit(c, &audit.Event{
+			Type:     audit.EventReauthenticated,
+			ActorID:  authCtx.UserID,
+			TargetID: authCtx.UserID,
+			Outcome:  audit.OutcomeFailure,
+			Reason:   err.Error(),
+			Metadata: map[string]string{"method": req.Method},
+		})
+		switch {
+		case errors.Is(err, user.ErrInvalidEmail), errors.Is(err, user.ErrInvalidPassword):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		case errors.Is(err, user.ErrMustResetPassword):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Password reset required"})
+		case errors.Is(err, user.ErrDirectoryUnavailable):
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Directory unavailable"})
+		default:
+			h.handleMFAError(c, err)
+		}
+		return
+	}
+
+	// A reautenticação substitui os métodos da sessão pelo que acabou de ser verificado
+	authCtx.AuthTime = time.Now().UTC()
+	authCtx.AMR = []string{mfa.AMR(req.Method)}
+
+	token, _ := c.Get("jwtToken")
+	if err := h.authService.UpdateToken(c.Request.Context(), token.(string), authCtx); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update session"})
+		return
+	}
+
+	h.recordAudit(c, &audit.Event{
+		Type:     audit.EventReauthenticated,
+		ActorID:  authCtx.UserID,
+		TargetID: authCtx.UserID,
+		Metadata: map[string]string{"method": req.Method},
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Reauthenticated successfully",
+		"auth_time": authCtx.AuthTime,
+		"amr":       authCtx.AMR,
+	})
+}
+
+// SwitchOrganization changes the active organization of the session
+// @Summary Switch organization
+// @Description Issue a new token scoped to another organization the user belongs to and invalidate the current one
+// @Tags auth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body organization.SwitchOrganizationRequest true "Target organization"
+// @Success 200 {object} map[string]interface{} "Organization switched"
+// @Failure 400 {object} map[string]string "Invalid input data"
+// @Failure 401 {object} map[string]string "Unauthorized or session expired"
+// @Failure 403 {object} map[string]string "Not a member of this organization"
+// @Failure 409 {object} map[string]string "Maximum number of active sessions reached"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /auth/switch-organization [post]
+func (h *AuthHandler) SwitchOrganization(c *gin.Context) {
+	var req organization.SwitchOrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	authContext, exists := c.Get("authContext")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	current := authContext.(*types.AuthContext)
+	if current.IsImpersonated() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Operation not allowed during impersonation"})
 		return
 	}
 
-	// Armazenar token no Redis
 	authCtx := &types.AuthContext{
-		UserID:      user.ID.Hex(),
-		Email:       user.Email,
-		Role:        user.Role,
-		Permissions: h.authService.GetUserPermissions(user.Role),
+		UserID:      current.UserID,
+		Email:       current.Email,
+		Role:        current.Role,
+		Permissions: current.Permissions,
+		AuthTime:    current.AuthTime,
+		AMR:         current.AMR,
+		ExpiresAt:   current.ExpiresAt,
+	}
+	if err := h.selectTenant(c, authCtx, req.OrganizationID); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Not a member of this organization"})
+		return
 	}
 
-	err = h.authService.StoreToken(c.Request.Context(), token, authCtx, h.jwtManager.GetTokenDuration())
+	token, err := h.jwtManager.GenerateTenantToken(authCtx.UserID, authCtx.Email, authCtx.Role, authCtx.TenantID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	// A sessão antiga é trocada pela nova de uma vez, para que a troca não
+	// conte como uma sessão a mais e uma falha não deixe o usuário sem sessão
+	oldToken, exists := c.Get("jwtToken")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+	err = h.authService.ReplaceToken(c.Request.Context(), oldToken.(string), token, authCtx, h.jwtManager.GetTokenDuration())
 	if err != nil {
+		if errors.Is(err, auth.ErrSessionExpired) || errors.Is(err, auth.ErrSessionNotFound) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Session expired, please log in again"})
+			return
+		}
+		if tooManySessions(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store session"})
 		return
 	}
+	h.cookies.Set(c, token, h.jwtManager.GetTokenDuration())
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Login successful",
-		"token":   token,
-		"user": gin.H{
-			"id":    user.ID.Hex(),
-			"name":  user.Name,
-			"email": user.Email,
-			"role":  user.Role,
-		},
+		"message":         "Organization switched successfully",
+		"token":           token,
+		"organization_id": authCtx.TenantID,
+		"role":            authCtx.TenantRole,
 	})
 }
 
 // Logout handles user logout
 // @Summary Logout user
-// @Description Invalidate current session token
+// @Description Invalidate current session token and clear the session cookies
 // @Tags auth
 // @Security BearerAuth
 // @Produce json
 // @Success 200 {object} map[string]string "Logout successful"
 // @Failure 400 {object} map[string]string "No active session"
 // @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Invalid CSRF token"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /auth/logout [post]
 func (h *AuthHandler) Logout(c *gin.Context) {
@@ -175,18 +653,22 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to logout"})
 		return
 	}
+	h.cookies.Clear(c)
+
+	h.recordAudit(c, &audit.Event{Type: audit.EventLogout})
 
 	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
 }
 
 // LogoutAll handles logout from all devices
 // @Summary Logout from all devices
-// @Description Invalidate all user sessions
+// @Description Invalidate all user sessions and clear the session cookies
 // @Tags auth
 // @Security BearerAuth
 // @Produce json
 // @Success 200 {object} map[string]string "Logout from all devices successful"
 // @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Invalid CSRF token or not allowed during impersonation"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /auth/logout-all [post]
 func (h *AuthHandler) LogoutAll(c *gin.Context) {
@@ -202,18 +684,62 @@ func (h *AuthHandler) LogoutAll(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to logout from all devices"})
 		return
 	}
+	h.cookies.Clear(c)
+
+	h.recordAudit(c, &audit.Event{Type: audit.EventLogoutAll, TargetID: authCtx.UserID})
 
 	c.JSON(http.StatusOK, gin.H{"message": "Logged out from all devices successfully"})
 }
 
+// StopImpersonation ends an impersonation session
+// @Summary Stop impersonation
+// @Description Invalidate the current impersonation token
+// @Tags auth
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} map[string]string "Impersonation stopped"
+// @Failure 400 {object} map[string]string "Not an impersonation session"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /auth/impersonation/stop [post]
+func (h *AuthHandler) StopImpersonation(c *gin.Context) {
+	authContext, exists := c.Get("authContext")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	authCtx := authContext.(*types.AuthContext)
+	if !authCtx.IsImpersonated() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Not an impersonation session"})
+		return
+	}
+
+	token, _ := c.Get("jwtToken")
+	if err := h.authService.DeleteToken(c.Request.Context(), token.(string)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to stop impersonation"})
+		return
+	}
+
+	h.recordAudit(c, &audit.Event{
+		Type:     audit.EventImpersonationStopped,
+		ActorID:  authCtx.ImpersonatorID,
+		TargetID: authCtx.UserID,
+	})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Impersonation stopped"})
+}
+
 // RefreshToken handles token refresh
 // @Summary Refresh authentication token
-// @Description Get a new token with extended expiration
+// @Description Get a new token with extended expiration. The new session keeps the active organization, the last authentication and the absolute lifetime of the original login; past it, a new login is required
 // @Tags auth
 // @Security BearerAuth
 // @Produce json
 // @Success 200 {object} map[string]interface{} "Token refreshed successfully"
-// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 401 {object} map[string]string "Unauthorized or session expired"
+// @Failure 403 {object} map[string]string "Not allowed during impersonation"
+// @Failure 409 {object} map[string]string "Maximum number of active sessions reached"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /auth/refresh [post]
 func (h *AuthHandler) RefreshToken(c *gin.Context) {
@@ -223,31 +749,62 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 		return
 	}
 
-	authCtx := authContext.(*types.AuthContext)
+	current := authContext.(*types.AuthContext)
+
+	// Tokens de personificação não podem ser estendidos
+	if current.IsImpersonated() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Operation not allowed during impersonation"})
+		return
+	}
+
+	// A nova sessão mantém a organização ativa, a última autenticação e o
+	// fim absoluto da original
+	authCtx := &types.AuthContext{
+		UserID:      current.UserID,
+		Email:       current.Email,
+		Role:        current.Role,
+		Permissions: current.Permissions,
+		TenantID:    current.TenantID,
+		TenantRole:  current.TenantRole,
+		AuthTime:    current.AuthTime,
+		AMR:         current.AMR,
+		ExpiresAt:   current.ExpiresAt,
+	}
 
 	// Gerar novo token
-	newToken, err := h.jwtManager.GenerateToken(authCtx.UserID, authCtx.Email, authCtx.Role)
+	newToken, err := h.jwtManager.GenerateTenantToken(authCtx.UserID, authCtx.Email, authCtx.Role, authCtx.TenantID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate new token"})
 		return
 	}
 
-	// Invalidar token antigo
+	// Trocar o token antigo pelo novo no Redis
 	oldToken, exists := c.Get("jwtToken")
-	if exists {
-		h.authService.DeleteToken(c.Request.Context(), oldToken.(string))
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
 	}
-
-	// Armazenar novo token no Redis
-	err = h.authService.StoreToken(c.Request.Context(), newToken, authCtx, h.jwtManager.GetTokenDuration())
+	err = h.authService.ReplaceToken(c.Request.Context(), oldToken.(string), newToken, authCtx, h.jwtManager.GetTokenDuration())
 	if err != nil {
+		// O refresh não estende o tempo máximo da sessão
+		if errors.Is(err, auth.ErrSessionExpired) || errors.Is(err, auth.ErrSessionNotFound) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Session expired, please log in again"})
+			return
+		}
+		if tooManySessions(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store new session"})
 		return
 	}
+	h.cookies.Set(c, newToken, h.jwtManager.GetTokenDuration())
+
+	h.recordAudit(c, &audit.Event{Type: audit.EventTokenRefreshed, TargetID: authCtx.UserID})
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Token refreshed successfully",
-		"token":   newToken,
+		"message":         "Token refreshed successfully",
+		"token":           newToken,
+		"organization_id": authCtx.TenantID,
 	})
 }
 
@@ -338,4 +895,171 @@ func (h *AuthHandler) GetSessions(c *gin.Context) {
 		"sessions": "Session list functionality requires additional Redis implementation",
 		"message":  "Active sessions retrieved successfully",
 	})
-}
\ No newline at end of file
+}
+
+// mfaAMR combina os métodos do primeiro passo do desafio com o segundo fator
+func mfaAMR(challenge *mfa.Challenge, method string) []string {
+	amr := append([]string{}, challenge.AMR...)
+	if factor := mfa.AMR(method); !slices.Contains(amr, factor) {
+		amr = append(amr, factor)
+	}
+	return append(amr, types.AMRMultiFactor)
+}
+
+// loginAttempt descreve a tentativa de login para a avaliação de risco. Contas
+// que ainda não existem localmente (diretório externo) ficam sem UserID.
+func (h *AuthHandler) loginAttempt(c *gin.Context, email string) *risk.Attempt {
+	attempt := &risk.Attempt{
+		Email:     email,
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		Time:      time.Now().UTC(),
+	}
+	if usr, err := h.userService.GetUserByEmail(c.Request.Context(), email); err == nil {
+		attempt.UserID = usr.ID
+	}
+	return attempt
+}
+
+// completeLogin emite a sessão de um usuário já autenticado pelos métodos
+// amr, com a organização ativa opcional. É o último passo do login por senha
+// e do segundo fator.
+func (h *AuthHandler) completeLogin(c *gin.Context, usr *user.User, organizationID string, amr []string, metadata map[string]string) {
+	permissions, err := h.authService.GetUserPermissions(c.Request.Context(), usr.ID.Hex(), usr.Role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve permissions"})
+		return
+	}
+
+	authCtx := &types.AuthContext{
+		UserID:      usr.ID.Hex(),
+		Email:       usr.Email,
+		Role:        usr.Role,
+		Permissions: permissions,
+		AuthTime:    time.Now().UTC(),
+		AMR:         amr,
+	}
+
+	// Selecionar a organização ativa, se informada
+	if organizationID != "" {
+		if err := h.selectTenant(c, authCtx, organizationID); err != nil {
+			h.recordAudit(c, &audit.Event{
+				Type:     audit.EventLoginFailed,
+				ActorID:  usr.ID.Hex(),
+				TargetID: usr.ID.Hex(),
+				Outcome:  audit.OutcomeFailure,
+				Reason:   err.Error(),
+				Metadata: map[string]string{"organization_id": organizationID},
+			})
+			c.JSON(http.StatusForbidden, gin.H{"error": "Not a member of this organization"})
+			return
+		}
+	}
+
+	// Gerar token JWT
+	token, err := h.jwtManager.GenerateTenantToken(usr.ID.Hex(), usr.Email, usr.Role, authCtx.TenantID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	// Armazenar token no Redis
+	err = h.authService.StoreToken(c.Request.Context(), token, authCtx, h.jwtManager.GetTokenDuration())
+	if err != nil {
+		if tooManySessions(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store session"})
+		return
+	}
+	h.cookies.Set(c, token, h.jwtManager.GetTokenDuration())
+
+	h.riskService.Record(c.Request.Context(), newLoginAttempt(c, usr), true)
+
+	checkNewLogin(c, h.alertService, usr, token)
+
+	h.recordAudit(c, &audit.Event{
+		Type:     audit.EventLogin,
+		ActorID:  usr.ID.Hex(),
+		TargetID: usr.ID.Hex(),
+		Metadata: metadata,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":         "Login successful",
+		"token":           token,
+		"organization_id": authCtx.TenantID,
+		"user": gin.H{
+			"id":    usr.ID.Hex(),
+			"name":  usr.Name,
+			"email": usr.Email,
+			"role":  usr.Role,
+		},
+	})
+}
+
+// selectTenant define a organização ativa da sessão após confirmar que o
+// usuário é membro dela.
+func (h *AuthHandler) selectTenant(c *gin.Context, authCtx *types.AuthContext, organizationID string) error {
+	membership, err := h.orgService.GetMembership(c.Request.Context(), organizationID, authCtx.UserID)
+	if err != nil {
+		return err
+	}
+
+	authCtx.TenantID = organizationID
+	authCtx.TenantRole = membership.Role
+	return nil
+}
+
+// verifyPassword confere a senha do dono da sessão pelo email atual da conta,
+// que pode ter mudado desde o login
+func (h *AuthHandler) verifyPassword(c *gin.Context, userID, password string) error {
+	current, err := h.userService.GetUserByID(c.Request.Context(), userID)
+	if err != nil {
+		return err
+	}
+
+	usr, err := h.userService.Authenticate(c.Request.Context(), current.Email, password)
+	if err != nil {
+		return err
+	}
+	if usr.ID.Hex() != userID {
+		return user.ErrInvalidPassword
+	}
+	return nil
+}
+
+func (h *AuthHandler) handleMFAError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, mfa.ErrInvalidChallenge):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired MFA challenge"})
+	case errors.Is(err, mfa.ErrMethodNotAllowed):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "MFA method not enrolled"})
+	case errors.Is(err, webauthn.ErrInvalidChallenge),
+		errors.Is(err, webauthn.ErrInvalidCredential),
+		errors.Is(err, webauthn.ErrCredentialNotFound),
+		errors.Is(err, webauthn.ErrClonedAuthenticator):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Second factor verification failed"})
+	case errors.Is(err, otp.ErrInvalidCode):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired verification code"})
+	case errors.Is(err, otp.ErrFactorNotFound):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "MFA method not enrolled"})
+	case errors.Is(err, otp.ErrTooManyAttempts):
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many attempts, request a new code"})
+	case errors.Is(err, otp.ErrRateLimited):
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many codes requested, try again later"})
+	case errors.Is(err, otp.ErrDeliveryFailed):
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to deliver verification code"})
+	case errors.Is(err, user.ErrUserDisabled):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Account is disabled"})
+	default:
+		log.Printf("Warning: mfa verification failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	}
+}
+
+func (h *AuthHandler) recordAudit(c *gin.Context, event *audit.Event) {
+	if err := h.auditService.Record(c.Request.Context(), event); err != nil {
+		log.Printf("Warning: failed to record audit event %s: %v", event.Type, err)
+	}
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/juanjerrah/go_auth_api/internal/domain/authz"
+)
+
+type AuthzHandler struct {
+	authzService authz.Service
+}
+
+func NewAuthzHandler(authzService authz.Service) *AuthzHandler {
+	return &AuthzHandler{
+		authzService: authzService,
+	}
+}
+
+// Check decides whether a principal may perform an action on a resource
+// @Summary Check authorization
+// @Description Decide whether the principal identified by an access token or user ID may perform an action on a resource, combining role permissions, relation tuples and policies
+// @Tags authz
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body authz.CheckRequest true "Authorization check"
+// @Success 200 {object} authz.CheckResponse "Decision"
+// @Failure 400 {object} map[string]string "Invalid request"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Insufficient permissions"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /authz/check [post]
+func (h *AuthzHandler) Check(c *gin.Context) {
+	var req authz.CheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.authzService.Check(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err, "Failed to check authorization")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// BatchCheck decides several authorization checks at once
+// @Summary Batch check authorization
+// @Description Decide up to 100 authorization checks in one request; results are returned in request order
+// @Tags authz
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body authz.BatchCheckRequest true "Authorization checks"
+// @Success 200 {object} authz.BatchCheckResponse "Decisions"
+// @Failure 400 {object} map[string]string "Invalid request"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Insufficient permissions"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /authz/check/batch [post]
+func (h *AuthzHandler) BatchCheck(c *gin.Context) {
+	var req authz.BatchCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.authzService.BatchCheck(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err, "Failed to check authorization")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *AuthzHandler) handleError(c *gin.Context, err error, message string) {
+	switch err {
+	case authz.ErrInvalidPrincipal:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Exactly one of token or subject is required"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/juanjerrah/go_auth_api/internal/domain/auth"
+	"github.com/juanjerrah/go_auth_api/internal/domain/device"
+	"github.com/juanjerrah/go_auth_api/internal/domain/user"
+)
+
+// Cookie que identifica um navegador confiável, enviado só às rotas de login
+const trustedDeviceCookie = "trusted_device"
+
+type DeviceHandler struct {
+	deviceService device.Service
+}
+
+func NewDeviceHandler(deviceService device.Service) *DeviceHandler {
+	return &DeviceHandler{deviceService: deviceService}
+}
+
+// ListDevices lists the trusted devices of the authenticated user
+// @Summary List trusted devices
+// @Description List the browsers where the authenticated user chose to skip the second factor
+// @Tags devices
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} user.TrustedDevice "Trusted devices"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /users/me/devices [get]
+func (h *DeviceHandler) ListDevices(c *gin.Context) {
+	authContext, _ := c.Get("authContext")
+	authCtx := authContext.(*auth.AuthContext)
+
+	devices, err := h.deviceService.List(c.Request.Context(), authCtx.UserID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, devices)
+}
+
+// RevokeDevice revokes a trusted device of the authenticated user
+// @Summary Revoke trusted device
+// @Description Require the second factor again on the given browser
+// @Tags devices
+// @Security BearerAuth
+// @Produce json
+// @Param deviceId path string true "Device ID"
+// @Success 200 {object} map[string]string "Device revoked"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Not allowed during impersonation"
+// @Failure 404 {object} map[string]string "Device not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /users/me/devices/{deviceId} [delete]
+func (h *DeviceHandler) RevokeDevice(c *gin.Context) {
+	authContext, _ := c.Get("authContext")
+	authCtx := authContext.(*auth.AuthContext)
+
+	if err := h.deviceService.Revoke(c.Request.Context(), authCtx.UserID, c.Param("deviceId")); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Device revoked successfully"})
+}
+
+// RevokeAllDevices revokes every trusted device of the authenticated user
+// @Summary Revoke all trusted devices
+// @Description Require the second factor again on every browser
+// @Tags devices
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Devices revoked"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Not allowed during impersonation"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /users/me/devices [delete]
+func (h *DeviceHandler) RevokeAllDevices(c *gin.Context) {
+	authContext, _ := c.Get("authContext")
+	authCtx := authContext.(*auth.AuthContext)
+
+	revoked, err := h.deviceService.RevokeAll(c.Request.Context(), authCtx.UserID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Devices revoked successfully", "revoked": revoked})
+}
+
+func (h *DeviceHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, device.ErrDeviceNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Device not found"})
+	case errors.Is(err, user.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	default:
+		log.Printf("Warning: trusted device operation failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	}
+}
+
+// rememberDevice confia no navegador da requisição quando o usuário pede no
+// segundo fator e grava o cookie que o identifica. Uma falha aqui não impede
+// o login, apenas o segundo fator volta a ser pedido.
+func rememberDevice(c *gin.Context, deviceService device.Service, usr *user.User, req *device.TrustRequest) {
+	if !req.RememberDevice {
+		return
+	}
+
+	trusted, err := deviceService.Trust(c.Request.Context(), usr, req.DeviceName)
+	if err != nil {
+		log.Printf("Warning: failed to trust device of user %s: %v", usr.ID.Hex(), err)
+		return
+	}
+
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     trustedDeviceCookie,
+		Value:    trusted.Cookie,
+		Path:     authCookiePath(c),
+		Expires:  trusted.ExpiresAt,
+		HttpOnly: true,
+		Secure:   isSecureRequest(c),
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// authCookiePath restringe um cookie às rotas de autenticação (/api/auth ou
+// /api/v1/auth) da requisição atual
+func authCookiePath(c *gin.Context) string {
+	path := c.Request.URL.Path
+	if index := strings.Index(path, "/auth/"); index >= 0 {
+		return path[:index+len("/auth")]
+	}
+	return "/"
+}
@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/juanjerrah/go_auth_api/internal/domain/group"
+)
+
+type GroupHandler struct {
+	groupService group.Service
+}
+
+func NewGroupHandler(groupService group.Service) *GroupHandler {
+	return &GroupHandler{
+		groupService: groupService,
+	}
+}
+
+// CreateGroup creates a permission group
+// @Summary Create group
+// @Description Create a group carrying a permission set, optionally nested in parent groups
+// @Tags groups
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body group.CreateGroupRequest true "Group data"
+// @Success 201 {object} group.GroupResponse "Group created"
+// @Failure 400 {object} map[string]string "Invalid input data"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Insufficient permissions"
+// @Failure 409 {object} map[string]string "Group name already in use"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/groups [post]
+func (h *GroupHandler) CreateGroup(c *gin.Context) {
+	var req group.CreateGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	grp, err := h.groupService.CreateGroup(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err, "Failed to create group")
+		return
+	}
+
+	c.JSON(http.StatusCreated, grp)
+}
+
+// ListGroups lists every group
+// @Summary List groups
+// @Description List all permission groups
+// @Tags groups
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} group.GroupResponse "Groups"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Insufficient permissions"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/groups [get]
+func (h *GroupHandler) ListGroups(c *gin.Context) {
+	groups, err := h.groupService.ListGroups(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list groups"})
+		return
+	}
+
+	c.JSON(http.StatusOK, groups)
+}
+
+// GetGroup returns a group
+// @Summary Get group
+// @Description Get a permission group by ID
+// @Tags groups
+// @Security BearerAuth
+// @Produce json
+// @Param groupId path string true "Group ID"
+// @Success 200 {object} group.GroupResponse "Group"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Insufficient permissions"
+// @Failure 404 {object} map[string]string "Group not found"
+// @Router /admin/groups/{groupId} [get]
+func (h *GroupHandler) GetGroup(c *gin.Context) {
+	grp, err := h.groupService.GetGroup(c.Request.Context(), c.Param("groupId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Group not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, grp)
+}
+
+// UpdateGroup updates a group
+// @Summary Update group
+// @Description Update name, description, permissions or parent groups; affected users get their permissions recomputed
+// @Tags groups
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param groupId path string true "Group ID"
+// @Param request body group.UpdateGroupRequest true "Group data"
+// @Success 200 {object} group.GroupResponse "Group updated"
+// @Failure 400 {object} map[string]string "Invalid input data"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Insufficient permissions"
+// @Failure 404 {object} map[string]string "Group not found"
+// @Failure 409 {object} map[string]string "Group name already in use"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/groups/{groupId} [put]
+func (h *GroupHandler) UpdateGroup(c *gin.Context) {
+	var req group.UpdateGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	grp, err := h.groupService.UpdateGroup(c.Request.Context(), c.Param("groupId"), &req)
+	if err != nil {
+		h.handleError(c, err, "Failed to update group")
+		return
+	}
+
+	c.JSON(http.StatusOK, grp)
+}
+
+// DeleteGroup deletes a group
+// @Summary Delete group
+// @Description Delete a group and detach it from groups that inherit from it
+// @Tags groups
+// @Security BearerAuth
+// @Produce json
+// @Param groupId path string true "Group ID"
+// @Success 200 {object} map[string]string "Group deleted"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Insufficient permissions"
+// @Failure 404 {object} map[string]string "Group not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/groups/{groupId} [delete]
+func (h *GroupHandler) DeleteGroup(c *gin.Context) {
+	if err := h.groupService.DeleteGroup(c.Request.Context(), c.Param("groupId")); err != nil {
+		h.handleError(c, err, "Failed to delete group")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Group deleted successfully"})
+}
+
+// ListMembers lists the direct members of a group
+// @Summary List group members
+// @Description List IDs of users directly assigned to a group
+// @Tags groups
+// @Security BearerAuth
+// @Produce json
+// @Param groupId path string true "Group ID"
+// @Success 200 {object} group.MembersResponse "Members"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Insufficient permissions"
+// @Failure 404 {object} map[string]string "Group not found"
+// @Router /admin/groups/{groupId}/members [get]
+func (h *GroupHandler) ListMembers(c *gin.Context) {
+	members, err := h.groupService.ListMembers(c.Request.Context(), c.Param("groupId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Group not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, members)
+}
+
+// AddMember adds a user to a group
+// @Summary Add group member
+// @Description Add a user to a group
+// @Tags groups
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param groupId path string true "Group ID"
+// @Param request body group.AddMemberRequest true "User"
+// @Success 200 {object} map[string]string "Member added"
+// @Failure 400 {object} map[string]string "Invalid input data"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Insufficient permissions"
+// @Failure 404 {object} map[string]string "Group or user not found"
+// @Failure 409 {object} map[string]string "User is already a member"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/groups/{groupId}/members [post]
+func (h *GroupHandler) AddMember(c *gin.Context) {
+	var req group.AddMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.groupService.AddMember(c.Request.Context(), c.Param("groupId"), req.UserID); err != nil {
+		h.handleError(c, err, "Failed to add member")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Member added successfully"})
+}
+
+// RemoveMember removes a user from a group
+// @Summary Remove group member
+// @Description Remove a user from a group
+// @Tags groups
+// @Security BearerAuth
+// @Produce json
+// @Param groupId path string true "Group ID"
+// @Param userId path string true "User ID"
+// @Success 200 {object} map[string]string "Member removed"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Insufficient permissions"
+// @Failure 404 {object} map[string]string "Group not found or user is not a member"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/groups/{groupId}/members/{userId} [delete]
+func (h *GroupHandler) RemoveMember(c *gin.Context) {
+	if err := h.groupService.RemoveMember(c.Request.Context(), c.Param("groupId"), c.Param("userId")); err != nil {
+		h.handleError(c, err, "Failed to remove member")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Member removed successfully"})
+}
+
+// ListUserGroups lists the groups a user belongs to
+// @Summary List user groups
+// @Description List the groups a user is directly assigned to
+// @Tags groups
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {array} group.GroupResponse "Groups"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Insufficient permissions"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/users/{id}/groups [get]
+func (h *GroupHandler) ListUserGroups(c *gin.Context) {
+	groups, err := h.groupService.ListUserGroups(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list groups"})
+		return
+	}
+
+	c.JSON(http.StatusOK, groups)
+}
+
+func (h *GroupHandler) handleError(c *gin.Context, err error, message string) {
+	switch err {
+	case group.ErrGroupNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Group not found"})
+	case group.ErrGroupMemberNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	case group.ErrNotGroupMember:
+		c.JSON(http.StatusNotFound, gin.H{"error": "User is not a member of this group"})
+	case group.ErrGroupNameInUse:
+		c.JSON(http.StatusConflict, gin.H{"error": "Group name already in use"})
+	case group.ErrAlreadyGroupMember:
+		c.JSON(http.StatusConflict, gin.H{"error": "User is already a member of this group"})
+	case group.ErrInvalidPermission:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid permission"})
+	case group.ErrInvalidParent:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid parent group"})
+	case group.ErrCyclicInheritance:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Group inheritance would create a cycle"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
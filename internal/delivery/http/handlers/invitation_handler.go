@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/juanjerrah/go_auth_api/internal/domain/auth"
+	"github.com/juanjerrah/go_auth_api/internal/domain/organization"
+	"github.com/juanjerrah/go_auth_api/internal/domain/user"
+)
+
+type InvitationHandler struct {
+	invitationService organization.InvitationService
+}
+
+func NewInvitationHandler(invitationService organization.InvitationService) *InvitationHandler {
+	return &InvitationHandler{
+		invitationService: invitationService,
+	}
+}
+
+// CreateInvitation invites an email address to the organization
+// @Summary Create invitation
+// @Description Send an expiring invitation link to join the organization with the given role
+// @Tags organizations
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param orgId path string true "Organization ID"
+// @Param request body organization.CreateInvitationRequest true "Invitation data"
+// @Success 201 {object} organization.InvitationResponse "Invitation created"
+// @Failure 400 {object} map[string]string "Invalid input data"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Insufficient organization permissions"
+// @Failure 404 {object} map[string]string "Organization not found"
+// @Failure 409 {object} map[string]string "Already a member or invitation pending"
+// @Failure 502 {object} map[string]string "Failed to deliver invitation"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /orgs/{orgId}/invitations [post]
+func (h *InvitationHandler) CreateInvitation(c *gin.Context) {
+	authContext, _ := c.Get("authContext")
+	authCtx := authContext.(*auth.AuthContext)
+
+	var req organization.CreateInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	invitation, err := h.invitationService.CreateInvitation(c.Request.Context(), c.Param("orgId"), authCtx.UserID, &req)
+	if err != nil {
+		switch err {
+		case organization.ErrOrganizationNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Organization not found"})
+		case organization.ErrAlreadyMember:
+			c.JSON(http.StatusConflict, gin.H{"error": "User is already a member"})
+		case organization.ErrInvitationPending:
+			c.JSON(http.StatusConflict, gin.H{"error": "A pending invitation already exists for this email"})
+		case organization.ErrInvitationDelivery:
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to deliver invitation"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create invitation"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, invitation)
+}
+
+// ListInvitations lists the organization's invitations
+// @Summary List invitations
+// @Description List invitations of the organization, optionally filtered by status
+// @Tags organizations
+// @Security BearerAuth
+// @Produce json
+// @Param orgId path string true "Organization ID"
+// @Param status query string false "Invitation status (pending, accepted, revoked, expired)"
+// @Success 200 {array} organization.InvitationResponse "Invitations"
+// @Failure 400 {object} map[string]string "Invalid input data"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Insufficient organization permissions"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /orgs/{orgId}/invitations [get]
+func (h *InvitationHandler) ListInvitations(c *gin.Context) {
+	var req organization.ListInvitationsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	invitations, err := h.invitationService.ListInvitations(c.Request.Context(), c.Param("orgId"), &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list invitations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, invitations)
+}
+
+// RevokeInvitation revokes a pending invitation
+// @Summary Revoke invitation
+// @Description Revoke an invitation so its link can no longer be used
+// @Tags organizations
+// @Security BearerAuth
+// @Produce json
+// @Param orgId path string true "Organization ID"
+// @Param invitationId path string true "Invitation ID"
+// @Success 200 {object} map[string]string "Invitation revoked"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Insufficient organization permissions"
+// @Failure 404 {object} map[string]string "Invitation not found"
+// @Failure 409 {object} map[string]string "Invitation already accepted"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /orgs/{orgId}/invitations/{invitationId} [delete]
+func (h *InvitationHandler) RevokeInvitation(c *gin.Context) {
+	err := h.invitationService.RevokeInvitation(c.Request.Context(), c.Param("orgId"), c.Param("invitationId"))
+	if err != nil {
+		switch err {
+		case organization.ErrInvitationNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Invitation not found"})
+		case organization.ErrInvitationAlreadyUsed:
+			c.JSON(http.StatusConflict, gin.H{"error": "Invitation already accepted"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke invitation"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Invitation revoked successfully"})
+}
+
+// AcceptInvitation accepts an invitation link
+// @Summary Accept invitation
+// @Description Join the organization from an invitation link. Existing accounts must provide their password; otherwise a new account is registered with the given name and password.
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Param request body organization.AcceptInvitationRequest true "Invitation token and credentials"
+// @Success 200 {object} organization.MemberResponse "Invitation accepted"
+// @Failure 400 {object} map[string]string "Invalid input data"
+// @Failure 401 {object} map[string]string "Invalid credentials"
+// @Failure 404 {object} map[string]string "Invitation not found"
+// @Failure 409 {object} map[string]string "Invitation already accepted"
+// @Failure 410 {object} map[string]string "Invitation expired or revoked"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /invitations/accept [post]
+func (h *InvitationHandler) AcceptInvitation(c *gin.Context) {
+	var req organization.AcceptInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	member, err := h.invitationService.AcceptInvitation(c.Request.Context(), &req)
+	if err != nil {
+		switch err {
+		case organization.ErrInvitationNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Invitation not found"})
+		case organization.ErrInvitationAlreadyUsed:
+			c.JSON(http.StatusConflict, gin.H{"error": "Invitation already accepted"})
+		case organization.ErrInvitationExpired:
+			c.JSON(http.StatusGone, gin.H{"error": "Invitation expired or revoked"})
+		case organization.ErrNameRequired:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Name is required to create an account"})
+		case user.ErrInvalidEmail, user.ErrInvalidPassword:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to accept invitation"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Invitation accepted successfully",
+		"member":  member,
+	})
+}
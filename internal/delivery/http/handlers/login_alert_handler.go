@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/juanjerrah/go_auth_api/internal/domain/loginalert"
+	"github.com/juanjerrah/go_auth_api/internal/domain/user"
+)
+
+// Página intermediária do "não fui eu": como no link de login, só o envio do
+// formulário consome o aviso, não o GET de scanners de e-mail
+var loginAlertConfirmPage = template.Must(template.New("confirm").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><meta name="robots" content="noindex"><title>Secure your account</title></head>
+<body>
+<p>If you did not sign in, end that session and reset your password.</p>
+<form method="post">
+<input type="hidden" name="token" value="{{.}}">
+<button type="submit">This wasn't me</button>
+</form>
+</body>
+</html>
+`))
+
+type LoginAlertHandler struct {
+	loginAlertService loginalert.Service
+}
+
+func NewLoginAlertHandler(loginAlertService loginalert.Service) *LoginAlertHandler {
+	return &LoginAlertHandler{loginAlertService: loginAlertService}
+}
+
+// ConfirmReport shows the confirmation page of a login alert
+// @Summary Confirm a login report
+// @Description Page opened from the new sign-in email. It does not consume the link; the user confirms with a POST, so mail scanners that prefetch links cannot use them
+// @Tags auth
+// @Produce html
+// @Param token query string true "Login alert token"
+// @Success 200 {string} string "Confirmation page"
+// @Failure 400 {object} map[string]string "Missing token"
+// @Router /auth/login-alerts/report [get]
+func (h *LoginAlertHandler) ConfirmReport(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing token"})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Referrer-Policy", "no-referrer")
+	c.Header("X-Robots-Tag", "noindex")
+	c.Header("Content-Security-Policy", "default-src 'none'; form-action 'self'")
+	c.Status(http.StatusOK)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	if err := loginAlertConfirmPage.Execute(c.Writer, token); err != nil {
+		log.Printf("Warning: failed to render login alert page: %v", err)
+	}
+}
+
+// Report handles a "this wasn't me" answer to a login alert
+// @Summary Report an unrecognized login
+// @Description End the session opened by the reported login and block password logins until the password is reset. Returns the token for /auth/password/reset; accounts whose password is managed by an external directory only have the session ended
+// @Tags auth
+// @Accept json,x-www-form-urlencoded
+// @Produce json
+// @Param request body loginalert.ReportRequest true "Login alert token"
+// @Success 200 {object} loginalert.ReportResponse "Session ended"
+// @Failure 400 {object} map[string]string "Invalid input data"
+// @Failure 401 {object} map[string]string "Invalid or expired link"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /auth/login-alerts/report [post]
+func (h *LoginAlertHandler) Report(c *gin.Context) {
+	var req loginalert.ReportRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.loginAlertService.Report(c.Request.Context(), req.Token)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, response)
+}
+
+// ResetPassword sets a new password after a reported login
+// @Summary Reset password
+// @Description Set a new password with the token returned by the login report and end every session of the user
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body loginalert.ResetPasswordRequest true "Reset token and new password"
+// @Success 200 {object} map[string]string "Password reset"
+// @Failure 400 {object} map[string]string "Invalid input data"
+// @Failure 401 {object} map[string]string "Invalid or expired token"
+// @Failure 409 {object} map[string]string "Password managed by an external directory"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /auth/password/reset [post]
+func (h *LoginAlertHandler) ResetPassword(c *gin.Context) {
+	var req loginalert.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.loginAlertService.ResetPassword(c.Request.Context(), req.Token, req.NewPassword); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset successfully"})
+}
+
+func (h *LoginAlertHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, loginalert.ErrInvalidToken), errors.Is(err, user.ErrUserNotFound):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired link"})
+	case errors.Is(err, user.ErrExternalPassword):
+		c.JSON(http.StatusConflict, gin.H{"error": "Password is managed by an external directory"})
+	default:
+		log.Printf("Warning: login alert operation failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	}
+}
@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/juanjerrah/go_auth_api/internal/domain/audit"
+	"github.com/juanjerrah/go_auth_api/internal/domain/auth"
+	"github.com/juanjerrah/go_auth_api/internal/domain/device"
+	"github.com/juanjerrah/go_auth_api/internal/domain/loginalert"
+	"github.com/juanjerrah/go_auth_api/internal/domain/magiclink"
+	"github.com/juanjerrah/go_auth_api/internal/domain/mfa"
+	"github.com/juanjerrah/go_auth_api/internal/domain/risk"
+	"github.com/juanjerrah/go_auth_api/internal/domain/user"
+	"github.com/juanjerrah/go_auth_api/pkg/middleware"
+)
+
+// Página intermediária do link: scanners de e-mail seguem o GET, mas não
+// enviam o formulário, então o link só é consumido pelo clique do usuário
+var magicLinkConfirmPage = template.Must(template.New("confirm").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><meta name="robots" content="noindex"><title>Sign in</title></head>
+<body>
+<form method="post">
+<input type="hidden" name="token" value="{{.}}">
+<button type="submit">Sign in</button>
+</form>
+</body>
+</html>
+`))
+
+type MagicLinkHandler struct {
+	magicLinkService magiclink.Service
+	jwtManager       *auth.JWTManager
+	authService      auth.AuthService
+	auditService     audit.Service
+	mfaService       mfa.Service
+	deviceService    device.Service
+	alertService     loginalert.Service
+	riskService      risk.Service
+	cookies          *middleware.SessionCookies
+}
+
+func NewMagicLinkHandler(magicLinkService magiclink.Service, jwtManager *auth.JWTManager, authService auth.AuthService, auditService audit.Service, mfaService mfa.Service, deviceService device.Service, alertService loginalert.Service, riskService risk.Service, cookies *middleware.SessionCookies) *MagicLinkHandler {
+	return &MagicLinkHandler{
+		magicLinkService: magicLinkService,
+		jwtManager:       jwtManager,
+		authService:      authService,
+		auditService:     auditService,
+		mfaService:       mfaService,
+		deviceService:    deviceService,
+		alertService:     alertService,
+		riskService:      riskService,
+		cookies:          cookies,
+	}
+}
+
+// RequestLink emails a sign-in link
+// @Summary Request a sign-in link
+// @Description Email a single-use, short-lived sign-in link. The response is the same whether or not the address has an account
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body magiclink.RequestLinkRequest true "Email address"
+// @Success 202 {object} map[string]string "Link sent if the account exists"
+// @Failure 400 {object} map[string]string "Invalid input data"
+// @Failure 429 {object} map[string]string "Too many links requested"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /auth/magic-link [post]
+func (h *MagicLinkHandler) RequestLink(c *gin.Context) {
+	var req magiclink.RequestLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.magicLinkService.RequestLink(c.Request.Context(), req.Email); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "If the address belongs to an account, a sign-in link has been sent"})
+}
+
+// ConfirmLink shows the confirmation page for a sign-in link
+// @Summary Confirm a sign-in link
+// @Description Page opened from the email. It does not consume the link; the user confirms with a POST, so mail scanners that prefetch links cannot use them
+// @Tags auth
+// @Produce html
+// @Param token query string true "Sign-in link token"
+// @Success 200 {string} string "Confirmation page"
+// @Failure 400 {object} map[string]string "Missing token"
+// @Router /auth/magic-link/consume [get]
+func (h *MagicLinkHandler) ConfirmLink(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing token"})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Referrer-Policy", "no-referrer")
+	c.Header("X-Robots-Tag", "noindex")
+	c.Header("Content-Security-Policy", "default-src 'none'; form-action 'self'")
+	c.Status(http.StatusOK)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	if err := magicLinkConfirmPage.Execute(c.Writer, token); err != nil {
+		log.Printf("Warning: failed to render magic link page: %v", err)
+	}
+}
+
+// ConsumeLink exchanges a sign-in link for a session
+// @Summary Consume a sign-in link
+// @Description Exchange a sign-in link token for a session. Each link works only once. Users with a second factor receive an MFA challenge instead of the token, unless the browser is a trusted device and the login is not considered risky. Risky logins of users without a second factor are blocked
+// @Tags auth
+// @Accept json,x-www-form-urlencoded
+// @Produce json
+// @Param request body magiclink.ConsumeLinkRequest true "Sign-in link token"
+// @Success 200 {object} map[string]interface{} "Login successful"
+// @Failure 400 {object} map[string]string "Invalid input data"
+// @Failure 401 {object} map[string]string "Invalid or expired link"
+// @Failure 403 {object} map[string]string "Account disabled or login blocked"
+// @Failure 409 {object} map[string]string "Maximum number of active sessions reached"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /auth/magic-link/consume [post]
+func (h *MagicLinkHandler) ConsumeLink(c *gin.Context) {
+	var req magiclink.ConsumeLinkRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	usr, err := h.magicLinkService.ConsumeLink(c.Request.Context(), req.Token)
+	if err != nil {
+		h.recordAudit(c, &audit.Event{
+			Type:     audit.EventLoginFailed,
+			Outcome:  audit.OutcomeFailure,
+			Reason:   err.Error(),
+			Metadata: map[string]string{"method": "magic_link"},
+		})
+		h.handleError(c, err)
+		return
+	}
+
+	attempt := newLoginAttempt(c, usr)
+	assessment := assessLogin(c, h.riskService, h.auditService, attempt, "magic_link")
+	if assessment == nil {
+		return
+	}
+
+	// O link substitui só a senha; o segundo fator continua obrigatório fora
+	// dos dispositivos confiáveis e nos logins de risco
+	hasMFA := len(h.mfaService.Methods(usr)) > 0
+	if hasMFA && (assessment.Decision != risk.DecisionAllow || trustedDevice(c, h.deviceService, usr) == nil) {
+		challenge, err := h.mfaService.StartChallenge(c.Request.Context(), usr, "", []string{auth.AMROTP})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start MFA challenge"})
+			return
+		}
+		c.Header("Cache-Control", "no-store")
+		c.JSON(http.StatusOK, challenge)
+		return
+	}
+	// Sem fator cadastrado, um código por e-mail não provaria mais que o
+	// próprio link; o login de risco é recusado
+	if assessment.Decision == risk.DecisionRequireMFA {
+		blockLogin(c, h.auditService, attempt, "magic_link", "second factor required by risk policy")
+		return
+	}
+
+	token, err := issueSession(c, h.jwtManager, h.authService, h.alertService, h.cookies, usr, []string{auth.AMROTP})
+	if err != nil {
+		if tooManySessions(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start session"})
+		return
+	}
+	h.riskService.Record(c.Request.Context(), attempt, true)
+
+	h.recordAudit(c, &audit.Event{
+		Type:     audit.EventLogin,
+		ActorID:  usr.ID.Hex(),
+		TargetID: usr.ID.Hex(),
+		Metadata: map[string]string{"method": "magic_link"},
+	})
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Login successful",
+		"token":   token,
+		"user": gin.H{
+			"id":    usr.ID.Hex(),
+			"name":  usr.Name,
+			"email": usr.Email,
+			"role":  usr.Role,
+		},
+	})
+}
+
+func (h *MagicLinkHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, magiclink.ErrInvalidLink):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired link"})
+	case errors.Is(err, magiclink.ErrRateLimited):
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many sign-in links requested, try again later"})
+	case errors.Is(err, user.ErrUserDisabled):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Account is disabled"})
+	default:
+		log.Printf("Warning: magic link login failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	}
+}
+
+func (h *MagicLinkHandler) recordAudit(c *gin.Context, event *audit.Event) {
+	if err := h.auditService.Record(c.Request.Context(), event); err != nil {
+		log.Printf("Warning: failed to record audit event %s: %v", event.Type, err)
+	}
+}
@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/juanjerrah/go_auth_api/internal/domain/audit"
+	"github.com/juanjerrah/go_auth_api/internal/domain/auth"
+	"github.com/juanjerrah/go_auth_api/internal/domain/loginalert"
+	"github.com/juanjerrah/go_auth_api/internal/domain/oidc"
+	"github.com/juanjerrah/go_auth_api/internal/domain/user"
+	"github.com/juanjerrah/go_auth_api/pkg/middleware"
+)
+
+// Cookie que vincula o state do login ao navegador que o iniciou
+const oidcStateCookie = "oidc_state"
+
+type OIDCHandler struct {
+	oidcService  oidc.Service
+	jwtManager   *auth.JWTManager
+	authService  auth.AuthService
+	auditService audit.Service
+	alertService loginalert.Service
+	cookies      *middleware.SessionCookies
+}
+
+func NewOIDCHandler(oidcService oidc.Service, jwtManager *auth.JWTManager, authService auth.AuthService, auditService audit.Service, alertService loginalert.Service, cookies *middleware.SessionCookies) *OIDCHandler {
+	return &OIDCHandler{
+		oidcService:  oidcService,
+		jwtManager:   jwtManager,
+		authService:  authService,
+		auditService: auditService,
+		alertService: alertService,
+		cookies:      cookies,
+	}
+}
+
+// ListProviders returns the configured identity providers
+// @Summary List identity providers
+// @Description List the external OpenID Connect providers available for login
+// @Tags auth
+// @Produce json
+// @Success 200 {array} oidc.ProviderResponse "Identity providers"
+// @Router /auth/oidc/providers [get]
+func (h *OIDCHandler) ListProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, h.oidcService.Providers())
+}
+
+// Login starts the login with an identity provider
+// @Summary Start federated login
+// @Description Redirect the browser to the identity provider's authorization endpoint
+// @Tags auth
+// @Param provider path string true "Provider name"
+// @Success 302 "Redirect to the identity provider"
+// @Failure 404 {object} map[string]string "Unknown provider"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Failure 503 {object} map[string]string "Identity provider unavailable"
+// @Router /auth/oidc/{provider}/login [get]
+func (h *OIDCHandler) Login(c *gin.Context) {
+	authURL, state, err := h.oidcService.Begin(c.Request.Context(), c.Param("provider"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	// O callback só é aceito no navegador que recebeu este cookie (proteção contra login CSRF)
+	setOIDCStateCookie(c, strings.TrimSuffix(c.Request.URL.Path, "/login"), state)
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// Link starts linking the authenticated user to an identity provider account
+// @Summary Link federated identity
+// @Description Return the authorization URL that links the authenticated user to their account at the identity provider. The callback links the account instead of starting a session.
+// @Tags users
+// @Security BearerAuth
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Success 200 {object} map[string]string "Authorization URL"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Not allowed during impersonation or recent authentication required"
+// @Failure 404 {object} map[string]string "Unknown provider"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Failure 503 {object} map[string]string "Identity provider unavailable"
+// @Router /users/me/identities/oidc/{provider} [post]
+func (h *OIDCHandler) Link(c *gin.Context) {
+	authContext, _ := c.Get("authContext")
+	authCtx := authContext.(*auth.AuthContext)
+
+	provider := c.Param("provider")
+	authURL, state, err := h.oidcService.BeginLink(c.Request.Context(), provider, authCtx.UserID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	// O cookie vale para o callback, em {base}/auth/oidc/{provider}
+	base := strings.TrimSuffix(c.Request.URL.Path, "/users/me/identities/oidc/"+provider)
+	setOIDCStateCookie(c, base+"/auth/oidc/"+provider, state)
+	c.JSON(http.StatusOK, gin.H{"redirect_url": authURL})
+}
+
+// Callback completes the login with an identity provider
+// @Summary Complete federated login
+// @Description Validate the identity provider response, link or create the user and start a session
+// @Tags auth
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Param state query string true "Login state"
+// @Param code query string true "Authorization code"
+// @Success 200 {object} map[string]interface{} "Login successful or identity linked"
+// @Failure 400 {object} map[string]string "Invalid or expired login state"
+// @Failure 401 {object} map[string]string "Authentication failed"
+// @Failure 403 {object} map[string]string "Account disabled or email not verified"
+// @Failure 404 {object} map[string]string "Unknown provider"
+// @Failure 409 {object} map[string]string "Account must be linked while signed in, account already linked to this provider or maximum number of active sessions reached"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Failure 503 {object} map[string]string "Identity provider unavailable"
+// @Router /auth/oidc/{provider}/callback [get]
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	provider := c.Param("provider")
+	state := c.Query("state")
+
+	cookie, _ := c.Cookie(oidcStateCookie)
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     oidcStateCookie,
+		Path:     strings.TrimSuffix(c.Request.URL.Path, "/callback"),
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   isSecureRequest(c),
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	if state == "" || subtle.ConstantTimeCompare([]byte(cookie), []byte(state)) != 1 {
+		h.recordFailure(c, provider, oidc.ErrInvalidState)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired login state"})
+		return
+	}
+
+	// Erro informado pelo provedor (ex.: usuário negou o consentimento)
+	if providerError := c.Query("error"); providerError != "" {
+		h.recordFailure(c, provider, errors.New(providerError))
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication failed: " + providerError})
+		return
+	}
+
+	usr, linked, err := h.oidcService.Complete(c.Request.Context(), provider, state, c.Query("code"))
+	if err != nil {
+		h.recordFailure(c, provider, err)
+		h.handleError(c, err)
+		return
+	}
+	if linked {
+		c.JSON(http.StatusOK, gin.H{"message": "Identity linked successfully", "identities": usr.Identities})
+		return
+	}
+
+	token, err := issueSession(c, h.jwtManager, h.authService, h.alertService, h.cookies, usr, []string{auth.AMRFederated})
+	if err != nil {
+		if tooManySessions(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start session"})
+		return
+	}
+
+	h.recordAudit(c, &audit.Event{
+		Type:     audit.EventLogin,
+		ActorID:  usr.ID.Hex(),
+		TargetID: usr.ID.Hex(),
+		Metadata: map[string]string{"provider": provider},
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Login successful",
+		"token":   token,
+		"user": gin.H{
+			"id":    usr.ID.Hex(),
+			"name":  usr.Name,
+			"email": usr.Email,
+			"role":  usr.Role,
+		},
+	})
+}
+
+func (h *OIDCHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, oidc.ErrUnknownProvider):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown identity provider"})
+	case errors.Is(err, oidc.ErrInvalidState):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired login state"})
+	case errors.Is(err, oidc.ErrProviderUnavailable):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Identity provider unavailable"})
+	case errors.Is(err, oidc.ErrExchangeFailed), errors.Is(err, oidc.ErrInvalidIDToken):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication failed"})
+	case errors.Is(err, user.ErrUserDisabled):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Account is disabled"})
+	case errors.Is(err, user.ErrEmailNotVerified):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Email not verified by identity provider"})
+	case errors.Is(err, user.ErrIdentityConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": "Account already linked to another identity of this provider"})
+	case errors.Is(err, user.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	case errors.Is(err, user.ErrIdentityInUse):
+		c.JSON(http.StatusConflict, gin.H{"error": "Identity already linked to another user"})
+	case errors.Is(err, user.ErrLinkRequiresSignIn):
+		c.JSON(http.StatusConflict, gin.H{"error": "An account with this email already exists; sign in and link the identity from your profile"})
+	default:
+		log.Printf("Warning: federated login failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	}
+}
+
+func (h *OIDCHandler) recordFailure(c *gin.Context, provider string, err error) {
+	h.recordAudit(c, &audit.Event{
+		Type:     audit.EventLoginFailed,
+		Outcome:  audit.OutcomeFailure,
+		Reason:   err.Error(),
+		Metadata: map[string]string{"provider": provider},
+	})
+}
+
+func (h *OIDCHandler) recordAudit(c *gin.Context, event *audit.Event) {
+	if err := h.auditService.Record(c.Request.Context(), event); err != nil {
+		log.Printf("Warning: failed to record audit event %s: %v", event.Type, err)
+	}
+}
+
+// setOIDCStateCookie vincula o state ao navegador; path é o prefixo do callback
+func setOIDCStateCookie(c *gin.Context, path, state string) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state,
+		Path:     path,
+		HttpOnly: true,
+		Secure:   isSecureRequest(c),
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// isSecureRequest informa se a requisição chegou por HTTPS, diretamente ou
+// por um proxy
+func isSecureRequest(c *gin.Context) bool {
+	return c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https"
+}
@@ -0,0 +1,274 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/juanjerrah/go_auth_api/internal/domain/auth"
+	"github.com/juanjerrah/go_auth_api/internal/domain/organization"
+	"github.com/juanjerrah/go_auth_api/internal/domain/user"
+)
+
+type OrganizationHandler struct {
+	orgService organization.Service
+}
+
+func NewOrganizationHandler(orgService organization.Service) *OrganizationHandler {
+	return &OrganizationHandler{
+		orgService: orgService,
+	}
+}
+
+// CreateOrganization creates an organization owned by the current user
+// @Summary Create organization
+// @Description Create an organization; the creator becomes its first admin
+// @Tags organizations
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body organization.CreateOrganizationRequest true "Organization data"
+// @Success 201 {object} organization.OrganizationResponse "Organization created"
+// @Failure 400 {object} map[string]string "Invalid input data"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 409 {object} map[string]string "Slug already in use"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /orgs [post]
+func (h *OrganizationHandler) CreateOrganization(c *gin.Context) {
+	authContext, _ := c.Get("authContext")
+	authCtx := authContext.(*auth.AuthContext)
+
+	var req organization.CreateOrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	org, err := h.orgService.CreateOrganization(c.Request.Context(), authCtx.UserID, &req)
+	if err != nil {
+		switch err {
+		case organization.ErrInvalidSlug:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Slug must contain only lowercase letters, digits and hyphens"})
+		case organization.ErrSlugAlreadyInUse:
+			c.JSON(http.StatusConflict, gin.H{"error": "Slug already in use"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create organization"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, org)
+}
+
+// ListMyOrganizations lists the organizations of the current user
+// @Summary List my organizations
+// @Description List organizations the authenticated user belongs to, with their role in each
+// @Tags organizations
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} organization.OrganizationResponse "Organizations"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /orgs [get]
+func (h *OrganizationHandler) ListMyOrganizations(c *gin.Context) {
+	authContext, _ := c.Get("authContext")
+	authCtx := authContext.(*auth.AuthContext)
+
+	orgs, err := h.orgService.ListUserOrganizations(c.Request.Context(), authCtx.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list organizations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, orgs)
+}
+
+// GetOrganization returns the active organization
+// @Summary Get organization
+// @Description Get an organization; requires a session scoped to it
+// @Tags organizations
+// @Security BearerAuth
+// @Produce json
+// @Param orgId path string true "Organization ID"
+// @Success 200 {object} organization.OrganizationResponse "Organization"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Cross-tenant access denied"
+// @Failure 404 {object} map[string]string "Organization not found"
+// @Router /orgs/{orgId} [get]
+func (h *OrganizationHandler) GetOrganization(c *gin.Context) {
+	org, err := h.orgService.GetOrganization(c.Request.Context(), c.Param("orgId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Organization not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, org)
+}
+
+// ListMembers lists the members of an organization
+// @Summary List organization members
+// @Description List members of the organization with their organization role
+// @Tags organizations
+// @Security BearerAuth
+// @Produce json
+// @Param orgId path string true "Organization ID"
+// @Param page query int false "Page number"
+// @Param limit query int false "Page size (max 100)"
+// @Success 200 {object} organization.ListMembersResponse "Members"
+// @Failure 400 {object} map[string]string "Invalid input data"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Cross-tenant access denied"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /orgs/{orgId}/members [get]
+func (h *OrganizationHandler) ListMembers(c *gin.Context) {
+	var req organization.ListMembersRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	members, err := h.orgService.ListMembers(c.Request.Context(), c.Param("orgId"), &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list members"})
+		return
+	}
+
+	c.JSON(http.StatusOK, members)
+}
+
+// GetMember returns a member of an organization
+// @Summary Get organization member
+// @Description Get a user that belongs to the organization
+// @Tags organizations
+// @Security BearerAuth
+// @Produce json
+// @Param orgId path string true "Organization ID"
+// @Param userId path string true "User ID"
+// @Success 200 {object} organization.MemberResponse "Member"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Cross-tenant access denied"
+// @Failure 404 {object} map[string]string "Member not found"
+// @Router /orgs/{orgId}/members/{userId} [get]
+func (h *OrganizationHandler) GetMember(c *gin.Context) {
+	member, err := h.orgService.GetMember(c.Request.Context(), c.Param("orgId"), c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, member)
+}
+
+// AddMember adds an existing user to an organization
+// @Summary Add organization member
+// @Description Add an existing user to the organization with the given role
+// @Tags organizations
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param orgId path string true "Organization ID"
+// @Param request body organization.AddMemberRequest true "Member data"
+// @Success 201 {object} organization.MemberResponse "Member added"
+// @Failure 400 {object} map[string]string "Invalid input data"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Insufficient organization permissions"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 409 {object} map[string]string "User is already a member"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /orgs/{orgId}/members [post]
+func (h *OrganizationHandler) AddMember(c *gin.Context) {
+	var req organization.AddMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	member, err := h.orgService.AddMember(c.Request.Context(), c.Param("orgId"), &req)
+	if err != nil {
+		switch err {
+		case organization.ErrOrganizationNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Organization not found"})
+		case user.ErrUserNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		case organization.ErrAlreadyMember:
+			c.JSON(http.StatusConflict, gin.H{"error": "User is already a member"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add member"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, member)
+}
+
+// UpdateMemberRole changes a member's organization role
+// @Summary Change member role
+// @Description Change a member's role within the organization
+// @Tags organizations
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param orgId path string true "Organization ID"
+// @Param userId path string true "User ID"
+// @Param request body organization.UpdateMemberRoleRequest true "New role"
+// @Success 200 {object} map[string]string "Role changed successfully"
+// @Failure 400 {object} map[string]string "Invalid input data"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Insufficient organization permissions"
+// @Failure 404 {object} map[string]string "Member not found"
+// @Failure 409 {object} map[string]string "Cannot demote the last organization admin"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /orgs/{orgId}/members/{userId} [put]
+func (h *OrganizationHandler) UpdateMemberRole(c *gin.Context) {
+	var req organization.UpdateMemberRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := h.orgService.UpdateMemberRole(c.Request.Context(), c.Param("orgId"), c.Param("userId"), req.Role)
+	if err != nil {
+		switch err {
+		case organization.ErrNotMember:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
+		case organization.ErrLastOrganizationAdmin:
+			c.JSON(http.StatusConflict, gin.H{"error": "Cannot demote the last organization admin"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change member role"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Role changed successfully"})
+}
+
+// RemoveMember removes a user from an organization
+// @Summary Remove organization member
+// @Description Remove a member from the organization
+// @Tags organizations
+// @Security BearerAuth
+// @Produce json
+// @Param orgId path string true "Organization ID"
+// @Param userId path string true "User ID"
+// @Success 200 {object} map[string]string "Member removed"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Insufficient organization permissions"
+// @Failure 404 {object} map[string]string "Member not found"
+// @Failure 409 {object} map[string]string "Cannot remove the last organization admin"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /orgs/{orgId}/members/{userId} [delete]
+func (h *OrganizationHandler) RemoveMember(c *gin.Context) {
+	err := h.orgService.RemoveMember(c.Request.Context(), c.Param("orgId"), c.Param("userId"))
+	if err != nil {
+		switch err {
+		case organization.ErrNotMember:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
+		case organization.ErrLastOrganizationAdmin:
+			c.JSON(http.StatusConflict, gin.H{"error": "Cannot remove the last organization admin"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove member"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Member removed successfully"})
+}
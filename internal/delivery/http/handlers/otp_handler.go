@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/juanjerrah/go_auth_api/internal/domain/auth"
+	"github.com/juanjerrah/go_auth_api/internal/domain/otp"
+	"github.com/juanjerrah/go_auth_api/internal/domain/user"
+)
+
+type OTPHandler struct {
+	otpService otp.Service
+}
+
+func NewOTPHandler(otpService otp.Service) *OTPHandler {
+	return &OTPHandler{otpService: otpService}
+}
+
+// Enroll starts the enrollment of a one-time code channel
+// @Summary Start one-time code enrollment
+// @Description Send a code to the account email or to the given phone number (E.164) to confirm it as a second factor
+// @Tags otp
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body otp.EnrollRequest true "Channel and phone number"
+// @Success 200 {object} otp.SendResponse "Code sent"
+// @Failure 400 {object} map[string]string "Invalid input data or phone number"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Not allowed during impersonation or recent authentication required"
+// @Failure 429 {object} map[string]string "Too many codes requested"
+// @Failure 502 {object} map[string]string "Failed to deliver the code"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /users/me/otp/enroll [post]
+func (h *OTPHandler) Enroll(c *gin.Context) {
+	var req otp.EnrollRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	authContext, _ := c.Get("authContext")
+	authCtx := authContext.(*auth.AuthContext)
+
+	sent, err := h.otpService.BeginEnrollment(c.Request.Context(), authCtx.UserID, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, sent)
+}
+
+// VerifyEnrollment confirms a one-time code channel
+// @Summary Confirm one-time code enrollment
+// @Description Verify the code sent by the enrollment and enable the channel as a second factor, replacing the previous destination of the same channel
+// @Tags otp
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body otp.VerifyEnrollmentRequest true "Channel and code"
+// @Success 201 {object} user.OTPFactor "Enrolled channel"
+// @Failure 400 {object} map[string]string "Invalid input data"
+// @Failure 401 {object} map[string]string "Invalid or expired code"
+// @Failure 403 {object} map[string]string "Not allowed during impersonation or recent authentication required"
+// @Failure 429 {object} map[string]string "Too many attempts"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /users/me/otp/enroll/verify [post]
+func (h *OTPHandler) VerifyEnrollment(c *gin.Context) {
+	var req otp.VerifyEnrollmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	authContext, _ := c.Get("authContext")
+	authCtx := authContext.(*auth.AuthContext)
+
+	factor, err := h.otpService.FinishEnrollment(c.Request.Context(), authCtx.UserID, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, factor)
+}
+
+// ListFactors lists the one-time code channels of the authenticated user
+// @Summary List one-time code channels
+// @Description List the email and SMS channels enrolled as second factors by the authenticated user
+// @Tags otp
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} user.OTPFactor "Enrolled channels"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /users/me/otp/factors [get]
+func (h *OTPHandler) ListFactors(c *gin.Context) {
+	authContext, _ := c.Get("authContext")
+	authCtx := authContext.(*auth.AuthContext)
+
+	factors, err := h.otpService.ListFactors(c.Request.Context(), authCtx.UserID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, factors)
+}
+
+// RemoveFactor removes a one-time code channel of the authenticated user
+// @Summary Remove one-time code channel
+// @Description Stop using the email or SMS channel as a second factor
+// @Tags otp
+// @Security BearerAuth
+// @Produce json
+// @Param channel path string true "Channel (email or sms)"
+// @Success 200 {object} map[string]string "Channel removed"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Not allowed during impersonation or recent authentication required"
+// @Failure 404 {object} map[string]string "Channel not enrolled"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /users/me/otp/factors/{channel} [delete]
+func (h *OTPHandler) RemoveFactor(c *gin.Context) {
+	authContext, _ := c.Get("authContext")
+	authCtx := authContext.(*auth.AuthContext)
+
+	if err := h.otpService.RemoveFactor(c.Request.Context(), authCtx.UserID, c.Param("channel")); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Channel removed successfully"})
+}
+
+func (h *OTPHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, otp.ErrInvalidPhoneNumber):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid phone number, use the E.164 format"})
+	case errors.Is(err, otp.ErrInvalidCode):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired verification code"})
+	case errors.Is(err, otp.ErrTooManyAttempts):
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many attempts, request a new code"})
+	case errors.Is(err, otp.ErrRateLimited):
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many codes requested, try again later"})
+	case errors.Is(err, otp.ErrDeliveryFailed):
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to deliver verification code"})
+	case errors.Is(err, otp.ErrFactorNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Channel not enrolled"})
+	case errors.Is(err, user.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	default:
+		log.Printf("Warning: otp operation failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	}
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/juanjerrah/go_auth_api/internal/domain/policy"
+)
+
+type PolicyHandler struct {
+	policyService policy.Service
+}
+
+func NewPolicyHandler(policyService policy.Service) *PolicyHandler {
+	return &PolicyHandler{
+		policyService: policyService,
+	}
+}
+
+// ListPolicies lists every authorization policy
+// @Summary List policies
+// @Description List built-in, file and database authorization policies
+// @Tags policies
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} policy.Policy "Policies"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Insufficient permissions"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/policies [get]
+func (h *PolicyHandler) ListPolicies(c *gin.Context) {
+	policies, err := h.policyService.ListPolicies(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list policies"})
+		return
+	}
+
+	c.JSON(http.StatusOK, policies)
+}
+
+// GetPolicy returns a policy
+// @Summary Get policy
+// @Description Get an authorization policy by ID
+// @Tags policies
+// @Security BearerAuth
+// @Produce json
+// @Param policyId path string true "Policy ID"
+// @Success 200 {object} policy.Policy "Policy"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Insufficient permissions"
+// @Failure 404 {object} map[string]string "Policy not found"
+// @Router /admin/policies/{policyId} [get]
+func (h *PolicyHandler) GetPolicy(c *gin.Context) {
+	pol, err := h.policyService.GetPolicy(c.Request.Context(), c.Param("policyId"))
+	if err != nil {
+		h.handleError(c, err, "Failed to get policy")
+		return
+	}
+
+	c.JSON(http.StatusOK, pol)
+}
+
+// CreatePolicy stores a new policy
+// @Summary Create policy
+// @Description Store an authorization policy evaluating subject, resource, action and environment attributes
+// @Tags policies
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body policy.CreatePolicyRequest true "Policy"
+// @Success 201 {object} policy.Policy "Policy created"
+// @Failure 400 {object} map[string]string "Invalid policy"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Insufficient permissions"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/policies [post]
+func (h *PolicyHandler) CreatePolicy(c *gin.Context) {
+	var req policy.CreatePolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pol, err := h.policyService.CreatePolicy(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err, "Failed to create policy")
+		return
+	}
+
+	c.JSON(http.StatusCreated, pol)
+}
+
+// UpdatePolicy updates a stored policy
+// @Summary Update policy
+// @Description Update a database policy; built-in and file policies are read-only
+// @Tags policies
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param policyId path string true "Policy ID"
+// @Param request body policy.UpdatePolicyRequest true "Policy changes"
+// @Success 200 {object} policy.Policy "Policy updated"
+// @Failure 400 {object} map[string]string "Invalid policy"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Insufficient permissions or read-only policy"
+// @Failure 404 {object} map[string]string "Policy not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/policies/{policyId} [put]
+func (h *PolicyHandler) UpdatePolicy(c *gin.Context) {
+	var req policy.UpdatePolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pol, err := h.policyService.UpdatePolicy(c.Request.Context(), c.Param("policyId"), &req)
+	if err != nil {
+		h.handleError(c, err, "Failed to update policy")
+		return
+	}
+
+	c.JSON(http.StatusOK, pol)
+}
+
+// DeletePolicy deletes a stored policy
+// @Summary Delete policy
+// @Description Delete a database policy; built-in and file policies are read-only
+// @Tags policies
+// @Security BearerAuth
+// @Produce json
+// @Param policyId path string true "Policy ID"
+// @Success 200 {object} map[string]string "Policy deleted"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Insufficient permissions or read-only policy"
+// @Failure 404 {object} map[string]string "Policy not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/policies/{policyId} [delete]
+func (h *PolicyHandler) DeletePolicy(c *gin.Context) {
+	if err := h.policyService.DeletePolicy(c.Request.Context(), c.Param("policyId")); err != nil {
+		h.handleError(c, err, "Failed to delete policy")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Policy deleted successfully"})
+}
+
+func (h *PolicyHandler) handleError(c *gin.Context, err error, message string) {
+	switch err {
+	case policy.ErrPolicyNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Policy not found"})
+	case policy.ErrInvalidPolicy:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid policy"})
+	case policy.ErrReadOnlyPolicy:
+		c.JSON(http.StatusForbidden, gin.H{"error": "Policy is read-only"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
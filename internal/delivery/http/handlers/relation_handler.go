@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/juanjerrah/go_auth_api/internal/domain/relation"
+)
+
+type RelationHandler struct {
+	relationService relation.Service
+}
+
+func NewRelationHandler(relationService relation.Service) *RelationHandler {
+	return &RelationHandler{
+		relationService: relationService,
+	}
+}
+
+// PutNamespace creates or replaces a namespace configuration
+// @Summary Put namespace
+// @Description Create or replace the relations and userset rewrites of a namespace
+// @Tags relations
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param namespace path string true "Namespace name"
+// @Param request body relation.NamespaceRequest true "Namespace relations"
+// @Success 200 {object} relation.NamespaceConfig "Namespace saved"
+// @Failure 400 {object} map[string]string "Invalid namespace configuration"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Insufficient permissions"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /relations/namespaces/{namespace} [put]
+func (h *RelationHandler) PutNamespace(c *gin.Context) {
+	var req relation.NamespaceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	config, err := h.relationService.PutNamespace(c.Request.Context(), c.Param("namespace"), &req)
+	if err != nil {
+		h.handleError(c, err, "Failed to save namespace")
+		return
+	}
+
+	c.JSON(http.StatusOK, config)
+}
+
+// ListNamespaces lists namespace configurations
+// @Summary List namespaces
+// @Description List namespace configurations
+// @Tags relations
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} relation.NamespaceConfig "Namespaces"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Insufficient permissions"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /relations/namespaces [get]
+func (h *RelationHandler) ListNamespaces(c *gin.Context) {
+	configs, err := h.relationService.ListNamespaces(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list namespaces"})
+		return
+	}
+
+	c.JSON(http.StatusOK, configs)
+}
+
+// DeleteNamespace deletes a namespace configuration
+// @Summary Delete namespace
+// @Description Delete a namespace configuration; existing tuples are kept but no longer evaluated
+// @Tags relations
+// @Security BearerAuth
+// @Produce json
+// @Param namespace path string true "Namespace name"
+// @Success 200 {object} map[string]string "Namespace deleted"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Insufficient permissions"
+// @Failure 404 {object} map[string]string "Namespace not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /relations/namespaces/{namespace} [delete]
+func (h *RelationHandler) DeleteNamespace(c *gin.Context) {
+	if err := h.relationService.DeleteNamespace(c.Request.Context(), c.Param("namespace")); err != nil {
+		h.handleError(c, err, "Failed to delete namespace")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Namespace deleted successfully"})
+}
+
+// WriteTuples writes and deletes relation tuples
+// @Summary Write tuples
+// @Description Write and delete relation tuples; the returned consistency token lets callers read their own writes
+// @Tags relations
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body relation.WriteTuplesRequest true "Tuples to write and delete"
+// @Success 200 {object} relation.WriteTuplesResponse "Tuples written"
+// @Failure 400 {object} map[string]string "Invalid tuple"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Insufficient permissions"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /relations/tuples [post]
+func (h *RelationHandler) WriteTuples(c *gin.Context) {
+	var req relation.WriteTuplesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.relationService.WriteTuples(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err, "Failed to write tuples")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ReadTuples lists relation tuples
+// @Summary Read tuples
+// @Description List relation tuples of a namespace, optionally filtered by object and relation
+// @Tags relations
+// @Security BearerAuth
+// @Produce json
+// @Param namespace query string true "Namespace"
+// @Param object_id query string false "Object ID"
+// @Param relation query string false "Relation"
+// @Success 200 {array} relation.TupleResponse "Tuples"
+// @Failure 400 {object} map[string]string "Invalid query"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Insufficient permissions"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /relations/tuples [get]
+func (h *RelationHandler) ReadTuples(c *gin.Context) {
+	var req relation.ReadTuplesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tuples, err := h.relationService.ReadTuples(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read tuples"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tuples)
+}
+
+// Check answers whether a subject has a relation with an object
+// @Summary Check relation
+// @Description Check whether the subject has the relation with the object, following userset rewrites
+// @Tags relations
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body relation.CheckRequest true "Check request"
+// @Success 200 {object} relation.CheckResponse "Check result"
+// @Failure 400 {object} map[string]string "Invalid request"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Insufficient permissions"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /relations/check [post]
+func (h *RelationHandler) Check(c *gin.Context) {
+	var req relation.CheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.relationService.Check(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err, "Failed to check relation")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Expand returns the userset tree of a relation
+// @Summary Expand relation
+// @Description Expand the userset tree of an object's relation
+// @Tags relations
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body relation.ExpandRequest true "Expand request"
+// @Success 200 {object} relation.ExpandResponse "Userset tree"
+// @Failure 400 {object} map[string]string "Invalid request"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Insufficient permissions"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /relations/expand [post]
+func (h *RelationHandler) Expand(c *gin.Context) {
+	var req relation.ExpandRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.relationService.Expand(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err, "Failed to expand relation")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ListObjects lists the objects a subject has a relation with
+// @Summary List objects
+// @Description List IDs of objects in a namespace the subject has the relation with
+// @Tags relations
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body relation.ListObjectsRequest true "List objects request"
+// @Success 200 {object} relation.ListObjectsResponse "Objects"
+// @Failure 400 {object} map[string]string "Invalid request"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Insufficient permissions"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /relations/list-objects [post]
+func (h *RelationHandler) ListObjects(c *gin.Context) {
+	var req relation.ListObjectsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.relationService.ListObjects(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err, "Failed to list objects")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *RelationHandler) handleError(c *gin.Context, err error, message string) {
+	switch err {
+	case relation.ErrUnknownNamespace:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown namespace"})
+	case relation.ErrUnknownRelation:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown relation"})
+	case relation.ErrInvalidSubject:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Subject must be formatted as namespace:id or namespace:id#relation"})
+	case relation.ErrInvalidNamespace:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid namespace configuration"})
+	case relation.ErrReservedNamespace:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Namespace name is reserved"})
+	case relation.ErrInvalidConsistencyToken:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid consistency token"})
+	case relation.ErrTooManyListObjectTargets:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Too many objects to evaluate"})
+	case relation.ErrNamespaceNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Namespace not found"})
+	case relation.ErrMaxDepthExceeded:
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Relation graph exceeds maximum depth"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
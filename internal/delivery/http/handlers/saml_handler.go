@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/juanjerrah/go_auth_api/internal/domain/audit"
+	"github.com/juanjerrah/go_auth_api/internal/domain/auth"
+	"github.com/juanjerrah/go_auth_api/internal/domain/loginalert"
+	"github.com/juanjerrah/go_auth_api/internal/domain/saml"
+	"github.com/juanjerrah/go_auth_api/internal/domain/user"
+	"github.com/juanjerrah/go_auth_api/pkg/middleware"
+)
+
+type SAMLHandler struct {
+	samlService  saml.Service
+	jwtManager   *auth.JWTManager
+	authService  auth.AuthService
+	auditService audit.Service
+	alertService loginalert.Service
+	cookies      *middleware.SessionCookies
+}
+
+func NewSAMLHandler(samlService saml.Service, jwtManager *auth.JWTManager, authService auth.AuthService, auditService audit.Service, alertService loginalert.Service, cookies *middleware.SessionCookies) *SAMLHandler {
+	return &SAMLHandler{
+		samlService:  samlService,
+		jwtManager:   jwtManager,
+		authService:  authService,
+		auditService: auditService,
+		alertService: alertService,
+		cookies:      cookies,
+	}
+}
+
+// ListProviders returns the configured SAML identity providers
+// @Summary List SAML identity providers
+// @Description List the SAML identity providers available for login
+// @Tags auth
+// @Produce json
+// @Success 200 {array} saml.ProviderResponse "Identity providers"
+// @Router /auth/saml/providers [get]
+func (h *SAMLHandler) ListProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, h.samlService.Providers())
+}
+
+// Metadata returns the service provider metadata
+// @Summary Get SAML SP metadata
+// @Description Service provider metadata to register with the identity provider
+// @Tags auth
+// @Produce xml
+// @Param provider path string true "Provider name"
+// @Success 200 {string} string "SP metadata"
+// @Failure 404 {object} map[string]string "Unknown provider"
+// @Router /auth/saml/{provider}/metadata [get]
+func (h *SAMLHandler) Metadata(c *gin.Context) {
+	metadata, err := h.samlService.Metadata(c.Param("provider"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.Data(http.StatusOK, "application/samlmetadata+xml", metadata)
+}
+
+// Login starts an SP-initiated login
+// @Summary Start SAML login
+// @Description Redirect the browser to the identity provider with an authentication request
+// @Tags auth
+// @Param provider path string true "Provider name"
+// @Success 302 "Redirect to the identity provider"
+// @Failure 404 {object} map[string]string "Unknown provider"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /auth/saml/{provider}/login [get]
+func (h *SAMLHandler) Login(c *gin.Context) {
+	location, err := h.samlService.Begin(c.Request.Context(), c.Param("provider"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.Redirect(http.StatusFound, location)
+}
+
+// Link starts linking the authenticated user to an identity provider account
+// @Summary Link SAML identity
+// @Description Return the identity provider URL that links the authenticated user to their account there. The response arrives at the assertion consumer service, which links the account instead of starting a session.
+// @Tags users
+// @Security BearerAuth
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Success 200 {object} map[string]string "Identity provider URL"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Not allowed during impersonation or recent authentication required"
+// @Failure 404 {object} map[string]string "Unknown provider"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /users/me/identities/saml/{provider} [post]
+func (h *SAMLHandler) Link(c *gin.Context) {
+	authContext, _ := c.Get("authContext")
+	authCtx := authContext.(*auth.AuthContext)
+
+	location, err := h.samlService.BeginLink(c.Request.Context(), c.Param("provider"), authCtx.UserID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"redirect_url": location})
+}
+
+// AssertionConsumerService completes the login with the identity provider response
+// @Summary SAML assertion consumer service
+// @Description Validate the signed SAML response (SP- or IdP-initiated), map the user and start a session. A response to a link request links the account to the user who started it instead.
+// @Tags auth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Param SAMLResponse formData string true "Base64-encoded SAML response"
+// @Success 200 {object} map[string]interface{} "Login successful or identity linked"
+// @Failure 401 {object} map[string]string "Invalid SAML response"
+// @Failure 403 {object} map[string]string "Account disabled or email not verified"
+// @Failure 404 {object} map[string]string "Unknown provider"
+// @Failure 409 {object} map[string]string "Account must be linked while signed in, identity already linked or maximum number of active sessions reached"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /auth/saml/{provider}/acs [post]
+func (h *SAMLHandler) AssertionConsumerService(c *gin.Context) {
+	provider := c.Param("provider")
+
+	usr, linked, err := h.samlService.Complete(c.Request.Context(), provider, c.PostForm("SAMLResponse"))
+	if err != nil {
+		h.recordAudit(c, &audit.Event{
+			Type:     audit.EventLoginFailed,
+			Outcome:  audit.OutcomeFailure,
+			Reason:   err.Error(),
+			Metadata: map[string]string{"provider": provider},
+		})
+		h.handleError(c, err)
+		return
+	}
+	if linked {
+		c.JSON(http.StatusOK, gin.H{"message": "Identity linked successfully", "identities": usr.Identities})
+		return
+	}
+
+	token, err := issueSession(c, h.jwtManager, h.authService, h.alertService, h.cookies, usr, []string{auth.AMRFederated})
+	if err != nil {
+		if tooManySessions(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start session"})
+		return
+	}
+
+	h.recordAudit(c, &audit.Event{
+		Type:     audit.EventLogin,
+		ActorID:  usr.ID.Hex(),
+		TargetID: usr.ID.Hex(),
+		Metadata: map[string]string{"provider": provider},
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Login successful",
+		"token":   token,
+		"user": gin.H{
+			"id":    usr.ID.Hex(),
+			"name":  usr.Name,
+			"email": usr.Email,
+			"role":  usr.Role,
+		},
+	})
+}
+
+func (h *SAMLHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, saml.ErrUnknownProvider):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown identity provider"})
+	case errors.Is(err, saml.ErrInvalidResponse),
+		errors.Is(err, saml.ErrAuthenticationFailed),
+		errors.Is(err, saml.ErrUnsolicitedResponse),
+		errors.Is(err, saml.ErrReplayedAssertion):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication failed"})
+	case errors.Is(err, user.ErrUserDisabled):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Account is disabled"})
+	case errors.Is(err, user.ErrEmailNotVerified):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Email not verified by identity provider"})
+	case errors.Is(err, user.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	case errors.Is(err, user.ErrIdentityConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": "Account already linked to another identity of this provider"})
+	case errors.Is(err, user.ErrIdentityInUse):
+		c.JSON(http.StatusConflict, gin.H{"error": "Identity already linked to another user"})
+	case errors.Is(err, user.ErrLinkRequiresSignIn):
+		c.JSON(http.StatusConflict, gin.H{"error": "An account with this email already exists; sign in and link the identity from your profile"})
+	default:
+		log.Printf("Warning: SAML login failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	}
+}
+
+func (h *SAMLHandler) recordAudit(c *gin.Context, event *audit.Event) {
+	if err := h.auditService.Record(c.Request.Context(), event); err != nil {
+		log.Printf("Warning: failed to record audit event %s: %v", event.Type, err)
+	}
+}
@@ -0,0 +1,472 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/juanjerrah/go_auth_api/internal/domain/scim"
+	"github.com/juanjerrah/go_auth_api/internal/domain/user"
+)
+
+type SCIMHandler struct {
+	scimService scim.Service
+	baseURL     string
+}
+
+func NewSCIMHandler(scimService scim.Service, baseURL string) *SCIMHandler {
+	return &SCIMHandler{
+		scimService: scimService,
+		baseURL:     baseURL,
+	}
+}
+
+// ServiceProviderConfig returns the SCIM service provider configuration
+// @Summary SCIM service provider configuration
+// @Description Describe the SCIM features supported by this server
+// @Tags scim
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Service provider configuration"
+// @Failure 401 {object} scim.ErrorResponse "Unauthorized"
+// @Router /scim/v2/ServiceProviderConfig [get]
+func (h *SCIMHandler) ServiceProviderConfig(c *gin.Context) {
+	h.respond(c, http.StatusOK, scim.ServiceProviderConfig(h.baseURL))
+}
+
+// ListResourceTypes lists the SCIM resource types
+// @Summary List SCIM resource types
+// @Description List the resource types exposed by this server
+// @Tags scim
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} scim.ListResponse "Resource types"
+// @Failure 401 {object} scim.ErrorResponse "Unauthorized"
+// @Router /scim/v2/ResourceTypes [get]
+func (h *SCIMHandler) ListResourceTypes(c *gin.Context) {
+	h.respondList(c, scim.ResourceTypes(h.baseURL))
+}
+
+// GetResourceType returns a SCIM resource type
+// @Summary Get SCIM resource type
+// @Description Get a resource type by name
+// @Tags scim
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Resource type name"
+// @Success 200 {object} map[string]interface{} "Resource type"
+// @Failure 401 {object} scim.ErrorResponse "Unauthorized"
+// @Failure 404 {object} scim.ErrorResponse "Resource type not found"
+// @Router /scim/v2/ResourceTypes/{id} [get]
+func (h *SCIMHandler) GetResourceType(c *gin.Context) {
+	h.respondItem(c, scim.ResourceTypes(h.baseURL), c.Param("id"))
+}
+
+// ListSchemas lists the SCIM schemas
+// @Summary List SCIM schemas
+// @Description List the schemas and attributes supported by this server
+// @Tags scim
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} scim.ListResponse "Schemas"
+// @Failure 401 {object} scim.ErrorResponse "Unauthorized"
+// @Router /scim/v2/Schemas [get]
+func (h *SCIMHandler) ListSchemas(c *gin.Context) {
+	h.respondList(c, scim.Schemas(h.baseURL))
+}
+
+// GetSchema returns a SCIM schema
+// @Summary Get SCIM schema
+// @Description Get a schema by URN
+// @Tags scim
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Schema URN"
+// @Success 200 {object} map[string]interface{} "Schema"
+// @Failure 401 {object} scim.ErrorResponse "Unauthorized"
+// @Failure 404 {object} scim.ErrorResponse "Schema not found"
+// @Router /scim/v2/Schemas/{id} [get]
+func (h *SCIMHandler) GetSchema(c *gin.Context) {
+	h.respondItem(c, scim.Schemas(h.baseURL), c.Param("id"))
+}
+
+// CreateUser provisions a user
+// @Summary Create SCIM user
+// @Description Provision a user; userName is the user's email
+// @Tags scim
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body scim.User true "User"
+// @Success 201 {object} scim.User "User created"
+// @Failure 400 {object} scim.ErrorResponse "Invalid user"
+// @Failure 401 {object} scim.ErrorResponse "Unauthorized"
+// @Failure 409 {object} scim.ErrorResponse "userName already in use"
+// @Failure 500 {object} scim.ErrorResponse "Internal server error"
+// @Router /scim/v2/Users [post]
+func (h *SCIMHandler) CreateUser(c *gin.Context) {
+	var resource scim.User
+	if err := c.ShouldBindJSON(&resource); err != nil {
+		h.respond(c, http.StatusBadRequest, scim.NewError(http.StatusBadRequest, "invalidSyntax", err.Error()))
+		return
+	}
+
+	created, err := h.scimService.CreateUser(c.Request.Context(), &resource)
+	if err != nil {
+		h.handleError(c, err, "Failed to create user")
+		return
+	}
+
+	h.respond(c, http.StatusCreated, created)
+}
+
+// GetUser returns a provisioned user
+// @Summary Get SCIM user
+// @Description Get a user by ID
+// @Tags scim
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} scim.User "User"
+// @Failure 401 {object} scim.ErrorResponse "Unauthorized"
+// @Failure 404 {object} scim.ErrorResponse "User not found"
+// @Router /scim/v2/Users/{id} [get]
+func (h *SCIMHandler) GetUser(c *gin.Context) {
+	resource, err := h.scimService.GetUser(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err, "Failed to get user")
+		return
+	}
+
+	h.respond(c, http.StatusOK, resource)
+}
+
+// ListUsers lists users
+// @Summary List SCIM users
+// @Description List users with optional SCIM filter and 1-based pagination
+// @Tags scim
+// @Security BearerAuth
+// @Produce json
+// @Param filter query string false "SCIM filter, e.g. userName eq \"jane@example.com\""
+// @Param startIndex query int false "1-based index of the first result"
+// @Param count query int false "Page size (max 200)"
+// @Param excludedAttributes query string false "Attributes to omit (groups)"
+// @Success 200 {object} scim.ListResponse "Users"
+// @Failure 400 {object} scim.ErrorResponse "Invalid filter"
+// @Failure 401 {object} scim.ErrorResponse "Unauthorized"
+// @Failure 500 {object} scim.ErrorResponse "Internal server error"
+// @Router /scim/v2/Users [get]
+func (h *SCIMHandler) ListUsers(c *gin.Context) {
+	var req scim.ListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.respond(c, http.StatusBadRequest, scim.NewError(http.StatusBadRequest, "invalidValue", err.Error()))
+		return
+	}
+
+	response, err := h.scimService.ListUsers(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err, "Failed to list users")
+		return
+	}
+
+	h.respond(c, http.StatusOK, response)
+}
+
+// ReplaceUser replaces a provisioned user
+// @Summary Replace SCIM user
+// @Description Replace the attributes of a user
+// @Tags scim
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param request body scim.User true "User"
+// @Success 200 {object} scim.User "User replaced"
+// @Failure 400 {object} scim.ErrorResponse "Invalid user"
+// @Failure 401 {object} scim.ErrorResponse "Unauthorized"
+// @Failure 404 {object} scim.ErrorResponse "User not found"
+// @Failure 409 {object} scim.ErrorResponse "userName already in use"
+// @Failure 500 {object} scim.ErrorResponse "Internal server error"
+// @Router /scim/v2/Users/{id} [put]
+func (h *SCIMHandler) ReplaceUser(c *gin.Context) {
+	var resource scim.User
+	if err := c.ShouldBindJSON(&resource); err != nil {
+		h.respond(c, http.StatusBadRequest, scim.NewError(http.StatusBadRequest, "invalidSyntax", err.Error()))
+		return
+	}
+
+	replaced, err := h.scimService.ReplaceUser(c.Request.Context(), c.Param("id"), &resource)
+	if err != nil {
+		h.handleError(c, err, "Failed to replace user")
+		return
+	}
+
+	h.respond(c, http.StatusOK, replaced)
+}
+
+// PatchUser partially updates a provisioned user
+// @Summary Patch SCIM user
+// @Description Apply add, replace and remove operations to a user; setting active to false disables the account and ends its sessions
+// @Tags scim
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param request body scim.PatchRequest true "Patch operations"
+// @Success 200 {object} scim.User "User updated"
+// @Failure 400 {object} scim.ErrorResponse "Invalid operation"
+// @Failure 401 {object} scim.ErrorResponse "Unauthorized"
+// @Failure 404 {object} scim.ErrorResponse "User not found"
+// @Failure 409 {object} scim.ErrorResponse "userName already in use"
+// @Failure 500 {object} scim.ErrorResponse "Internal server error"
+// @Router /scim/v2/Users/{id} [patch]
+func (h *SCIMHandler) PatchUser(c *gin.Context) {
+	var req scim.PatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.respond(c, http.StatusBadRequest, scim.NewError(http.StatusBadRequest, "invalidSyntax", err.Error()))
+		return
+	}
+
+	patched, err := h.scimService.PatchUser(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		h.handleError(c, err, "Failed to patch user")
+		return
+	}
+
+	h.respond(c, http.StatusOK, patched)
+}
+
+// DeleteUser deprovisions a user
+// @Summary Delete SCIM user
+// @Description Delete a user and end its sessions
+// @Tags scim
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Success 204 "User deleted"
+// @Failure 401 {object} scim.ErrorResponse "Unauthorized"
+// @Failure 404 {object} scim.ErrorResponse "User not found"
+// @Failure 409 {object} scim.ErrorResponse "Cannot remove the last admin"
+// @Failure 500 {object} scim.ErrorResponse "Internal server error"
+// @Router /scim/v2/Users/{id} [delete]
+func (h *SCIMHandler) DeleteUser(c *gin.Context) {
+	if err := h.scimService.DeleteUser(c.Request.Context(), c.Param("id")); err != nil {
+		h.handleError(c, err, "Failed to delete user")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// CreateGroup provisions a group
+// @Summary Create SCIM group
+// @Description Provision a group and its user members
+// @Tags scim
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body scim.Group true "Group"
+// @Success 201 {object} scim.Group "Group created"
+// @Failure 400 {object} scim.ErrorResponse "Invalid group"
+// @Failure 401 {object} scim.ErrorResponse "Unauthorized"
+// @Failure 409 {object} scim.ErrorResponse "displayName already in use"
+// @Failure 500 {object} scim.ErrorResponse "Internal server error"
+// @Router /scim/v2/Groups [post]
+func (h *SCIMHandler) CreateGroup(c *gin.Context) {
+	var resource scim.Group
+	if err := c.ShouldBindJSON(&resource); err != nil {
+		h.respond(c, http.StatusBadRequest, scim.NewError(http.StatusBadRequest, "invalidSyntax", err.Error()))
+		return
+	}
+
+	created, err := h.scimService.CreateGroup(c.Request.Context(), &resource)
+	if err != nil {
+		h.handleError(c, err, "Failed to create group")
+		return
+	}
+
+	h.respond(c, http.StatusCreated, created)
+}
+
+// GetGroup returns a provisioned group
+// @Summary Get SCIM group
+// @Description Get a group by ID
+// @Tags scim
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Group ID"
+// @Success 200 {object} scim.Group "Group"
+// @Failure 401 {object} scim.ErrorResponse "Unauthorized"
+// @Failure 404 {object} scim.ErrorResponse "Group not found"
+// @Router /scim/v2/Groups/{id} [get]
+func (h *SCIMHandler) GetGroup(c *gin.Context) {
+	resource, err := h.scimService.GetGroup(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err, "Failed to get group")
+		return
+	}
+
+	h.respond(c, http.StatusOK, resource)
+}
+
+// ListGroups lists groups
+// @Summary List SCIM groups
+// @Description List groups with optional SCIM filter and 1-based pagination
+// @Tags scim
+// @Security BearerAuth
+// @Produce json
+// @Param filter query string false "SCIM filter, e.g. displayName eq \"Engineering\""
+// @Param startIndex query int false "1-based index of the first result"
+// @Param count query int false "Page size (max 200)"
+// @Param excludedAttributes query string false "Attributes to omit (members)"
+// @Success 200 {object} scim.ListResponse "Groups"
+// @Failure 400 {object} scim.ErrorResponse "Invalid filter"
+// @Failure 401 {object} scim.ErrorResponse "Unauthorized"
+// @Failure 500 {object} scim.ErrorResponse "Internal server error"
+// @Router /scim/v2/Groups [get]
+func (h *SCIMHandler) ListGroups(c *gin.Context) {
+	var req scim.ListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.respond(c, http.StatusBadRequest, scim.NewError(http.StatusBadRequest, "invalidValue", err.Error()))
+		return
+	}
+
+	response, err := h.scimService.ListGroups(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err, "Failed to list groups")
+		return
+	}
+
+	h.respond(c, http.StatusOK, response)
+}
+
+// ReplaceGroup replaces a provisioned group
+// @Summary Replace SCIM group
+// @Description Replace the name and members of a group
+// @Tags scim
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Group ID"
+// @Param request body scim.Group true "Group"
+// @Success 200 {object} scim.Group "Group replaced"
+// @Failure 400 {object} scim.ErrorResponse "Invalid group"
+// @Failure 401 {object} scim.ErrorResponse "Unauthorized"
+// @Failure 404 {object} scim.ErrorResponse "Group not found"
+// @Failure 409 {object} scim.ErrorResponse "displayName already in use"
+// @Failure 500 {object} scim.ErrorResponse "Internal server error"
+// @Router /scim/v2/Groups/{id} [put]
+func (h *SCIMHandler) ReplaceGroup(c *gin.Context) {
+	var resource scim.Group
+	if err := c.ShouldBindJSON(&resource); err != nil {
+		h.respond(c, http.StatusBadRequest, scim.NewError(http.StatusBadRequest, "invalidSyntax", err.Error()))
+		return
+	}
+
+	replaced, err := h.scimService.ReplaceGroup(c.Request.Context(), c.Param("id"), &resource)
+	if err != nil {
+		h.handleError(c, err, "Failed to replace group")
+		return
+	}
+
+	h.respond(c, http.StatusOK, replaced)
+}
+
+// PatchGroup partially updates a provisioned group
+// @Summary Patch SCIM group
+// @Description Rename a group or add, replace and remove members
+// @Tags scim
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Group ID"
+// @Param request body scim.PatchRequest true "Patch operations"
+// @Success 200 {object} scim.Group "Group updated"
+// @Failure 400 {object} scim.ErrorResponse "Invalid operation"
+// @Failure 401 {object} scim.ErrorResponse "Unauthorized"
+// @Failure 404 {object} scim.ErrorResponse "Group not found"
+// @Failure 409 {object} scim.ErrorResponse "displayName already in use"
+// @Failure 500 {object} scim.ErrorResponse "Internal server error"
+// @Router /scim/v2/Groups/{id} [patch]
+func (h *SCIMHandler) PatchGroup(c *gin.Context) {
+	var req scim.PatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.respond(c, http.StatusBadRequest, scim.NewError(http.StatusBadRequest, "invalidSyntax", err.Error()))
+		return
+	}
+
+	patched, err := h.scimService.PatchGroup(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		h.handleError(c, err, "Failed to patch group")
+		return
+	}
+
+	h.respond(c, http.StatusOK, patched)
+}
+
+// DeleteGroup deprovisions a group
+// @Summary Delete SCIM group
+// @Description Delete a group
+// @Tags scim
+// @Security BearerAuth
+// @Param id path string true "Group ID"
+// @Success 204 "Group deleted"
+// @Failure 401 {object} scim.ErrorResponse "Unauthorized"
+// @Failure 404 {object} scim.ErrorResponse "Group not found"
+// @Failure 500 {object} scim.ErrorResponse "Internal server error"
+// @Router /scim/v2/Groups/{id} [delete]
+func (h *SCIMHandler) DeleteGroup(c *gin.Context) {
+	if err := h.scimService.DeleteGroup(c.Request.Context(), c.Param("id")); err != nil {
+		h.handleError(c, err, "Failed to delete group")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// respond escreve o corpo com o media type definido pela RFC 7644
+func (h *SCIMHandler) respond(c *gin.Context, status int, body any) {
+	c.Header("Content-Type", "application/scim+json")
+	c.JSON(status, body)
+}
+
+func (h *SCIMHandler) respondList(c *gin.Context, items []map[string]any) {
+	resources := make([]any, 0, len(items))
+	for _, item := range items {
+		resources = append(resources, item)
+	}
+	h.respond(c, http.StatusOK, &scim.ListResponse{
+		Schemas:      []string{scim.MessageListResponse},
+		TotalResults: int64(len(resources)),
+		StartIndex:   1,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
+
+func (h *SCIMHandler) respondItem(c *gin.Context, items []map[string]any, id string) {
+	for _, item := range items {
+		if item["id"] == id {
+			h.respond(c, http.StatusOK, item)
+			return
+		}
+	}
+	h.respond(c, http.StatusNotFound, scim.NewError(http.StatusNotFound, "", "Resource not found"))
+}
+
+func (h *SCIMHandler) handleError(c *gin.Context, err error, message string) {
+	switch err {
+	case scim.ErrResourceNotFound:
+		h.respond(c, http.StatusNotFound, scim.NewError(http.StatusNotFound, "", "Resource not found"))
+	case scim.ErrUniqueness:
+		h.respond(c, http.StatusConflict, scim.NewError(http.StatusConflict, "uniqueness", "Resource already exists"))
+	case scim.ErrInvalidFilter:
+		h.respond(c, http.StatusBadRequest, scim.NewError(http.StatusBadRequest, "invalidFilter", "Invalid filter"))
+	case scim.ErrInvalidPath:
+		h.respond(c, http.StatusBadRequest, scim.NewError(http.StatusBadRequest, "invalidPath", "Unsupported attribute path"))
+	case scim.ErrInvalidValue:
+		h.respond(c, http.StatusBadRequest, scim.NewError(http.StatusBadRequest, "invalidValue", "Invalid attribute value"))
+	case user.ErrLastAdmin:
+		h.respond(c, http.StatusConflict, scim.NewError(http.StatusConflict, "", "Cannot remove or disable the last admin"))
+	default:
+		h.respond(c, http.StatusInternalServerError, scim.NewError(http.StatusInternalServerError, "", message))
+	}
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/juanjerrah/go_auth_api/internal/domain/audit"
+	"github.com/juanjerrah/go_auth_api/internal/domain/auth"
+	"github.com/juanjerrah/go_auth_api/internal/domain/device"
+	"github.com/juanjerrah/go_auth_api/internal/domain/loginalert"
+	"github.com/juanjerrah/go_auth_api/internal/domain/risk"
+	"github.com/juanjerrah/go_auth_api/internal/domain/user"
+	"github.com/juanjerrah/go_auth_api/pkg/middleware"
+	"github.com/juanjerrah/go_auth_api/pkg/types"
+)
+
+// issueSession gera o token JWT do usuário autenticado pelos métodos amr e
+// registra a sessão no Redis, como no login por senha. No modo de sessão por
+// cookie, também grava os cookies de sessão e CSRF. Logins de dispositivos ou
+// redes novos são avisados ao usuário.
+func issueSession(c *gin.Context, jwtManager *auth.JWTManager, authService auth.AuthService, alertService loginalert.Service, cookies *middleware.SessionCookies, usr *user.User, amr []string) (string, error) {
+	permissions, err := authService.GetUserPermissions(c.Request.Context(), usr.ID.Hex(), usr.Role)
+	if err != nil {
+		return "", err
+	}
+
+	token, err := jwtManager.GenerateToken(usr.ID.Hex(), usr.Email, usr.Role)
+	if err != nil {
+		return "", err
+	}
+
+	authCtx := &types.AuthContext{
+		UserID:      usr.ID.Hex(),
+		Email:       usr.Email,
+		Role:        usr.Role,
+		Permissions: permissions,
+		AuthTime:    time.Now().UTC(),
+		AMR:         amr,
+	}
+	if err := authService.StoreToken(c.Request.Context(), token, authCtx, jwtManager.GetTokenDuration()); err != nil {
+		return "", err
+	}
+	cookies.Set(c, token, jwtManager.GetTokenDuration())
+	checkNewLogin(c, alertService, usr, token)
+	return token, nil
+}
+
+// checkNewLogin avisa o usuário de logins vindos de dispositivos ou redes
+// novos; uma falha aqui não impede o login
+func checkNewLogin(c *gin.Context, alertService loginalert.Service, usr *user.User, token string) {
+	if err := alertService.CheckLogin(c.Request.Context(), usr, token); err != nil {
+		log.Printf("Warning: failed to check login of user %s: %v", usr.ID.Hex(), err)
+	}
+}
+
+// tooManySessions responde 409 quando o login foi recusado pelo limite de
+// sessões simultâneas do usuário
+func tooManySessions(c *gin.Context, err error) bool {
+	if !errors.Is(err, auth.ErrTooManySessions) {
+		return false
+	}
+	c.JSON(http.StatusConflict, gin.H{"error": "Maximum number of active sessions reached"})
+	return true
+}
+
+// trustedDevice retorna o dispositivo confiável do usuário identificado pelo
+// cookie da requisição, que dispensa o segundo fator, ou nil
+func trustedDevice(c *gin.Context, deviceService device.Service, usr *user.User) *user.TrustedDevice {
+	cookie, err := c.Cookie(trustedDeviceCookie)
+	if err != nil || cookie == "" {
+		return nil
+	}
+
+	trusted, err := deviceService.Verify(c.Request.Context(), usr, cookie)
+	if err != nil {
+		if !errors.Is(err, device.ErrUntrustedDevice) {
+			log.Printf("Warning: failed to verify trusted device of user %s: %v", usr.ID.Hex(), err)
+		}
+		return nil
+	}
+	return trusted
+}
+
+// newLoginAttempt descreve para a avaliação de risco o login de um usuário já
+// identificado pelo primeiro fator
+func newLoginAttempt(c *gin.Context, usr *user.User) *risk.Attempt {
+	return &risk.Attempt{
+		UserID:    usr.ID.Hex(),
+		Email:     usr.Email,
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		Time:      time.Now().UTC(),
+	}
+}
+
+// assessLogin avalia o risco do login pelo método informado. Um login
+// bloqueado é auditado e respondido com 403; nesse caso retorna nil.
+func assessLogin(c *gin.Context, riskService risk.Service, auditService audit.Service, attempt *risk.Attempt, method string) *risk.Assessment {
+	assessment := riskService.Assess(c.Request.Context(), attempt)
+	if assessment.Decision == risk.DecisionBlock {
+		blockLogin(c, auditService, attempt, method, "blocked by risk policy")
+		return nil
+	}
+	return assessment
+}
+
+// blockLogin audita e recusa um login de risco
+func blockLogin(c *gin.Context, auditService audit.Service, attempt *risk.Attempt, method, reason string) {
+	event := &audit.Event{
+		Type:     audit.EventLoginFailed,
+		ActorID:  attempt.UserID,
+		TargetID: attempt.UserID,
+		Outcome:  audit.OutcomeFailure,
+		Reason:   reason,
+		Metadata: map[string]string{"email": attempt.Email, "method": method},
+	}
+	if err := auditService.Record(c.Request.Context(), event); err != nil {
+		log.Printf("Warning: failed to record audit event %s: %v", event.Type, err)
+	}
+	c.JSON(http.StatusForbidden, gin.H{"error": "Login blocked due to suspicious activity"})
+}
@@ -5,16 +5,19 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/juanjerrah/go_auth_api/internal/domain/auth"
+	"github.com/juanjerrah/go_auth_api/internal/domain/policy"
 	"github.com/juanjerrah/go_auth_api/internal/domain/user"
 )
 
 type UserHandler struct {
-	userService user.Service
+	userService   user.Service
+	policyService policy.Service
 }
 
-func NewUserHandler(userService user.Service) *UserHandler {
+func NewUserHandler(userService user.Service, policyService policy.Service) *UserHandler {
 	return &UserHandler{
-		userService: userService,
+		userService:   userService,
+		policyService: policyService,
 	}
 }
 
@@ -30,6 +33,8 @@ func NewUserHandler(userService user.Service) *UserHandler {
 // @Success 200 {object} map[string]string "Password changed successfully"
 // @Failure 400 {object} map[string]string "Invalid input data"
 // @Failure 401 {object} map[string]string "Invalid credentials"
+// @Failure 403 {object} map[string]string "Recent authentication required"
+// @Failure 409 {object} map[string]string "Password managed by an external directory"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /users/{id}/password [put]
 func (h *UserHandler) ChangePassword(c *gin.Context) {
@@ -49,6 +54,10 @@ func (h *UserHandler) ChangePassword(c *gin.Context) {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid password"})
 			return
 		}
+		if err == user.ErrExternalPassword {
+			c.JSON(http.StatusConflict, gin.H{"error": "Password is managed by an external directory"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change password"})
 		return
 	}
@@ -115,19 +124,17 @@ func (h *UserHandler) GetUserByID(c *gin.Context) {
 // @Success 200 {object} map[string]string "User updated successfully"
 // @Failure 400 {object} map[string]string "Invalid input data"
 // @Failure 401 {object} map[string]string "Unauthorized"
-// @Failure 403 {object} map[string]string "Insufficient permissions"
+// @Failure 403 {object} map[string]string "Insufficient permissions or recent authentication required"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 409 {object} map[string]string "Email already in use or cannot demote the last admin"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /users/{id} [put]
 func (h *UserHandler) UpdateUser(c *gin.Context) {
-	userID := c.Param("id")
-	authContext, _ := c.Get("authContext")
-	authCtx := authContext.(*auth.AuthContext)
-
-	// Verificar se o usuário está atualizando a si mesmo ou tem permissão
-	if authCtx.UserID != userID && !auth.HasPermission(authCtx.Role, auth.PermissionUserWrite) {
+	if !h.policyAllowed(c, "user:update") {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
 		return
 	}
+	userID := c.Param("id")
 
 	var req user.UpdateUserRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -135,9 +142,28 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 		return
 	}
 
+	// Role e departamento alimentam as decisões de autorização e têm políticas próprias
+	if req.Role != "" && !h.authorize(c, "user:change_role") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions to change role"})
+		return
+	}
+	if req.Department != "" && !h.authorize(c, "user:change_department") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions to change department"})
+		return
+	}
+
 	err := h.userService.UpdateUser(c.Request.Context(), userID, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+		switch err {
+		case user.ErrUserNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		case user.ErrEmailAlreadyInUse:
+			c.JSON(http.StatusConflict, gin.H{"error": "Email already in use"})
+		case user.ErrLastAdmin:
+			c.JSON(http.StatusConflict, gin.H{"error": "Cannot demote the last admin"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+		}
 		return
 	}
 
@@ -153,29 +179,78 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 // @Param id path string true "User ID"
 // @Success 200 {object} map[string]string "User deleted successfully"
 // @Failure 401 {object} map[string]string "Unauthorized"
-// @Failure 403 {object} map[string]string "Insufficient permissions"
+// @Failure 403 {object} map[string]string "Insufficient permissions or recent authentication required"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 409 {object} map[string]string "Cannot delete the last admin"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /users/{id} [delete]
 func (h *UserHandler) DeleteUser(c *gin.Context) {
-	userID := c.Param("id")
-	authContext, _ := c.Get("authContext")
-	authCtx := authContext.(*auth.AuthContext)
-
-	// Apenas admins podem deletar outros usuários
-	if authCtx.UserID != userID && !auth.HasPermission(authCtx.Role, auth.PermissionUserDelete) {
+	if !h.policyAllowed(c, "user:delete") {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
 		return
 	}
+	userID := c.Param("id")
 
 	err := h.userService.DeleteUser(c.Request.Context(), userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user"})
+		switch err {
+		case user.ErrUserNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		case user.ErrLastAdmin:
+			c.JSON(http.StatusConflict, gin.H{"error": "Cannot delete the last admin"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user"})
+		}
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "User deleted successfully"})
 }
 
+// ResolveUser descreve o usuário da rota como recurso para o motor de políticas
+func (h *UserHandler) ResolveUser(c *gin.Context) (policy.Attributes, error) {
+	userID := c.Param("id")
+	resource := policy.Attributes{"id": userID}
+
+	// Um usuário inexistente é avaliado só pelo ID; o handler responde 404
+	if target, err := h.userService.GetUserByID(c.Request.Context(), userID); err == nil {
+		resource["email"] = target.Email
+		resource["role"] = target.Role
+		if target.Department != "" {
+			resource["department"] = target.Department
+		}
+	}
+	return resource, nil
+}
+
+// policyAllowed confirma que a ação foi autorizada pelo PolicyMiddleware da
+// rota; sem essa decisão no contexto, consulta o motor de políticas, negando
+// por padrão
+func (h *UserHandler) policyAllowed(c *gin.Context, action string) bool {
+	if c.GetString("policyAction") == action {
+		return true
+	}
+	return h.authorize(c, action)
+}
+
+func (h *UserHandler) authorize(c *gin.Context, action string) bool {
+	authContext, exists := c.Get("authContext")
+	if !exists {
+		return false
+	}
+	authCtx := authContext.(*auth.AuthContext)
+
+	resource, _ := h.ResolveUser(c)
+	resource["type"] = "user"
+
+	decision, err := h.policyService.Evaluate(c.Request.Context(), &policy.Request{
+		Subject:  policy.SubjectFromAuthContext(authCtx),
+		Action:   action,
+		Resource: resource,
+	})
+	return err == nil && decision.Allowed
+}
+
 // func (h *UserHandler) ListUsers(c *gin.Context) {
 // 	// Implementar listagem de usuários com paginação
 // 	users, err := h.userService.ListUsers(c.Request.Context(), &user.ListUsersRequest{
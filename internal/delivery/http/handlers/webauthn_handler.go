@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/juanjerrah/go_auth_api/internal/domain/audit"
+	"github.com/juanjerrah/go_auth_api/internal/domain/auth"
+	"github.com/juanjerrah/go_auth_api/internal/domain/loginalert"
+	"github.com/juanjerrah/go_auth_api/internal/domain/risk"
+	"github.com/juanjerrah/go_auth_api/internal/domain/user"
+	"github.com/juanjerrah/go_auth_api/internal/domain/webauthn"
+	"github.com/juanjerrah/go_auth_api/pkg/middleware"
+)
+
+type WebAuthnHandler struct {
+	webauthnService webauthn.Service
+	jwtManager      *auth.JWTManager
+	authService     auth.AuthService
+	auditService    audit.Service
+	alertService    loginalert.Service
+	riskService     risk.Service
+	cookies         *middleware.SessionCookies
+}
+
+func NewWebAuthnHandler(webauthnService webauthn.Service, jwtManager *auth.JWTManager, authService auth.AuthService, auditService audit.Service, alertService loginalert.Service, riskService risk.Service, cookies *middleware.SessionCookies) *WebAuthnHandler {
+	return &WebAuthnHandler{
+		webauthnService: webauthnService,
+		jwtManager:      jwtManager,
+		authService:     authService,
+		auditService:    auditService,
+		alertService:    alertService,
+		riskService:     riskService,
+		cookies:         cookies,
+	}
+}
+
+// RegistrationOptions starts the registration of a passkey
+// @Summary Start passkey registration
+// @Description Return the navigator.credentials.create() options for a new passkey of the authenticated user
+// @Tags webauthn
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} webauthn.CreationOptions "Creation options"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Not allowed during impersonation or recent authentication required"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /users/me/webauthn/register/options [post]
+func (h *WebAuthnHandler) RegistrationOptions(c *gin.Context) {
+	authContext, _ := c.Get("authContext")
+	authCtx := authContext.(*auth.AuthContext)
+
+	options, err := h.webauthnService.BeginRegistration(c.Request.Context(), authCtx.UserID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, options)
+}
+
+// Register completes the registration of a passkey
+// @Summary Register passkey
+// @Description Verify the attestation ("none" or "packed") and store the passkey for the authenticated user
+// @Tags webauthn
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body webauthn.RegistrationRequest true "Credential created by the browser"
+// @Success 201 {object} user.WebAuthnCredential "Registered passkey"
+// @Failure 400 {object} map[string]string "Invalid input data or attestation"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Not allowed during impersonation or recent authentication required"
+// @Failure 409 {object} map[string]string "Passkey already registered"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /users/me/webauthn/register [post]
+func (h *WebAuthnHandler) Register(c *gin.Context) {
+	var req webauthn.RegistrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	authContext, _ := c.Get("authContext")
+	authCtx := authContext.(*auth.AuthContext)
+
+	credential, err := h.webauthnService.FinishRegistration(c.Request.Context(), authCtx.UserID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, webauthn.ErrCredentialExists):
+			c.JSON(http.StatusConflict, gin.H{"error": "Passkey already registered"})
+		case errors.Is(err, webauthn.ErrInvalidChallenge), errors.Is(err, webauthn.ErrInvalidCredential):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid passkey registration"})
+		default:
+			h.handleError(c, err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, credential)
+}
+
+// ListCredentials lists the passkeys of the authenticated user
+// @Summary List passkeys
+// @Description List the passkeys registered by the authenticated user
+// @Tags webauthn
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} user.WebAuthnCredential "Passkeys"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /users/me/webauthn/credentials [get]
+func (h *WebAuthnHandler) ListCredentials(c *gin.Context) {
+	authContext, _ := c.Get("authContext")
+	authCtx := authContext.(*auth.AuthContext)
+
+	credentials, err := h.webauthnService.ListCredentials(c.Request.Context(), authCtx.UserID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, credentials)
+}
+
+// RemoveCredential removes a passkey of the authenticated user
+// @Summary Remove passkey
+// @Description Remove one of the authenticated user's passkeys
+// @Tags webauthn
+// @Security BearerAuth
+// @Produce json
+// @Param credentialId path string true "Credential ID"
+// @Success 200 {object} map[string]string "Passkey removed"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Not allowed during impersonation or recent authentication required"
+// @Failure 404 {object} map[string]string "Passkey not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /users/me/webauthn/credentials/{credentialId} [delete]
+func (h *WebAuthnHandler) RemoveCredential(c *gin.Context) {
+	authContext, _ := c.Get("authContext")
+	authCtx := authContext.(*auth.AuthContext)
+
+	if err := h.webauthnService.RemoveCredential(c.Request.Context(), authCtx.UserID, c.Param("credentialId")); err != nil {
+		if errors.Is(err, webauthn.ErrCredentialNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Passkey not found"})
+			return
+		}
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Passkey removed successfully"})
+}
+
+// LoginOptions starts a passwordless login with a passkey
+// @Summary Start passkey login
+// @Description Return the navigator.credentials.get() options for a passwordless login with a discoverable passkey
+// @Tags auth
+// @Produce json
+// @Success 200 {object} webauthn.RequestOptions "Assertion options"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /auth/webauthn/login/options [post]
+func (h *WebAuthnHandler) LoginOptions(c *gin.Context) {
+	options, err := h.webauthnService.BeginLogin(c.Request.Context(), "")
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, options)
+}
+
+// Login completes a passwordless login with a passkey
+// @Summary Login with passkey
+// @Description Verify the passkey assertion, which must include user verification, and start the session
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body webauthn.LoginRequest true "Assertion from the browser"
+// @Success 200 {object} map[string]interface{} "Login successful"
+// @Failure 400 {object} map[string]string "Invalid input data"
+// @Failure 401 {object} map[string]string "Verification failed"
+// @Failure 403 {object} map[string]string "Account disabled or login blocked"
+// @Failure 409 {object} map[string]string "Maximum number of active sessions reached"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /auth/webauthn/login/verify [post]
+func (h *WebAuthnHandler) Login(c *gin.Context) {
+	var req webauthn.LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	usr, err := h.webauthnService.FinishLogin(c.Request.Context(), "", &req.Credential)
+	if err != nil {
+		h.recordAudit(c, &audit.Event{
+			Type:     audit.EventLoginFailed,
+			Outcome:  audit.OutcomeFailure,
+			Reason:   err.Error(),
+			Metadata: map[string]string{"method": "webauthn"},
+		})
+		h.handleError(c, err)
+		return
+	}
+
+	// A passkey com verificação do usuário já é um login multifator; só o
+	// bloqueio impede a sessão
+	attempt := newLoginAttempt(c, usr)
+	if assessment := assessLogin(c, h.riskService, h.auditService, attempt, "webauthn"); assessment == nil {
+		return
+	}
+
+	token, err := issueSession(c, h.jwtManager, h.authService, h.alertService, h.cookies, usr, []string{auth.AMRHardwareKey})
+	if err != nil {
+		if tooManySessions(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start session"})
+		return
+	}
+	h.riskService.Record(c.Request.Context(), attempt, true)
+
+	h.recordAudit(c, &audit.Event{
+		Type:     audit.EventLogin,
+		ActorID:  usr.ID.Hex(),
+		TargetID: usr.ID.Hex(),
+		Metadata: map[string]string{"method": "webauthn"},
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Login successful",
+		"token":   token,
+		"user": gin.H{
+			"id":    usr.ID.Hex(),
+			"name":  usr.Name,
+			"email": usr.Email,
+			"role":  usr.Role,
+		},
+	})
+}
+
+func (h *WebAuthnHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, webauthn.ErrInvalidChallenge),
+		errors.Is(err, webauthn.ErrInvalidCredential),
+		errors.Is(err, webauthn.ErrCredentialNotFound),
+		errors.Is(err, webauthn.ErrClonedAuthenticator):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Passkey verification failed"})
+	case errors.Is(err, user.ErrUserDisabled):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Account is disabled"})
+	case errors.Is(err, user.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	default:
+		log.Printf("Warning: webauthn operation failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	}
+}
+
+func (h *WebAuthnHandler) recordAudit(c *gin.Context, event *audit.Event) {
+	if err := h.auditService.Record(c.Request.Context(), event); err != nil {
+		log.Printf("Warning: failed to record audit event %s: %v", event.Type, err)
+	}
+}
@@ -1,35 +1,197 @@
 package http
 
 import (
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"github.com/juanjerrah/go_auth_api/internal/delivery/http/handlers"
+	"github.com/juanjerrah/go_auth_api/internal/domain/audit"
 	"github.com/juanjerrah/go_auth_api/internal/domain/auth"
+	"github.com/juanjerrah/go_auth_api/internal/domain/authz"
+	"github.com/juanjerrah/go_auth_api/internal/domain/device"
+	"github.com/juanjerrah/go_auth_api/internal/domain/group"
+	"github.com/juanjerrah/go_auth_api/internal/domain/loginalert"
+	"github.com/juanjerrah/go_auth_api/internal/domain/magiclink"
+	"github.com/juanjerrah/go_auth_api/internal/domain/mfa"
+	"github.com/juanjerrah/go_auth_api/internal/domain/oidc"
+	"github.com/juanjerrah/go_auth_api/internal/domain/organization"
+	"github.com/juanjerrah/go_auth_api/internal/domain/otp"
+	"github.com/juanjerrah/go_auth_api/internal/domain/policy"
+	"github.com/juanjerrah/go_auth_api/internal/domain/relation"
+	"github.com/juanjerrah/go_auth_api/internal/domain/risk"
+	"github.com/juanjerrah/go_auth_api/internal/domain/saml"
+	"github.com/juanjerrah/go_auth_api/internal/domain/scim"
 	"github.com/juanjerrah/go_auth_api/internal/domain/user"
+	"github.com/juanjerrah/go_auth_api/internal/domain/webauthn"
 	"github.com/juanjerrah/go_auth_api/pkg/middleware"
 )
 
-func SetupRoutes(router *gin.Engine, userService user.Service, authService auth.AuthService, jwtManager *auth.JWTManager) {
+func SetupRoutes(router *gin.Engine, userService user.Service, authService auth.AuthService, auditService audit.Service, orgService organization.Service, invitationService organization.InvitationService, groupService group.Service, policyService policy.Service, relationService relation.Service, authzService authz.Service, scimService scim.Service, oidcService oidc.Service, samlService saml.Service, magicLinkService magiclink.Service, mfaService mfa.Service, webauthnService webauthn.Service, otpService otp.Service, deviceService device.Service, loginAlertService loginalert.Service, riskService risk.Service, jwtManager *auth.JWTManager, sessionCookies *middleware.SessionCookies, impersonationTTL, reauthMaxAge time.Duration, scimBaseURL string, scimTokens []string) {
 	// Handlers
-	authHandler := handlers.NewAuthHandler(userService, jwtManager, authService)
-	userHandler := handlers.NewUserHandler(userService)
+	authHandler := handlers.NewAuthHandler(userService, jwtManager, authService, auditService, orgService, mfaService, webauthnService, otpService, deviceService, loginAlertService, riskService, sessionCookies)
+	userHandler := handlers.NewUserHandler(userService, policyService)
+	auditHandler := handlers.NewAuditHandler(auditService)
+	adminHandler := handlers.NewAdminHandler(userService, jwtManager, authService, auditService, impersonationTTL)
+	orgHandler := handlers.NewOrganizationHandler(orgService)
+	invitationHandler := handlers.NewInvitationHandler(invitationService)
+	groupHandler := handlers.NewGroupHandler(groupService)
+	policyHandler := handlers.NewPolicyHandler(policyService)
+	relationHandler := handlers.NewRelationHandler(relationService)
+	authzHandler := handlers.NewAuthzHandler(authzService)
+	scimHandler := handlers.NewSCIMHandler(scimService, scimBaseURL)
+	oidcHandler := handlers.NewOIDCHandler(oidcService, jwtManager, authService, auditService, loginAlertService, sessionCookies)
+	samlHandler := handlers.NewSAMLHandler(samlService, jwtManager, authService, auditService, loginAlertService, sessionCookies)
+	magicLinkHandler := handlers.NewMagicLinkHandler(magicLinkService, jwtManager, authService, auditService, mfaService, deviceService, loginAlertService, riskService, sessionCookies)
+	webauthnHandler := handlers.NewWebAuthnHandler(webauthnService, jwtManager, authService, auditService, loginAlertService, riskService, sessionCookies)
+	otpHandler := handlers.NewOTPHandler(otpService)
+	deviceHandler := handlers.NewDeviceHandler(deviceService)
+	loginAlertHandler := handlers.NewLoginAlertHandler(loginAlertService)
+
+	router.Use(middleware.RequestInfoMiddleware())
 
 	// Public routes
 	public := router.Group("/api/v1")
 	{
 		public.POST("/auth/register", authHandler.Register)
 		public.POST("/auth/login", authHandler.Login)
+		public.POST("/invitations/accept", invitationHandler.AcceptInvitation)
+
+		// Second factor routes (challenge opened by the login)
+		public.POST("/auth/mfa/webauthn/options", authHandler.WebAuthnMFAOptions)
+		public.POST("/auth/mfa/webauthn/verify", authHandler.WebAuthnMFAVerify)
+		public.POST("/auth/mfa/otp/send", authHandler.OTPMFASend)
+		public.POST("/auth/mfa/otp/verify", authHandler.OTPMFAVerify)
+
+		// Passwordless login routes (passkeys)
+		public.POST("/auth/webauthn/login/options", webauthnHandler.LoginOptions)
+		public.POST("/auth/webauthn/login/verify", webauthnHandler.Login)
+
+		// Passwordless login routes (single-use links sent by email)
+		public.POST("/auth/magic-link", magicLinkHandler.RequestLink)
+		public.GET("/auth/magic-link/consume", magicLinkHandler.ConfirmLink)
+		public.POST("/auth/magic-link/consume", magicLinkHandler.ConsumeLink)
+
+		// Unrecognized login reports ("this wasn't me" link) and password reset
+		public.GET("/auth/login-alerts/report", loginAlertHandler.ConfirmReport)
+		public.POST("/auth/login-alerts/report", loginAlertHandler.Report)
+		public.POST("/auth/password/reset", loginAlertHandler.ResetPassword)
+
+		// Federated login routes (OpenID Connect)
+		public.GET("/auth/oidc/providers", oidcHandler.ListProviders)
+		public.GET("/auth/oidc/:provider/login", oidcHandler.Login)
+		public.GET("/auth/oidc/:provider/callback", oidcHandler.Callback)
+
+		// Federated login routes (SAML 2.0 service provider)
+		public.GET("/auth/saml/providers", samlHandler.ListProviders)
+		public.GET("/auth/saml/:provider/metadata", samlHandler.Metadata)
+		public.GET("/auth/saml/:provider/login", samlHandler.Login)
+		public.POST("/auth/saml/:provider/acs", samlHandler.AssertionConsumerService)
+	}
+
+	// SCIM 2.0 provisioning routes, authenticated by provisioning tokens
+	scimRoutes := router.Group("/api/v1/scim/v2")
+	scimRoutes.Use(middleware.SCIMAuthMiddleware(scimTokens))
+	{
+		scimRoutes.GET("/ServiceProviderConfig", scimHandler.ServiceProviderConfig)
+		scimRoutes.GET("/ResourceTypes", scimHandler.ListResourceTypes)
+		scimRoutes.GET("/ResourceTypes/:id", scimHandler.GetResourceType)
+		scimRoutes.GET("/Schemas", scimHandler.ListSchemas)
+		scimRoutes.GET("/Schemas/:id", scimHandler.GetSchema)
+		scimRoutes.POST("/Users", scimHandler.CreateUser)
+		scimRoutes.GET("/Users", scimHandler.ListUsers)
+		scimRoutes.GET("/Users/:id", scimHandler.GetUser)
+		scimRoutes.PUT("/Users/:id", scimHandler.ReplaceUser)
+		scimRoutes.PATCH("/Users/:id", scimHandler.PatchUser)
+		scimRoutes.DELETE("/Users/:id", scimHandler.DeleteUser)
+		scimRoutes.POST("/Groups", scimHandler.CreateGroup)
+		scimRoutes.GET("/Groups", scimHandler.ListGroups)
+		scimRoutes.GET("/Groups/:id", scimHandler.GetGroup)
+		scimRoutes.PUT("/Groups/:id", scimHandler.ReplaceGroup)
+		scimRoutes.PATCH("/Groups/:id", scimHandler.PatchGroup)
+		scimRoutes.DELETE("/Groups/:id", scimHandler.DeleteGroup)
 	}
 
 	// Protected routes
 	protected := router.Group("/api/v1")
-	protected.Use(middleware.AuthMiddleware(jwtManager, authService))
+	protected.Use(middleware.AuthMiddleware(jwtManager, authService, sessionCookies))
 	{
-		// User routes
+		protected.POST("/auth/refresh", authHandler.RefreshToken)
+		protected.POST("/auth/logout", authHandler.Logout)
+		protected.POST("/auth/logout-all", middleware.DenyImpersonation(), authHandler.LogoutAll)
+		protected.POST("/auth/impersonation/stop", authHandler.StopImpersonation)
+		protected.POST("/auth/switch-organization", authHandler.SwitchOrganization)
+		protected.POST("/auth/reauthenticate/options", middleware.DenyImpersonation(), authHandler.ReauthenticationOptions)
+		protected.POST("/auth/reauthenticate", middleware.DenyImpersonation(), authHandler.Reauthenticate)
+
+		// User routes (sensitive changes require a recent authentication)
+		recentAuth := middleware.RequireRecentAuth(reauthMaxAge)
 		userRoutes := protected.Group("/users")
 		{
 			userRoutes.GET("/profile", userHandler.GetUserProfile)
-			userRoutes.PUT("/:id", userHandler.UpdateUser)
-			userRoutes.DELETE("/:id", userHandler.DeleteUser)
+			userRoutes.GET("/me/activity", auditHandler.GetMyActivity)
+			userRoutes.POST("/me/webauthn/register/options", middleware.DenyImpersonation(), recentAuth, webauthnHandler.RegistrationOptions)
+			userRoutes.POST("/me/webauthn/register", middleware.DenyImpersonation(), recentAuth, webauthnHandler.Register)
+			userRoutes.GET("/me/webauthn/credentials", webauthnHandler.ListCredentials)
+			userRoutes.DELETE("/me/webauthn/credentials/:credentialId", middleware.DenyImpersonation(), recentAuth, webauthnHandler.RemoveCredential)
+			userRoutes.POST("/me/otp/enroll", middleware.DenyImpersonation(), recentAuth, otpHandler.Enroll)
+			userRoutes.POST("/me/otp/enroll/verify", middleware.DenyImpersonation(), recentAuth, otpHandler.VerifyEnrollment)
+			userRoutes.GET("/me/otp/factors", otpHandler.ListFactors)
+			userRoutes.DELETE("/me/otp/factors/:channel", middleware.DenyImpersonation(), recentAuth, otpHandler.RemoveFactor)
+			userRoutes.POST("/me/identities/oidc/:provider", middleware.DenyImpersonation(), recentAuth, oidcHandler.Link)
+			userRoutes.POST("/me/identities/saml/:provider", middleware.DenyImpersonation(), recentAuth, samlHandler.Link)
+			userRoutes.GET("/me/devices", deviceHandler.ListDevices)
+			userRoutes.DELETE("/me/devices", middleware.DenyImpersonation(), deviceHandler.RevokeAllDevices)
+			userRoutes.DELETE("/me/devices/:deviceId", middleware.DenyImpersonation(), deviceHandler.RevokeDevice)
+			userRoutes.PUT("/:id", middleware.DenyImpersonation(), recentAuth, middleware.PolicyMiddleware(policyService, "user:update", "user", userHandler.ResolveUser), userHandler.UpdateUser)
+			userRoutes.DELETE("/:id", middleware.DenyImpersonation(), recentAuth, middleware.PolicyMiddleware(policyService, "user:delete", "user", userHandler.ResolveUser), userHandler.DeleteUser)
+		}
+
+		// Organization routes
+		protected.POST("/orgs", orgHandler.CreateOrganization)
+		protected.GET("/orgs", orgHandler.ListMyOrganizations)
+
+		tenantRoutes := protected.Group("/orgs/:orgId")
+		tenantRoutes.Use(middleware.TenantMiddleware("orgId"))
+		{
+			tenantRoutes.GET("", orgHandler.GetOrganization)
+			tenantRoutes.GET("/members", orgHandler.ListMembers)
+			tenantRoutes.GET("/members/:userId", orgHandler.GetMember)
+
+			tenantAdmin := tenantRoutes.Group("", middleware.TenantRoleMiddleware(user.RoleAdmin))
+			{
+				tenantAdmin.POST("/members", orgHandler.AddMember)
+				tenantAdmin.PUT("/members/:userId", orgHandler.UpdateMemberRole)
+				tenantAdmin.DELETE("/members/:userId", orgHandler.RemoveMember)
+				tenantAdmin.POST("/invitations", invitationHandler.CreateInvitation)
+				tenantAdmin.GET("/invitations", invitationHandler.ListInvitations)
+				tenantAdmin.DELETE("/invitations/:invitationId", invitationHandler.RevokeInvitation)
+			}
+		}
+
+		// Relationship-based authorization routes
+		relationRoutes := protected.Group("/relations")
+		relationRoutes.Use(middleware.PermissionMiddleware(auth.PermissionRelationRead))
+		{
+			relationRoutes.GET("/namespaces", relationHandler.ListNamespaces)
+			relationRoutes.GET("/tuples", relationHandler.ReadTuples)
+			relationRoutes.POST("/check", relationHandler.Check)
+			relationRoutes.POST("/expand", relationHandler.Expand)
+			relationRoutes.POST("/list-objects", relationHandler.ListObjects)
+
+			relationWrite := relationRoutes.Group("", middleware.PermissionMiddleware(auth.PermissionRelationWrite))
+			{
+				relationWrite.PUT("/namespaces/:namespace", relationHandler.PutNamespace)
+				relationWrite.DELETE("/namespaces/:namespace", relationHandler.DeleteNamespace)
+				relationWrite.POST("/tuples", relationHandler.WriteTuples)
+			}
+		}
+
+		// Authorization decision routes for other services
+		authzRoutes := protected.Group("/authz")
+		authzRoutes.Use(middleware.PermissionMiddleware(auth.PermissionAuthzCheck))
+		{
+			authzRoutes.POST("/check", authzHandler.Check)
+			authzRoutes.POST("/check/batch", authzHandler.BatchCheck)
 		}
 
 		// Admin only routes
@@ -38,6 +200,32 @@ func SetupRoutes(router *gin.Engine, userService user.Service, authService auth.
 		{
 			//adminRoutes.GET("/users", userHandler.ListUsers)
 			adminRoutes.GET("/users/:id", userHandler.GetUserByID)
+			adminRoutes.GET("/audit", auditHandler.ListEvents)
+			adminRoutes.GET("/audit/verify", auditHandler.VerifyChain)
+			adminRoutes.GET("/users/:id/groups", groupHandler.ListUserGroups)
+			adminRoutes.GET("/groups", groupHandler.ListGroups)
+			adminRoutes.GET("/groups/:groupId", groupHandler.GetGroup)
+			adminRoutes.GET("/groups/:groupId/members", groupHandler.ListMembers)
+			adminRoutes.GET("/policies", policyHandler.ListPolicies)
+			adminRoutes.GET("/policies/:policyId", policyHandler.GetPolicy)
+
+			// Uma sessão de personificação nunca altera dados administrativos
+			adminWrite := adminRoutes.Group("", middleware.PermissionMiddleware(auth.PermissionAdminWrite), middleware.DenyImpersonation())
+			{
+				adminWrite.POST("/users", adminHandler.CreateUser)
+				adminWrite.PUT("/users/:id/role", adminHandler.ChangeRole)
+				adminWrite.POST("/users/:id/logout", adminHandler.ForceLogout)
+				adminWrite.POST("/users/:id/impersonate", adminHandler.Impersonate)
+				adminWrite.POST("/users/:id/mfa/reset", adminHandler.ResetMFA)
+				adminWrite.POST("/groups", groupHandler.CreateGroup)
+				adminWrite.PUT("/groups/:groupId", groupHandler.UpdateGroup)
+				adminWrite.DELETE("/groups/:groupId", groupHandler.DeleteGroup)
+				adminWrite.POST("/groups/:groupId/members", groupHandler.AddMember)
+				adminWrite.DELETE("/groups/:groupId/members/:userId", groupHandler.RemoveMember)
+				adminWrite.POST("/policies", policyHandler.CreatePolicy)
+				adminWrite.PUT("/policies/:policyId", policyHandler.UpdatePolicy)
+				adminWrite.DELETE("/policies/:policyId", policyHandler.DeletePolicy)
+			}
 		}
 	}
 
@@ -45,4 +233,4 @@ func SetupRoutes(router *gin.Engine, userService user.Service, authService auth.
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{"status": "ok"})
 	})
-}
\ No newline at end of file
+}
@@ -0,0 +1,37 @@
+package audit
+
+import "context"
+
+type requestInfoKey struct{}
+
+// RequestInfo carrega os dados da requisição HTTP que acompanham os eventos
+// emitidos pelas camadas de serviço.
+type RequestInfo struct {
+	ActorID        string
+	ImpersonatorID string
+	IP             string
+	UserAgent      string
+}
+
+func WithRequestInfo(ctx context.Context, info RequestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey{}, info)
+}
+
+func RequestInfoFromContext(ctx context.Context) RequestInfo {
+	info, _ := ctx.Value(requestInfoKey{}).(RequestInfo)
+	return info
+}
+
+// WithActor associa o usuário autenticado às informações da requisição.
+func WithActor(ctx context.Context, actorID string) context.Context {
+	info := RequestInfoFromContext(ctx)
+	info.ActorID = actorID
+	return WithRequestInfo(ctx, info)
+}
+
+// WithImpersonator registra o admin que age em nome do usuário autenticado.
+func WithImpersonator(ctx context.Context, impersonatorID string) context.Context {
+	info := RequestInfoFromContext(ctx)
+	info.ImpersonatorID = impersonatorID
+	return WithRequestInfo(ctx, info)
+}
@@ -0,0 +1,123 @@
+package audit
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type EventType string
+
+const (
+	EventLogin                 EventType = "auth.login"
+	EventLoginFailed           EventType = "auth.login_failed"
+	EventLogout                EventType = "auth.logout"
+	EventLogoutAll             EventType = "auth.logout_all"
+	EventTokenRefreshed        EventType = "auth.token_refreshed"
+	EventMagicLinkSent         EventType = "auth.magic_link_sent"
+	EventOTPSent               EventType = "auth.otp_sent"
+	EventReauthenticated       EventType = "auth.reauthenticated"
+	EventLoginAlertSent        EventType = "auth.login_alert_sent"
+	EventLoginReported         EventType = "auth.login_reported"
+	EventRiskAssessed          EventType = "auth.risk_assessed"
+	EventUserCreated           EventType = "user.created"
+	EventUserUpdated           EventType = "user.updated"
+	EventRoleChanged           EventType = "user.role_changed"
+	EventUserDeleted           EventType = "user.deleted"
+	EventPasswordChanged       EventType = "user.password_changed"
+	EventPasswordResetRequired EventType = "user.password_reset_required"
+	EventIdentityLinked        EventType = "user.identity_linked"
+	EventWebAuthnRegistered    EventType = "user.webauthn_registered"
+	EventWebAuthnRemoved       EventType = "user.webauthn_removed"
+	EventOTPEnrolled           EventType = "user.otp_enrolled"
+	EventOTPRemoved            EventType = "user.otp_removed"
+	EventDeviceTrusted         EventType = "user.device_trusted"
+	EventDeviceRevoked         EventType = "user.device_revoked"
+	EventForceLogout           EventType = "admin.force_logout"
+	EventImpersonationStarted  EventType = "admin.impersonation_started"
+	EventImpersonationStopped  EventType = "admin.impersonation_stopped"
+	EventMFAReset              EventType = "admin.mfa_reset"
+	EventOrganizationCreated   EventType = "org.created"
+	EventMemberAdded           EventType = "org.member_added"
+	EventMemberRoleChanged     EventType = "org.member_role_changed"
+	EventMemberRemoved         EventType = "org.member_removed"
+	EventInvitationCreated     EventType = "org.invitation_created"
+	EventInvitationAccepted    EventType = "org.invitation_accepted"
+	EventInvitationRevoked     EventType = "org.invitation_revoked"
+	EventGroupCreated          EventType = "group.created"
+	EventGroupUpdated          EventType = "group.updated"
+	EventGroupDeleted          EventType = "group.deleted"
+	EventGroupMemberAdded      EventType = "group.member_added"
+	EventGroupMemberRemoved    EventType = "group.member_removed"
+	EventPolicyCreated         EventType = "policy.created"
+	EventPolicyUpdated         EventType = "policy.updated"
+	EventPolicyDeleted         EventType = "policy.deleted"
+	EventNamespaceUpdated      EventType = "relation.namespace_updated"
+	EventNamespaceDeleted      EventType = "relation.namespace_deleted"
+	EventRelationTuplesWritten EventType = "relation.tuples_written"
+)
+
+type Outcome string
+
+const (
+	OutcomeSuccess Outcome = "success"
+	OutcomeFailure Outcome = "failure"
+)
+
+// Event é um registro imutável do log de auditoria. Cada evento carrega o hash
+// do evento anterior, formando uma cadeia que permite detectar adulterações.
+type Event struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Sequence int64              `bson:"sequence" json:"sequence"`
+	Type     EventType          `bson:"type" json:"type"`
+	ActorID  string             `bson:"actor_id,omitempty" json:"actor_id,omitempty"`
+	// ImpersonatorID identifica o admin que agia em nome do ator
+	ImpersonatorID string            `bson:"impersonator_id,omitempty" json:"impersonator_id,omitempty"`
+	TargetID       string            `bson:"target_id,omitempty" json:"target_id,omitempty"`
+	IP             string            `bson:"ip,omitempty" json:"ip,omitempty"`
+	UserAgent      string            `bson:"user_agent,omitempty" json:"user_agent,omitempty"`
+	Outcome        Outcome           `bson:"outcome" json:"outcome"`
+	Reason         string            `bson:"reason,omitempty" json:"reason,omitempty"`
+	Metadata       map[string]string `bson:"metadata,omitempty" json:"metadata,omitempty"`
+	Timestamp      time.Time         `bson:"timestamp" json:"timestamp"`
+	PrevHash       string            `bson:"prev_hash" json:"prev_hash"`
+	Hash           string            `bson:"hash" json:"hash"`
+}
+
+type Filter struct {
+	ActorID  string
+	TargetID string
+	// SubjectID seleciona eventos em que o usuário é o ator ou o alvo
+	SubjectID string
+	Type      EventType
+	Outcome   Outcome
+	From      time.Time
+	To        time.Time
+	Page      int
+	Limit     int
+}
+
+type ListEventsRequest struct {
+	ActorID  string    `form:"actor_id"`
+	TargetID string    `form:"target_id"`
+	Type     EventType `form:"type"`
+	Outcome  Outcome   `form:"outcome" binding:"omitempty,oneof=success failure"`
+	From     time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To       time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Page     int       `form:"page" binding:"omitempty,min=1"`
+	Limit    int       `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+type ListEventsResponse struct {
+	Events []*Event `json:"events"`
+	Total  int64    `json:"total"`
+	Page   int      `json:"page"`
+	Limit  int      `json:"limit"`
+}
+
+type VerifyResponse struct {
+	Valid          bool   `json:"valid"`
+	CheckedEvents  int64  `json:"checked_events"`
+	BrokenSequence int64  `json:"broken_sequence,omitempty"`
+	Reason         string `json:"reason,omitempty"`
+}
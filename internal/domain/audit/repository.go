@@ -0,0 +1,16 @@
+package audit
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrSequenceConflict = errors.New("audit sequence conflict")
+
+// Repository é append-only: eventos gravados não podem ser alterados nem removidos.
+type Repository interface {
+	Append(ctx context.Context, event *Event) error
+	Last(ctx context.Context) (*Event, error)
+	Find(ctx context.Context, filter *Filter) ([]*Event, int64, error)
+	FindAfter(ctx context.Context, sequence int64, limit int) ([]*Event, error)
+}
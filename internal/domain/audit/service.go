@@ -0,0 +1,238 @@
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	defaultPage      = 1
+	defaultLimit     = 20
+	maxAppendRetries = 5
+	verifyBatchSize  = 500
+)
+
+var ErrAppendFailed = errors.New("failed to append audit event")
+
+type Service interface {
+	Record(ctx context.Context, event *Event) error
+	List(ctx context.Context, req *ListEventsRequest) (*ListEventsResponse, error)
+	ListForUser(ctx context.Context, userID string, req *ListEventsRequest) (*ListEventsResponse, error)
+	VerifyChain(ctx context.Context) (*VerifyResponse, error)
+}
+
+type service struct {
+	repo Repository
+	mu   sync.Mutex
+}
+
+func NewService(repo Repository) Service {
+	return &service{
+		repo: repo,
+	}
+}
+
+// Record implements Service.
+func (s *service) Record(ctx context.Context, event *Event) error {
+	info := RequestInfoFromContext(ctx)
+	if event.ActorID == "" {
+		event.ActorID = info.ActorID
+	}
+	if event.ImpersonatorID == "" {
+		event.ImpersonatorID = info.ImpersonatorID
+	}
+	if event.IP == "" {
+		event.IP = info.IP
+	}
+	if event.UserAgent == "" {
+		event.UserAgent = info.UserAgent
+	}
+	if event.Outcome == "" {
+		event.Outcome = OutcomeSuccess
+	}
+	// O MongoDB armazena datas com precisão de milissegundos; truncar antes de
+	// calcular o hash garante que a verificação reproduza o mesmo valor.
+	event.Timestamp = time.Now().UTC().Truncate(time.Millisecond)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Outras instâncias podem gravar concorrentemente; o índice único de
+	// sequência rejeita a gravação e tentamos novamente a partir do novo topo.
+	for range maxAppendRetries {
+		last, err := s.repo.Last(ctx)
+		if err != nil {
+			return err
+		}
+
+		event.Sequence = 1
+		event.PrevHash = ""
+		if last != nil {
+			event.Sequence = last.Sequence + 1
+			event.PrevHash = last.Hash
+		}
+
+		event.Hash, err = computeHash(event)
+		if err != nil {
+			return err
+		}
+
+		err = s.repo.Append(ctx, event)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, ErrSequenceConflict) {
+			return err
+		}
+	}
+
+	return ErrAppendFailed
+}
+
+// List implements Service.
+func (s *service) List(ctx context.Context, req *ListEventsRequest) (*ListEventsResponse, error) {
+	return s.list(ctx, s.toFilter(req))
+}
+
+// ListForUser implements Service.
+func (s *service) ListForUser(ctx context.Context, userID string, req *ListEventsRequest) (*ListEventsResponse, error) {
+	filter := s.toFilter(req)
+	filter.ActorID = ""
+	filter.TargetID = ""
+	filter.SubjectID = userID
+	return s.list(ctx, filter)
+}
+
+// VerifyChain implements Service.
+func (s *service) VerifyChain(ctx context.Context) (*VerifyResponse, error) {
+	result := &VerifyResponse{Valid: true}
+
+	var (
+		lastSequence int64
+		lastHash     string
+	)
+	for {
+		events, err := s.repo.FindAfter(ctx, lastSequence, verifyBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		if len(events) == 0 {
+			return result, nil
+		}
+
+		for _, event := range events {
+			result.CheckedEvents++
+
+			if event.Sequence != lastSequence+1 {
+				return s.broken(result, event.Sequence, "missing sequence"), nil
+			}
+			if event.PrevHash != lastHash {
+				return s.broken(result, event.Sequence, "previous hash mismatch"), nil
+			}
+
+			hash, err := computeHash(event)
+			if err != nil {
+				return nil, err
+			}
+			if hash != event.Hash {
+				return s.broken(result, event.Sequence, "hash mismatch"), nil
+			}
+
+			lastSequence = event.Sequence
+			lastHash = event.Hash
+		}
+	}
+}
+
+func (s *service) list(ctx context.Context, filter *Filter) (*ListEventsResponse, error) {
+	events, total, err := s.repo.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListEventsResponse{
+		Events: events,
+		Total:  total,
+		Page:   filter.Page,
+		Limit:  filter.Limit,
+	}, nil
+}
+
+func (s *service) toFilter(req *ListEventsRequest) *Filter {
+	filter := &Filter{
+		ActorID:  req.ActorID,
+		TargetID: req.TargetID,
+		Type:     req.Type,
+		Outcome:  req.Outcome,
+		From:     req.From,
+		To:       req.To,
+		Page:     req.Page,
+		Limit:    req.Limit,
+	}
+	if filter.Page <= 0 {
+		filter.Page = defaultPage
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultLimit
+	}
+	return filter
+}
+
+func (s *service) broken(result *VerifyResponse, sequence int64, reason string) *VerifyResponse {
+	result.Valid = false
+	result.BrokenSequence = sequence
+	result.Reason = reason
+	return result
+}
+
+// computeHash calcula o hash encadeado do evento. A serialização JSON de uma
+// struct tem ordem de campos fixa e as chaves de mapas são ordenadas, o que
+// torna o resultado determinístico.
+func computeHash(event *Event) (string, error) {
+	// Metadados vazios não são persistidos e voltam como nil do banco
+	metadata := event.Metadata
+	if len(metadata) == 0 {
+		metadata = nil
+	}
+
+	payload, err := json.Marshal(struct {
+		Sequence int64     `json:"sequence"`
+		Type     EventType `json:"type"`
+		ActorID  string    `json:"actor_id"`
+		// omitempty mantém válidos os hashes de eventos gravados antes do campo existir
+		ImpersonatorID string            `json:"impersonator_id,omitempty"`
+		TargetID       string            `json:"target_id"`
+		IP             string            `json:"ip"`
+		UserAgent      string            `json:"user_agent"`
+		Outcome        Outcome           `json:"outcome"`
+		Reason         string            `json:"reason"`
+		Metadata       map[string]string `json:"metadata"`
+		Timestamp      int64             `json:"timestamp"`
+		PrevHash       string            `json:"prev_hash"`
+	}{
+		Sequence:       event.Sequence,
+		Type:           event.Type,
+		ActorID:        event.ActorID,
+		ImpersonatorID: event.ImpersonatorID,
+		TargetID:       event.TargetID,
+		IP:             event.IP,
+		UserAgent:      event.UserAgent,
+		Outcome:        event.Outcome,
+		Reason:         event.Reason,
+		Metadata:       metadata,
+		Timestamp:      event.Timestamp.UnixMilli(),
+		PrevHash:       event.PrevHash,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal audit event: %w", err)
+	}
+
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,244 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"sort"
+	"sync"
+	"testing"
+)
+
+// memoryRepository guarda os eventos como o MongoDB os devolveria: cópias
+// independentes e metadados vazios omitidos.
+type memoryRepository struct {
+	mu        sync.Mutex
+	events    []*Event
+	conflicts int
+}
+
+func (r *memoryRepository) Append(ctx context.Context, event *Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.conflicts > 0 {
+		r.conflicts--
+		return ErrSequenceConflict
+	}
+	for _, existing := range r.events {
+		if existing.Sequence == event.Sequence {
+			return ErrSequenceConflict
+		}
+	}
+	r.events = append(r.events, stored(event))
+	return nil
+}
+
+func (r *memoryRepository) Last(ctx context.Context) (*Event, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.events) == 0 {
+		return nil, nil
+	}
+	return stored(r.events[len(r.events)-1]), nil
+}
+
+func (r *memoryRepository) Find(ctx context.Context, filter *Filter) ([]*Event, int64, error) {
+	return nil, 0, errors.New("not implemented")
+}
+
+func (r *memoryRepository) FindAfter(ctx context.Context, sequence int64, limit int) ([]*Event, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sorted := append([]*Event(nil), r.events...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Sequence < sorted[j].Sequence })
+
+	var found []*Event
+	for _, event := range sorted {
+		if event.Sequence > sequence && len(found) < limit {
+			found = append(found, stored(event))
+		}
+	}
+	return found, nil
+}
+
+func stored(event *Event) *Event {
+	clone := *event
+	clone.Metadata = maps.Clone(event.Metadata)
+	if len(clone.Metadata) == 0 {
+		clone.Metadata = nil
+	}
+	return &clone
+}
+
+// recordEvents grava count eventos pelo serviço, formando uma cadeia válida
+func recordEvents(t *testing.T, svc Service, count int) {
+	t.Helper()
+
+	ctx := WithRequestInfo(context.Background(), RequestInfo{ActorID: "admin", IP: "203.0.113.7", UserAgent: "test"})
+	for i := range count {
+		event := &Event{Type: EventLogin, TargetID: "user", Metadata: map[string]string{}}
+		if i%2 == 0 {
+			event.Metadata["method"] = "password"
+		}
+		if err := svc.Record(ctx, event); err != nil {
+			t.Fatalf("Record(): %v", err)
+		}
+	}
+}
+
+func TestVerifyChain(t *testing.T) {
+	tests := []struct {
+		name    string
+		events  int
+		tamper  func(events []*Event) []*Event
+		valid   bool
+		checked int64
+		broken  int64
+		reason  string
+	}{
+		{name: "empty log", valid: true},
+		{name: "intact chain", events: 5, valid: true, checked: 5},
+		{name: "intact chain across batches", events: verifyBatchSize + 3, valid: true, checked: verifyBatchSize + 3},
+		{
+			name:   "field altered",
+			events: 5,
+			tamper: func(events []*Event) []*Event {
+				events[2].Reason = "edited"
+				return events
+			},
+			checked: 3, broken: 3, reason: "hash mismatch",
+		},
+		{
+			name:   "metadata altered",
+			events: 5,
+			tamper: func(events []*Event) []*Event {
+				events[0].Metadata["method"] = "webauthn"
+				return events
+			},
+			checked: 1, broken: 1, reason: "hash mismatch",
+		},
+		{
+			name:   "impersonator added",
+			events: 5,
+			tamper: func(events []*Event) []*Event {
+				events[1].ImpersonatorID = "admin-2"
+				return events
+			},
+			checked: 2, broken: 2, reason: "hash mismatch",
+		},
+		{
+			name:   "event removed",
+			events: 5,
+			tamper: func(events []*Event) []*Event {
+				return append(events[:2], events[3:]...)
+			},
+			checked: 3, broken: 4, reason: "missing sequence",
+		},
+		{
+			name:   "first event removed",
+			events: 3,
+			tamper: func(events []*Event) []*Event {
+				return events[1:]
+			},
+			checked: 1, broken: 2, reason: "missing sequence",
+		},
+		{
+			name:   "event rewritten with a recomputed hash",
+			events: 5,
+			tamper: func(events []*Event) []*Event {
+				events[2].Reason = "edited"
+				events[2].Hash, _ = computeHash(events[2])
+				return events
+			},
+			checked: 4, broken: 4, reason: "previous hash mismatch",
+		},
+		{
+			name:   "event replaced by a chain of its own",
+			events: 3,
+			tamper: func(events []*Event) []*Event {
+				forged := &Event{Sequence: 2, Type: EventLogin, Outcome: OutcomeSuccess, Timestamp: events[1].Timestamp}
+				forged.Hash, _ = computeHash(forged)
+				events[1] = forged
+				return events
+			},
+			checked: 2, broken: 2, reason: "previous hash mismatch",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &memoryRepository{}
+			svc := NewService(repo)
+			recordEvents(t, svc, tt.events)
+			if tt.tamper != nil {
+				repo.events = tt.tamper(repo.events)
+			}
+
+			result, err := svc.VerifyChain(context.Background())
+			if err != nil {
+				t.Fatalf("VerifyChain(): %v", err)
+			}
+			want := &VerifyResponse{Valid: tt.valid, CheckedEvents: tt.checked, BrokenSequence: tt.broken, Reason: tt.reason}
+			if *result != *want {
+				t.Fatalf("VerifyChain() = %+v, want %+v", result, want)
+			}
+		})
+	}
+}
+
+func TestRecordLinksEvents(t *testing.T) {
+	repo := &memoryRepository{}
+	svc := NewService(repo)
+	recordEvents(t, svc, 3)
+
+	var prevHash string
+	for i, event := range repo.events {
+		if event.Sequence != int64(i+1) || event.PrevHash != prevHash || event.Hash == "" {
+			t.Fatalf("event %d = sequence %d, prev_hash %q, hash %q", i, event.Sequence, event.PrevHash, event.Hash)
+		}
+		if event.ActorID != "admin" || event.IP != "203.0.113.7" || event.Outcome != OutcomeSuccess {
+			t.Fatalf("event %d did not take the request info: %+v", i, event)
+		}
+		prevHash = event.Hash
+	}
+}
+
+func TestRecordRetriesSequenceConflicts(t *testing.T) {
+	tests := []struct {
+		name      string
+		conflicts int
+		want      error
+	}{
+		{name: "no conflict"},
+		{name: "retried conflicts", conflicts: maxAppendRetries - 1},
+		{name: "persistent conflict", conflicts: maxAppendRetries, want: ErrAppendFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &memoryRepository{}
+			svc := NewService(repo)
+			recordEvents(t, svc, 1)
+			repo.conflicts = tt.conflicts
+
+			err := svc.Record(context.Background(), &Event{Type: EventLogin})
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Record() error = %v, want %v", err, tt.want)
+			}
+			if tt.want != nil {
+				return
+			}
+
+			result, err := svc.VerifyChain(context.Background())
+			if err != nil {
+				t.Fatalf("VerifyChain(): %v", err)
+			}
+			if !result.Valid || result.CheckedEvents != 2 {
+				t.Fatalf("VerifyChain() = %+v, want a valid chain of 2 events", result)
+			}
+		})
+	}
+}
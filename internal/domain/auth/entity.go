@@ -8,14 +8,26 @@ type AuthContext = types.AuthContext
 
 // Constantes re-exportadas
 const (
-	PermissionUserRead   = types.PermissionUserRead
-	PermissionUserWrite  = types.PermissionUserWrite
-	PermissionUserDelete = types.PermissionUserDelete
-	PermissionAdminRead  = types.PermissionAdminRead
-	PermissionAdminWrite = types.PermissionAdminWrite
+	PermissionUserRead      = types.PermissionUserRead
+	PermissionUserWrite     = types.PermissionUserWrite
+	PermissionUserDelete    = types.PermissionUserDelete
+	PermissionAdminRead     = types.PermissionAdminRead
+	PermissionAdminWrite    = types.PermissionAdminWrite
+	PermissionRelationRead  = types.PermissionRelationRead
+	PermissionRelationWrite = types.PermissionRelationWrite
+	PermissionAuthzCheck    = types.PermissionAuthzCheck
+)
+
+const (
+	AMRPassword    = types.AMRPassword
+	AMROTP         = types.AMROTP
+	AMRSMS         = types.AMRSMS
+	AMRHardwareKey = types.AMRHardwareKey
+	AMRMultiFactor = types.AMRMultiFactor
+	AMRFederated   = types.AMRFederated
 )
 
 // Funções de utilidade
 func HasPermission(role types.Role, permission types.Permission) bool {
 	return types.HasPermission(role, permission)
-}
\ No newline at end of file
+}
@@ -0,0 +1,31 @@
+package auth
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// RunSessionJanitor reconcilia os índices de sessões logo ao iniciar e depois
+// a cada interval, até ctx ser cancelado. Sessões expiram sozinhas no Redis,
+// mas suas entradas nos índices só saem quando o usuário volta a logar; sem a
+// reconciliação, índices de usuários inativos acumulariam entradas órfãs.
+func RunSessionJanitor(ctx context.Context, repo TokenRepository, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		pruned, reindexed, err := repo.ReconcileSessions(ctx)
+		if err != nil {
+			log.Printf("Warning: failed to reconcile sessions: %v", err)
+		} else if pruned > 0 || reindexed > 0 {
+			log.Printf("Session janitor: pruned %d stale index entries, reindexed %d sessions", pruned, reindexed)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -13,12 +13,20 @@ type JWTManager struct {
 }
 
 type Claims struct {
-	UserID string     `json:"user_id"`
-	Email  string     `json:"email"`
-	Role   types.Role `json:"role"`
+	UserID string       `json:"user_id"`
+	Email  string       `json:"email"`
+	Role   types.Role   `json:"role"`
+	Actor  *ActorClaims `json:"act,omitempty"`
+	// TenantID é a organização selecionada no login
+	TenantID string `json:"tid,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// ActorClaims identifica quem está agindo em nome do usuário (RFC 8693).
+type ActorClaims struct {
+	Subject string `json:"sub"`
+}
+
 func NewJWTManager(secretKey string, tokenDuration time.Duration) *JWTManager {
 	return &JWTManager{
 		secretKey:     secretKey,
@@ -27,16 +35,41 @@ func NewJWTManager(secretKey string, tokenDuration time.Duration) *JWTManager {
 }
 
 func (m *JWTManager) GetTokenDuration() time.Duration {
-    return m.tokenDuration
+	return m.tokenDuration
 }
 
 func (m *JWTManager) GenerateToken(userID, email string, role types.Role) (string, error) {
+	return m.GenerateTenantToken(userID, email, role, "")
+}
+
+// GenerateTenantToken gera um token restrito à organização informada.
+func (m *JWTManager) GenerateTenantToken(userID, email string, role types.Role, tenantID string) (string, error) {
+	claims := &Claims{
+		UserID:   userID,
+		Email:    email,
+		Role:     role,
+		TenantID: tenantID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(m.tokenDuration)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(m.secretKey))
+}
+
+// GenerateImpersonationToken gera um token de curta duração para o usuário
+// alvo, carregando o admin responsável na claim "act".
+func (m *JWTManager) GenerateImpersonationToken(userID, email string, role types.Role, actorID string, duration time.Duration) (string, error) {
 	claims := &Claims{
 		UserID: userID,
 		Email:  email,
 		Role:   role,
+		Actor:  &ActorClaims{Subject: actorID},
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(m.tokenDuration)),
+			Subject:   userID,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(duration)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
@@ -3,6 +3,8 @@ package auth
 import (
 	"context"
 	"errors"
+	"log"
+	"slices"
 	"time"
 
 	"github.com/juanjerrah/go_auth_api/internal/domain/user"
@@ -13,26 +15,59 @@ var (
 	ErrPermissionDenied = errors.New("permission denied")
 	ErrInvalidRole      = errors.New("invalid role")
 	ErrInvalidToken     = errors.New("invalid token")
+	ErrSessionExpired   = errors.New("session expired")
+	ErrTooManySessions  = errors.New("too many active sessions")
+	ErrSessionNotFound  = errors.New("session not found")
 )
 
 type AuthService interface {
 	HasPermission(role user.Role, permission types.Permission) bool
-	GetUserPermissions(role user.Role) []types.Permission
+	GetUserPermissions(ctx context.Context, userID string, role user.Role) ([]types.Permission, error)
 	ValidateRole(role user.Role) error
 	StoreToken(ctx context.Context, token string, authCtx *types.AuthContext, expiration time.Duration) error
+	// ReplaceToken troca a sessão oldToken pela nova (refresh, troca de
+	// organização) sem que o usuário fique sem sessão se a nova for recusada
+	ReplaceToken(ctx context.Context, oldToken, token string, authCtx *types.AuthContext, expiration time.Duration) error
+	UpdateToken(ctx context.Context, token string, authCtx *types.AuthContext) error
+	// TouchToken registra o uso da sessão, estendendo o prazo de inatividade
+	// até o fim absoluto
+	TouchToken(ctx context.Context, token string, authCtx *types.AuthContext) error
 	GetToken(ctx context.Context, token string) (*types.AuthContext, error)
 	DeleteToken(ctx context.Context, token string) error
+	// SessionID identifica a sessão do token sem expô-lo, para referências
+	// guardadas fora da sessão
+	SessionID(token string) string
+	DeleteSession(ctx context.Context, sessionID string) error
 	InvalidateUserTokens(ctx context.Context, userID string) error
 	ValidateToken(ctx context.Context, token string) (*types.AuthContext, error)
 }
 
+// PermissionCache guarda as permissões efetivas calculadas por usuário e role
+type PermissionCache interface {
+	Get(ctx context.Context, userID string, role user.Role) ([]types.Permission, bool, error)
+	Set(ctx context.Context, userID string, role user.Role, permissions []types.Permission) error
+	Invalidate(ctx context.Context, userIDs ...string) error
+}
+
+// GroupPermissionResolver resolve as permissões concedidas ao usuário pelos
+// grupos dos quais participa, incluindo as herdadas.
+type GroupPermissionResolver interface {
+	EffectivePermissions(ctx context.Context, userID string) ([]types.Permission, error)
+}
+
 type authService struct {
-	tokenRepo TokenRepository
+	tokenRepo       TokenRepository
+	permissionCache PermissionCache
+	groups          GroupPermissionResolver
+	sessions        SessionPolicies
 }
 
-func NewAuthService(tokenRepo TokenRepository) AuthService {
+func NewAuthService(tokenRepo TokenRepository, permissionCache PermissionCache, groups GroupPermissionResolver, sessions SessionPolicies) AuthService {
 	return &authService{
-		tokenRepo: tokenRepo,
+		tokenRepo:       tokenRepo,
+		permissionCache: permissionCache,
+		groups:          groups,
+		sessions:        sessions,
 	}
 }
 
@@ -40,8 +75,34 @@ func (s *authService) HasPermission(role user.Role, permission types.Permission)
 	return types.HasPermission(role, permission)
 }
 
-func (s *authService) GetUserPermissions(role user.Role) []types.Permission {
-	return types.RolePermissionMap[role]
+// GetUserPermissions retorna a união das permissões da role com as concedidas
+// pelos grupos do usuário.
+func (s *authService) GetUserPermissions(ctx context.Context, userID string, role user.Role) ([]types.Permission, error) {
+	if permissions, ok, err := s.permissionCache.Get(ctx, userID, role); err == nil && ok {
+		return permissions, nil
+	} else if err != nil {
+		log.Printf("Warning: failed to read permission cache for user %s: %v", userID, err)
+	}
+
+	permissions := slices.Clone(types.RolePermissionMap[role])
+	granted, err := s.groups.EffectivePermissions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, permission := range granted {
+		if !slices.Contains(permissions, permission) {
+			permissions = append(permissions, permission)
+		}
+	}
+	if permissions == nil {
+		permissions = []types.Permission{}
+	}
+
+	if err := s.permissionCache.Set(ctx, userID, role, permissions); err != nil {
+		log.Printf("Warning: failed to cache permissions for user %s: %v", userID, err)
+	}
+
+	return permissions, nil
 }
 
 func (s *authService) ValidateRole(role user.Role) error {
@@ -51,8 +112,78 @@ func (s *authService) ValidateRole(role user.Role) error {
 	return nil
 }
 
+// StoreToken registra a sessão com os limites da role. O fim absoluto é o
+// menor entre expiration (a validade do token) e o tempo máximo da política;
+// sessões renovadas (refresh, troca de organização) mantêm o fim da sessão
+// original, para que o tempo máximo não seja estendido.
+// Sessões de personificação não contam para o limite de sessões do usuário
+// nem entram no seu índice; são encerradas com as sessões do admin.
 func (s *authService) StoreToken(ctx context.Context, token string, authCtx *types.AuthContext, expiration time.Duration) error {
-	return s.tokenRepo.StoreToken(ctx, token, authCtx, expiration)
+	ttl, limit, err := s.prepareSession(authCtx, expiration)
+	if err != nil {
+		return err
+	}
+	return s.tokenRepo.StoreToken(ctx, token, authCtx, ttl, limit)
+}
+
+// ReplaceToken implements AuthService. Os limites são os de StoreToken.
+func (s *authService) ReplaceToken(ctx context.Context, oldToken, token string, authCtx *types.AuthContext, expiration time.Duration) error {
+	ttl, limit, err := s.prepareSession(authCtx, expiration)
+	if err != nil {
+		return err
+	}
+	return s.tokenRepo.ReplaceToken(ctx, oldToken, token, authCtx, ttl, limit)
+}
+
+// prepareSession aplica a política da role à sessão e retorna sua expiração
+// e o limite de sessões
+func (s *authService) prepareSession(authCtx *types.AuthContext, expiration time.Duration) (time.Duration, SessionLimit, error) {
+	now := time.Now().UTC()
+	policy := s.sessions.forRole(authCtx.Role)
+	if authCtx.ExpiresAt.IsZero() {
+		lifetime := expiration
+		if policy.MaxLifetime > 0 && policy.MaxLifetime < lifetime {
+			lifetime = policy.MaxLifetime
+		}
+		authCtx.ExpiresAt = now.Add(lifetime)
+	}
+	authCtx.IdleTimeout = policy.IdleTimeout
+	authCtx.LastActiveAt = now
+
+	ttl := sessionTTL(authCtx, now)
+	if ttl <= 0 {
+		return 0, SessionLimit{}, ErrSessionExpired
+	}
+
+	limit := SessionLimit{Max: policy.MaxSessions, EvictOldest: s.sessions.EvictOldest}
+	if authCtx.IsImpersonated() {
+		limit = SessionLimit{}
+	}
+	return ttl, limit, nil
+}
+
+func (s *authService) UpdateToken(ctx context.Context, token string, authCtx *types.AuthContext) error {
+	return s.tokenRepo.UpdateToken(ctx, token, authCtx)
+}
+
+// TouchToken implements AuthService. Para poupar escritas, a sessão só é
+// regravada quando a última atividade registrada tem mais de TouchInterval;
+// o prazo de inatividade pode assim ser até TouchInterval menor.
+func (s *authService) TouchToken(ctx context.Context, token string, authCtx *types.AuthContext) error {
+	if authCtx.IdleTimeout <= 0 {
+		return nil
+	}
+	now := time.Now().UTC()
+	if now.Sub(authCtx.LastActiveAt) < s.sessions.TouchInterval {
+		return nil
+	}
+
+	authCtx.LastActiveAt = now
+	ttl := sessionTTL(authCtx, now)
+	if ttl <= 0 {
+		return ErrSessionExpired
+	}
+	return s.tokenRepo.TouchToken(ctx, token, now, ttl)
 }
 
 func (s *authService) GetToken(ctx context.Context, token string) (*types.AuthContext, error) {
@@ -63,6 +194,14 @@ func (s *authService) DeleteToken(ctx context.Context, token string) error {
 	return s.tokenRepo.DeleteToken(ctx, token)
 }
 
+func (s *authService) SessionID(token string) string {
+	return s.tokenRepo.SessionID(token)
+}
+
+func (s *authService) DeleteSession(ctx context.Context, sessionID string) error {
+	return s.tokenRepo.DeleteSession(ctx, sessionID)
+}
+
 func (s *authService) InvalidateUserTokens(ctx context.Context, userID string) error {
 	return s.tokenRepo.InvalidateUserTokens(ctx, userID)
 }
@@ -72,5 +211,9 @@ func (s *authService) ValidateToken(ctx context.Context, token string) (*types.A
 	if err != nil {
 		return nil, ErrInvalidToken
 	}
+	// O Redis expira a sessão no prazo, mas o fim absoluto não depende disso
+	if !authCtx.ExpiresAt.IsZero() && time.Now().After(authCtx.ExpiresAt) {
+		return nil, ErrInvalidToken
+	}
 	return authCtx, nil
 }
@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/juanjerrah/go_auth_api/internal/domain/user"
+	"github.com/juanjerrah/go_auth_api/pkg/types"
+)
+
+// SessionPolicy limita a duração das sessões. IdleTimeout encerra a sessão
+// após o período sem uso e MaxLifetime exige novo login passado o período
+// desde o login, mesmo com uso contínuo. MaxSessions limita as sessões
+// simultâneas do usuário. Zero desativa o respectivo limite; a validade do
+// token continua limitando a sessão.
+type SessionPolicy struct {
+	IdleTimeout time.Duration
+	MaxLifetime time.Duration
+	MaxSessions int
+}
+
+// SessionPolicies define a política padrão e as específicas de cada role.
+// TouchInterval é o intervalo mínimo entre as escritas que registram a
+// atividade da sessão. Com EvictOldest, um login além de MaxSessions encerra
+// a sessão mais antiga; sem ele, o login é recusado.
+type SessionPolicies struct {
+	Default       SessionPolicy
+	Roles         map[user.Role]SessionPolicy
+	TouchInterval time.Duration
+	EvictOldest   bool
+}
+
+// SessionLimit é o limite de sessões aplicado pelo repositório ao registrar
+// uma sessão. Max zero não limita.
+type SessionLimit struct {
+	Max         int
+	EvictOldest bool
+}
+
+func (p SessionPolicies) forRole(role user.Role) SessionPolicy {
+	if policy, ok := p.Roles[role]; ok {
+		return policy
+	}
+	return p.Default
+}
+
+// sessionTTL é o tempo de vida restante da sessão no Redis: o prazo de
+// inatividade, sem ultrapassar o fim absoluto
+func sessionTTL(authCtx *types.AuthContext, now time.Time) time.Duration {
+	ttl := authCtx.ExpiresAt.Sub(now)
+	if authCtx.IdleTimeout > 0 && authCtx.IdleTimeout < ttl {
+		ttl = authCtx.IdleTimeout
+	}
+	return ttl
+}
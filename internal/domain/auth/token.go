@@ -9,9 +9,32 @@ import (
 
 // Interface do repositório de tokens (agora definida aqui)
 type TokenRepository interface {
-	StoreToken(ctx context.Context, token string, authCtx *types.AuthContext, expiration time.Duration) error
+	// StoreToken registra a sessão e a inclui no índice de sessões do usuário.
+	// Se o usuário já tiver limit.Max sessões, retorna ErrTooManySessions ou,
+	// com limit.EvictOldest, encerra as mais antigas.
+	StoreToken(ctx context.Context, token string, authCtx *types.AuthContext, expiration time.Duration, limit SessionLimit) error
+	// ReplaceToken encerra a sessão oldToken e registra a nova em uma única
+	// operação; a sessão encerrada não conta para o limite. Se oldToken não
+	// existir mais, retorna ErrSessionNotFound sem registrar a nova.
+	ReplaceToken(ctx context.Context, oldToken, token string, authCtx *types.AuthContext, expiration time.Duration, limit SessionLimit) error
+	// UpdateToken substitui os dados de uma sessão existente sem alterar sua expiração
+	UpdateToken(ctx context.Context, token string, authCtx *types.AuthContext) error
+	// TouchToken registra a última atividade de uma sessão existente e sua
+	// nova expiração, sem alterar os demais dados
+	TouchToken(ctx context.Context, token string, lastActiveAt time.Time, expiration time.Duration) error
 	GetToken(ctx context.Context, token string) (*types.AuthContext, error)
 	DeleteToken(ctx context.Context, token string) error
+	// SessionID deriva do token o ID que identifica a sessão no
+	// armazenamento, sem expor o token
+	SessionID(token string) string
+	// DeleteSession encerra a sessão pelo ID retornado por SessionID
+	DeleteSession(ctx context.Context, sessionID string) error
 	InvalidateUserTokens(ctx context.Context, userID string) error
 	TokenExists(ctx context.Context, token string) (bool, error)
+	// ReconcileSessions remove dos índices as sessões que não existem mais e
+	// inclui nos índices as sessões que estão fora deles
+	ReconcileSessions(ctx context.Context) (pruned, reindexed int, err error)
+	// MigrateSessionKeys passa as sessões gravadas com o token na chave para
+	// a chave pelo ID da sessão
+	MigrateSessionKeys(ctx context.Context) (int, error)
 }
\ No newline at end of file
@@ -0,0 +1,39 @@
+package authz
+
+// Origem da decisão de autorização
+const (
+	SourcePermission = "permission"
+	SourceRelation   = "relation"
+	SourcePolicy     = "policy"
+	SourcePrincipal  = "principal"
+)
+
+type Resource struct {
+	Type       string         `json:"type"`
+	ID         string         `json:"id"`
+	Attributes map[string]any `json:"attributes"`
+}
+
+// CheckRequest identifica o principal por um token de acesso ou pelo ID do
+// usuário (Subject); apenas um dos dois deve ser informado.
+type CheckRequest struct {
+	Token    string   `json:"token"`
+	Subject  string   `json:"subject"`
+	Action   string   `json:"action" binding:"required"`
+	Resource Resource `json:"resource"`
+}
+
+type CheckResponse struct {
+	Allowed bool   `json:"allowed"`
+	Reason  string `json:"reason"`
+	Source  string `json:"source"`
+	Cached  bool   `json:"cached"`
+}
+
+type BatchCheckRequest struct {
+	Checks []CheckRequest `json:"checks" binding:"required,min=1,max=100,dive"`
+}
+
+type BatchCheckResponse struct {
+	Results []*CheckResponse `json:"results"`
+}
@@ -0,0 +1,253 @@
+package authz
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/juanjerrah/go_auth_api/internal/domain/auth"
+	"github.com/juanjerrah/go_auth_api/internal/domain/policy"
+	"github.com/juanjerrah/go_auth_api/internal/domain/relation"
+	"github.com/juanjerrah/go_auth_api/internal/domain/user"
+	"github.com/juanjerrah/go_auth_api/pkg/types"
+)
+
+// Limite de entradas em cada cache antes de descartá-lo por inteiro
+const cacheSize = 10000
+
+var ErrInvalidPrincipal = errors.New("exactly one of token or subject is required")
+
+type Service interface {
+	Check(ctx context.Context, req *CheckRequest) (*CheckResponse, error)
+	BatchCheck(ctx context.Context, req *BatchCheckRequest) (*BatchCheckResponse, error)
+}
+
+type cacheEntry[T any] struct {
+	value     T
+	expiresAt time.Time
+}
+
+// ttlCache é um cache em memória com expiração por entrada
+type ttlCache[T any] struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry[T]
+}
+
+func (c *ttlCache[T]) get(key string) (T, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		var zero T
+		return zero, false
+	}
+	return entry.value, true
+}
+
+func (c *ttlCache[T]) set(key string, value T, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.entries == nil || len(c.entries) >= cacheSize {
+		c.entries = make(map[string]cacheEntry[T])
+	}
+	c.entries[key] = cacheEntry[T]{value: value, expiresAt: time.Now().Add(ttl)}
+}
+
+type service struct {
+	jwtManager      *auth.JWTManager
+	authService     auth.AuthService
+	userService     user.Service
+	policyService   policy.Service
+	relationService relation.Service
+	cacheTTL        time.Duration
+
+	principals ttlCache[*types.AuthContext]
+	decisions  ttlCache[CheckResponse]
+}
+
+// NewService cria o serviço de decisões. Decisões e principais resolvidos
+// ficam em cache por cacheTTL, o que limita o atraso com que revogações e
+// alterações de permissão passam a valer.
+func NewService(jwtManager *auth.JWTManager, authService auth.AuthService, userService user.Service, policyService policy.Service, relationService relation.Service, cacheTTL time.Duration) Service {
+	return &service{
+		jwtManager:      jwtManager,
+		authService:     authService,
+		userService:     userService,
+		policyService:   policyService,
+		relationService: relationService,
+		cacheTTL:        cacheTTL,
+	}
+}
+
+// Check implements Service. Ações que correspondem a permissões são avaliadas
+// pelas permissões efetivas do principal; as demais pelas tuplas de relação do
+// namespace do recurso e, na falta delas, pelo motor de políticas.
+func (s *service) Check(ctx context.Context, req *CheckRequest) (*CheckResponse, error) {
+	if (req.Token == "") == (req.Subject == "") {
+		return nil, ErrInvalidPrincipal
+	}
+
+	principal, reason := s.resolvePrincipal(ctx, req)
+	if principal == nil {
+		return &CheckResponse{Allowed: false, Reason: reason, Source: SourcePrincipal}, nil
+	}
+
+	key, err := decisionKey(principal, req)
+	if err != nil {
+		return nil, err
+	}
+	if decision, ok := s.decisions.get(key); ok {
+		decision.Cached = true
+		return &decision, nil
+	}
+
+	decision, err := s.decide(ctx, principal, req)
+	if err != nil {
+		return nil, err
+	}
+	s.decisions.set(key, *decision, s.cacheTTL)
+
+	return decision, nil
+}
+
+// BatchCheck implements Service.
+func (s *service) BatchCheck(ctx context.Context, req *BatchCheckRequest) (*BatchCheckResponse, error) {
+	results := make([]*CheckResponse, 0, len(req.Checks))
+	for i := range req.Checks {
+		result, err := s.Check(ctx, &req.Checks[i])
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return &BatchCheckResponse{Results: results}, nil
+}
+
+func (s *service) decide(ctx context.Context, principal *types.AuthContext, req *CheckRequest) (*CheckResponse, error) {
+	permission := types.Permission(req.Action)
+	if types.IsValidPermission(permission) {
+		if principal.HasPermission(permission) {
+			return &CheckResponse{Allowed: true, Reason: "granted by permission " + req.Action, Source: SourcePermission}, nil
+		}
+		return &CheckResponse{Allowed: false, Reason: "missing permission " + req.Action, Source: SourcePermission}, nil
+	}
+
+	if req.Resource.Type != "" && req.Resource.ID != "" {
+		result, err := s.relationService.Check(ctx, &relation.CheckRequest{
+			Namespace: req.Resource.Type,
+			ObjectID:  req.Resource.ID,
+			Relation:  req.Action,
+			Subject:   relation.UserNamespace + ":" + principal.UserID,
+		})
+		switch err {
+		case nil:
+			if result.Allowed {
+				return &CheckResponse{Allowed: true, Reason: "granted by relation " + req.Action, Source: SourceRelation}, nil
+			}
+			return &CheckResponse{Allowed: false, Reason: "no " + req.Action + " relation with the resource", Source: SourceRelation}, nil
+		case relation.ErrUnknownNamespace, relation.ErrUnknownRelation:
+			// O recurso não é modelado por relações; seguir para as políticas
+		default:
+			return nil, err
+		}
+	}
+
+	resource := policy.Attributes{}
+	for k, v := range req.Resource.Attributes {
+		resource[k] = v
+	}
+	resource["type"] = req.Resource.Type
+	if req.Resource.ID != "" {
+		resource["id"] = req.Resource.ID
+	}
+
+	decision, err := s.policyService.Evaluate(ctx, &policy.Request{
+		Subject:  policy.SubjectFromAuthContext(principal),
+		Action:   req.Action,
+		Resource: resource,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &CheckResponse{Allowed: decision.Allowed, Reason: decision.Reason, Source: SourcePolicy}, nil
+}
+
+// resolvePrincipal retorna o contexto do principal ou o motivo pelo qual ele
+// não pôde ser identificado.
+func (s *service) resolvePrincipal(ctx context.Context, req *CheckRequest) (*types.AuthContext, string) {
+	key := "subject:" + req.Subject
+	if req.Token != "" {
+		hash := sha256.Sum256([]byte(req.Token))
+		key = "token:" + hex.EncodeToString(hash[:])
+	}
+	if principal, ok := s.principals.get(key); ok {
+		return principal, ""
+	}
+
+	var principal *types.AuthContext
+	if req.Token != "" {
+		if _, err := s.jwtManager.VerifyToken(req.Token); err != nil {
+			return nil, "invalid token signature"
+		}
+		authCtx, err := s.authService.ValidateToken(ctx, req.Token)
+		if err != nil {
+			return nil, "invalid or expired token"
+		}
+		principal = authCtx
+	} else {
+		usr, err := s.userService.GetUserByID(ctx, req.Subject)
+		if err != nil {
+			return nil, "subject not found"
+		}
+		if usr.Disabled {
+			return nil, "subject is disabled"
+		}
+		principal = &types.AuthContext{
+			UserID: usr.ID,
+			Email:  usr.Email,
+			Role:   types.Role(usr.Role),
+		}
+	}
+
+	permissions, err := s.authService.GetUserPermissions(ctx, principal.UserID, principal.Role)
+	if err != nil {
+		return nil, "failed to resolve permissions"
+	}
+	resolved := *principal
+	resolved.Permissions = permissions
+
+	s.principals.set(key, &resolved, s.cacheTTL)
+	return &resolved, ""
+}
+
+// decisionKey identifica a decisão pelos atributos do principal que decide
+// lê, e não só pelo usuário: o mesmo usuário pode ter roles e permissões
+// diferentes em cada organização.
+func decisionKey(principal *types.AuthContext, req *CheckRequest) (string, error) {
+	permissions := slices.Clone(principal.Permissions)
+	slices.Sort(permissions)
+
+	data, err := json.Marshal(struct {
+		UserID       string             `json:"u"`
+		Email        string             `json:"e"`
+		Role         types.Role         `json:"ro"`
+		TenantID     string             `json:"t"`
+		TenantRole   types.Role         `json:"tr"`
+		Impersonated bool               `json:"i"`
+		Permissions  []types.Permission `json:"p"`
+		Action       string             `json:"a"`
+		Resource     Resource           `json:"r"`
+	}{principal.UserID, principal.Email, principal.Role, principal.TenantID, principal.TenantRole, principal.IsImpersonated(), permissions, req.Action, req.Resource})
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:]), nil
+}
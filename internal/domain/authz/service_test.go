@@ -0,0 +1,106 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/juanjerrah/go_auth_api/internal/domain/auth"
+	"github.com/juanjerrah/go_auth_api/internal/domain/policy"
+	"github.com/juanjerrah/go_auth_api/internal/domain/user"
+	"github.com/juanjerrah/go_auth_api/pkg/types"
+)
+
+// sessionStore resolve tokens para as sessões gravadas no teste
+type sessionStore struct {
+	auth.AuthService
+	sessions map[string]*types.AuthContext
+}
+
+func (s *sessionStore) ValidateToken(ctx context.Context, token string) (*types.AuthContext, error) {
+	if authCtx, ok := s.sessions[token]; ok {
+		return authCtx, nil
+	}
+	return nil, errors.New("token not found")
+}
+
+func (s *sessionStore) GetUserPermissions(ctx context.Context, userID string, role user.Role) ([]types.Permission, error) {
+	return types.RolePermissionMap[role], nil
+}
+
+type userDirectory struct {
+	user.Service
+	users map[string]*user.UserResponse
+}
+
+func (d *userDirectory) GetUserByID(ctx context.Context, id string) (*user.UserResponse, error) {
+	if usr, ok := d.users[id]; ok {
+		return usr, nil
+	}
+	return nil, user.ErrUserNotFound
+}
+
+// tenantPolicies permite a ação apenas a administradores da organização
+// org-a, e conta as avaliações para mostrar o uso do cache
+type tenantPolicies struct {
+	policy.Service
+	evaluations int
+}
+
+func (p *tenantPolicies) Evaluate(ctx context.Context, req *policy.Request) (*policy.Decision, error) {
+	p.evaluations++
+	if req.Subject["tenant_id"] == "org-a" && req.Subject["tenant_role"] == string(user.RoleAdmin) {
+		return &policy.Decision{Allowed: true, Reason: "tenant admin"}, nil
+	}
+	return &policy.Decision{Allowed: false, Reason: "no matching policy"}, nil
+}
+
+func TestCheckCachesDecisionsPerTenant(t *testing.T) {
+	jwtManager := auth.NewJWTManager("test-secret", time.Hour)
+	tokenA, err := jwtManager.GenerateTenantToken("user-1", "ana@example.com", user.RoleUser, "org-a")
+	if err != nil {
+		t.Fatalf("GenerateTenantToken: %v", err)
+	}
+	tokenB, err := jwtManager.GenerateTenantToken("user-1", "ana@example.com", user.RoleUser, "org-b")
+	if err != nil {
+		t.Fatalf("GenerateTenantToken: %v", err)
+	}
+
+	sessions := &sessionStore{sessions: map[string]*types.AuthContext{
+		tokenA: {UserID: "user-1", Email: "ana@example.com", Role: user.RoleUser, TenantID: "org-a", TenantRole: user.RoleAdmin},
+		tokenB: {UserID: "user-1", Email: "ana@example.com", Role: user.RoleUser, TenantID: "org-b", TenantRole: user.RoleUser},
+	}}
+	users := &userDirectory{users: map[string]*user.UserResponse{
+		"user-1": {ID: "user-1", Email: "ana@example.com", Role: string(user.RoleUser)},
+	}}
+	policies := &tenantPolicies{}
+	svc := NewService(jwtManager, sessions, users, policies, nil, time.Minute)
+
+	resource := Resource{Type: "invoice", Attributes: map[string]any{"amount": 100}}
+	tests := []struct {
+		name    string
+		req     *CheckRequest
+		allowed bool
+		cached  bool
+	}{
+		{name: "admin of org-a", req: &CheckRequest{Token: tokenA, Action: "invoice:approve", Resource: resource}, allowed: true},
+		{name: "member of org-b", req: &CheckRequest{Token: tokenB, Action: "invoice:approve", Resource: resource}},
+		{name: "subject without tenant", req: &CheckRequest{Subject: "user-1", Action: "invoice:approve", Resource: resource}},
+		{name: "admin of org-a again", req: &CheckRequest{Token: tokenA, Action: "invoice:approve", Resource: resource}, allowed: true, cached: true},
+		{name: "member of org-b again", req: &CheckRequest{Token: tokenB, Action: "invoice:approve", Resource: resource}, cached: true},
+	}
+
+	for _, tt := range tests {
+		result, err := svc.Check(context.Background(), tt.req)
+		if err != nil {
+			t.Fatalf("%s: Check(): %v", tt.name, err)
+		}
+		if result.Allowed != tt.allowed || result.Cached != tt.cached || result.Source != SourcePolicy {
+			t.Fatalf("%s: Check() = %+v, want allowed %v, cached %v", tt.name, result, tt.allowed, tt.cached)
+		}
+	}
+	if policies.evaluations != 3 {
+		t.Fatalf("policies evaluated %d times, want 3", policies.evaluations)
+	}
+}
@@ -0,0 +1,16 @@
+package device
+
+import "time"
+
+// TrustRequest é enviado junto com o segundo fator para lembrar o navegador
+type TrustRequest struct {
+	RememberDevice bool   `json:"remember_device"`
+	DeviceName     string `json:"device_name" binding:"max=100"`
+}
+
+// Trusted é um dispositivo recém-confiado e o valor do cookie que o identifica
+type Trusted struct {
+	DeviceID  string
+	Cookie    string
+	ExpiresAt time.Time
+}
@@ -0,0 +1,277 @@
+package device
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/juanjerrah/go_auth_api/internal/domain/audit"
+	"github.com/juanjerrah/go_auth_api/internal/domain/user"
+	"github.com/juanjerrah/go_auth_api/pkg/common"
+)
+
+var (
+	ErrUntrustedDevice = errors.New("device is not trusted")
+	ErrDeviceNotFound  = errors.New("trusted device not found")
+)
+
+// Nome usado quando o usuário não informa um e o navegador não envia User-Agent
+const defaultDeviceName = "Unknown device"
+
+type Service interface {
+	// Trust registra o navegador da requisição como dispositivo confiável do
+	// usuário e retorna o cookie que o identifica
+	Trust(ctx context.Context, usr *user.User, name string) (*Trusted, error)
+	// Verify confere o cookie do navegador da requisição e retorna o
+	// dispositivo, ou ErrUntrustedDevice
+	Verify(ctx context.Context, usr *user.User, cookie string) (*user.TrustedDevice, error)
+	List(ctx context.Context, userID string) ([]user.TrustedDevice, error)
+	Revoke(ctx context.Context, userID, deviceID string) error
+	// RevokeAll remove todos os dispositivos do usuário e retorna quantos eram
+	RevokeAll(ctx context.Context, userID string) (int, error)
+}
+
+type service struct {
+	userRepo user.Repository
+	tokens   common.TokenGenerator
+	audit    audit.Service
+	secret   []byte
+	ttl      time.Duration
+	limit    int
+}
+
+// NewService cria o serviço de dispositivos confiáveis. secret assina os
+// cookies; cada dispositivo dispensa o segundo fator por ttl e cada usuário
+// mantém no máximo limit dispositivos, descartando os usados há mais tempo.
+func NewService(userRepo user.Repository, tokens common.TokenGenerator, auditService audit.Service, secret string, ttl time.Duration, limit int) Service {
+	return &service{
+		userRepo: userRepo,
+		tokens:   tokens,
+		audit:    auditService,
+		secret:   []byte(secret),
+		ttl:      ttl,
+		limit:    limit,
+	}
+}
+
+// Trust implements Service.
+func (s *service) Trust(ctx context.Context, usr *user.User, name string) (*Trusted, error) {
+	id, err := s.tokens.Generate()
+	if err != nil {
+		return nil, err
+	}
+	secret, err := s.tokens.Generate()
+	if err != nil {
+		return nil, err
+	}
+
+	info := audit.RequestInfoFromContext(ctx)
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = deviceName(info.UserAgent)
+	}
+
+	now := time.Now().UTC()
+	trusted := user.TrustedDevice{
+		ID:              id,
+		Name:            name,
+		TokenHash:       s.tokens.Hash(secret),
+		FingerprintHash: fingerprint(info.UserAgent),
+		IPAddress:       info.IP,
+		FirstSeenAt:     now,
+		LastSeenAt:      now,
+		ExpiresAt:       now.Add(s.ttl),
+	}
+
+	devices := append(active(usr.TrustedDevices, now), trusted)
+	if s.limit > 0 && len(devices) > s.limit {
+		sort.SliceStable(devices, func(i, j int) bool {
+			return devices[i].LastSeenAt.After(devices[j].LastSeenAt)
+		})
+		devices = devices[:s.limit]
+	}
+	usr.TrustedDevices = devices
+	usr.UpdatedAt = now
+	if err := s.userRepo.Update(ctx, usr); err != nil {
+		return nil, err
+	}
+
+	s.record(ctx, &audit.Event{
+		Type:     audit.EventDeviceTrusted,
+		ActorID:  usr.ID.Hex(),
+		TargetID: usr.ID.Hex(),
+		Metadata: map[string]string{"device_id": id, "name": name},
+	})
+
+	payload := id + "." + secret
+	return &Trusted{
+		DeviceID:  id,
+		Cookie:    payload + "." + s.mac(usr.ID.Hex()+"."+payload),
+		ExpiresAt: trusted.ExpiresAt,
+	}, nil
+}
+
+// Verify implements Service. O cookie só vale para o usuário a que foi
+// emitido e para o mesmo User-Agent; um dispositivo confiável não renova o
+// prazo, apenas registra o último uso.
+func (s *service) Verify(ctx context.Context, usr *user.User, cookie string) (*user.TrustedDevice, error) {
+	index := strings.LastIndex(cookie, ".")
+	if index < 0 {
+		return nil, ErrUntrustedDevice
+	}
+	payload, signature := cookie[:index], cookie[index+1:]
+	if !hmac.Equal([]byte(signature), []byte(s.mac(usr.ID.Hex()+"."+payload))) {
+		return nil, ErrUntrustedDevice
+	}
+	id, secret, ok := strings.Cut(payload, ".")
+	if !ok {
+		return nil, ErrUntrustedDevice
+	}
+
+	now := time.Now().UTC()
+	trusted := findDevice(usr, id)
+	if trusted == nil || !now.Before(trusted.ExpiresAt) {
+		return nil, ErrUntrustedDevice
+	}
+	info := audit.RequestInfoFromContext(ctx)
+	if !hmac.Equal([]byte(s.tokens.Hash(secret)), []byte(trusted.TokenHash)) ||
+		!hmac.Equal([]byte(fingerprint(info.UserAgent)), []byte(trusted.FingerprintHash)) {
+		return nil, ErrUntrustedDevice
+	}
+
+	trusted.LastSeenAt = now
+	if info.IP != "" {
+		trusted.IPAddress = info.IP
+	}
+	if err := s.userRepo.Update(ctx, usr); err != nil {
+		log.Printf("Warning: failed to update trusted device of user %s: %v", usr.ID.Hex(), err)
+	}
+
+	device := *trusted
+	return &device, nil
+}
+
+// List implements Service. Dispositivos expirados não são listados.
+func (s *service) List(ctx context.Context, userID string) ([]user.TrustedDevice, error) {
+	usr, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, user.ErrUserNotFound
+	}
+	return active(usr.TrustedDevices, time.Now().UTC()), nil
+}
+
+// Revoke implements Service.
+func (s *service) Revoke(ctx context.Context, userID, deviceID string) error {
+	usr, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return user.ErrUserNotFound
+	}
+
+	remaining := make([]user.TrustedDevice, 0, len(usr.TrustedDevices))
+	for _, trusted := range usr.TrustedDevices {
+		if trusted.ID != deviceID {
+			remaining = append(remaining, trusted)
+		}
+	}
+	if len(remaining) == len(usr.TrustedDevices) {
+		return ErrDeviceNotFound
+	}
+
+	usr.TrustedDevices = remaining
+	usr.UpdatedAt = time.Now().UTC()
+	if err := s.userRepo.Update(ctx, usr); err != nil {
+		return err
+	}
+
+	s.record(ctx, &audit.Event{
+		Type:     audit.EventDeviceRevoked,
+		TargetID: userID,
+		Metadata: map[string]string{"device_id": deviceID},
+	})
+	return nil
+}
+
+// RevokeAll implements Service.
+func (s *service) RevokeAll(ctx context.Context, userID string) (int, error) {
+	usr, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return 0, user.ErrUserNotFound
+	}
+
+	removed := len(usr.TrustedDevices)
+	if removed == 0 {
+		return 0, nil
+	}
+
+	usr.TrustedDevices = nil
+	usr.UpdatedAt = time.Now().UTC()
+	if err := s.userRepo.Update(ctx, usr); err != nil {
+		return 0, err
+	}
+
+	s.record(ctx, &audit.Event{
+		Type:     audit.EventDeviceRevoked,
+		TargetID: userID,
+		Metadata: map[string]string{"devices": strconv.Itoa(removed)},
+	})
+	return removed, nil
+}
+
+func (s *service) mac(payload string) string {
+	h := hmac.New(sha256.New, s.secret)
+	h.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
+
+func (s *service) record(ctx context.Context, event *audit.Event) {
+	if err := s.audit.Record(ctx, event); err != nil {
+		log.Printf("Warning: failed to record audit event %s: %v", event.Type, err)
+	}
+}
+
+// active retorna uma cópia dos dispositivos que ainda não expiraram
+func active(devices []user.TrustedDevice, now time.Time) []user.TrustedDevice {
+	result := make([]user.TrustedDevice, 0, len(devices))
+	for _, trusted := range devices {
+		if now.Before(trusted.ExpiresAt) {
+			result = append(result, trusted)
+		}
+	}
+	return result
+}
+
+func findDevice(usr *user.User, id string) *user.TrustedDevice {
+	for i := range usr.TrustedDevices {
+		if usr.TrustedDevices[i].ID == id {
+			return &usr.TrustedDevices[i]
+		}
+	}
+	return nil
+}
+
+// fingerprint vincula o dispositivo ao navegador que o registrou
+func fingerprint(userAgent string) string {
+	sum := sha256.Sum256([]byte(userAgent))
+	return hex.EncodeToString(sum[:])
+}
+
+// deviceName usa o User-Agent como nome padrão, limitado ao tamanho aceito
+// no pedido
+func deviceName(userAgent string) string {
+	userAgent = strings.TrimSpace(userAgent)
+	if userAgent == "" {
+		return defaultDeviceName
+	}
+	if len(userAgent) > 100 {
+		userAgent = userAgent[:100]
+	}
+	return userAgent
+}
@@ -0,0 +1,55 @@
+package group
+
+import (
+	"time"
+
+	"github.com/juanjerrah/go_auth_api/pkg/types"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Group concede um conjunto de permissões aos seus membros. Um grupo pode
+// estar aninhado em outros (ParentIDs), herdando as permissões deles.
+type Group struct {
+	ID          primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	Name        string               `bson:"name" json:"name"`
+	Description string               `bson:"description" json:"description"`
+	Permissions []types.Permission   `bson:"permissions" json:"permissions"`
+	ParentIDs   []primitive.ObjectID `bson:"parent_ids" json:"parent_ids"`
+	MemberIDs   []primitive.ObjectID `bson:"member_ids" json:"-"`
+	CreatedAt   time.Time            `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time            `bson:"updated_at" json:"updated_at"`
+}
+
+type CreateGroupRequest struct {
+	Name        string             `json:"name" binding:"required"`
+	Description string             `json:"description"`
+	Permissions []types.Permission `json:"permissions"`
+	ParentIDs   []string           `json:"parent_ids"`
+}
+
+type UpdateGroupRequest struct {
+	Name        string             `json:"name"`
+	Description *string            `json:"description"`
+	Permissions []types.Permission `json:"permissions"`
+	ParentIDs   []string           `json:"parent_ids"`
+}
+
+type AddMemberRequest struct {
+	UserID string `json:"user_id" binding:"required"`
+}
+
+type GroupResponse struct {
+	ID          string             `json:"id"`
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	Permissions []types.Permission `json:"permissions"`
+	ParentIDs   []string           `json:"parent_ids"`
+	MemberCount int                `json:"member_count"`
+	CreatedAt   time.Time          `json:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+}
+
+type MembersResponse struct {
+	GroupID string   `json:"group_id"`
+	UserIDs []string `json:"user_ids"`
+}
@@ -0,0 +1,22 @@
+package group
+
+import (
+	"context"
+)
+
+type Repository interface {
+	Create(ctx context.Context, group *Group) error
+	FindByID(ctx context.Context, id string) (*Group, error)
+	FindByIDs(ctx context.Context, ids []string) ([]*Group, error)
+	List(ctx context.Context) ([]*Group, error)
+	ExistsByName(ctx context.Context, name string) (bool, error)
+	Update(ctx context.Context, group *Group) error
+	Delete(ctx context.Context, id string) error
+	// FindByMember retorna os grupos dos quais o usuário é membro direto
+	FindByMember(ctx context.Context, userID string) ([]*Group, error)
+	// FindChildren retorna os grupos aninhados diretamente no grupo informado
+	FindChildren(ctx context.Context, id string) ([]*Group, error)
+	AddMember(ctx context.Context, id, userID string) error
+	RemoveMember(ctx context.Context, id, userID string) error
+	RemoveParent(ctx context.Context, parentID string) error
+}
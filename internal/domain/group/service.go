@@ -0,0 +1,494 @@
+package group
+
+import (
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/juanjerrah/go_auth_api/internal/domain/audit"
+	"github.com/juanjerrah/go_auth_api/internal/domain/user"
+	"github.com/juanjerrah/go_auth_api/pkg/common"
+	"github.com/juanjerrah/go_auth_api/pkg/types"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrGroupNotFound       = errors.New("group not found")
+	ErrGroupNameInUse      = errors.New("group name already in use")
+	ErrInvalidPermission   = errors.New("invalid permission")
+	ErrInvalidParent       = errors.New("invalid parent group")
+	ErrCyclicInheritance   = errors.New("group inheritance would create a cycle")
+	ErrAlreadyGroupMember  = errors.New("user is already a member of this group")
+	ErrNotGroupMember      = errors.New("user is not a member of this group")
+	ErrGroupMemberNotFound = errors.New("user not found")
+)
+
+// PermissionCacheInvalidator descarta as permissões efetivas em cache dos
+// usuários afetados por uma alteração de grupo.
+type PermissionCacheInvalidator interface {
+	Invalidate(ctx context.Context, userIDs ...string) error
+}
+
+type Service interface {
+	CreateGroup(ctx context.Context, req *CreateGroupRequest) (*GroupResponse, error)
+	GetGroup(ctx context.Context, id string) (*GroupResponse, error)
+	ListGroups(ctx context.Context) ([]*GroupResponse, error)
+	UpdateGroup(ctx context.Context, id string, req *UpdateGroupRequest) (*GroupResponse, error)
+	DeleteGroup(ctx context.Context, id string) error
+	ListMembers(ctx context.Context, id string) (*MembersResponse, error)
+	AddMember(ctx context.Context, id, userID string) error
+	RemoveMember(ctx context.Context, id, userID string) error
+	ListUserGroups(ctx context.Context, userID string) ([]*GroupResponse, error)
+	EffectivePermissions(ctx context.Context, userID string) ([]types.Permission, error)
+}
+
+type service struct {
+	repo       Repository
+	userRepo   user.Repository
+	mongoUtils common.MongoUtils
+	audit      audit.Service
+	cache      PermissionCacheInvalidator
+}
+
+func NewService(repo Repository, userRepo user.Repository, mongoUtils common.MongoUtils, auditService audit.Service, cache PermissionCacheInvalidator) Service {
+	return &service{
+		repo:       repo,
+		userRepo:   userRepo,
+		mongoUtils: mongoUtils,
+		audit:      auditService,
+		cache:      cache,
+	}
+}
+
+// CreateGroup implements Service.
+func (s *service) CreateGroup(ctx context.Context, req *CreateGroupRequest) (*GroupResponse, error) {
+	if err := validatePermissions(req.Permissions); err != nil {
+		return nil, err
+	}
+
+	exists, err := s.repo.ExistsByName(ctx, req.Name)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrGroupNameInUse
+	}
+
+	now := time.Now().UTC()
+	group := &Group{
+		ID:          s.mongoUtils.GenerateObjectID(),
+		Name:        req.Name,
+		Description: req.Description,
+		Permissions: normalizePermissions(req.Permissions),
+		ParentIDs:   []primitive.ObjectID{},
+		MemberIDs:   []primitive.ObjectID{},
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	// Um grupo novo não tem filhos, então não há como formar ciclo
+	if len(req.ParentIDs) > 0 {
+		parents, err := s.resolveParents(ctx, group.ID, req.ParentIDs)
+		if err != nil {
+			return nil, err
+		}
+		group.ParentIDs = parents
+	}
+
+	if err := s.repo.Create(ctx, group); err != nil {
+		return nil, err
+	}
+
+	s.record(ctx, &audit.Event{
+		Type:     audit.EventGroupCreated,
+		Metadata: map[string]string{"group_id": group.ID.Hex(), "name": group.Name},
+	})
+
+	return s.toResponse(group), nil
+}
+
+// GetGroup implements Service.
+func (s *service) GetGroup(ctx context.Context, id string) (*GroupResponse, error) {
+	group, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, ErrGroupNotFound
+	}
+	return s.toResponse(group), nil
+}
+
+// ListGroups implements Service.
+func (s *service) ListGroups(ctx context.Context) ([]*GroupResponse, error) {
+	groups, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.toResponses(groups), nil
+}
+
+// UpdateGroup implements Service.
+func (s *service) UpdateGroup(ctx context.Context, id string, req *UpdateGroupRequest) (*GroupResponse, error) {
+	group, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, ErrGroupNotFound
+	}
+
+	if req.Name != "" && req.Name != group.Name {
+		exists, err := s.repo.ExistsByName(ctx, req.Name)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			return nil, ErrGroupNameInUse
+		}
+		group.Name = req.Name
+	}
+
+	if req.Description != nil {
+		group.Description = *req.Description
+	}
+
+	if req.Permissions != nil {
+		if err := validatePermissions(req.Permissions); err != nil {
+			return nil, err
+		}
+		group.Permissions = normalizePermissions(req.Permissions)
+	}
+
+	if req.ParentIDs != nil {
+		parents, err := s.resolveParents(ctx, group.ID, req.ParentIDs)
+		if err != nil {
+			return nil, err
+		}
+		group.ParentIDs = parents
+	}
+
+	group.UpdatedAt = time.Now().UTC()
+	if err := s.repo.Update(ctx, group); err != nil {
+		return nil, err
+	}
+
+	// Permissões e herança afetam o grupo e todos os grupos aninhados nele
+	s.invalidateGroup(ctx, group.ID.Hex())
+
+	s.record(ctx, &audit.Event{
+		Type:     audit.EventGroupUpdated,
+		Metadata: map[string]string{"group_id": group.ID.Hex(), "name": group.Name},
+	})
+
+	return s.toResponse(group), nil
+}
+
+// DeleteGroup implements Service.
+func (s *service) DeleteGroup(ctx context.Context, id string) error {
+	group, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return ErrGroupNotFound
+	}
+
+	// Os usuários afetados precisam ser coletados antes da exclusão
+	affected, err := s.affectedUsers(ctx, group)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	if err := s.repo.RemoveParent(ctx, id); err != nil {
+		return err
+	}
+
+	s.invalidate(ctx, affected...)
+
+	s.record(ctx, &audit.Event{
+		Type:     audit.EventGroupDeleted,
+		Metadata: map[string]string{"group_id": id, "name": group.Name},
+	})
+
+	return nil
+}
+
+// ListMembers implements Service.
+func (s *service) ListMembers(ctx context.Context, id string) (*MembersResponse, error) {
+	group, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, ErrGroupNotFound
+	}
+
+	userIDs := make([]string, 0, len(group.MemberIDs))
+	for _, memberID := range group.MemberIDs {
+		userIDs = append(userIDs, memberID.Hex())
+	}
+
+	return &MembersResponse{
+		GroupID: id,
+		UserIDs: userIDs,
+	}, nil
+}
+
+// AddMember implements Service.
+func (s *service) AddMember(ctx context.Context, id, userID string) error {
+	group, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return ErrGroupNotFound
+	}
+
+	if _, err := s.userRepo.FindByID(ctx, userID); err != nil {
+		return ErrGroupMemberNotFound
+	}
+
+	if slices.Contains(group.MemberIDs, s.mongoUtils.ToObjectID(userID)) {
+		return ErrAlreadyGroupMember
+	}
+
+	if err := s.repo.AddMember(ctx, id, userID); err != nil {
+		return err
+	}
+
+	s.invalidate(ctx, userID)
+
+	s.record(ctx, &audit.Event{
+		Type:     audit.EventGroupMemberAdded,
+		TargetID: userID,
+		Metadata: map[string]string{"group_id": id, "name": group.Name},
+	})
+
+	return nil
+}
+
+// RemoveMember implements Service.
+func (s *service) RemoveMember(ctx context.Context, id, userID string) error {
+	group, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return ErrGroupNotFound
+	}
+
+	if !slices.Contains(group.MemberIDs, s.mongoUtils.ToObjectID(userID)) {
+		return ErrNotGroupMember
+	}
+
+	if err := s.repo.RemoveMember(ctx, id, userID); err != nil {
+		return err
+	}
+
+	s.invalidate(ctx, userID)
+
+	s.record(ctx, &audit.Event{
+		Type:     audit.EventGroupMemberRemoved,
+		TargetID: userID,
+		Metadata: map[string]string{"group_id": id, "name": group.Name},
+	})
+
+	return nil
+}
+
+// ListUserGroups implements Service.
+func (s *service) ListUserGroups(ctx context.Context, userID string) ([]*GroupResponse, error) {
+	groups, err := s.repo.FindByMember(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.toResponses(groups), nil
+}
+
+// EffectivePermissions implements Service. Percorre a hierarquia a partir dos
+// grupos diretos do usuário, acumulando as permissões de cada ancestral.
+func (s *service) EffectivePermissions(ctx context.Context, userID string) ([]types.Permission, error) {
+	groups, err := s.repo.FindByMember(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	permissions := []types.Permission{}
+	visited := make(map[primitive.ObjectID]bool)
+	for len(groups) > 0 {
+		var next []string
+		for _, group := range groups {
+			if visited[group.ID] {
+				continue
+			}
+			visited[group.ID] = true
+
+			for _, permission := range group.Permissions {
+				if !slices.Contains(permissions, permission) {
+					permissions = append(permissions, permission)
+				}
+			}
+			for _, parentID := range group.ParentIDs {
+				if !visited[parentID] {
+					next = append(next, parentID.Hex())
+				}
+			}
+		}
+
+		if len(next) == 0 {
+			break
+		}
+		groups, err = s.repo.FindByIDs(ctx, next)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return permissions, nil
+}
+
+// resolveParents valida os grupos pais informados e garante que o grupo não
+// herde de si mesmo nem de um de seus descendentes.
+func (s *service) resolveParents(ctx context.Context, groupID primitive.ObjectID, parentIDs []string) ([]primitive.ObjectID, error) {
+	parentIDs = slices.Compact(slices.Sorted(slices.Values(parentIDs)))
+
+	parents, err := s.repo.FindByIDs(ctx, parentIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(parents) != len(parentIDs) {
+		return nil, ErrInvalidParent
+	}
+
+	descendants, err := s.descendants(ctx, groupID.Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(parents))
+	for _, parent := range parents {
+		if parent.ID == groupID || descendants[parent.ID] {
+			return nil, ErrCyclicInheritance
+		}
+		ids = append(ids, parent.ID)
+	}
+	return ids, nil
+}
+
+// descendants retorna todos os grupos que herdam, direta ou indiretamente, do
+// grupo informado.
+func (s *service) descendants(ctx context.Context, id string) (map[primitive.ObjectID]bool, error) {
+	result := make(map[primitive.ObjectID]bool)
+	queue := []string{id}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		children, err := s.repo.FindChildren(ctx, current)
+		if err != nil {
+			return nil, err
+		}
+		for _, child := range children {
+			if result[child.ID] {
+				continue
+			}
+			result[child.ID] = true
+			queue = append(queue, child.ID.Hex())
+		}
+	}
+	return result, nil
+}
+
+// affectedUsers reúne os membros do grupo e de todos os seus descendentes.
+func (s *service) affectedUsers(ctx context.Context, group *Group) ([]string, error) {
+	descendants, err := s.descendants(ctx, group.ID.Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(descendants))
+	for id := range descendants {
+		ids = append(ids, id.Hex())
+	}
+	groups, err := s.repo.FindByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	groups = append(groups, group)
+
+	seen := make(map[string]bool)
+	userIDs := []string{}
+	for _, g := range groups {
+		for _, memberID := range g.MemberIDs {
+			if !seen[memberID.Hex()] {
+				seen[memberID.Hex()] = true
+				userIDs = append(userIDs, memberID.Hex())
+			}
+		}
+	}
+	return userIDs, nil
+}
+
+func (s *service) invalidateGroup(ctx context.Context, id string) {
+	group, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return
+	}
+
+	userIDs, err := s.affectedUsers(ctx, group)
+	if err != nil {
+		log.Printf("Warning: failed to resolve members of group %s: %v", id, err)
+		return
+	}
+	s.invalidate(ctx, userIDs...)
+}
+
+func (s *service) invalidate(ctx context.Context, userIDs ...string) {
+	if len(userIDs) == 0 {
+		return
+	}
+	if err := s.cache.Invalidate(ctx, userIDs...); err != nil {
+		log.Printf("Warning: failed to invalidate permission cache: %v", err)
+	}
+}
+
+func (s *service) record(ctx context.Context, event *audit.Event) {
+	if err := s.audit.Record(ctx, event); err != nil {
+		log.Printf("Warning: failed to record audit event %s: %v", event.Type, err)
+	}
+}
+
+func (s *service) toResponse(group *Group) *GroupResponse {
+	parentIDs := make([]string, 0, len(group.ParentIDs))
+	for _, parentID := range group.ParentIDs {
+		parentIDs = append(parentIDs, parentID.Hex())
+	}
+
+	permissions := group.Permissions
+	if permissions == nil {
+		permissions = []types.Permission{}
+	}
+
+	return &GroupResponse{
+		ID:          group.ID.Hex(),
+		Name:        group.Name,
+		Description: group.Description,
+		Permissions: permissions,
+		ParentIDs:   parentIDs,
+		MemberCount: len(group.MemberIDs),
+		CreatedAt:   group.CreatedAt,
+		UpdatedAt:   group.UpdatedAt,
+	}
+}
+
+func (s *service) toResponses(groups []*Group) []*GroupResponse {
+	response := make([]*GroupResponse, 0, len(groups))
+	for _, group := range groups {
+		response = append(response, s.toResponse(group))
+	}
+	return response
+}
+
+func validatePermissions(permissions []types.Permission) error {
+	for _, permission := range permissions {
+		if !types.IsValidPermission(permission) {
+			return ErrInvalidPermission
+		}
+	}
+	return nil
+}
+
+func normalizePermissions(permissions []types.Permission) []types.Permission {
+	if permissions == nil {
+		return []types.Permission{}
+	}
+	return slices.Compact(slices.Sorted(slices.Values(permissions)))
+}
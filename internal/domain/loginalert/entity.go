@@ -0,0 +1,46 @@
+package loginalert
+
+import "time"
+
+// Alert é um aviso de login enviado ao usuário, guardado pelo hash do token
+// do link "não fui eu". SessionID identifica a sessão aberta pelo login
+// avisado; SessionToken só existe em avisos gravados antes dele.
+type Alert struct {
+	UserID       string    `json:"user_id"`
+	SessionID    string    `json:"session_id,omitempty"`
+	SessionToken string    `json:"session_token,omitempty"`
+	IP           string    `json:"ip"`
+	UserAgent    string    `json:"user_agent"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Reset é uma redefinição de senha liberada pelo "não fui eu", guardada pelo
+// hash do token
+type Reset struct {
+	UserID    string    `json:"user_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Sighting compara um login com o histórico do usuário. FirstLogin indica
+// que não havia histórico para comparar.
+type Sighting struct {
+	NewDevice  bool
+	NewNetwork bool
+	FirstLogin bool
+}
+
+type ReportRequest struct {
+	Token string `json:"token" form:"token" binding:"required"`
+}
+
+// ReportResponse traz o token para redefinir a senha. Contas cuja senha é
+// gerida por um diretório externo não recebem token.
+type ReportResponse struct {
+	ResetToken string     `json:"reset_token,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
+}
@@ -0,0 +1,23 @@
+package loginalert
+
+import (
+	"context"
+	"time"
+)
+
+// Repository guarda o histórico de dispositivos e redes de login de cada
+// usuário, os avisos enviados e as redefinições de senha pendentes. Os
+// métodos Consume removem o registro, garantindo um único uso.
+type Repository interface {
+	// RecordLogin registra o dispositivo e a rede do login e informa quais
+	// ainda não eram conhecidos. Entradas não vistas há mais de retention são
+	// esquecidas.
+	RecordLogin(ctx context.Context, userID, device, network string, retention time.Duration) (*Sighting, error)
+	// KnownDevice informa se o dispositivo foi visto nos últimos retention e
+	// se o usuário tem algum histórico
+	KnownDevice(ctx context.Context, userID, device string, retention time.Duration) (known bool, history bool, err error)
+	SaveAlert(ctx context.Context, tokenHash string, alert *Alert, ttl time.Duration) error
+	ConsumeAlert(ctx context.Context, tokenHash string) (*Alert, error)
+	SaveReset(ctx context.Context, tokenHash string, reset *Reset, ttl time.Duration) error
+	ConsumeReset(ctx context.Context, tokenHash string) (*Reset, error)
+}
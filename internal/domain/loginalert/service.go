@@ -0,0 +1,256 @@
+package loginalert
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/netip"
+	"strconv"
+	"time"
+
+	"github.com/juanjerrah/go_auth_api/internal/domain/audit"
+	"github.com/juanjerrah/go_auth_api/internal/domain/user"
+	"github.com/juanjerrah/go_auth_api/pkg/common"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid or expired token")
+)
+
+// SessionStore encerra a sessão aberta pelo login avisado. O aviso guarda só
+// o ID da sessão, não o token.
+type SessionStore interface {
+	SessionID(token string) string
+	DeleteSession(ctx context.Context, sessionID string) error
+}
+
+type Service interface {
+	// CheckLogin compara o login que abriu a sessão com o histórico do
+	// usuário e, se o dispositivo ou a rede forem novos, envia o aviso com o
+	// link "não fui eu". Falhas de entrega não afetam o login.
+	CheckLogin(ctx context.Context, usr *user.User, sessionToken string) error
+	// KnownDevice informa se o User-Agent já foi usado pelo usuário. Sem
+	// histórico, todo dispositivo é considerado conhecido.
+	KnownDevice(ctx context.Context, userID, userAgent string) (bool, error)
+	// Report trata o "não fui eu": encerra a sessão do aviso, bloqueia o
+	// login por senha e retorna o token para redefini-la
+	Report(ctx context.Context, token string) (*ReportResponse, error)
+	// ResetPassword redefine a senha com o token retornado por Report
+	ResetPassword(ctx context.Context, token, newPassword string) error
+}
+
+type service struct {
+	repo        Repository
+	userService user.Service
+	sessions    SessionStore
+	mailer      common.Mailer
+	tokens      common.TokenGenerator
+	audit       audit.Service
+	reportURL   string
+	alertTTL    time.Duration
+	resetTTL    time.Duration
+	retention   time.Duration
+}
+
+// NewService cria o serviço de avisos de login. reportURL recebe o token do
+// link "não fui eu" (?token=...), válido por alertTTL; a redefinição de senha
+// liberada por ele vale por resetTTL. Dispositivos e redes não vistos há mais
+// de retention voltam a ser tratados como novos.
+func NewService(
+	repo Repository,
+	userService user.Service,
+	sessions SessionStore,
+	mailer common.Mailer,
+	tokens common.TokenGenerator,
+	auditService audit.Service,
+	reportURL string,
+	alertTTL time.Duration,
+	resetTTL time.Duration,
+	retention time.Duration,
+) Service {
+	return &service{
+		repo:        repo,
+		userService: userService,
+		sessions:    sessions,
+		mailer:      mailer,
+		tokens:      tokens,
+		audit:       auditService,
+		reportURL:   reportURL,
+		alertTTL:    alertTTL,
+		resetTTL:    resetTTL,
+		retention:   retention,
+	}
+}
+
+// CheckLogin implements Service. O primeiro login registrado apenas inicia o
+// histórico, sem aviso.
+func (s *service) CheckLogin(ctx context.Context, usr *user.User, sessionToken string) error {
+	info := audit.RequestInfoFromContext(ctx)
+	userID := usr.ID.Hex()
+
+	sighting, err := s.repo.RecordLogin(ctx, userID, fingerprint(info.UserAgent), network(info.IP), s.retention)
+	if err != nil {
+		return err
+	}
+	if sighting.FirstLogin || (!sighting.NewDevice && !sighting.NewNetwork) {
+		return nil
+	}
+
+	token, err := s.tokens.Generate()
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	alert := &Alert{
+		UserID:    userID,
+		SessionID: s.sessions.SessionID(sessionToken),
+		IP:        info.IP,
+		UserAgent: info.UserAgent,
+		CreatedAt: now,
+	}
+	if err := s.repo.SaveAlert(ctx, s.tokens.Hash(token), alert, s.alertTTL); err != nil {
+		return err
+	}
+
+	if err := s.mailer.Send(ctx, s.alertMessage(usr, alert, token)); err != nil {
+		log.Printf("Warning: failed to send login alert to user %s: %v", userID, err)
+		return nil
+	}
+
+	s.record(ctx, &audit.Event{
+		Type:     audit.EventLoginAlertSent,
+		TargetID: userID,
+		Metadata: map[string]string{
+			"new_device":  strconv.FormatBool(sighting.NewDevice),
+			"new_network": strconv.FormatBool(sighting.NewNetwork),
+		},
+	})
+	return nil
+}
+
+// KnownDevice implements Service.
+func (s *service) KnownDevice(ctx context.Context, userID, userAgent string) (bool, error) {
+	known, history, err := s.repo.KnownDevice(ctx, userID, fingerprint(userAgent), s.retention)
+	if err != nil {
+		return false, err
+	}
+	return known || !history, nil
+}
+
+// Report implements Service.
+func (s *service) Report(ctx context.Context, token string) (*ReportResponse, error) {
+	alert, err := s.repo.ConsumeAlert(ctx, s.tokens.Hash(token))
+	if err != nil {
+		return nil, err
+	}
+	if alert == nil {
+		return nil, ErrInvalidToken
+	}
+
+	sessionID := alert.SessionID
+	if sessionID == "" {
+		sessionID = s.sessions.SessionID(alert.SessionToken)
+	}
+	// A sessão pode já ter expirado ou sido encerrada
+	if err := s.sessions.DeleteSession(ctx, sessionID); err != nil {
+		log.Printf("Warning: failed to revoke reported session of user %s: %v", alert.UserID, err)
+	}
+
+	s.record(ctx, &audit.Event{
+		Type:     audit.EventLoginReported,
+		ActorID:  alert.UserID,
+		TargetID: alert.UserID,
+		Metadata: map[string]string{
+			"ip":         alert.IP,
+			"user_agent": alert.UserAgent,
+			"login_at":   alert.CreatedAt.Format(time.RFC3339),
+		},
+	})
+
+	// Senhas de diretório externo são redefinidas lá; só a sessão é encerrada
+	err = s.userService.RequirePasswordReset(ctx, alert.UserID)
+	if errors.Is(err, user.ErrExternalPassword) {
+		return &ReportResponse{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	resetToken, err := s.tokens.Generate()
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := time.Now().UTC().Add(s.resetTTL)
+	reset := &Reset{UserID: alert.UserID, ExpiresAt: expiresAt}
+	if err := s.repo.SaveReset(ctx, s.tokens.Hash(resetToken), reset, s.resetTTL); err != nil {
+		return nil, err
+	}
+
+	return &ReportResponse{ResetToken: resetToken, ExpiresAt: &expiresAt}, nil
+}
+
+// ResetPassword implements Service.
+func (s *service) ResetPassword(ctx context.Context, token, newPassword string) error {
+	reset, err := s.repo.ConsumeReset(ctx, s.tokens.Hash(token))
+	if err != nil {
+		return err
+	}
+	if reset == nil {
+		return ErrInvalidToken
+	}
+
+	return s.userService.ResetPassword(ctx, reset.UserID, newPassword)
+}
+
+func (s *service) alertMessage(usr *user.User, alert *Alert, token string) *common.EmailMessage {
+	link := fmt.Sprintf("%s?token=%s", s.reportURL, token)
+	device := alert.UserAgent
+	if device == "" {
+		device = "unknown"
+	}
+
+	return &common.EmailMessage{
+		To:      usr.Email,
+		Subject: "New sign-in to your account",
+		Body: fmt.Sprintf(
+			"Hello %s,\n\nYour account was signed in from a new device or location.\n\nTime: %s\nIP address: %s\nDevice: %s\n\nIf this was you, you can ignore this message. If it wasn't, use this link to end that session and reset your password: %s\n",
+			usr.Name, alert.CreatedAt.Format(time.RFC1123), alert.IP, device, link,
+		),
+	}
+}
+
+func (s *service) record(ctx context.Context, event *audit.Event) {
+	if err := s.audit.Record(ctx, event); err != nil {
+		log.Printf("Warning: failed to record audit event %s: %v", event.Type, err)
+	}
+}
+
+// fingerprint identifica o dispositivo pelo hash do User-Agent
+func fingerprint(userAgent string) string {
+	sum := sha256.Sum256([]byte(userAgent))
+	return hex.EncodeToString(sum[:])
+}
+
+// network agrupa o IP na sua rede (/24 no IPv4, /48 no IPv6), para que a
+// troca de endereço dentro do mesmo provedor não gere aviso. Retorna vazio
+// para endereços inválidos.
+func network(ip string) string {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return ""
+	}
+	addr = addr.Unmap()
+
+	bits := 48
+	if addr.Is4() {
+		bits = 24
+	}
+	prefix, err := addr.Prefix(bits)
+	if err != nil {
+		return ""
+	}
+	return prefix.String()
+}
@@ -0,0 +1,18 @@
+package magiclink
+
+import "time"
+
+// Link é o registro de um link de login pendente, guardado pelo hash do token
+type Link struct {
+	UserID    string    `json:"user_id"`
+	Email     string    `json:"email"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type RequestLinkRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ConsumeLinkRequest struct {
+	Token string `json:"token" form:"token" binding:"required"`
+}
@@ -0,0 +1,16 @@
+package magiclink
+
+import (
+	"context"
+	"time"
+)
+
+// Repository guarda os links pendentes e os contadores de envio. Consume
+// remove o registro, garantindo que cada link seja usado uma única vez.
+type Repository interface {
+	Save(ctx context.Context, tokenHash string, link *Link, ttl time.Duration) error
+	Consume(ctx context.Context, tokenHash string) (*Link, error)
+	// CountRequest incrementa e retorna o número de links pedidos para o
+	// endereço dentro da janela
+	CountRequest(ctx context.Context, email string, window time.Duration) (int64, error)
+}
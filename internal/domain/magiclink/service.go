@@ -0,0 +1,209 @@
+package magiclink
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/juanjerrah/go_auth_api/internal/domain/audit"
+	"github.com/juanjerrah/go_auth_api/internal/domain/user"
+	"github.com/juanjerrah/go_auth_api/pkg/common"
+)
+
+var (
+	ErrInvalidLink = errors.New("invalid or expired login link")
+	ErrRateLimited = errors.New("too many login links requested for this address")
+)
+
+type Service interface {
+	// RequestLink envia um link de login para o endereço. Endereços sem conta
+	// local ativa não recebem nada, mas o retorno é o mesmo, para não revelar
+	// quais e-mails estão cadastrados.
+	RequestLink(ctx context.Context, email string) error
+	// ConsumeLink valida e consome o link, retornando o usuário autenticado
+	ConsumeLink(ctx context.Context, token string) (*user.User, error)
+}
+
+type service struct {
+	links      Repository
+	userRepo   user.Repository
+	mailer     common.Mailer
+	tokens     common.TokenGenerator
+	audit      audit.Service
+	secret     []byte
+	linkURL    string
+	ttl        time.Duration
+	rateLimit  int64
+	rateWindow time.Duration
+}
+
+// NewService cria o serviço de login por link. linkURL é a página que recebe
+// o token (?token=...); secret assina os links, que expiram após ttl. Cada
+// endereço pode pedir até rateLimit links por rateWindow.
+func NewService(
+	links Repository,
+	userRepo user.Repository,
+	mailer common.Mailer,
+	tokens common.TokenGenerator,
+	auditService audit.Service,
+	secret, linkURL string,
+	ttl time.Duration,
+	rateLimit int,
+	rateWindow time.Duration,
+) Service {
+	return &service{
+		links:      links,
+		userRepo:   userRepo,
+		mailer:     mailer,
+		tokens:     tokens,
+		audit:      auditService,
+		secret:     []byte(secret),
+		linkURL:    linkURL,
+		ttl:        ttl,
+		rateLimit:  int64(rateLimit),
+		rateWindow: rateWindow,
+	}
+}
+
+// RequestLink implements Service.
+func (s *service) RequestLink(ctx context.Context, email string) error {
+	email = strings.TrimSpace(email)
+
+	// O limite vale para qualquer endereço, cadastrado ou não
+	count, err := s.links.CountRequest(ctx, strings.ToLower(email), s.rateWindow)
+	if err != nil {
+		return err
+	}
+	if count > s.rateLimit {
+		return ErrRateLimited
+	}
+
+	// Contas de diretório ou federadas autenticam na origem; o link só vale
+	// para contas locais ativas
+	usr, err := s.userRepo.FindByEmail(ctx, email)
+	if err != nil || usr.Disabled || usr.AuthProvider != user.AuthProviderLocal {
+		return nil
+	}
+
+	expiresAt := time.Now().UTC().Add(s.ttl)
+	token, err := s.sign(expiresAt)
+	if err != nil {
+		return err
+	}
+
+	link := &Link{UserID: usr.ID.Hex(), Email: usr.Email, ExpiresAt: expiresAt}
+	if err := s.links.Save(ctx, s.tokens.Hash(token), link, s.ttl); err != nil {
+		return err
+	}
+
+	if err := s.mailer.Send(ctx, s.linkMessage(usr, token, expiresAt)); err != nil {
+		log.Printf("Warning: failed to send login link to user %s: %v", usr.ID.Hex(), err)
+		// O link nunca chegou ao destinatário; descartá-lo evita um registro
+		// órfão. A falha não é retornada, pois só ocorre para contas existentes.
+		if _, err := s.links.Consume(ctx, s.tokens.Hash(token)); err != nil {
+			log.Printf("Warning: failed to discard undelivered login link: %v", err)
+		}
+		return nil
+	}
+
+	s.record(ctx, &audit.Event{
+		Type:     audit.EventMagicLinkSent,
+		ActorID:  usr.ID.Hex(),
+		TargetID: usr.ID.Hex(),
+		Metadata: map[string]string{"email": usr.Email},
+	})
+	return nil
+}
+
+// ConsumeLink implements Service.
+func (s *service) ConsumeLink(ctx context.Context, token string) (*user.User, error) {
+	// A assinatura e a expiração são conferidas antes de consultar o Redis
+	if !s.verify(token, time.Now().UTC()) {
+		return nil, ErrInvalidLink
+	}
+
+	link, err := s.links.Consume(ctx, s.tokens.Hash(token))
+	if err != nil {
+		return nil, err
+	}
+	if link == nil {
+		return nil, ErrInvalidLink
+	}
+
+	usr, err := s.userRepo.FindByID(ctx, link.UserID)
+	if err != nil {
+		return nil, ErrInvalidLink
+	}
+	// A conta pode ter mudado depois do envio do link
+	if usr.Disabled {
+		return nil, user.ErrUserDisabled
+	}
+	if usr.AuthProvider != user.AuthProviderLocal || !strings.EqualFold(usr.Email, link.Email) {
+		return nil, ErrInvalidLink
+	}
+	return usr, nil
+}
+
+// sign gera o token do link: um valor aleatório e a expiração, assinados com
+// HMAC-SHA256 no formato <aleatório>.<expiração>.<assinatura>
+func (s *service) sign(expiresAt time.Time) (string, error) {
+	nonce, err := s.tokens.Generate()
+	if err != nil {
+		return "", err
+	}
+	payload := nonce + "." + strconv.FormatInt(expiresAt.Unix(), 10)
+	return payload + "." + s.mac(payload), nil
+}
+
+func (s *service) verify(token string, now time.Time) bool {
+	index := strings.LastIndex(token, ".")
+	if index < 0 {
+		return false
+	}
+	payload, signature := token[:index], token[index+1:]
+	if !hmac.Equal([]byte(signature), []byte(s.mac(payload))) {
+		return false
+	}
+
+	parts := strings.Split(payload, ".")
+	if len(parts) != 2 {
+		return false
+	}
+	expiresAt, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return false
+	}
+	return now.Unix() < expiresAt
+}
+
+func (s *service) mac(payload string) string {
+	h := hmac.New(sha256.New, s.secret)
+	h.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
+
+func (s *service) linkMessage(usr *user.User, token string, expiresAt time.Time) *common.EmailMessage {
+	link := fmt.Sprintf("%s?token=%s", s.linkURL, token)
+
+	return &common.EmailMessage{
+		To:      usr.Email,
+		Subject: "Your sign-in link",
+		Body: fmt.Sprintf(
+			"Hello %s,\n\nSign in with this link: %s\n\nThe link can be used only once and expires on %s.\nIf you did not request it, you can ignore this message.\n",
+			usr.Name, link, expiresAt.Format(time.RFC1123),
+		),
+	}
+}
+
+func (s *service) record(ctx context.Context, event *audit.Event) {
+	if err := s.audit.Record(ctx, event); err != nil {
+		log.Printf("Warning: failed to record audit event %s: %v", event.Type, err)
+	}
+}
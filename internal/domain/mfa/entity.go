@@ -0,0 +1,111 @@
+package mfa
+
+import (
+	"time"
+
+	"github.com/juanjerrah/go_auth_api/internal/domain/device"
+	"github.com/juanjerrah/go_auth_api/internal/domain/otp"
+	"github.com/juanjerrah/go_auth_api/internal/domain/webauthn"
+	"github.com/juanjerrah/go_auth_api/pkg/types"
+)
+
+// Fatores aceitos no segundo passo do login
+const (
+	MethodWebAuthn = "webauthn"
+	MethodEmailOTP = "email_otp"
+	MethodSMSOTP   = "sms_otp"
+)
+
+// MethodPassword só é aceito na reautenticação de uma sessão já aberta
+const MethodPassword = "password"
+
+// OTPMethod retorna o método de MFA do canal de códigos de uso único
+func OTPMethod(channel string) string {
+	if channel == otp.ChannelSMS {
+		return MethodSMSOTP
+	}
+	return MethodEmailOTP
+}
+
+// OTPChannel retorna o canal de códigos de uso único do método de MFA
+func OTPChannel(method string) string {
+	if method == MethodSMSOTP {
+		return otp.ChannelSMS
+	}
+	return otp.ChannelEmail
+}
+
+// AMR retorna o método de autenticação (RFC 8176) registrado na sessão
+// para o fator
+func AMR(method string) string {
+	switch method {
+	case MethodPassword:
+		return types.AMRPassword
+	case MethodWebAuthn:
+		return types.AMRHardwareKey
+	case MethodSMSOTP:
+		return types.AMRSMS
+	}
+	return types.AMROTP
+}
+
+// Challenge é um login que passou pelo primeiro fator e aguarda o segundo.
+// OrganizationID preserva a organização pedida no primeiro passo e AMR os
+// métodos já usados, que entram na sessão junto com o segundo fator.
+// AccountEmail indica que o código vai para o e-mail da conta, e não para um
+// canal cadastrado.
+type Challenge struct {
+	UserID         string    `json:"user_id"`
+	OrganizationID string    `json:"organization_id,omitempty"`
+	Methods        []string  `json:"methods"`
+	AMR            []string  `json:"amr,omitempty"`
+	AccountEmail   bool      `json:"account_email,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// ChallengeResponse é devolvido pelo login no lugar da sessão quando o
+// usuário tem segundo fator cadastrado
+type ChallengeResponse struct {
+	MFARequired bool      `json:"mfa_required"`
+	MFAToken    string    `json:"mfa_token"`
+	Methods     []string  `json:"methods"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+type WebAuthnOptionsRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+}
+
+// WebAuthnVerifyRequest e OTPVerifyRequest aceitam remember_device para
+// dispensar o segundo fator nos próximos logins do mesmo navegador
+type WebAuthnVerifyRequest struct {
+	MFAToken   string                       `json:"mfa_token" binding:"required"`
+	Credential webauthn.AssertionCredential `json:"credential" binding:"required"`
+	device.TrustRequest
+}
+
+type OTPSendRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Channel  string `json:"channel" binding:"required,oneof=email sms"`
+}
+
+type OTPVerifyRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Channel  string `json:"channel" binding:"required,oneof=email sms"`
+	Code     string `json:"code" binding:"required"`
+	device.TrustRequest
+}
+
+type ReauthenticationOptionsRequest struct {
+	Method string `json:"method" binding:"required,oneof=webauthn email_otp sms_otp"`
+}
+
+// ReauthenticateRequest confirma a identidade do dono da sessão: Password
+// para o método password, Code para os códigos de uso único e Credential
+// para passkeys
+type ReauthenticateRequest struct {
+	Method     string                        `json:"method" binding:"required,oneof=password webauthn email_otp sms_otp"`
+	Password   string                        `json:"password"`
+	Code       string                        `json:"code"`
+	Credential *webauthn.AssertionCredential `json:"credential"`
+}
@@ -0,0 +1,15 @@
+package mfa
+
+import (
+	"context"
+	"time"
+)
+
+// ChallengeRepository guarda os logins pendentes pelo hash do token. Find
+// permite consultar o desafio durante a verificação; Consume o remove,
+// garantindo que cada desafio gere uma única sessão.
+type ChallengeRepository interface {
+	Save(ctx context.Context, tokenHash string, challenge *Challenge, ttl time.Duration) error
+	Find(ctx context.Context, tokenHash string) (*Challenge, error)
+	Consume(ctx context.Context, tokenHash string) (*Challenge, error)
+}
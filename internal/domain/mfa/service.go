@@ -0,0 +1,130 @@
+package mfa
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/juanjerrah/go_auth_api/internal/domain/user"
+	"github.com/juanjerrah/go_auth_api/pkg/common"
+)
+
+var (
+	ErrInvalidChallenge = errors.New("invalid or expired mfa challenge")
+	ErrMethodNotAllowed = errors.New("mfa method not enrolled for this user")
+)
+
+type Service interface {
+	// Methods lista os fatores cadastrados pelo usuário; sem nenhum, o login
+	// não exige segundo fator
+	Methods(usr *user.User) []string
+	// StartChallenge registra um login pendente do segundo fator; amr são os
+	// métodos do primeiro passo
+	StartChallenge(ctx context.Context, usr *user.User, organizationID string, amr []string) (*ChallengeResponse, error)
+	// StartAccountEmailChallenge registra um login pendente de um código
+	// enviado ao e-mail da conta, para quem não cadastrou segundo fator mas
+	// precisa apresentar um (login de risco)
+	StartAccountEmailChallenge(ctx context.Context, usr *user.User, organizationID string, amr []string) (*ChallengeResponse, error)
+	// Challenge retorna o login pendente, conferindo se o método foi cadastrado
+	Challenge(ctx context.Context, token, method string) (*Challenge, error)
+	// Complete consome o desafio depois que o fator foi verificado
+	Complete(ctx context.Context, token string) (*Challenge, error)
+}
+
+type service struct {
+	challenges ChallengeRepository
+	tokens     common.TokenGenerator
+	ttl        time.Duration
+}
+
+func NewService(challenges ChallengeRepository, tokens common.TokenGenerator, ttl time.Duration) Service {
+	return &service{
+		challenges: challenges,
+		tokens:     tokens,
+		ttl:        ttl,
+	}
+}
+
+// Methods implements Service.
+func (s *service) Methods(usr *user.User) []string {
+	methods := []string{}
+	if len(usr.WebAuthnCredentials) > 0 {
+		methods = append(methods, MethodWebAuthn)
+	}
+	for _, factor := range usr.OTPFactors {
+		methods = append(methods, OTPMethod(factor.Channel))
+	}
+	return methods
+}
+
+// StartChallenge implements Service.
+func (s *service) StartChallenge(ctx context.Context, usr *user.User, organizationID string, amr []string) (*ChallengeResponse, error) {
+	return s.start(ctx, &Challenge{
+		UserID:         usr.ID.Hex(),
+		OrganizationID: organizationID,
+		Methods:        s.Methods(usr),
+		AMR:            amr,
+	})
+}
+
+// StartAccountEmailChallenge implements Service.
+func (s *service) StartAccountEmailChallenge(ctx context.Context, usr *user.User, organizationID string, amr []string) (*ChallengeResponse, error) {
+	return s.start(ctx, &Challenge{
+		UserID:         usr.ID.Hex(),
+		OrganizationID: organizationID,
+		Methods:        []string{MethodEmailOTP},
+		AMR:            amr,
+		AccountEmail:   true,
+	})
+}
+
+func (s *service) start(ctx context.Context, challenge *Challenge) (*ChallengeResponse, error) {
+	token, err := s.tokens.Generate()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	challenge.CreatedAt = now
+	if err := s.challenges.Save(ctx, s.tokens.Hash(token), challenge, s.ttl); err != nil {
+		return nil, err
+	}
+
+	return &ChallengeResponse{
+		MFARequired: true,
+		MFAToken:    token,
+		Methods:     challenge.Methods,
+		ExpiresAt:   now.Add(s.ttl),
+	}, nil
+}
+
+// Challenge implements Service.
+func (s *service) Challenge(ctx context.Context, token, method string) (*Challenge, error) {
+	challenge, err := s.challenges.Find(ctx, s.tokens.Hash(token))
+	if err != nil {
+		return nil, err
+	}
+	if challenge == nil {
+		return nil, ErrInvalidChallenge
+	}
+
+	for _, enrolled := range challenge.Methods {
+		if enrolled == method {
+			return challenge, nil
+		}
+	}
+	return nil, ErrMethodNotAllowed
+}
+
+// Complete implements Service.
+func (s *service) Complete(ctx context.Context, token string) (*Challenge, error) {
+	challenge, err := s.challenges.Consume(ctx, s.tokens.Hash(token))
+	if err != nil {
+		return nil, err
+	}
+	if challenge == nil {
+		// Outra requisição concluiu o mesmo desafio primeiro
+		return nil, ErrInvalidChallenge
+	}
+	return challenge, nil
+}
@@ -0,0 +1,38 @@
+package oidc
+
+import "time"
+
+// ProviderConfig configura um provedor OpenID Connect. Os endpoints são
+// obtidos do documento de descoberta do emissor.
+type ProviderConfig struct {
+	Name         string
+	DisplayName  string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	// TrustedEmailDomains são domínios administrados pelo provedor cujos
+	// e-mails são tratados como verificados mesmo sem a claim email_verified
+	// (provedores corporativos que não a emitem)
+	TrustedEmailDomains []string
+}
+
+// AuthRequest é o estado de um login em andamento, guardado entre o
+// redirecionamento ao provedor e o retorno ao callback. LinkUserID, quando
+// presente, é o usuário autenticado que pediu para vincular a conta externa
+// em vez de fazer login.
+type AuthRequest struct {
+	State        string    `json:"state"`
+	Provider     string    `json:"provider"`
+	Nonce        string    `json:"nonce"`
+	CodeVerifier string    `json:"code_verifier"`
+	LinkUserID   string    `json:"link_user_id,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// ProviderResponse descreve um provedor disponível para login
+type ProviderResponse struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+	LoginURL    string `json:"login_url"`
+}
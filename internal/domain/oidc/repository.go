@@ -0,0 +1,13 @@
+package oidc
+
+import (
+	"context"
+	"time"
+)
+
+// StateRepository guarda os logins em andamento. Consume remove o registro,
+// garantindo que cada state seja usado uma única vez.
+type StateRepository interface {
+	Save(ctx context.Context, request *AuthRequest, ttl time.Duration) error
+	Consume(ctx context.Context, state string) (*AuthRequest, error)
+}
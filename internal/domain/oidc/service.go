@@ -0,0 +1,172 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/juanjerrah/go_auth_api/internal/domain/user"
+	"github.com/juanjerrah/go_auth_api/pkg/common"
+)
+
+var (
+	ErrUnknownProvider     = errors.New("unknown identity provider")
+	ErrInvalidState        = errors.New("invalid or expired login state")
+	ErrProviderUnavailable = errors.New("identity provider unavailable")
+	ErrExchangeFailed      = errors.New("authorization code exchange failed")
+	ErrInvalidIDToken      = errors.New("invalid id token")
+)
+
+type Service interface {
+	Providers() []*ProviderResponse
+	// Begin inicia o login no provedor e retorna a URL de autorização e o
+	// state, que o cliente deve apresentar de volta no callback
+	Begin(ctx context.Context, providerName string) (authURL, state string, err error)
+	// BeginLink inicia, para o usuário autenticado, o vínculo com sua conta
+	// no provedor; o retorno é o mesmo de Begin
+	BeginLink(ctx context.Context, providerName, userID string) (authURL, state string, err error)
+	// Complete valida o retorno do provedor e autentica o usuário vinculado à
+	// conta externa, vinculando-a ou criando o usuário quando necessário. Se
+	// o state é de BeginLink, vincula a conta ao usuário que o iniciou e
+	// retorna linked verdadeiro; nesse caso nenhuma sessão deve ser iniciada.
+	Complete(ctx context.Context, providerName, state, code string) (usr *user.User, linked bool, err error)
+}
+
+type service struct {
+	providers      map[string]*provider
+	order          []string
+	baseURL        string
+	states         StateRepository
+	userService    user.Service
+	tokenGenerator common.TokenGenerator
+	stateTTL       time.Duration
+}
+
+// NewService cria o serviço de login federado. baseURL é a URL pública das
+// rotas OIDC; o callback de cada provedor fica em {baseURL}/{nome}/callback.
+func NewService(configs []ProviderConfig, baseURL string, states StateRepository, userService user.Service, tokenGenerator common.TokenGenerator, httpClient *http.Client, stateTTL time.Duration) Service {
+	s := &service{
+		providers:      make(map[string]*provider, len(configs)),
+		baseURL:        baseURL,
+		states:         states,
+		userService:    userService,
+		tokenGenerator: tokenGenerator,
+		stateTTL:       stateTTL,
+	}
+	for _, config := range configs {
+		s.providers[config.Name] = newProvider(config, s.providerURL(config.Name)+"/callback", httpClient)
+		s.order = append(s.order, config.Name)
+	}
+	return s
+}
+
+// Providers implements Service.
+func (s *service) Providers() []*ProviderResponse {
+	providers := make([]*ProviderResponse, 0, len(s.order))
+	for _, name := range s.order {
+		displayName := s.providers[name].config.DisplayName
+		if displayName == "" {
+			displayName = name
+		}
+		providers = append(providers, &ProviderResponse{
+			Name:        name,
+			DisplayName: displayName,
+			LoginURL:    s.providerURL(name) + "/login",
+		})
+	}
+	return providers
+}
+
+// Begin implements Service.
+func (s *service) Begin(ctx context.Context, providerName string) (string, string, error) {
+	return s.begin(ctx, providerName, "")
+}
+
+// BeginLink implements Service.
+func (s *service) BeginLink(ctx context.Context, providerName, userID string) (string, string, error) {
+	return s.begin(ctx, providerName, userID)
+}
+
+func (s *service) begin(ctx context.Context, providerName, linkUserID string) (string, string, error) {
+	p, ok := s.providers[providerName]
+	if !ok {
+		return "", "", ErrUnknownProvider
+	}
+
+	request := &AuthRequest{Provider: providerName, LinkUserID: linkUserID, CreatedAt: time.Now().UTC()}
+	for _, value := range []*string{&request.State, &request.Nonce, &request.CodeVerifier} {
+		generated, err := s.tokenGenerator.Generate()
+		if err != nil {
+			return "", "", err
+		}
+		*value = generated
+	}
+
+	authURL, err := p.authCodeURL(ctx, request)
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := s.states.Save(ctx, request, s.stateTTL); err != nil {
+		return "", "", err
+	}
+	return authURL, request.State, nil
+}
+
+// Complete implements Service.
+func (s *service) Complete(ctx context.Context, providerName, state, code string) (*user.User, bool, error) {
+	p, ok := s.providers[providerName]
+	if !ok {
+		return nil, false, ErrUnknownProvider
+	}
+
+	// O state é consumido antes de qualquer validação, para que não possa ser reutilizado
+	request, err := s.states.Consume(ctx, state)
+	if err != nil {
+		return nil, false, err
+	}
+	if request == nil || request.Provider != providerName {
+		return nil, false, ErrInvalidState
+	}
+	if code == "" {
+		return nil, false, fmt.Errorf("%w: missing authorization code", ErrExchangeFailed)
+	}
+
+	rawIDToken, err := p.exchange(ctx, code, request.CodeVerifier)
+	if err != nil {
+		return nil, false, err
+	}
+
+	claims, err := p.verify(ctx, rawIDToken, request.Nonce)
+	if err != nil {
+		return nil, false, err
+	}
+
+	identity := &user.FederatedIdentity{
+		Protocol:      user.AuthProviderOIDC,
+		Provider:      providerName,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.emailVerified() || (claims.EmailVerified == nil && user.EmailInDomains(claims.Email, p.config.TrustedEmailDomains)),
+		Name:          claims.Name,
+	}
+	if request.LinkUserID != "" {
+		usr, err := s.userService.LinkFederatedIdentity(ctx, request.LinkUserID, identity)
+		if err != nil {
+			return nil, false, err
+		}
+		return usr, true, nil
+	}
+
+	usr, err := s.userService.AuthenticateFederated(ctx, identity)
+	if err != nil {
+		return nil, false, err
+	}
+	return usr, false, nil
+}
+
+func (s *service) providerURL(name string) string {
+	return s.baseURL + "/" + name
+}
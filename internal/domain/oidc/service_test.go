@@ -0,0 +1,476 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/juanjerrah/go_auth_api/internal/domain/user"
+)
+
+const (
+	testProvider     = "idp"
+	testClientID     = "api-client"
+	testClientSecret = "api-secret"
+	testBaseURL      = "https://api.example.com/api/auth/oidc"
+)
+
+// fakeIdP é um provedor OpenID Connect mínimo: descoberta, JWKS, e o endpoint
+// de token, que confere o PKCE e emite o ID token assinado com a chave atual.
+type fakeIdP struct {
+	t      *testing.T
+	server *httptest.Server
+
+	mu             sync.Mutex
+	issuer         string
+	keys           map[string]*rsa.PrivateKey
+	signingKid     string
+	authorizations map[string]authorization
+	claims         func(claims jwt.MapClaims)
+}
+
+type authorization struct {
+	challenge string
+	nonce     string
+}
+
+func newFakeIdP(t *testing.T) *fakeIdP {
+	t.Helper()
+
+	idp := &fakeIdP{t: t, authorizations: map[string]authorization{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", idp.discovery)
+	mux.HandleFunc("/jwks", idp.jwks)
+	mux.HandleFunc("/token", idp.token)
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+
+	idp.issuer = idp.server.URL
+	idp.rotate("key-1")
+	return idp
+}
+
+// rotate publica apenas a nova chave e passa a assinar com ela
+func (idp *fakeIdP) rotate(kid string) {
+	idp.t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		idp.t.Fatalf("generate key: %v", err)
+	}
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	idp.keys = map[string]*rsa.PrivateKey{kid: key}
+	idp.signingKid = kid
+}
+
+// authorize faz o papel do navegador no endpoint de autorização e retorna o
+// código emitido
+func (idp *fakeIdP) authorize(authURL string) string {
+	idp.t.Helper()
+
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		idp.t.Fatalf("parse authorization URL: %v", err)
+	}
+	query := parsed.Query()
+	if query.Get("code_challenge_method") != "S256" {
+		idp.t.Fatalf("code_challenge_method = %q, want S256", query.Get("code_challenge_method"))
+	}
+	if query.Get("client_id") != testClientID || query.Get("redirect_uri") != testBaseURL+"/"+testProvider+"/callback" {
+		idp.t.Fatalf("unexpected client in authorization URL: %s", authURL)
+	}
+
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	code := fmt.Sprintf("code-%d", len(idp.authorizations)+1)
+	idp.authorizations[code] = authorization{challenge: query.Get("code_challenge"), nonce: query.Get("nonce")}
+	return code
+}
+
+func (idp *fakeIdP) discovery(w http.ResponseWriter, r *http.Request) {
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string]string{
+		"issuer":                 idp.issuer,
+		"authorization_endpoint": idp.server.URL + "/authorize",
+		"token_endpoint":         idp.server.URL + "/token",
+		"jwks_uri":               idp.server.URL + "/jwks",
+	})
+}
+
+func (idp *fakeIdP) jwks(w http.ResponseWriter, r *http.Request) {
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+
+	keys := make([]map[string]string, 0, len(idp.keys))
+	for kid, key := range idp.keys {
+		keys = append(keys, map[string]string{
+			"kty": "RSA",
+			"kid": kid,
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"keys": keys})
+}
+
+func (idp *fakeIdP) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.Form.Get("grant_type") != "authorization_code" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+	if clientID, secret, ok := r.BasicAuth(); !ok || clientID != testClientID || secret != testClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+
+	grant, ok := idp.authorizations[r.Form.Get("code")]
+	delete(idp.authorizations, r.Form.Get("code"))
+	challenge := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(challenge[:]) != grant.challenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":            idp.server.URL,
+		"aud":            testClientID,
+		"sub":            "subject-1",
+		"email":          "ana@example.com",
+		"email_verified": true,
+		"name":           "Ana",
+		"nonce":          grant.nonce,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+	}
+	if idp.claims != nil {
+		idp.claims(claims)
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = idp.signingKid
+	signed, err := token.SignedString(idp.keys[idp.signingKid])
+	if err != nil {
+		idp.t.Errorf("sign id token: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"id_token": signed, "token_type": "Bearer"})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+type memoryStates struct {
+	mu       sync.Mutex
+	requests map[string]*AuthRequest
+}
+
+func (m *memoryStates) Save(ctx context.Context, request *AuthRequest, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	copied := *request
+	m.requests[request.State] = &copied
+	return nil
+}
+
+func (m *memoryStates) Consume(ctx context.Context, state string) (*AuthRequest, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	request := m.requests[state]
+	delete(m.requests, state)
+	return request, nil
+}
+
+type sequenceTokens struct {
+	mu   sync.Mutex
+	next int
+}
+
+func (g *sequenceTokens) Generate() (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.next++
+	return fmt.Sprintf("random-%d", g.next), nil
+}
+
+func (g *sequenceTokens) Hash(token string) string { return token }
+
+// recordingUsers registra a identidade recebida; os demais métodos de
+// user.Service não são usados pelo serviço OIDC
+type recordingUsers struct {
+	user.Service
+	identity *user.FederatedIdentity
+	linkedTo string
+}
+
+func (u *recordingUsers) AuthenticateFederated(ctx context.Context, identity *user.FederatedIdentity) (*user.User, error) {
+	u.identity = identity
+	return &user.User{Email: identity.Email}, nil
+}
+
+func (u *recordingUsers) LinkFederatedIdentity(ctx context.Context, id string, identity *user.FederatedIdentity) (*user.User, error) {
+	u.identity = identity
+	u.linkedTo = id
+	return &user.User{Email: identity.Email}, nil
+}
+
+type fixture struct {
+	idp     *fakeIdP
+	service *service
+	states  *memoryStates
+	users   *recordingUsers
+}
+
+func newFixture(t *testing.T, trustedDomains ...string) *fixture {
+	t.Helper()
+
+	idp := newFakeIdP(t)
+	states := &memoryStates{requests: map[string]*AuthRequest{}}
+	users := &recordingUsers{}
+	svc := NewService([]ProviderConfig{{
+		Name:                testProvider,
+		Issuer:              idp.server.URL,
+		ClientID:            testClientID,
+		ClientSecret:        testClientSecret,
+		TrustedEmailDomains: trustedDomains,
+	}}, testBaseURL, states, users, &sequenceTokens{}, idp.server.Client(), time.Minute)
+
+	return &fixture{idp: idp, service: svc.(*service), states: states, users: users}
+}
+
+// login percorre o fluxo completo: Begin, autorização no provedor e callback
+func (f *fixture) login(t *testing.T) (*user.User, bool, error) {
+	t.Helper()
+
+	authURL, state, err := f.service.Begin(context.Background(), testProvider)
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	code := f.idp.authorize(authURL)
+	return f.service.Complete(context.Background(), testProvider, state, code)
+}
+
+func TestCompleteValidatesIDToken(t *testing.T) {
+	tests := []struct {
+		name    string
+		claims  func(claims jwt.MapClaims)
+		tamper  func(request *AuthRequest)
+		wantErr error
+	}{
+		{name: "valid token"},
+		{
+			name:    "wrong issuer",
+			claims:  func(c jwt.MapClaims) { c["iss"] = "https://attacker.example.com" },
+			wantErr: ErrInvalidIDToken,
+		},
+		{
+			name:    "wrong audience",
+			claims:  func(c jwt.MapClaims) { c["aud"] = "another-client" },
+			wantErr: ErrInvalidIDToken,
+		},
+		{
+			name:    "multiple audiences without azp",
+			claims:  func(c jwt.MapClaims) { c["aud"] = []string{testClientID, "another-client"} },
+			wantErr: ErrInvalidIDToken,
+		},
+		{
+			name: "multiple audiences with azp",
+			claims: func(c jwt.MapClaims) {
+				c["aud"] = []string{testClientID, "another-client"}
+				c["azp"] = testClientID
+			},
+		},
+		{
+			name:    "nonce mismatch",
+			claims:  func(c jwt.MapClaims) { c["nonce"] = "replayed-nonce" },
+			wantErr: ErrInvalidIDToken,
+		},
+		{
+			name:    "missing nonce",
+			claims:  func(c jwt.MapClaims) { delete(c, "nonce") },
+			wantErr: ErrInvalidIDToken,
+		},
+		{
+			name:    "expired",
+			claims:  func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() },
+			wantErr: ErrInvalidIDToken,
+		},
+		{
+			name:    "missing subject",
+			claims:  func(c jwt.MapClaims) { delete(c, "sub") },
+			wantErr: ErrInvalidIDToken,
+		},
+		{
+			name:    "wrong PKCE verifier",
+			tamper:  func(r *AuthRequest) { r.CodeVerifier = "forged-verifier" },
+			wantErr: ErrExchangeFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			f.idp.claims = tt.claims
+
+			authURL, state, err := f.service.Begin(context.Background(), testProvider)
+			if err != nil {
+				t.Fatalf("Begin: %v", err)
+			}
+			code := f.idp.authorize(authURL)
+			if tt.tamper != nil {
+				tt.tamper(f.states.requests[state])
+			}
+
+			usr, linked, err := f.service.Complete(context.Background(), testProvider, state, code)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Complete error = %v, want %v", err, tt.wantErr)
+				}
+				if f.users.identity != nil {
+					t.Fatal("user service called for a rejected token")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Complete: %v", err)
+			}
+			if linked || usr.Email != "ana@example.com" {
+				t.Fatalf("Complete = (%+v, %v), want login of ana@example.com", usr, linked)
+			}
+			if got := f.users.identity; got.Subject != "subject-1" || got.Provider != testProvider || !got.EmailVerified {
+				t.Fatalf("identity = %+v", got)
+			}
+		})
+	}
+}
+
+func TestCompleteRejectsReusedState(t *testing.T) {
+	f := newFixture(t)
+
+	authURL, state, err := f.service.Begin(context.Background(), testProvider)
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	code := f.idp.authorize(authURL)
+	if _, _, err := f.service.Complete(context.Background(), testProvider, state, code); err != nil {
+		t.Fatalf("first Complete: %v", err)
+	}
+
+	if _, _, err := f.service.Complete(context.Background(), testProvider, state, code); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("second Complete error = %v, want %v", err, ErrInvalidState)
+	}
+}
+
+func TestDiscoveryRejectsIssuerMismatch(t *testing.T) {
+	f := newFixture(t)
+	f.idp.issuer = "https://attacker.example.com"
+
+	if _, _, err := f.service.Begin(context.Background(), testProvider); !errors.Is(err, ErrProviderUnavailable) {
+		t.Fatalf("Begin error = %v, want %v", err, ErrProviderUnavailable)
+	}
+}
+
+func TestCompleteFollowsJWKSRotation(t *testing.T) {
+	f := newFixture(t)
+	if _, _, err := f.login(t); err != nil {
+		t.Fatalf("login with the first key: %v", err)
+	}
+
+	// Logo após a última carga, um kid desconhecido não provoca outra
+	f.idp.rotate("key-2")
+	if _, _, err := f.login(t); !errors.Is(err, ErrInvalidIDToken) {
+		t.Fatalf("login right after rotation error = %v, want %v", err, ErrInvalidIDToken)
+	}
+
+	// Passado o intervalo, o JWKS é recarregado e a nova chave aceita
+	p := f.service.providers[testProvider]
+	p.mu.Lock()
+	p.keysAt = p.keysAt.Add(-jwksRefreshInterval)
+	p.mu.Unlock()
+	if _, _, err := f.login(t); err != nil {
+		t.Fatalf("login with the rotated key: %v", err)
+	}
+	if _, ok := p.keys["key-1"]; ok {
+		t.Fatal("retired key still cached after reloading the JWKS")
+	}
+}
+
+func TestCompleteEmailVerification(t *testing.T) {
+	tests := []struct {
+		name          string
+		email         string
+		emailVerified any
+		want          bool
+	}{
+		{name: "verified claim", email: "ana@other.com", emailVerified: true, want: true},
+		{name: "verified claim as string", email: "ana@other.com", emailVerified: "true", want: true},
+		{name: "unverified claim", email: "ana@other.com", emailVerified: false, want: false},
+		{name: "no claim, trusted domain", email: "ana@corp.example", want: true},
+		{name: "no claim, trusted domain in other case", email: "ana@CORP.example", want: true},
+		{name: "no claim, untrusted domain", email: "ana@other.com", want: false},
+		{name: "no claim, trusted domain as subdomain", email: "ana@evil.corp.example", want: false},
+		{name: "unverified claim, trusted domain", email: "ana@corp.example", emailVerified: false, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t, "corp.example")
+			f.idp.claims = func(c jwt.MapClaims) {
+				c["email"] = tt.email
+				if tt.emailVerified == nil {
+					delete(c, "email_verified")
+				} else {
+					c["email_verified"] = tt.emailVerified
+				}
+			}
+
+			if _, _, err := f.login(t); err != nil {
+				t.Fatalf("login: %v", err)
+			}
+			if got := f.users.identity.EmailVerified; got != tt.want {
+				t.Fatalf("EmailVerified = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBeginLinkLinksToSignedInUser(t *testing.T) {
+	f := newFixture(t)
+
+	authURL, state, err := f.service.BeginLink(context.Background(), testProvider, "user-42")
+	if err != nil {
+		t.Fatalf("BeginLink: %v", err)
+	}
+	code := f.idp.authorize(authURL)
+
+	_, linked, err := f.service.Complete(context.Background(), testProvider, state, code)
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if !linked || f.users.linkedTo != "user-42" {
+		t.Fatalf("Complete linked = %v to %q, want link to user-42", linked, f.users.linkedTo)
+	}
+}
@@ -0,0 +1,143 @@
+package organization
+
+import (
+	"time"
+
+	"github.com/juanjerrah/go_auth_api/internal/domain/user"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Organization struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name      string             `bson:"name" json:"name"`
+	Slug      string             `bson:"slug" json:"slug"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// Membership vincula um usuário a uma organização com uma role própria,
+// independente da role global do usuário.
+type Membership struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	OrganizationID primitive.ObjectID `bson:"organization_id" json:"organization_id"`
+	UserID         primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Role           user.Role          `bson:"role" json:"role"`
+	CreatedAt      time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt      time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+type InvitationStatus string
+
+const (
+	InvitationPending  InvitationStatus = "pending"
+	InvitationAccepted InvitationStatus = "accepted"
+	InvitationRevoked  InvitationStatus = "revoked"
+	InvitationExpired  InvitationStatus = "expired"
+)
+
+// Invitation convida um e-mail para entrar na organização. Apenas o hash do
+// token enviado no link é persistido.
+type Invitation struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	OrganizationID primitive.ObjectID `bson:"organization_id" json:"organization_id"`
+	Email          string             `bson:"email" json:"email"`
+	Role           user.Role          `bson:"role" json:"role"`
+	TokenHash      string             `bson:"token_hash" json:"-"`
+	InvitedBy      string             `bson:"invited_by" json:"invited_by"`
+	ExpiresAt      time.Time          `bson:"expires_at" json:"expires_at"`
+	AcceptedAt     *time.Time         `bson:"accepted_at,omitempty" json:"accepted_at,omitempty"`
+	AcceptedBy     string             `bson:"accepted_by,omitempty" json:"accepted_by,omitempty"`
+	RevokedAt      *time.Time         `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+	CreatedAt      time.Time          `bson:"created_at" json:"created_at"`
+}
+
+func (i *Invitation) Status(now time.Time) InvitationStatus {
+	switch {
+	case i.AcceptedAt != nil:
+		return InvitationAccepted
+	case i.RevokedAt != nil:
+		return InvitationRevoked
+	case now.After(i.ExpiresAt):
+		return InvitationExpired
+	default:
+		return InvitationPending
+	}
+}
+
+type CreateOrganizationRequest struct {
+	Name string `json:"name" binding:"required"`
+	Slug string `json:"slug" binding:"required,min=3,max=63"`
+}
+
+type AddMemberRequest struct {
+	Email string    `json:"email" binding:"required,email"`
+	Role  user.Role `json:"role" binding:"required,oneof=admin user"`
+}
+
+type UpdateMemberRoleRequest struct {
+	Role user.Role `json:"role" binding:"required,oneof=admin user"`
+}
+
+type SwitchOrganizationRequest struct {
+	OrganizationID string `json:"organization_id" binding:"required"`
+}
+
+type CreateInvitationRequest struct {
+	Email string    `json:"email" binding:"required,email"`
+	Role  user.Role `json:"role" binding:"required,oneof=admin user"`
+	// ExpiresInHours sobrescreve a validade padrão do convite
+	ExpiresInHours int `json:"expires_in_hours" binding:"omitempty,min=1,max=720"`
+}
+
+type ListInvitationsRequest struct {
+	Status InvitationStatus `form:"status" binding:"omitempty,oneof=pending accepted revoked expired"`
+}
+
+// AcceptInvitationRequest aceita um convite. Se já existir conta com o e-mail
+// convidado, a senha dela é exigida; caso contrário Name e Password são usados
+// para criar a conta.
+type AcceptInvitationRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Name     string `json:"name"`
+	Password string `json:"password" binding:"required,min=6"`
+}
+
+type InvitationResponse struct {
+	ID             string           `json:"id"`
+	OrganizationID string           `json:"organization_id"`
+	Email          string           `json:"email"`
+	Role           string           `json:"role"`
+	Status         InvitationStatus `json:"status"`
+	InvitedBy      string           `json:"invited_by"`
+	ExpiresAt      time.Time        `json:"expires_at"`
+	CreatedAt      time.Time        `json:"created_at"`
+}
+
+type ListMembersRequest struct {
+	Page  int `form:"page" binding:"omitempty,min=1"`
+	Limit int `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+type OrganizationResponse struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Slug      string    `json:"slug"`
+	Role      string    `json:"role,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type MemberResponse struct {
+	UserID   string    `json:"user_id"`
+	Name     string    `json:"name"`
+	Email    string    `json:"email"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+type ListMembersResponse struct {
+	Members []*MemberResponse `json:"members"`
+	Total   int64             `json:"total"`
+	Page    int               `json:"page"`
+	Limit   int               `json:"limit"`
+}
@@ -0,0 +1,305 @@
+package organization
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/juanjerrah/go_auth_api/internal/domain/audit"
+	"github.com/juanjerrah/go_auth_api/internal/domain/user"
+	"github.com/juanjerrah/go_auth_api/pkg/common"
+)
+
+var (
+	ErrInvitationNotFound    = errors.New("invitation not found")
+	ErrInvitationExpired     = errors.New("invitation expired or revoked")
+	ErrInvitationAlreadyUsed = errors.New("invitation already accepted")
+	ErrInvitationPending     = errors.New("a pending invitation already exists for this email")
+	ErrInvitationDelivery    = errors.New("failed to deliver invitation")
+	ErrNameRequired          = errors.New("name is required to create an account")
+)
+
+type InvitationService interface {
+	CreateInvitation(ctx context.Context, organizationID, inviterID string, req *CreateInvitationRequest) (*InvitationResponse, error)
+	ListInvitations(ctx context.Context, organizationID string, req *ListInvitationsRequest) ([]*InvitationResponse, error)
+	RevokeInvitation(ctx context.Context, organizationID, invitationID string) error
+	AcceptInvitation(ctx context.Context, req *AcceptInvitationRequest) (*MemberResponse, error)
+}
+
+type invitationService struct {
+	invitations InvitationRepository
+	repo        Repository
+	memberships MembershipRepository
+	userRepo    user.Repository
+	userService user.Service
+	mailer      common.Mailer
+	tokens      common.TokenGenerator
+	mongoUtils  common.MongoUtils
+	audit       audit.Service
+	baseURL     string
+	ttl         time.Duration
+}
+
+func NewInvitationService(
+	invitations InvitationRepository,
+	repo Repository,
+	memberships MembershipRepository,
+	userRepo user.Repository,
+	userService user.Service,
+	mailer common.Mailer,
+	tokens common.TokenGenerator,
+	mongoUtils common.MongoUtils,
+	auditService audit.Service,
+	baseURL string,
+	ttl time.Duration,
+) InvitationService {
+	return &invitationService{
+		invitations: invitations,
+		repo:        repo,
+		memberships: memberships,
+		userRepo:    userRepo,
+		userService: userService,
+		mailer:      mailer,
+		tokens:      tokens,
+		mongoUtils:  mongoUtils,
+		audit:       auditService,
+		baseURL:     strings.TrimRight(baseURL, "/"),
+		ttl:         ttl,
+	}
+}
+
+// CreateInvitation implements InvitationService.
+func (s *invitationService) CreateInvitation(ctx context.Context, organizationID, inviterID string, req *CreateInvitationRequest) (*InvitationResponse, error) {
+	org, err := s.repo.FindByID(ctx, organizationID)
+	if err != nil {
+		return nil, ErrOrganizationNotFound
+	}
+
+	email := strings.TrimSpace(req.Email)
+	if existing, err := s.userRepo.FindByEmail(ctx, email); err == nil {
+		if _, err := s.memberships.Find(ctx, organizationID, existing.ID.Hex()); err == nil {
+			return nil, ErrAlreadyMember
+		}
+	}
+	if _, err := s.invitations.FindPendingByEmail(ctx, organizationID, email); err == nil {
+		return nil, ErrInvitationPending
+	}
+
+	token, err := s.tokens.Generate()
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := s.ttl
+	if req.ExpiresInHours > 0 {
+		ttl = time.Duration(req.ExpiresInHours) * time.Hour
+	}
+
+	now := time.Now().UTC()
+	invitation := &Invitation{
+		ID:             s.mongoUtils.GenerateObjectID(),
+		OrganizationID: org.ID,
+		Email:          email,
+		Role:           req.Role,
+		TokenHash:      s.tokens.Hash(token),
+		InvitedBy:      inviterID,
+		ExpiresAt:      now.Add(ttl),
+		CreatedAt:      now,
+	}
+	if err := s.invitations.Create(ctx, invitation); err != nil {
+		return nil, err
+	}
+
+	if err := s.mailer.Send(ctx, s.invitationMessage(org, invitation, token)); err != nil {
+		log.Printf("Warning: failed to send invitation %s: %v", invitation.ID.Hex(), err)
+		// O link nunca chegou ao destinatário; revogar evita um convite pendente órfão
+		invitation.RevokedAt = &now
+		if err := s.invitations.Update(ctx, invitation); err != nil {
+			log.Printf("Warning: failed to revoke undelivered invitation %s: %v", invitation.ID.Hex(), err)
+		}
+		return nil, ErrInvitationDelivery
+	}
+
+	s.record(ctx, &audit.Event{
+		Type: audit.EventInvitationCreated,
+		Metadata: map[string]string{
+			"organization_id": organizationID,
+			"invitation_id":   invitation.ID.Hex(),
+			"email":           email,
+			"role":            string(req.Role),
+		},
+	})
+
+	return s.toResponse(invitation, now), nil
+}
+
+// ListInvitations implements InvitationService.
+func (s *invitationService) ListInvitations(ctx context.Context, organizationID string, req *ListInvitationsRequest) ([]*InvitationResponse, error) {
+	invitations, err := s.invitations.ListByOrganization(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	response := make([]*InvitationResponse, 0, len(invitations))
+	for _, invitation := range invitations {
+		if req.Status != "" && invitation.Status(now) != req.Status {
+			continue
+		}
+		response = append(response, s.toResponse(invitation, now))
+	}
+	return response, nil
+}
+
+// RevokeInvitation implements InvitationService.
+func (s *invitationService) RevokeInvitation(ctx context.Context, organizationID, invitationID string) error {
+	invitation, err := s.invitations.FindByID(ctx, invitationID)
+	if err != nil || invitation.OrganizationID.Hex() != organizationID {
+		return ErrInvitationNotFound
+	}
+
+	now := time.Now().UTC()
+	switch invitation.Status(now) {
+	case InvitationAccepted:
+		return ErrInvitationAlreadyUsed
+	case InvitationRevoked:
+		return nil
+	}
+
+	invitation.RevokedAt = &now
+	if err := s.invitations.Update(ctx, invitation); err != nil {
+		return err
+	}
+
+	s.record(ctx, &audit.Event{
+		Type: audit.EventInvitationRevoked,
+		Metadata: map[string]string{
+			"organization_id": organizationID,
+			"invitation_id":   invitationID,
+			"email":           invitation.Email,
+		},
+	})
+
+	return nil
+}
+
+// AcceptInvitation implements InvitationService.
+func (s *invitationService) AcceptInvitation(ctx context.Context, req *AcceptInvitationRequest) (*MemberResponse, error) {
+	invitation, err := s.invitations.FindByTokenHash(ctx, s.tokens.Hash(req.Token))
+	if err != nil {
+		return nil, ErrInvitationNotFound
+	}
+
+	now := time.Now().UTC()
+	switch invitation.Status(now) {
+	case InvitationAccepted:
+		return nil, ErrInvitationAlreadyUsed
+	case InvitationRevoked, InvitationExpired:
+		return nil, ErrInvitationExpired
+	}
+
+	usr, err := s.resolveUser(ctx, invitation, req)
+	if err != nil {
+		return nil, err
+	}
+
+	organizationID := invitation.OrganizationID.Hex()
+	membership, err := s.memberships.Find(ctx, organizationID, usr.ID.Hex())
+	if err != nil {
+		membership = &Membership{
+			ID:             s.mongoUtils.GenerateObjectID(),
+			OrganizationID: invitation.OrganizationID,
+			UserID:         usr.ID,
+			Role:           invitation.Role,
+			CreatedAt:      now,
+			UpdatedAt:      now,
+		}
+		if err := s.memberships.Create(ctx, membership); err != nil {
+			return nil, err
+		}
+	}
+
+	invitation.AcceptedAt = &now
+	invitation.AcceptedBy = usr.ID.Hex()
+	if err := s.invitations.Update(ctx, invitation); err != nil {
+		return nil, err
+	}
+
+	s.record(ctx, &audit.Event{
+		Type:     audit.EventInvitationAccepted,
+		ActorID:  usr.ID.Hex(),
+		TargetID: usr.ID.Hex(),
+		Metadata: map[string]string{
+			"organization_id": organizationID,
+			"invitation_id":   invitation.ID.Hex(),
+			"role":            string(membership.Role),
+		},
+	})
+
+	return &MemberResponse{
+		UserID:   usr.ID.Hex(),
+		Name:     usr.Name,
+		Email:    usr.Email,
+		Role:     string(membership.Role),
+		JoinedAt: membership.CreatedAt,
+	}, nil
+}
+
+// resolveUser vincula o convite a uma conta existente, validando a senha, ou
+// registra uma nova conta pelo fluxo normal de criação de usuários.
+func (s *invitationService) resolveUser(ctx context.Context, invitation *Invitation, req *AcceptInvitationRequest) (*user.User, error) {
+	if _, err := s.userRepo.FindByEmail(ctx, invitation.Email); err == nil {
+		return s.userService.Authenticate(ctx, invitation.Email, req.Password)
+	}
+
+	if strings.TrimSpace(req.Name) == "" {
+		return nil, ErrNameRequired
+	}
+
+	created, err := s.userService.CreateUser(ctx, &user.CreateUserRequest{
+		Name:     req.Name,
+		Email:    invitation.Email,
+		Password: req.Password,
+		Role:     user.RoleUser,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.userRepo.FindByID(ctx, created.ID)
+}
+
+func (s *invitationService) invitationMessage(org *Organization, invitation *Invitation, token string) *common.EmailMessage {
+	link := fmt.Sprintf("%s/invitations/accept?token=%s", s.baseURL, token)
+
+	return &common.EmailMessage{
+		To:      invitation.Email,
+		Subject: fmt.Sprintf("You have been invited to join %s", org.Name),
+		Body: fmt.Sprintf(
+			"You have been invited to join %s as %s.\n\nAccept the invitation: %s\n\nThis link expires on %s.\n",
+			org.Name, invitation.Role, link, invitation.ExpiresAt.Format(time.RFC1123),
+		),
+	}
+}
+
+func (s *invitationService) record(ctx context.Context, event *audit.Event) {
+	if err := s.audit.Record(ctx, event); err != nil {
+		log.Printf("Warning: failed to record audit event %s: %v", event.Type, err)
+	}
+}
+
+func (s *invitationService) toResponse(invitation *Invitation, now time.Time) *InvitationResponse {
+	return &InvitationResponse{
+		ID:             invitation.ID.Hex(),
+		OrganizationID: invitation.OrganizationID.Hex(),
+		Email:          invitation.Email,
+		Role:           string(invitation.Role),
+		Status:         invitation.Status(now),
+		InvitedBy:      invitation.InvitedBy,
+		ExpiresAt:      invitation.ExpiresAt,
+		CreatedAt:      invitation.CreatedAt,
+	}
+}
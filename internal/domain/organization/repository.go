@@ -0,0 +1,33 @@
+package organization
+
+import (
+	"context"
+
+	"github.com/juanjerrah/go_auth_api/internal/domain/user"
+)
+
+type Repository interface {
+	Create(ctx context.Context, org *Organization) error
+	FindByID(ctx context.Context, id string) (*Organization, error)
+	FindByIDs(ctx context.Context, ids []string) ([]*Organization, error)
+	ExistsBySlug(ctx context.Context, slug string) (bool, error)
+}
+
+type MembershipRepository interface {
+	Create(ctx context.Context, membership *Membership) error
+	Find(ctx context.Context, organizationID, userID string) (*Membership, error)
+	ListByOrganization(ctx context.Context, organizationID string) ([]*Membership, error)
+	ListByUser(ctx context.Context, userID string) ([]*Membership, error)
+	Update(ctx context.Context, membership *Membership) error
+	Delete(ctx context.Context, organizationID, userID string) error
+	CountByRole(ctx context.Context, organizationID string, role user.Role) (int64, error)
+}
+
+type InvitationRepository interface {
+	Create(ctx context.Context, invitation *Invitation) error
+	FindByID(ctx context.Context, id string) (*Invitation, error)
+	FindByTokenHash(ctx context.Context, tokenHash string) (*Invitation, error)
+	FindPendingByEmail(ctx context.Context, organizationID, email string) (*Invitation, error)
+	ListByOrganization(ctx context.Context, organizationID string) ([]*Invitation, error)
+	Update(ctx context.Context, invitation *Invitation) error
+}
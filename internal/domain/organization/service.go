@@ -0,0 +1,355 @@
+package organization
+
+import (
+	"context"
+	"errors"
+	"log"
+	"regexp"
+	"time"
+
+	"github.com/juanjerrah/go_auth_api/internal/domain/audit"
+	"github.com/juanjerrah/go_auth_api/internal/domain/user"
+	"github.com/juanjerrah/go_auth_api/pkg/common"
+)
+
+const (
+	defaultPage  = 1
+	defaultLimit = 20
+)
+
+var (
+	ErrOrganizationNotFound  = errors.New("organization not found")
+	ErrSlugAlreadyInUse      = errors.New("slug already in use")
+	ErrInvalidSlug           = errors.New("invalid slug")
+	ErrNotMember             = errors.New("user is not a member of this organization")
+	ErrAlreadyMember         = errors.New("user is already a member of this organization")
+	ErrLastOrganizationAdmin = errors.New("cannot remove the last organization admin")
+)
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+type Service interface {
+	CreateOrganization(ctx context.Context, ownerID string, req *CreateOrganizationRequest) (*OrganizationResponse, error)
+	GetOrganization(ctx context.Context, id string) (*OrganizationResponse, error)
+	ListUserOrganizations(ctx context.Context, userID string) ([]*OrganizationResponse, error)
+	GetMembership(ctx context.Context, organizationID, userID string) (*Membership, error)
+	GetMember(ctx context.Context, organizationID, userID string) (*MemberResponse, error)
+	ListMembers(ctx context.Context, organizationID string, req *ListMembersRequest) (*ListMembersResponse, error)
+	AddMember(ctx context.Context, organizationID string, req *AddMemberRequest) (*MemberResponse, error)
+	UpdateMemberRole(ctx context.Context, organizationID, userID string, role user.Role) error
+	RemoveMember(ctx context.Context, organizationID, userID string) error
+}
+
+type service struct {
+	repo        Repository
+	memberships MembershipRepository
+	userRepo    user.Repository
+	mongoUtils  common.MongoUtils
+	audit       audit.Service
+	sessions    user.SessionRevoker
+}
+
+func NewService(repo Repository, memberships MembershipRepository, userRepo user.Repository, mongoUtils common.MongoUtils, auditService audit.Service, sessions user.SessionRevoker) Service {
+	return &service{
+		repo:        repo,
+		memberships: memberships,
+		userRepo:    userRepo,
+		mongoUtils:  mongoUtils,
+		audit:       auditService,
+		sessions:    sessions,
+	}
+}
+
+// CreateOrganization implements Service.
+func (s *service) CreateOrganization(ctx context.Context, ownerID string, req *CreateOrganizationRequest) (*OrganizationResponse, error) {
+	if !slugPattern.MatchString(req.Slug) {
+		return nil, ErrInvalidSlug
+	}
+
+	exists, err := s.repo.ExistsBySlug(ctx, req.Slug)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrSlugAlreadyInUse
+	}
+
+	now := time.Now().UTC()
+	org := &Organization{
+		ID:        s.mongoUtils.GenerateObjectID(),
+		Name:      req.Name,
+		Slug:      req.Slug,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.repo.Create(ctx, org); err != nil {
+		return nil, err
+	}
+
+	// Quem cria a organização se torna o primeiro admin dela
+	owner := &Membership{
+		ID:             s.mongoUtils.GenerateObjectID(),
+		OrganizationID: org.ID,
+		UserID:         s.mongoUtils.ToObjectID(ownerID),
+		Role:           user.RoleAdmin,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := s.memberships.Create(ctx, owner); err != nil {
+		return nil, err
+	}
+
+	s.record(ctx, &audit.Event{
+		Type:     audit.EventOrganizationCreated,
+		TargetID: ownerID,
+		Metadata: map[string]string{"organization_id": org.ID.Hex(), "slug": org.Slug},
+	})
+
+	return s.toResponse(org, owner.Role), nil
+}
+
+// GetOrganization implements Service.
+func (s *service) GetOrganization(ctx context.Context, id string) (*OrganizationResponse, error) {
+	org, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, ErrOrganizationNotFound
+	}
+	return s.toResponse(org, ""), nil
+}
+
+// ListUserOrganizations implements Service.
+func (s *service) ListUserOrganizations(ctx context.Context, userID string) ([]*OrganizationResponse, error) {
+	memberships, err := s.memberships.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	roles := make(map[string]user.Role, len(memberships))
+	ids := make([]string, 0, len(memberships))
+	for _, membership := range memberships {
+		roles[membership.OrganizationID.Hex()] = membership.Role
+		ids = append(ids, membership.OrganizationID.Hex())
+	}
+
+	orgs, err := s.repo.FindByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]*OrganizationResponse, 0, len(orgs))
+	for _, org := range orgs {
+		response = append(response, s.toResponse(org, roles[org.ID.Hex()]))
+	}
+	return response, nil
+}
+
+// GetMembership implements Service.
+func (s *service) GetMembership(ctx context.Context, organizationID, userID string) (*Membership, error) {
+	membership, err := s.memberships.Find(ctx, organizationID, userID)
+	if err != nil {
+		return nil, ErrNotMember
+	}
+	return membership, nil
+}
+
+// GetMember implements Service.
+func (s *service) GetMember(ctx context.Context, organizationID, userID string) (*MemberResponse, error) {
+	usr, err := s.userRepo.FindByIDInTenant(ctx, organizationID, userID)
+	if err != nil {
+		return nil, ErrNotMember
+	}
+
+	membership, err := s.memberships.Find(ctx, organizationID, userID)
+	if err != nil {
+		return nil, ErrNotMember
+	}
+
+	return s.toMemberResponse(usr, membership), nil
+}
+
+// ListMembers implements Service.
+func (s *service) ListMembers(ctx context.Context, organizationID string, req *ListMembersRequest) (*ListMembersResponse, error) {
+	page, limit := req.Page, req.Limit
+	if page <= 0 {
+		page = defaultPage
+	}
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+
+	users, total, err := s.userRepo.ListByTenant(ctx, organizationID, page, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	memberships, err := s.memberships.ListByOrganization(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	byUser := make(map[string]*Membership, len(memberships))
+	for _, membership := range memberships {
+		byUser[membership.UserID.Hex()] = membership
+	}
+
+	members := make([]*MemberResponse, 0, len(users))
+	for _, usr := range users {
+		if membership, ok := byUser[usr.ID.Hex()]; ok {
+			members = append(members, s.toMemberResponse(usr, membership))
+		}
+	}
+
+	return &ListMembersResponse{
+		Members: members,
+		Total:   total,
+		Page:    page,
+		Limit:   limit,
+	}, nil
+}
+
+// AddMember implements Service.
+func (s *service) AddMember(ctx context.Context, organizationID string, req *AddMemberRequest) (*MemberResponse, error) {
+	if _, err := s.repo.FindByID(ctx, organizationID); err != nil {
+		return nil, ErrOrganizationNotFound
+	}
+
+	usr, err := s.userRepo.FindByEmail(ctx, req.Email)
+	if err != nil {
+		return nil, user.ErrUserNotFound
+	}
+
+	if _, err := s.memberships.Find(ctx, organizationID, usr.ID.Hex()); err == nil {
+		return nil, ErrAlreadyMember
+	}
+
+	now := time.Now().UTC()
+	membership := &Membership{
+		ID:             s.mongoUtils.GenerateObjectID(),
+		OrganizationID: s.mongoUtils.ToObjectID(organizationID),
+		UserID:         usr.ID,
+		Role:           req.Role,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := s.memberships.Create(ctx, membership); err != nil {
+		return nil, err
+	}
+
+	s.record(ctx, &audit.Event{
+		Type:     audit.EventMemberAdded,
+		TargetID: usr.ID.Hex(),
+		Metadata: map[string]string{"organization_id": organizationID, "role": string(req.Role)},
+	})
+
+	return s.toMemberResponse(usr, membership), nil
+}
+
+// UpdateMemberRole implements Service.
+func (s *service) UpdateMemberRole(ctx context.Context, organizationID, userID string, role user.Role) error {
+	membership, err := s.memberships.Find(ctx, organizationID, userID)
+	if err != nil {
+		return ErrNotMember
+	}
+
+	if membership.Role == role {
+		return nil
+	}
+
+	if err := s.ensureNotLastAdmin(ctx, membership); err != nil {
+		return err
+	}
+
+	previousRole := membership.Role
+	membership.Role = role
+	membership.UpdatedAt = time.Now().UTC()
+	if err := s.memberships.Update(ctx, membership); err != nil {
+		return err
+	}
+
+	// A role na organização fica gravada na sessão
+	if err := s.sessions.InvalidateUserTokens(ctx, userID); err != nil {
+		log.Printf("Warning: failed to invalidate sessions after membership change of user %s: %v", userID, err)
+	}
+
+	s.record(ctx, &audit.Event{
+		Type:     audit.EventMemberRoleChanged,
+		TargetID: userID,
+		Metadata: map[string]string{
+			"organization_id": organizationID,
+			"from":            string(previousRole),
+			"to":              string(role),
+		},
+	})
+
+	return nil
+}
+
+// RemoveMember implements Service.
+func (s *service) RemoveMember(ctx context.Context, organizationID, userID string) error {
+	membership, err := s.memberships.Find(ctx, organizationID, userID)
+	if err != nil {
+		return ErrNotMember
+	}
+
+	if err := s.ensureNotLastAdmin(ctx, membership); err != nil {
+		return err
+	}
+
+	if err := s.memberships.Delete(ctx, organizationID, userID); err != nil {
+		return err
+	}
+
+	if err := s.sessions.InvalidateUserTokens(ctx, userID); err != nil {
+		log.Printf("Warning: failed to invalidate sessions after membership removal of user %s: %v", userID, err)
+	}
+
+	s.record(ctx, &audit.Event{
+		Type:     audit.EventMemberRemoved,
+		TargetID: userID,
+		Metadata: map[string]string{"organization_id": organizationID},
+	})
+
+	return nil
+}
+
+func (s *service) ensureNotLastAdmin(ctx context.Context, membership *Membership) error {
+	if membership.Role != user.RoleAdmin {
+		return nil
+	}
+
+	admins, err := s.memberships.CountByRole(ctx, membership.OrganizationID.Hex(), user.RoleAdmin)
+	if err != nil {
+		return err
+	}
+	if admins <= 1 {
+		return ErrLastOrganizationAdmin
+	}
+
+	return nil
+}
+
+func (s *service) record(ctx context.Context, event *audit.Event) {
+	if err := s.audit.Record(ctx, event); err != nil {
+		log.Printf("Warning: failed to record audit event %s: %v", event.Type, err)
+	}
+}
+
+func (s *service) toResponse(org *Organization, role user.Role) *OrganizationResponse {
+	return &OrganizationResponse{
+		ID:        org.ID.Hex(),
+		Name:      org.Name,
+		Slug:      org.Slug,
+		Role:      string(role),
+		CreatedAt: org.CreatedAt,
+		UpdatedAt: org.UpdatedAt,
+	}
+}
+
+func (s *service) toMemberResponse(usr *user.User, membership *Membership) *MemberResponse {
+	return &MemberResponse{
+		UserID:   usr.ID.Hex(),
+		Name:     usr.Name,
+		Email:    usr.Email,
+		Role:     string(membership.Role),
+		JoinedAt: membership.CreatedAt,
+	}
+}
@@ -0,0 +1,46 @@
+package otp
+
+import "time"
+
+// Canais de entrega dos códigos
+const (
+	ChannelEmail = "email"
+	ChannelSMS   = "sms"
+)
+
+// Finalidades dos códigos; fazem parte da chave no repositório, para que um
+// código de cadastro não sirva no login e vice-versa. purposeAccount é o
+// código enviado ao e-mail da conta de quem não cadastrou segundo fator.
+const (
+	purposeLogin      = "login"
+	purposeEnrollment = "enroll"
+	purposeAccount    = "account"
+)
+
+// Code é um código pendente. Hash é o HMAC do código, que nunca é guardado
+// em claro; Destination é para onde ele foi enviado.
+type Code struct {
+	UserID      string    `json:"user_id"`
+	Channel     string    `json:"channel"`
+	Destination string    `json:"destination"`
+	Hash        string    `json:"hash"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// SendResponse informa o envio de um código, com o destino mascarado
+type SendResponse struct {
+	Channel     string    `json:"channel"`
+	Destination string    `json:"destination"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+type EnrollRequest struct {
+	Channel string `json:"channel" binding:"required,oneof=email sms"`
+	// PhoneNumber é obrigatório no canal sms, no formato E.164 (ex.: +5511999998888)
+	PhoneNumber string `json:"phone_number"`
+}
+
+type VerifyEnrollmentRequest struct {
+	Channel string `json:"channel" binding:"required,oneof=email sms"`
+	Code    string `json:"code" binding:"required"`
+}
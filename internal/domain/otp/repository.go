@@ -0,0 +1,22 @@
+package otp
+
+import (
+	"context"
+	"time"
+)
+
+// Repository guarda os códigos pendentes, as tentativas de verificação de
+// cada código e os contadores de envio por usuário, todos com expiração.
+type Repository interface {
+	// Save guarda o código, substituindo o pendente e zerando suas tentativas
+	Save(ctx context.Context, key string, code *Code, ttl time.Duration) error
+	Find(ctx context.Context, key string) (*Code, error)
+	// CountAttempt incrementa e retorna as tentativas de verificação do código
+	CountAttempt(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	// Delete remove o código e informa se ele ainda existia, garantindo que
+	// apenas uma verificação o consuma
+	Delete(ctx context.Context, key string) (bool, error)
+	// CountSend incrementa e retorna o número de códigos enviados ao usuário
+	// dentro da janela
+	CountSend(ctx context.Context, userID string, window time.Duration) (int64, error)
+}
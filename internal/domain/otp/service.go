@@ -0,0 +1,455 @@
+package otp
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/juanjerrah/go_auth_api/internal/domain/audit"
+	"github.com/juanjerrah/go_auth_api/internal/domain/user"
+	"github.com/juanjerrah/go_auth_api/pkg/common"
+)
+
+var (
+	ErrInvalidCode        = errors.New("invalid or expired verification code")
+	ErrTooManyAttempts    = errors.New("too many verification attempts")
+	ErrRateLimited        = errors.New("too many verification codes requested")
+	ErrFactorNotFound     = errors.New("otp factor not found")
+	ErrInvalidPhoneNumber = errors.New("invalid phone number")
+	ErrDeliveryFailed     = errors.New("failed to deliver verification code")
+)
+
+// Telefones no formato E.164, depois de removidos espaços e separadores
+var phonePattern = regexp.MustCompile(`^\+[1-9][0-9]{7,14}$`)
+
+type Service interface {
+	// BeginEnrollment envia um código para confirmar o cadastro do canal. No
+	// e-mail, o destino é sempre o endereço da conta.
+	BeginEnrollment(ctx context.Context, userID string, req *EnrollRequest) (*SendResponse, error)
+	// FinishEnrollment confere o código e cadastra o canal, substituindo o
+	// destino anterior do mesmo canal
+	FinishEnrollment(ctx context.Context, userID string, req *VerifyEnrollmentRequest) (*user.OTPFactor, error)
+	ListFactors(ctx context.Context, userID string) ([]user.OTPFactor, error)
+	RemoveFactor(ctx context.Context, userID, channel string) error
+	// SendLoginCode envia um código ao canal cadastrado, no segundo passo do login
+	SendLoginCode(ctx context.Context, userID, channel string) (*SendResponse, error)
+	// VerifyLoginCode confere o código do login e retorna o usuário
+	VerifyLoginCode(ctx context.Context, userID, channel, code string) (*user.User, error)
+	// SendAccountCode envia um código ao e-mail da conta, sem canal
+	// cadastrado, quando o login de risco exige segundo fator
+	SendAccountCode(ctx context.Context, userID string) (*SendResponse, error)
+	// VerifyAccountCode confere o código enviado por SendAccountCode
+	VerifyAccountCode(ctx context.Context, userID, code string) (*user.User, error)
+}
+
+type service struct {
+	codes       Repository
+	userRepo    user.Repository
+	mailer      common.Mailer
+	sms         common.SMSSender
+	audit       audit.Service
+	secret      []byte
+	length      int
+	ttl         time.Duration
+	maxAttempts int64
+	rateLimit   int64
+	rateWindow  time.Duration
+}
+
+// NewService cria o serviço de códigos de uso único. secret assina os hashes
+// dos códigos; cada código tem length dígitos, expira após ttl e aceita até
+// maxAttempts tentativas. Cada usuário pode pedir até rateLimit códigos por
+// rateWindow.
+func NewService(
+	codes Repository,
+	userRepo user.Repository,
+	mailer common.Mailer,
+	sms common.SMSSender,
+	auditService audit.Service,
+	secret string,
+	length int,
+	ttl time.Duration,
+	maxAttempts int,
+	rateLimit int,
+	rateWindow time.Duration,
+) Service {
+	return &service{
+		codes:       codes,
+		userRepo:    userRepo,
+		mailer:      mailer,
+		sms:         sms,
+		audit:       auditService,
+		secret:      []byte(secret),
+		length:      length,
+		ttl:         ttl,
+		maxAttempts: int64(maxAttempts),
+		rateLimit:   int64(rateLimit),
+		rateWindow:  rateWindow,
+	}
+}
+
+// BeginEnrollment implements Service.
+func (s *service) BeginEnrollment(ctx context.Context, userID string, req *EnrollRequest) (*SendResponse, error) {
+	usr, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, user.ErrUserNotFound
+	}
+
+	destination := usr.Email
+	if req.Channel == ChannelSMS {
+		destination = normalizePhone(req.PhoneNumber)
+		if !phonePattern.MatchString(destination) {
+			return nil, ErrInvalidPhoneNumber
+		}
+	}
+
+	return s.send(ctx, usr, purposeEnrollment, req.Channel, destination)
+}
+
+// FinishEnrollment implements Service.
+func (s *service) FinishEnrollment(ctx context.Context, userID string, req *VerifyEnrollmentRequest) (*user.OTPFactor, error) {
+	code, err := s.verify(ctx, key(purposeEnrollment, userID, req.Channel), req.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	usr, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, user.ErrUserNotFound
+	}
+
+	now := time.Now().UTC()
+	factor := user.OTPFactor{Channel: code.Channel, Destination: code.Destination, CreatedAt: now}
+	factors := make([]user.OTPFactor, 0, len(usr.OTPFactors)+1)
+	for _, existing := range usr.OTPFactors {
+		if existing.Channel != factor.Channel {
+			factors = append(factors, existing)
+		}
+	}
+	usr.OTPFactors = append(factors, factor)
+	usr.UpdatedAt = now
+	if err := s.userRepo.Update(ctx, usr); err != nil {
+		return nil, err
+	}
+
+	s.record(ctx, &audit.Event{
+		Type:     audit.EventOTPEnrolled,
+		TargetID: userID,
+		Metadata: map[string]string{"channel": factor.Channel, "destination": mask(factor.Channel, factor.Destination)},
+	})
+
+	return &factor, nil
+}
+
+// ListFactors implements Service.
+func (s *service) ListFactors(ctx context.Context, userID string) ([]user.OTPFactor, error) {
+	usr, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, user.ErrUserNotFound
+	}
+	if usr.OTPFactors == nil {
+		return []user.OTPFactor{}, nil
+	}
+	return usr.OTPFactors, nil
+}
+
+// RemoveFactor implements Service.
+func (s *service) RemoveFactor(ctx context.Context, userID, channel string) error {
+	usr, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return user.ErrUserNotFound
+	}
+
+	remaining := make([]user.OTPFactor, 0, len(usr.OTPFactors))
+	for _, factor := range usr.OTPFactors {
+		if factor.Channel != channel {
+			remaining = append(remaining, factor)
+		}
+	}
+	if len(remaining) == len(usr.OTPFactors) {
+		return ErrFactorNotFound
+	}
+
+	usr.OTPFactors = remaining
+	usr.UpdatedAt = time.Now().UTC()
+	if err := s.userRepo.Update(ctx, usr); err != nil {
+		return err
+	}
+
+	s.record(ctx, &audit.Event{
+		Type:     audit.EventOTPRemoved,
+		TargetID: userID,
+		Metadata: map[string]string{"channel": channel},
+	})
+	return nil
+}
+
+// SendLoginCode implements Service.
+func (s *service) SendLoginCode(ctx context.Context, userID, channel string) (*SendResponse, error) {
+	usr, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, user.ErrUserNotFound
+	}
+	factor := findFactor(usr, channel)
+	if factor == nil {
+		return nil, ErrFactorNotFound
+	}
+
+	return s.send(ctx, usr, purposeLogin, channel, factor.Destination)
+}
+
+// VerifyLoginCode implements Service.
+func (s *service) VerifyLoginCode(ctx context.Context, userID, channel, code string) (*user.User, error) {
+	pending, err := s.verify(ctx, key(purposeLogin, userID, channel), code)
+	if err != nil {
+		return nil, err
+	}
+
+	usr, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, user.ErrUserNotFound
+	}
+	// O canal pode ter sido removido ou trocado depois do envio
+	factor := findFactor(usr, channel)
+	if factor == nil || factor.Destination != pending.Destination {
+		return nil, ErrInvalidCode
+	}
+	if usr.Disabled {
+		return nil, user.ErrUserDisabled
+	}
+
+	now := time.Now().UTC()
+	factor.LastUsedAt = &now
+	if err := s.userRepo.Update(ctx, usr); err != nil {
+		log.Printf("Warning: failed to update otp factor of user %s: %v", userID, err)
+	}
+
+	return usr, nil
+}
+
+// SendAccountCode implements Service.
+func (s *service) SendAccountCode(ctx context.Context, userID string) (*SendResponse, error) {
+	usr, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, user.ErrUserNotFound
+	}
+
+	return s.send(ctx, usr, purposeAccount, ChannelEmail, usr.Email)
+}
+
+// VerifyAccountCode implements Service.
+func (s *service) VerifyAccountCode(ctx context.Context, userID, code string) (*user.User, error) {
+	pending, err := s.verify(ctx, key(purposeAccount, userID, ChannelEmail), code)
+	if err != nil {
+		return nil, err
+	}
+
+	usr, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, user.ErrUserNotFound
+	}
+	// O e-mail da conta pode ter mudado depois do envio
+	if usr.Email != pending.Destination {
+		return nil, ErrInvalidCode
+	}
+	if usr.Disabled {
+		return nil, user.ErrUserDisabled
+	}
+
+	return usr, nil
+}
+
+// send gera um código para a finalidade e o entrega pelo canal
+func (s *service) send(ctx context.Context, usr *user.User, purpose, channel, destination string) (*SendResponse, error) {
+	userID := usr.ID.Hex()
+
+	count, err := s.codes.CountSend(ctx, userID, s.rateWindow)
+	if err != nil {
+		return nil, err
+	}
+	if count > s.rateLimit {
+		return nil, ErrRateLimited
+	}
+
+	value, err := s.generate()
+	if err != nil {
+		return nil, err
+	}
+
+	codeKey := key(purpose, userID, channel)
+	expiresAt := time.Now().UTC().Add(s.ttl)
+	code := &Code{
+		UserID:      userID,
+		Channel:     channel,
+		Destination: destination,
+		Hash:        s.hash(codeKey, value),
+		ExpiresAt:   expiresAt,
+	}
+	if err := s.codes.Save(ctx, codeKey, code, s.ttl); err != nil {
+		return nil, err
+	}
+
+	if err := s.deliver(ctx, usr, purpose, channel, destination, value); err != nil {
+		log.Printf("Warning: failed to send %s code to user %s: %v", channel, userID, err)
+		// O código nunca chegou ao destinatário e não deve ficar pendente
+		if _, err := s.codes.Delete(ctx, codeKey); err != nil {
+			log.Printf("Warning: failed to discard undelivered code: %v", err)
+		}
+		return nil, ErrDeliveryFailed
+	}
+
+	s.record(ctx, &audit.Event{
+		Type:     audit.EventOTPSent,
+		ActorID:  userID,
+		TargetID: userID,
+		Metadata: map[string]string{"channel": channel, "purpose": purpose},
+	})
+
+	return &SendResponse{Channel: channel, Destination: mask(channel, destination), ExpiresAt: expiresAt}, nil
+}
+
+// verify confere o código pendente da chave, contando a tentativa antes da
+// comparação. Um código correto é consumido; esgotadas as tentativas, o
+// código é descartado e um novo precisa ser pedido.
+func (s *service) verify(ctx context.Context, codeKey, value string) (*Code, error) {
+	code, err := s.codes.Find(ctx, codeKey)
+	if err != nil {
+		return nil, err
+	}
+	if code == nil {
+		return nil, ErrInvalidCode
+	}
+
+	attempts, err := s.codes.CountAttempt(ctx, codeKey, s.ttl)
+	if err != nil {
+		return nil, err
+	}
+	if attempts > s.maxAttempts {
+		s.discard(ctx, codeKey)
+		return nil, ErrTooManyAttempts
+	}
+
+	value = strings.TrimSpace(value)
+	if !hmac.Equal([]byte(s.hash(codeKey, value)), []byte(code.Hash)) {
+		if attempts == s.maxAttempts {
+			s.discard(ctx, codeKey)
+		}
+		return nil, ErrInvalidCode
+	}
+
+	// Apenas a verificação que remove o código o consome
+	deleted, err := s.codes.Delete(ctx, codeKey)
+	if err != nil {
+		return nil, err
+	}
+	if !deleted {
+		return nil, ErrInvalidCode
+	}
+	return code, nil
+}
+
+func (s *service) discard(ctx context.Context, codeKey string) {
+	if _, err := s.codes.Delete(ctx, codeKey); err != nil {
+		log.Printf("Warning: failed to discard exhausted code: %v", err)
+	}
+}
+
+func (s *service) deliver(ctx context.Context, usr *user.User, purpose, channel, destination, value string) error {
+	minutes := int(s.ttl.Minutes())
+	if minutes < 1 {
+		minutes = 1
+	}
+
+	if channel == ChannelSMS {
+		return s.sms.Send(ctx, &common.SMSMessage{
+			To:   destination,
+			Body: fmt.Sprintf("Your verification code is %s. It expires in %d minutes. Do not share it with anyone.", value, minutes),
+		})
+	}
+
+	notice := "If you did not request it, you can ignore this message."
+	if purpose == purposeLogin || purpose == purposeAccount {
+		notice = "If you did not try to sign in, someone may know your password; change it as soon as possible."
+	}
+	return s.mailer.Send(ctx, &common.EmailMessage{
+		To:      destination,
+		Subject: "Your verification code",
+		Body: fmt.Sprintf(
+			"Hello %s,\n\nYour verification code is %s. It expires in %d minutes.\n%s\n",
+			usr.Name, value, minutes, notice,
+		),
+	})
+}
+
+// generate sorteia um código numérico com o número de dígitos configurado
+func (s *service) generate() (string, error) {
+	limit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(s.length)), nil)
+	n, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", s.length, n), nil
+}
+
+// hash vincula o código à sua chave; com poucos dígitos, um hash sem segredo
+// seria revertido por força bruta a partir do Redis
+func (s *service) hash(codeKey, value string) string {
+	h := hmac.New(sha256.New, s.secret)
+	h.Write([]byte(codeKey + ":" + value))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (s *service) record(ctx context.Context, event *audit.Event) {
+	if err := s.audit.Record(ctx, event); err != nil {
+		log.Printf("Warning: failed to record audit event %s: %v", event.Type, err)
+	}
+}
+
+func key(purpose, userID, channel string) string {
+	return purpose + ":" + userID + ":" + channel
+}
+
+func findFactor(usr *user.User, channel string) *user.OTPFactor {
+	for i := range usr.OTPFactors {
+		if usr.OTPFactors[i].Channel == channel {
+			return &usr.OTPFactors[i]
+		}
+	}
+	return nil
+}
+
+// normalizePhone remove espaços e separadores comuns do telefone informado
+func normalizePhone(phone string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.', '(', ')':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(phone))
+}
+
+// mask oculta a maior parte do destino nas respostas e na auditoria
+func mask(channel, destination string) string {
+	if channel == ChannelSMS {
+		if len(destination) <= 4 {
+			return destination
+		}
+		return destination[:1] + strings.Repeat("*", len(destination)-5) + destination[len(destination)-4:]
+	}
+
+	at := strings.LastIndex(destination, "@")
+	if at < 1 {
+		return destination
+	}
+	return destination[:1] + "***" + destination[at:]
+}
@@ -0,0 +1,91 @@
+package policy
+
+import (
+	"time"
+)
+
+type Effect string
+
+const (
+	EffectAllow Effect = "allow"
+	EffectDeny  Effect = "deny"
+)
+
+type Operator string
+
+const (
+	OperatorEquals         Operator = "eq"
+	OperatorNotEquals      Operator = "ne"
+	OperatorIn             Operator = "in"
+	OperatorNotIn          Operator = "not_in"
+	OperatorContains       Operator = "contains"
+	OperatorGreaterOrEqual Operator = "gte"
+	OperatorLessOrEqual    Operator = "lte"
+	OperatorExists         Operator = "exists"
+)
+
+// Origem da política; apenas as armazenadas no banco podem ser alteradas pela API
+const (
+	SourceBuiltin  = "builtin"
+	SourceFile     = "file"
+	SourceDatabase = "database"
+)
+
+// Attributes são os atributos de um sujeito, recurso ou ambiente
+type Attributes map[string]any
+
+// Condition compara um atributo ("subject.id", "resource.department",
+// "environment.hour"...) com um valor. Valores do tipo string iniciados por "$"
+// referenciam outro atributo, por exemplo "$subject.id".
+type Condition struct {
+	Attribute string   `bson:"attribute" json:"attribute" binding:"required"`
+	Operator  Operator `bson:"operator" json:"operator" binding:"required"`
+	Value     any      `bson:"value" json:"value"`
+}
+
+// Policy se aplica quando a ação e o tipo do recurso coincidem e todas as
+// condições são satisfeitas.
+type Policy struct {
+	ID          string      `bson:"_id" json:"id"`
+	Name        string      `bson:"name" json:"name"`
+	Description string      `bson:"description" json:"description"`
+	Effect      Effect      `bson:"effect" json:"effect"`
+	Actions     []string    `bson:"actions" json:"actions"`
+	Resources   []string    `bson:"resources" json:"resources"`
+	Conditions  []Condition `bson:"conditions" json:"conditions"`
+	Source      string      `bson:"-" json:"source"`
+	CreatedAt   time.Time   `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time   `bson:"updated_at" json:"updated_at"`
+}
+
+// Request descreve uma decisão de autorização. Resource deve conter "type".
+type Request struct {
+	Subject     Attributes `json:"subject"`
+	Action      string     `json:"action"`
+	Resource    Attributes `json:"resource"`
+	Environment Attributes `json:"environment"`
+}
+
+type Decision struct {
+	Allowed  bool   `json:"allowed"`
+	PolicyID string `json:"policy_id,omitempty"`
+	Reason   string `json:"reason"`
+}
+
+type CreatePolicyRequest struct {
+	Name        string      `json:"name" binding:"required"`
+	Description string      `json:"description"`
+	Effect      Effect      `json:"effect" binding:"required,oneof=allow deny"`
+	Actions     []string    `json:"actions" binding:"required,min=1"`
+	Resources   []string    `json:"resources" binding:"required,min=1"`
+	Conditions  []Condition `json:"conditions" binding:"dive"`
+}
+
+type UpdatePolicyRequest struct {
+	Name        string      `json:"name"`
+	Description *string     `json:"description"`
+	Effect      Effect      `json:"effect" binding:"omitempty,oneof=allow deny"`
+	Actions     []string    `json:"actions"`
+	Resources   []string    `json:"resources"`
+	Conditions  []Condition `json:"conditions" binding:"omitempty,dive"`
+}
@@ -0,0 +1,200 @@
+package policy
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// evaluate aplica as políticas com semântica deny-overrides: qualquer política
+// de negação aplicável prevalece e, sem nenhuma permissão explícita, o acesso
+// é negado.
+func evaluate(policies []*Policy, req *Request) *Decision {
+	var allowedBy *Policy
+	for _, policy := range policies {
+		if !policy.appliesTo(req) {
+			continue
+		}
+		if policy.Effect == EffectDeny {
+			return &Decision{Allowed: false, PolicyID: policy.ID, Reason: "denied by policy " + policy.Name}
+		}
+		if allowedBy == nil {
+			allowedBy = policy
+		}
+	}
+
+	if allowedBy == nil {
+		return &Decision{Allowed: false, Reason: "no policy allows this action"}
+	}
+	return &Decision{Allowed: true, PolicyID: allowedBy.ID, Reason: "allowed by policy " + allowedBy.Name}
+}
+
+func (p *Policy) appliesTo(req *Request) bool {
+	resourceType, _ := req.Resource["type"].(string)
+	if !matchesAny(p.Actions, req.Action) || !matchesAny(p.Resources, resourceType) {
+		return false
+	}
+
+	for _, condition := range p.Conditions {
+		if !condition.holds(req) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c Condition) holds(req *Request) bool {
+	actual, found := lookup(req, c.Attribute)
+
+	if c.Operator == OperatorExists {
+		expected, ok := c.Value.(bool)
+		if !ok {
+			expected = true
+		}
+		return found == expected
+	}
+	if !found {
+		return false
+	}
+
+	actual = normalize(actual)
+	expected := normalize(c.Value)
+	if ref, ok := expected.(string); ok && strings.HasPrefix(ref, "$") {
+		value, found := lookup(req, strings.TrimPrefix(ref, "$"))
+		if !found {
+			return false
+		}
+		expected = normalize(value)
+	}
+
+	switch c.Operator {
+	case OperatorEquals:
+		return reflect.DeepEqual(actual, expected)
+	case OperatorNotEquals:
+		return !reflect.DeepEqual(actual, expected)
+	case OperatorIn:
+		list, ok := expected.([]any)
+		return ok && containsValue(list, actual)
+	case OperatorNotIn:
+		list, ok := expected.([]any)
+		return ok && !containsValue(list, actual)
+	case OperatorContains:
+		switch value := actual.(type) {
+		case []any:
+			return containsValue(value, expected)
+		case string:
+			substr, ok := expected.(string)
+			return ok && strings.Contains(value, substr)
+		}
+		return false
+	case OperatorGreaterOrEqual, OperatorLessOrEqual:
+		cmp, ok := compare(actual, expected)
+		if !ok {
+			return false
+		}
+		if c.Operator == OperatorGreaterOrEqual {
+			return cmp >= 0
+		}
+		return cmp <= 0
+	}
+	return false
+}
+
+// lookup resolve atributos no formato "<categoria>.<nome>"; "action" retorna a
+// ação solicitada.
+func lookup(req *Request, attribute string) (any, bool) {
+	if attribute == "action" {
+		return req.Action, true
+	}
+
+	category, name, ok := strings.Cut(attribute, ".")
+	if !ok {
+		return nil, false
+	}
+
+	var attrs Attributes
+	switch category {
+	case "subject":
+		attrs = req.Subject
+	case "resource":
+		attrs = req.Resource
+	case "environment":
+		attrs = req.Environment
+	default:
+		return nil, false
+	}
+
+	value, found := attrs[name]
+	return value, found && value != nil
+}
+
+// normalize converte números para float64 e listas para []any, para que
+// valores vindos de JSON, BSON e Go possam ser comparados entre si.
+func normalize(value any) any {
+	if value == nil {
+		return nil
+	}
+
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	case reflect.Slice, reflect.Array:
+		list := make([]any, v.Len())
+		for i := range list {
+			list[i] = normalize(v.Index(i).Interface())
+		}
+		return list
+	}
+	return value
+}
+
+func containsValue(list []any, value any) bool {
+	return slices.ContainsFunc(list, func(item any) bool {
+		return reflect.DeepEqual(item, value)
+	})
+}
+
+func compare(a, b any) (int, bool) {
+	switch x := a.(type) {
+	case float64:
+		y, ok := b.(float64)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case x < y:
+			return -1, true
+		case x > y:
+			return 1, true
+		}
+		return 0, true
+	case string:
+		y, ok := b.(string)
+		if !ok {
+			return 0, false
+		}
+		return strings.Compare(x, y), true
+	}
+	return 0, false
+}
+
+func matchesAny(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if pattern == "*" || pattern == value {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok && strings.HasPrefix(value, prefix) {
+			return true
+		}
+	}
+	return false
+}
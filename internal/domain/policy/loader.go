@@ -0,0 +1,53 @@
+package policy
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+//go:embed default_policies.json
+var defaultPolicies []byte
+
+// DefaultPolicies retorna as políticas embutidas que reproduzem as regras de
+// propriedade e de permissão da API.
+func DefaultPolicies() ([]*Policy, error) {
+	return parse(defaultPolicies, SourceBuiltin)
+}
+
+// LoadFiles lê políticas de arquivos JSON, cada um contendo uma lista de políticas.
+func LoadFiles(paths ...string) ([]*Policy, error) {
+	var policies []*Policy
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read policy file %s: %w", path, err)
+		}
+
+		loaded, err := parse(data, SourceFile)
+		if err != nil {
+			return nil, fmt.Errorf("invalid policy file %s: %w", path, err)
+		}
+		policies = append(policies, loaded...)
+	}
+	return policies, nil
+}
+
+func parse(data []byte, source string) ([]*Policy, error) {
+	var policies []*Policy
+	if err := json.Unmarshal(data, &policies); err != nil {
+		return nil, err
+	}
+
+	for _, policy := range policies {
+		if policy.ID == "" {
+			policy.ID = source + ":" + policy.Name
+		}
+		policy.Source = source
+		if err := validate(policy); err != nil {
+			return nil, fmt.Errorf("policy %s: %w", policy.ID, err)
+		}
+	}
+	return policies, nil
+}
@@ -0,0 +1,13 @@
+package policy
+
+import (
+	"context"
+)
+
+type Repository interface {
+	Create(ctx context.Context, policy *Policy) error
+	FindByID(ctx context.Context, id string) (*Policy, error)
+	List(ctx context.Context) ([]*Policy, error)
+	Update(ctx context.Context, policy *Policy) error
+	Delete(ctx context.Context, id string) error
+}
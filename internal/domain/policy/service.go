@@ -0,0 +1,321 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/juanjerrah/go_auth_api/internal/domain/audit"
+	"github.com/juanjerrah/go_auth_api/internal/domain/group"
+	"github.com/juanjerrah/go_auth_api/internal/domain/user"
+	"github.com/juanjerrah/go_auth_api/pkg/common"
+)
+
+// Intervalo máximo entre recargas das políticas armazenadas no banco, para que
+// alterações feitas por outras instâncias também sejam aplicadas
+const reloadInterval = 30 * time.Second
+
+var (
+	ErrPolicyNotFound = errors.New("policy not found")
+	ErrInvalidPolicy  = errors.New("invalid policy")
+	ErrReadOnlyPolicy = errors.New("policy is read-only")
+)
+
+// GroupLister lista os grupos do usuário, expostos como atributo do sujeito
+type GroupLister interface {
+	ListUserGroups(ctx context.Context, userID string) ([]*group.GroupResponse, error)
+}
+
+type Service interface {
+	Evaluate(ctx context.Context, req *Request) (*Decision, error)
+	ListPolicies(ctx context.Context) ([]*Policy, error)
+	GetPolicy(ctx context.Context, id string) (*Policy, error)
+	CreatePolicy(ctx context.Context, req *CreatePolicyRequest) (*Policy, error)
+	UpdatePolicy(ctx context.Context, id string, req *UpdatePolicyRequest) (*Policy, error)
+	DeletePolicy(ctx context.Context, id string) error
+}
+
+type service struct {
+	repo       Repository
+	static     []*Policy
+	userRepo   user.Repository
+	groups     GroupLister
+	mongoUtils common.MongoUtils
+	audit      audit.Service
+
+	mu       sync.RWMutex
+	stored   []*Policy
+	loadedAt time.Time
+}
+
+func NewService(repo Repository, static []*Policy, userRepo user.Repository, groups GroupLister, mongoUtils common.MongoUtils, auditService audit.Service) Service {
+	return &service{
+		repo:       repo,
+		static:     static,
+		userRepo:   userRepo,
+		groups:     groups,
+		mongoUtils: mongoUtils,
+		audit:      auditService,
+	}
+}
+
+// Evaluate implements Service. Os atributos do sujeito são completados com
+// departamento e grupos, e os do ambiente com horário e IP da requisição.
+func (s *service) Evaluate(ctx context.Context, req *Request) (*Decision, error) {
+	policies, err := s.policies(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	subject := Attributes{}
+	for k, v := range req.Subject {
+		subject[k] = v
+	}
+	if err := s.enrichSubject(ctx, subject); err != nil {
+		return nil, err
+	}
+
+	environment := Attributes{}
+	now := time.Now()
+	environment["time"] = now.Format(time.RFC3339)
+	environment["hour"] = now.Hour()
+	environment["weekday"] = int(now.Weekday())
+	if ip := audit.RequestInfoFromContext(ctx).IP; ip != "" {
+		environment["ip"] = ip
+	}
+	for k, v := range req.Environment {
+		environment[k] = v
+	}
+
+	return evaluate(policies, &Request{
+		Subject:     subject,
+		Action:      req.Action,
+		Resource:    req.Resource,
+		Environment: environment,
+	}), nil
+}
+
+// ListPolicies implements Service.
+func (s *service) ListPolicies(ctx context.Context) ([]*Policy, error) {
+	return s.policies(ctx)
+}
+
+// GetPolicy implements Service.
+func (s *service) GetPolicy(ctx context.Context, id string) (*Policy, error) {
+	policies, err := s.policies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, policy := range policies {
+		if policy.ID == id {
+			return policy, nil
+		}
+	}
+	return nil, ErrPolicyNotFound
+}
+
+// CreatePolicy implements Service.
+func (s *service) CreatePolicy(ctx context.Context, req *CreatePolicyRequest) (*Policy, error) {
+	now := time.Now().UTC()
+	policy := &Policy{
+		ID:          s.mongoUtils.GenerateObjectID().Hex(),
+		Name:        req.Name,
+		Description: req.Description,
+		Effect:      req.Effect,
+		Actions:     req.Actions,
+		Resources:   req.Resources,
+		Conditions:  req.Conditions,
+		Source:      SourceDatabase,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if policy.Conditions == nil {
+		policy.Conditions = []Condition{}
+	}
+	if err := validate(policy); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(ctx, policy); err != nil {
+		return nil, err
+	}
+	s.invalidate()
+
+	s.record(ctx, audit.EventPolicyCreated, policy)
+
+	return policy, nil
+}
+
+// UpdatePolicy implements Service.
+func (s *service) UpdatePolicy(ctx context.Context, id string, req *UpdatePolicyRequest) (*Policy, error) {
+	policy, err := s.findStored(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != "" {
+		policy.Name = req.Name
+	}
+	if req.Description != nil {
+		policy.Description = *req.Description
+	}
+	if req.Effect != "" {
+		policy.Effect = req.Effect
+	}
+	if req.Actions != nil {
+		policy.Actions = req.Actions
+	}
+	if req.Resources != nil {
+		policy.Resources = req.Resources
+	}
+	if req.Conditions != nil {
+		policy.Conditions = req.Conditions
+	}
+	if err := validate(policy); err != nil {
+		return nil, err
+	}
+
+	policy.UpdatedAt = time.Now().UTC()
+	if err := s.repo.Update(ctx, policy); err != nil {
+		return nil, err
+	}
+	s.invalidate()
+
+	s.record(ctx, audit.EventPolicyUpdated, policy)
+
+	return policy, nil
+}
+
+// DeletePolicy implements Service.
+func (s *service) DeletePolicy(ctx context.Context, id string) error {
+	policy, err := s.findStored(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.invalidate()
+
+	s.record(ctx, audit.EventPolicyDeleted, policy)
+
+	return nil
+}
+
+// findStored busca uma política do banco; as embutidas e as de arquivo não
+// podem ser alteradas pela API.
+func (s *service) findStored(ctx context.Context, id string) (*Policy, error) {
+	for _, policy := range s.static {
+		if policy.ID == id {
+			return nil, ErrReadOnlyPolicy
+		}
+	}
+
+	policy, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, ErrPolicyNotFound
+	}
+	policy.Source = SourceDatabase
+	return policy, nil
+}
+
+// policies retorna as políticas estáticas seguidas das armazenadas no banco,
+// recarregando estas quando o cache expira.
+func (s *service) policies(ctx context.Context) ([]*Policy, error) {
+	s.mu.RLock()
+	stored, loadedAt := s.stored, s.loadedAt
+	s.mu.RUnlock()
+
+	if time.Since(loadedAt) > reloadInterval {
+		var err error
+		stored, err = s.repo.List(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, policy := range stored {
+			policy.Source = SourceDatabase
+		}
+
+		s.mu.Lock()
+		s.stored, s.loadedAt = stored, time.Now()
+		s.mu.Unlock()
+	}
+
+	policies := make([]*Policy, 0, len(s.static)+len(stored))
+	policies = append(policies, s.static...)
+	return append(policies, stored...), nil
+}
+
+func (s *service) invalidate() {
+	s.mu.Lock()
+	s.loadedAt = time.Time{}
+	s.mu.Unlock()
+}
+
+func (s *service) enrichSubject(ctx context.Context, subject Attributes) error {
+	userID, _ := subject["id"].(string)
+	if userID == "" {
+		return nil
+	}
+
+	if _, ok := subject["department"]; !ok {
+		if usr, err := s.userRepo.FindByID(ctx, userID); err == nil && usr.Department != "" {
+			subject["department"] = usr.Department
+		}
+	}
+
+	if _, ok := subject["groups"]; !ok {
+		groups, err := s.groups.ListUserGroups(ctx, userID)
+		if err != nil {
+			return err
+		}
+		names := make([]string, 0, len(groups))
+		for _, grp := range groups {
+			names = append(names, grp.Name)
+		}
+		subject["groups"] = names
+	}
+
+	return nil
+}
+
+func (s *service) record(ctx context.Context, eventType audit.EventType, policy *Policy) {
+	event := &audit.Event{
+		Type:     eventType,
+		Metadata: map[string]string{"policy_id": policy.ID, "name": policy.Name, "effect": string(policy.Effect)},
+	}
+	if err := s.audit.Record(ctx, event); err != nil {
+		log.Printf("Warning: failed to record audit event %s: %v", event.Type, err)
+	}
+}
+
+func validate(policy *Policy) error {
+	if policy.Name == "" || len(policy.Actions) == 0 || len(policy.Resources) == 0 {
+		return ErrInvalidPolicy
+	}
+	if policy.Effect != EffectAllow && policy.Effect != EffectDeny {
+		return ErrInvalidPolicy
+	}
+
+	for _, condition := range policy.Conditions {
+		category, _, _ := strings.Cut(condition.Attribute, ".")
+		if condition.Attribute != "action" && category != "subject" && category != "resource" && category != "environment" {
+			return ErrInvalidPolicy
+		}
+
+		switch condition.Operator {
+		case OperatorEquals, OperatorNotEquals, OperatorContains, OperatorGreaterOrEqual, OperatorLessOrEqual, OperatorExists:
+		case OperatorIn, OperatorNotIn:
+			if _, ok := normalize(condition.Value).([]any); !ok {
+				return ErrInvalidPolicy
+			}
+		default:
+			return ErrInvalidPolicy
+		}
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package policy
+
+import (
+	"github.com/juanjerrah/go_auth_api/pkg/types"
+)
+
+// SubjectFromAuthContext extrai os atributos do sujeito a partir da sessão
+func SubjectFromAuthContext(authCtx *types.AuthContext) Attributes {
+	permissions := make([]string, 0, len(authCtx.Permissions))
+	for _, permission := range authCtx.Permissions {
+		permissions = append(permissions, string(permission))
+	}
+
+	subject := Attributes{
+		"id":           authCtx.UserID,
+		"email":        authCtx.Email,
+		"role":         string(authCtx.Role),
+		"permissions":  permissions,
+		"impersonated": authCtx.IsImpersonated(),
+	}
+	if authCtx.TenantID != "" {
+		subject["tenant_id"] = authCtx.TenantID
+		subject["tenant_role"] = string(authCtx.TenantRole)
+	}
+	return subject
+}
@@ -0,0 +1,162 @@
+package relation
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// UserNamespace identifica sujeitos que são usuários da API ("user:<id>")
+const UserNamespace = "user"
+
+var ErrInvalidSubject = errors.New("invalid subject")
+
+// Subject é um objeto ("group:eng") ou um userset ("group:eng#member")
+type Subject struct {
+	Namespace string `bson:"namespace" json:"namespace"`
+	ObjectID  string `bson:"object_id" json:"object_id"`
+	Relation  string `bson:"relation" json:"relation,omitempty"`
+}
+
+func (s Subject) String() string {
+	if s.Relation == "" {
+		return s.Namespace + ":" + s.ObjectID
+	}
+	return s.Namespace + ":" + s.ObjectID + "#" + s.Relation
+}
+
+// ParseSubject interpreta sujeitos nos formatos "namespace:id" e
+// "namespace:id#relation".
+func ParseSubject(value string) (Subject, error) {
+	object, relation, _ := strings.Cut(value, "#")
+	namespace, objectID, ok := strings.Cut(object, ":")
+	if !ok || namespace == "" || objectID == "" {
+		return Subject{}, ErrInvalidSubject
+	}
+	if namespace == UserNamespace && relation != "" {
+		return Subject{}, ErrInvalidSubject
+	}
+	return Subject{Namespace: namespace, ObjectID: objectID, Relation: relation}, nil
+}
+
+// Tuple registra que o sujeito tem a relação com o objeto
+// (namespace:object_id#relation@subject).
+type Tuple struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	Namespace string             `bson:"namespace" json:"namespace"`
+	ObjectID  string             `bson:"object_id" json:"object_id"`
+	Relation  string             `bson:"relation" json:"relation"`
+	Subject   Subject            `bson:"subject" json:"subject"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
+
+func (t *Tuple) String() string {
+	return t.Namespace + ":" + t.ObjectID + "#" + t.Relation + "@" + t.Subject.String()
+}
+
+// Rewrite define como uma relação é computada. Um rewrite vazio equivale a
+// "this": apenas as tuplas gravadas diretamente com a relação.
+type Rewrite struct {
+	This            bool            `bson:"this,omitempty" json:"this,omitempty"`
+	ComputedUserset string          `bson:"computed_userset,omitempty" json:"computed_userset,omitempty"`
+	TupleToUserset  *TupleToUserset `bson:"tuple_to_userset,omitempty" json:"tuple_to_userset,omitempty"`
+	Union           []*Rewrite      `bson:"union,omitempty" json:"union,omitempty"`
+	Intersection    []*Rewrite      `bson:"intersection,omitempty" json:"intersection,omitempty"`
+}
+
+// TupleToUserset segue a relação Tupleset do objeto (ex.: "parent") e avalia
+// ComputedUserset nos objetos encontrados (ex.: "viewer" da pasta pai).
+type TupleToUserset struct {
+	Tupleset        string `bson:"tupleset" json:"tupleset"`
+	ComputedUserset string `bson:"computed_userset" json:"computed_userset"`
+}
+
+type NamespaceConfig struct {
+	Name      string              `bson:"_id" json:"name"`
+	Relations map[string]*Rewrite `bson:"relations" json:"relations"`
+	UpdatedAt time.Time           `bson:"updated_at" json:"updated_at"`
+}
+
+type NamespaceRequest struct {
+	Relations map[string]*Rewrite `json:"relations" binding:"required"`
+}
+
+type TupleKey struct {
+	Namespace string `json:"namespace" binding:"required"`
+	ObjectID  string `json:"object_id" binding:"required"`
+	Relation  string `json:"relation" binding:"required"`
+	Subject   string `json:"subject" binding:"required"`
+}
+
+type WriteTuplesRequest struct {
+	Writes  []TupleKey `json:"writes" binding:"dive"`
+	Deletes []TupleKey `json:"deletes" binding:"dive"`
+}
+
+type WriteTuplesResponse struct {
+	ConsistencyToken string `json:"consistency_token"`
+}
+
+type ReadTuplesRequest struct {
+	Namespace string `form:"namespace" binding:"required"`
+	ObjectID  string `form:"object_id"`
+	Relation  string `form:"relation"`
+}
+
+type TupleResponse struct {
+	Tuple     string    `json:"tuple"`
+	Namespace string    `json:"namespace"`
+	ObjectID  string    `json:"object_id"`
+	Relation  string    `json:"relation"`
+	Subject   string    `json:"subject"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ConsistencyToken, quando informado, garante que a resposta reflete ao menos
+// as escritas que geraram o token.
+type CheckRequest struct {
+	Namespace        string `json:"namespace" binding:"required"`
+	ObjectID         string `json:"object_id" binding:"required"`
+	Relation         string `json:"relation" binding:"required"`
+	Subject          string `json:"subject" binding:"required"`
+	ConsistencyToken string `json:"consistency_token"`
+}
+
+type CheckResponse struct {
+	Allowed          bool   `json:"allowed"`
+	ConsistencyToken string `json:"consistency_token"`
+}
+
+type ExpandRequest struct {
+	Namespace        string `json:"namespace" binding:"required"`
+	ObjectID         string `json:"object_id" binding:"required"`
+	Relation         string `json:"relation" binding:"required"`
+	ConsistencyToken string `json:"consistency_token"`
+}
+
+// ExpandNode é um nó da árvore de usersets; folhas listam os sujeitos diretos.
+type ExpandNode struct {
+	Operation string        `json:"operation"`
+	Userset   string        `json:"userset,omitempty"`
+	Subjects  []string      `json:"subjects,omitempty"`
+	Children  []*ExpandNode `json:"children,omitempty"`
+}
+
+type ExpandResponse struct {
+	Tree             *ExpandNode `json:"tree"`
+	ConsistencyToken string      `json:"consistency_token"`
+}
+
+type ListObjectsRequest struct {
+	Namespace        string `json:"namespace" binding:"required"`
+	Relation         string `json:"relation" binding:"required"`
+	Subject          string `json:"subject" binding:"required"`
+	ConsistencyToken string `json:"consistency_token"`
+}
+
+type ListObjectsResponse struct {
+	ObjectIDs        []string `json:"object_ids"`
+	ConsistencyToken string   `json:"consistency_token"`
+}
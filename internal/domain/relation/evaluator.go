@@ -0,0 +1,181 @@
+package relation
+
+import (
+	"context"
+	"errors"
+)
+
+// Profundidade máxima de recursão ao seguir usersets, o que também protege
+// contra configurações com ciclos
+const maxDepth = 25
+
+var ErrMaxDepthExceeded = errors.New("relation graph exceeds maximum depth")
+
+// evaluator percorre os rewrites de uma configuração de namespaces sobre as
+// tuplas armazenadas.
+type evaluator struct {
+	repo       Repository
+	namespaces map[string]*NamespaceConfig
+}
+
+func (e *evaluator) rewriteFor(namespace, relation string) (*Rewrite, error) {
+	config, ok := e.namespaces[namespace]
+	if !ok {
+		return nil, ErrUnknownNamespace
+	}
+	rewrite, ok := config.Relations[relation]
+	if !ok {
+		return nil, ErrUnknownRelation
+	}
+	if rewrite == nil {
+		rewrite = &Rewrite{}
+	}
+	return rewrite, nil
+}
+
+func (e *evaluator) check(ctx context.Context, namespace, objectID, relation string, subject Subject, depth int) (bool, error) {
+	if depth > maxDepth {
+		return false, ErrMaxDepthExceeded
+	}
+
+	rewrite, err := e.rewriteFor(namespace, relation)
+	if err != nil {
+		return false, err
+	}
+	return e.checkRewrite(ctx, namespace, objectID, relation, rewrite, subject, depth)
+}
+
+func (e *evaluator) checkRewrite(ctx context.Context, namespace, objectID, relation string, rewrite *Rewrite, subject Subject, depth int) (bool, error) {
+	switch {
+	case len(rewrite.Union) > 0:
+		for _, child := range rewrite.Union {
+			ok, err := e.checkRewrite(ctx, namespace, objectID, relation, child, subject, depth)
+			if err != nil || ok {
+				return ok, err
+			}
+		}
+		return false, nil
+
+	case len(rewrite.Intersection) > 0:
+		for _, child := range rewrite.Intersection {
+			ok, err := e.checkRewrite(ctx, namespace, objectID, relation, child, subject, depth)
+			if err != nil || !ok {
+				return false, err
+			}
+		}
+		return true, nil
+
+	case rewrite.ComputedUserset != "":
+		return e.check(ctx, namespace, objectID, rewrite.ComputedUserset, subject, depth+1)
+
+	case rewrite.TupleToUserset != nil:
+		tuples, err := e.repo.Find(ctx, namespace, objectID, rewrite.TupleToUserset.Tupleset)
+		if err != nil {
+			return false, err
+		}
+		for _, tuple := range tuples {
+			if tuple.Subject.Namespace == UserNamespace {
+				continue
+			}
+			ok, err := e.check(ctx, tuple.Subject.Namespace, tuple.Subject.ObjectID, rewrite.TupleToUserset.ComputedUserset, subject, depth+1)
+			if err != nil || ok {
+				return ok, err
+			}
+		}
+		return false, nil
+	}
+
+	// "this": tuplas diretas, expandindo usersets gravados como sujeito
+	tuples, err := e.repo.Find(ctx, namespace, objectID, relation)
+	if err != nil {
+		return false, err
+	}
+	for _, tuple := range tuples {
+		if tuple.Subject == subject {
+			return true, nil
+		}
+	}
+	for _, tuple := range tuples {
+		if tuple.Subject.Relation == "" {
+			continue
+		}
+		ok, err := e.check(ctx, tuple.Subject.Namespace, tuple.Subject.ObjectID, tuple.Subject.Relation, subject, depth+1)
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+	return false, nil
+}
+
+func (e *evaluator) expand(ctx context.Context, namespace, objectID, relation string, depth int) (*ExpandNode, error) {
+	if depth > maxDepth {
+		return nil, ErrMaxDepthExceeded
+	}
+
+	rewrite, err := e.rewriteFor(namespace, relation)
+	if err != nil {
+		return nil, err
+	}
+
+	node, err := e.expandRewrite(ctx, namespace, objectID, relation, rewrite, depth)
+	if err != nil {
+		return nil, err
+	}
+	node.Userset = Subject{Namespace: namespace, ObjectID: objectID, Relation: relation}.String()
+	return node, nil
+}
+
+func (e *evaluator) expandRewrite(ctx context.Context, namespace, objectID, relation string, rewrite *Rewrite, depth int) (*ExpandNode, error) {
+	switch {
+	case len(rewrite.Union) > 0 || len(rewrite.Intersection) > 0:
+		node := &ExpandNode{Operation: "union"}
+		children := rewrite.Union
+		if len(rewrite.Intersection) > 0 {
+			node.Operation = "intersection"
+			children = rewrite.Intersection
+		}
+		for _, child := range children {
+			expanded, err := e.expandRewrite(ctx, namespace, objectID, relation, child, depth)
+			if err != nil {
+				return nil, err
+			}
+			node.Children = append(node.Children, expanded)
+		}
+		return node, nil
+
+	case rewrite.ComputedUserset != "":
+		expanded, err := e.expand(ctx, namespace, objectID, rewrite.ComputedUserset, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		return &ExpandNode{Operation: "union", Children: []*ExpandNode{expanded}}, nil
+
+	case rewrite.TupleToUserset != nil:
+		tuples, err := e.repo.Find(ctx, namespace, objectID, rewrite.TupleToUserset.Tupleset)
+		if err != nil {
+			return nil, err
+		}
+		node := &ExpandNode{Operation: "union"}
+		for _, tuple := range tuples {
+			if tuple.Subject.Namespace == UserNamespace {
+				continue
+			}
+			expanded, err := e.expand(ctx, tuple.Subject.Namespace, tuple.Subject.ObjectID, rewrite.TupleToUserset.ComputedUserset, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			node.Children = append(node.Children, expanded)
+		}
+		return node, nil
+	}
+
+	tuples, err := e.repo.Find(ctx, namespace, objectID, relation)
+	if err != nil {
+		return nil, err
+	}
+	node := &ExpandNode{Operation: "leaf", Subjects: []string{}}
+	for _, tuple := range tuples {
+		node.Subjects = append(node.Subjects, tuple.Subject.String())
+	}
+	return node, nil
+}
@@ -0,0 +1,23 @@
+package relation
+
+import (
+	"context"
+)
+
+type Repository interface {
+	// Write grava a tupla; gravar uma tupla existente não tem efeito
+	Write(ctx context.Context, tuple *Tuple) error
+	Delete(ctx context.Context, tuple *Tuple) error
+	// Find filtra pelo namespace e, quando informados, pelo objeto e relação
+	Find(ctx context.Context, namespace, objectID, relation string) ([]*Tuple, error)
+	ObjectIDs(ctx context.Context, namespace string) ([]string, error)
+	// NextRevision avança a revisão global após um lote de escritas
+	NextRevision(ctx context.Context) (int64, error)
+	CurrentRevision(ctx context.Context) (int64, error)
+}
+
+type NamespaceRepository interface {
+	Save(ctx context.Context, config *NamespaceConfig) error
+	FindAll(ctx context.Context) ([]*NamespaceConfig, error)
+	Delete(ctx context.Context, name string) error
+}
@@ -0,0 +1,499 @@
+package relation
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/juanjerrah/go_auth_api/internal/domain/audit"
+)
+
+const (
+	// Intervalo máximo entre recargas das configurações de namespace
+	namespaceReloadInterval = 30 * time.Second
+	// Validade das respostas de check em cache quando não há token de consistência
+	checkCacheTTL = 5 * time.Second
+	// Limite de entradas no cache antes de descartá-lo por inteiro
+	checkCacheSize = 10000
+)
+
+var (
+	ErrUnknownNamespace         = errors.New("unknown namespace")
+	ErrUnknownRelation          = errors.New("unknown relation")
+	ErrInvalidNamespace         = errors.New("invalid namespace configuration")
+	ErrInvalidConsistencyToken  = errors.New("invalid consistency token")
+	ErrNamespaceNotFound        = errors.New("namespace not found")
+	ErrReservedNamespace        = errors.New("namespace name is reserved")
+	ErrTooManyListObjectTargets = errors.New("too many objects to evaluate")
+)
+
+// Quantidade máxima de objetos candidatos avaliados por list-objects
+const maxListObjects = 1000
+
+var namePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+type Service interface {
+	PutNamespace(ctx context.Context, name string, req *NamespaceRequest) (*NamespaceConfig, error)
+	ListNamespaces(ctx context.Context) ([]*NamespaceConfig, error)
+	DeleteNamespace(ctx context.Context, name string) error
+	WriteTuples(ctx context.Context, req *WriteTuplesRequest) (*WriteTuplesResponse, error)
+	ReadTuples(ctx context.Context, req *ReadTuplesRequest) ([]*TupleResponse, error)
+	Check(ctx context.Context, req *CheckRequest) (*CheckResponse, error)
+	Expand(ctx context.Context, req *ExpandRequest) (*ExpandResponse, error)
+	ListObjects(ctx context.Context, req *ListObjectsRequest) (*ListObjectsResponse, error)
+}
+
+type checkEntry struct {
+	allowed   bool
+	revision  int64
+	expiresAt time.Time
+}
+
+type service struct {
+	repo       Repository
+	namespaces NamespaceRepository
+	audit      audit.Service
+
+	mu       sync.RWMutex
+	configs  map[string]*NamespaceConfig
+	loadedAt time.Time
+
+	cacheMu sync.Mutex
+	cache   map[string]checkEntry
+}
+
+func NewService(repo Repository, namespaces NamespaceRepository, auditService audit.Service) Service {
+	return &service{
+		repo:       repo,
+		namespaces: namespaces,
+		audit:      auditService,
+		cache:      make(map[string]checkEntry),
+	}
+}
+
+// PutNamespace implements Service.
+func (s *service) PutNamespace(ctx context.Context, name string, req *NamespaceRequest) (*NamespaceConfig, error) {
+	if name == UserNamespace {
+		return nil, ErrReservedNamespace
+	}
+	if !namePattern.MatchString(name) {
+		return nil, ErrInvalidNamespace
+	}
+
+	config := &NamespaceConfig{
+		Name:      name,
+		Relations: req.Relations,
+		UpdatedAt: time.Now().UTC(),
+	}
+	if err := validateNamespace(config); err != nil {
+		return nil, err
+	}
+
+	if err := s.namespaces.Save(ctx, config); err != nil {
+		return nil, err
+	}
+	s.invalidate()
+
+	s.record(ctx, &audit.Event{
+		Type:     audit.EventNamespaceUpdated,
+		Metadata: map[string]string{"namespace": name},
+	})
+
+	return config, nil
+}
+
+// ListNamespaces implements Service.
+func (s *service) ListNamespaces(ctx context.Context) ([]*NamespaceConfig, error) {
+	return s.namespaces.FindAll(ctx)
+}
+
+// DeleteNamespace implements Service.
+func (s *service) DeleteNamespace(ctx context.Context, name string) error {
+	configs, err := s.loadNamespaces(ctx)
+	if err != nil {
+		return err
+	}
+	if _, ok := configs[name]; !ok {
+		return ErrNamespaceNotFound
+	}
+
+	if err := s.namespaces.Delete(ctx, name); err != nil {
+		return err
+	}
+	s.invalidate()
+
+	s.record(ctx, &audit.Event{
+		Type:     audit.EventNamespaceDeleted,
+		Metadata: map[string]string{"namespace": name},
+	})
+
+	return nil
+}
+
+// WriteTuples implements Service. A revisão é avançada depois das escritas,
+// de modo que qualquer leitura feita numa revisão igual ou posterior ao token
+// retornado já enxerga as tuplas gravadas.
+func (s *service) WriteTuples(ctx context.Context, req *WriteTuplesRequest) (*WriteTuplesResponse, error) {
+	configs, err := s.loadNamespaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	writes := make([]*Tuple, 0, len(req.Writes))
+	for _, key := range req.Writes {
+		tuple, err := toTuple(configs, key)
+		if err != nil {
+			return nil, err
+		}
+		writes = append(writes, tuple)
+	}
+	deletes := make([]*Tuple, 0, len(req.Deletes))
+	for _, key := range req.Deletes {
+		tuple, err := toTuple(configs, key)
+		if err != nil {
+			return nil, err
+		}
+		deletes = append(deletes, tuple)
+	}
+
+	now := time.Now().UTC()
+	for _, tuple := range writes {
+		tuple.CreatedAt = now
+		if err := s.repo.Write(ctx, tuple); err != nil {
+			return nil, err
+		}
+	}
+	for _, tuple := range deletes {
+		if err := s.repo.Delete(ctx, tuple); err != nil {
+			return nil, err
+		}
+	}
+
+	revision, err := s.repo.NextRevision(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.clearCache()
+
+	s.record(ctx, &audit.Event{
+		Type: audit.EventRelationTuplesWritten,
+		Metadata: map[string]string{
+			"writes":   strconv.Itoa(len(writes)),
+			"deletes":  strconv.Itoa(len(deletes)),
+			"revision": strconv.FormatInt(revision, 10),
+		},
+	})
+
+	return &WriteTuplesResponse{ConsistencyToken: encodeToken(revision)}, nil
+}
+
+// ReadTuples implements Service.
+func (s *service) ReadTuples(ctx context.Context, req *ReadTuplesRequest) ([]*TupleResponse, error) {
+	tuples, err := s.repo.Find(ctx, req.Namespace, req.ObjectID, req.Relation)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]*TupleResponse, 0, len(tuples))
+	for _, tuple := range tuples {
+		response = append(response, &TupleResponse{
+			Tuple:     tuple.String(),
+			Namespace: tuple.Namespace,
+			ObjectID:  tuple.ObjectID,
+			Relation:  tuple.Relation,
+			Subject:   tuple.Subject.String(),
+			CreatedAt: tuple.CreatedAt,
+		})
+	}
+	return response, nil
+}
+
+// Check implements Service. Respostas em cache são reaproveitadas apenas se
+// forem ao menos tão recentes quanto o token de consistência informado.
+func (s *service) Check(ctx context.Context, req *CheckRequest) (*CheckResponse, error) {
+	minRevision, err := decodeToken(req.ConsistencyToken)
+	if err != nil {
+		return nil, err
+	}
+	subject, err := ParseSubject(req.Subject)
+	if err != nil {
+		return nil, err
+	}
+
+	key := req.Namespace + ":" + req.ObjectID + "#" + req.Relation + "@" + subject.String()
+	if entry, ok := s.cached(key, minRevision); ok {
+		return &CheckResponse{Allowed: entry.allowed, ConsistencyToken: encodeToken(entry.revision)}, nil
+	}
+
+	eval, revision, err := s.snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	allowed, err := eval.check(ctx, req.Namespace, req.ObjectID, req.Relation, subject, 0)
+	if err != nil {
+		return nil, err
+	}
+	s.store(key, checkEntry{allowed: allowed, revision: revision, expiresAt: time.Now().Add(checkCacheTTL)})
+
+	return &CheckResponse{Allowed: allowed, ConsistencyToken: encodeToken(revision)}, nil
+}
+
+// Expand implements Service.
+func (s *service) Expand(ctx context.Context, req *ExpandRequest) (*ExpandResponse, error) {
+	if _, err := decodeToken(req.ConsistencyToken); err != nil {
+		return nil, err
+	}
+
+	eval, revision, err := s.snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := eval.expand(ctx, req.Namespace, req.ObjectID, req.Relation, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ExpandResponse{Tree: tree, ConsistencyToken: encodeToken(revision)}, nil
+}
+
+// ListObjects implements Service. Avalia check para cada objeto do namespace
+// que aparece em alguma tupla.
+func (s *service) ListObjects(ctx context.Context, req *ListObjectsRequest) (*ListObjectsResponse, error) {
+	if _, err := decodeToken(req.ConsistencyToken); err != nil {
+		return nil, err
+	}
+	subject, err := ParseSubject(req.Subject)
+	if err != nil {
+		return nil, err
+	}
+
+	eval, revision, err := s.snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := eval.rewriteFor(req.Namespace, req.Relation); err != nil {
+		return nil, err
+	}
+
+	candidates, err := s.repo.ObjectIDs(ctx, req.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) > maxListObjects {
+		return nil, ErrTooManyListObjectTargets
+	}
+
+	objectIDs := []string{}
+	for _, objectID := range candidates {
+		allowed, err := eval.check(ctx, req.Namespace, objectID, req.Relation, subject, 0)
+		if err != nil {
+			return nil, err
+		}
+		if allowed {
+			objectIDs = append(objectIDs, objectID)
+		}
+	}
+
+	return &ListObjectsResponse{ObjectIDs: objectIDs, ConsistencyToken: encodeToken(revision)}, nil
+}
+
+// snapshot lê a revisão atual antes de avaliar, para que o token retornado
+// nunca seja mais novo que os dados usados na resposta.
+func (s *service) snapshot(ctx context.Context) (*evaluator, int64, error) {
+	revision, err := s.repo.CurrentRevision(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	configs, err := s.loadNamespaces(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	return &evaluator{repo: s.repo, namespaces: configs}, revision, nil
+}
+
+func (s *service) loadNamespaces(ctx context.Context) (map[string]*NamespaceConfig, error) {
+	s.mu.RLock()
+	configs, loadedAt := s.configs, s.loadedAt
+	s.mu.RUnlock()
+
+	if configs != nil && time.Since(loadedAt) < namespaceReloadInterval {
+		return configs, nil
+	}
+
+	stored, err := s.namespaces.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	configs = make(map[string]*NamespaceConfig, len(stored))
+	for _, config := range stored {
+		configs[config.Name] = config
+	}
+
+	s.mu.Lock()
+	s.configs, s.loadedAt = configs, time.Now()
+	s.mu.Unlock()
+
+	return configs, nil
+}
+
+func (s *service) invalidate() {
+	s.mu.Lock()
+	s.configs = nil
+	s.mu.Unlock()
+	s.clearCache()
+}
+
+func (s *service) cached(key string, minRevision int64) (checkEntry, bool) {
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+
+	entry, ok := s.cache[key]
+	if !ok || time.Now().After(entry.expiresAt) || entry.revision < minRevision {
+		return checkEntry{}, false
+	}
+	return entry, true
+}
+
+func (s *service) store(key string, entry checkEntry) {
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+
+	if len(s.cache) >= checkCacheSize {
+		s.cache = make(map[string]checkEntry)
+	}
+	s.cache[key] = entry
+}
+
+func (s *service) clearCache() {
+	s.cacheMu.Lock()
+	s.cache = make(map[string]checkEntry)
+	s.cacheMu.Unlock()
+}
+
+func (s *service) record(ctx context.Context, event *audit.Event) {
+	if err := s.audit.Record(ctx, event); err != nil {
+		log.Printf("Warning: failed to record audit event %s: %v", event.Type, err)
+	}
+}
+
+// toTuple valida a tupla contra as configurações de namespace
+func toTuple(configs map[string]*NamespaceConfig, key TupleKey) (*Tuple, error) {
+	config, ok := configs[key.Namespace]
+	if !ok {
+		return nil, ErrUnknownNamespace
+	}
+	if _, ok := config.Relations[key.Relation]; !ok {
+		return nil, ErrUnknownRelation
+	}
+
+	subject, err := ParseSubject(key.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if subject.Namespace != UserNamespace {
+		subjectConfig, ok := configs[subject.Namespace]
+		if !ok {
+			return nil, ErrUnknownNamespace
+		}
+		if subject.Relation != "" {
+			if _, ok := subjectConfig.Relations[subject.Relation]; !ok {
+				return nil, ErrUnknownRelation
+			}
+		}
+	}
+
+	return &Tuple{
+		Namespace: key.Namespace,
+		ObjectID:  key.ObjectID,
+		Relation:  key.Relation,
+		Subject:   subject,
+	}, nil
+}
+
+func validateNamespace(config *NamespaceConfig) error {
+	if len(config.Relations) == 0 {
+		return ErrInvalidNamespace
+	}
+	for name, rewrite := range config.Relations {
+		if !namePattern.MatchString(name) {
+			return ErrInvalidNamespace
+		}
+		if rewrite != nil {
+			if err := validateRewrite(config, rewrite); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateRewrite exige um único tipo de regra por nó e que as relações
+// referenciadas existam no namespace.
+func validateRewrite(config *NamespaceConfig, rewrite *Rewrite) error {
+	kinds := 0
+	if rewrite.This {
+		kinds++
+	}
+	if rewrite.ComputedUserset != "" {
+		kinds++
+		if _, ok := config.Relations[rewrite.ComputedUserset]; !ok {
+			return ErrInvalidNamespace
+		}
+	}
+	if rewrite.TupleToUserset != nil {
+		kinds++
+		if _, ok := config.Relations[rewrite.TupleToUserset.Tupleset]; !ok || rewrite.TupleToUserset.ComputedUserset == "" {
+			return ErrInvalidNamespace
+		}
+	}
+	if len(rewrite.Union) > 0 {
+		kinds++
+	}
+	if len(rewrite.Intersection) > 0 {
+		kinds++
+	}
+	if kinds > 1 {
+		return ErrInvalidNamespace
+	}
+
+	for _, child := range append(rewrite.Union, rewrite.Intersection...) {
+		if child == nil {
+			return ErrInvalidNamespace
+		}
+		if err := validateRewrite(config, child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Tokens de consistência codificam a revisão global das tuplas
+func encodeToken(revision int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("rev:" + strconv.FormatInt(revision, 10)))
+}
+
+func decodeToken(token string) (int64, error) {
+	if token == "" {
+		return 0, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, ErrInvalidConsistencyToken
+	}
+	value, ok := strings.CutPrefix(string(data), "rev:")
+	if !ok {
+		return 0, ErrInvalidConsistencyToken
+	}
+	revision, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, ErrInvalidConsistencyToken
+	}
+	return revision, nil
+}
@@ -0,0 +1,45 @@
+package risk
+
+import "time"
+
+// Decision é a ação tomada para uma tentativa de login conforme a pontuação
+type Decision string
+
+const (
+	DecisionAllow      Decision = "allow"
+	DecisionRequireMFA Decision = "require_mfa"
+	DecisionBlock      Decision = "block"
+)
+
+// Attempt descreve uma tentativa de login. UserID fica vazio quando o e-mail
+// não pertence a uma conta local.
+type Attempt struct {
+	UserID    string
+	Email     string
+	IP        string
+	UserAgent string
+	Time      time.Time
+}
+
+// Assessment é o resultado da avaliação: a pontuação total, a decisão e a
+// contribuição de cada sinal que pontuou
+type Assessment struct {
+	Score    int
+	Decision Decision
+	Signals  map[string]int
+}
+
+// Location é a posição aproximada de um endereço IP
+type Location struct {
+	Country   string  `json:"country,omitempty"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// LastLogin é o último login bem-sucedido com localização conhecida, base do
+// sinal de viagem impossível
+type LastLogin struct {
+	Location Location  `json:"location"`
+	IP       string    `json:"ip"`
+	Time     time.Time `json:"time"`
+}
@@ -0,0 +1,128 @@
+package risk
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// GeoDatabase localiza endereços IP sem consultas externas
+type GeoDatabase interface {
+	Lookup(ip string) (Location, bool)
+}
+
+// geoDatabase indexa as redes por tamanho de prefixo para a busca do prefixo
+// mais longo
+type geoDatabase struct {
+	networks map[netip.Prefix]Location
+	bits     []int
+}
+
+// LoadGeoDatabase lê uma base de geolocalização em CSV com cabeçalho. As
+// colunas network, latitude e longitude são obrigatórias e country (ou
+// country_iso_code) é opcional, o que aceita o formato City do GeoLite2 com
+// as coordenadas.
+func LoadGeoDatabase(path string) (GeoDatabase, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open geoip database: %w", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read geoip database header: %w", err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	networkCol, okNetwork := columns["network"]
+	latitudeCol, okLatitude := columns["latitude"]
+	longitudeCol, okLongitude := columns["longitude"]
+	if !okNetwork || !okLatitude || !okLongitude {
+		return nil, errors.New("geoip database must have network, latitude and longitude columns")
+	}
+	countryCol, okCountry := columns["country"]
+	if !okCountry {
+		countryCol, okCountry = columns["country_iso_code"]
+	}
+
+	db := &geoDatabase{networks: map[netip.Prefix]Location{}}
+	seen := map[int]bool{}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read geoip database line %d: %w", line, err)
+		}
+		if len(record) <= max(networkCol, latitudeCol, longitudeCol) {
+			continue
+		}
+		// Redes sem coordenadas não servem para a distância
+		if record[latitudeCol] == "" || record[longitudeCol] == "" {
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(record[networkCol])
+		if err != nil {
+			return nil, fmt.Errorf("invalid network on geoip database line %d: %w", line, err)
+		}
+		latitude, err := strconv.ParseFloat(record[latitudeCol], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid latitude on geoip database line %d: %w", line, err)
+		}
+		longitude, err := strconv.ParseFloat(record[longitudeCol], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid longitude on geoip database line %d: %w", line, err)
+		}
+		// ParseFloat também aceita NaN e Inf, que inutilizariam a distância
+		if !(latitude >= -90 && latitude <= 90 && longitude >= -180 && longitude <= 180) {
+			return nil, fmt.Errorf("coordinates out of range on geoip database line %d", line)
+		}
+
+		location := Location{Latitude: latitude, Longitude: longitude}
+		if okCountry && countryCol < len(record) {
+			location.Country = record[countryCol]
+		}
+		prefix = prefix.Masked()
+		db.networks[prefix] = location
+		if !seen[prefix.Bits()] {
+			seen[prefix.Bits()] = true
+			db.bits = append(db.bits, prefix.Bits())
+		}
+	}
+
+	// Do prefixo mais específico para o mais genérico
+	sort.Sort(sort.Reverse(sort.IntSlice(db.bits)))
+	return db, nil
+}
+
+func (db *geoDatabase) Lookup(ip string) (Location, bool) {
+	addr, ok := parseAddr(ip)
+	if !ok {
+		return Location{}, false
+	}
+	for _, bits := range db.bits {
+		prefix, err := addr.Prefix(bits)
+		if err != nil {
+			continue
+		}
+		if location, ok := db.networks[prefix]; ok {
+			return location, true
+		}
+	}
+	return Location{}, false
+}
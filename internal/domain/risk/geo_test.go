@@ -0,0 +1,122 @@
+package risk
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeGeoDatabase(t *testing.T, lines ...string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "geoip.csv")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	return path
+}
+
+func TestGeoDatabaseLookup(t *testing.T) {
+	path := writeGeoDatabase(t,
+		"Network,Country,Latitude,Longitude",
+		"10.0.0.0/8,BR,-23.55,-46.63",
+		"10.1.0.0/16,PT,38.72,-9.14",
+		"10.1.2.0/24,JP,35.68,139.69",
+		"10.1.2.128/25,,,",
+		"192.0.2.77/24,US,40.71,-74.01",
+		"203.0.113.5/32,AU,-33.87,151.21",
+		"2001:db8::/32,DE,52.52,13.40",
+		"2001:db8:1::/48,FR,48.86,2.35",
+	)
+	db, err := LoadGeoDatabase(path)
+	if err != nil {
+		t.Fatalf("LoadGeoDatabase(): %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		ip      string
+		country string
+		found   bool
+	}{
+		{name: "shortest prefix", ip: "10.200.0.1", country: "BR", found: true},
+		{name: "intermediate prefix", ip: "10.1.200.1", country: "PT", found: true},
+		{name: "longest prefix", ip: "10.1.2.3", country: "JP", found: true},
+		{name: "network without coordinates is skipped", ip: "10.1.2.200", country: "JP", found: true},
+		{name: "unmasked network", ip: "192.0.2.1", country: "US", found: true},
+		{name: "single address", ip: "203.0.113.5", country: "AU", found: true},
+		{name: "neighbour of single address", ip: "203.0.113.6"},
+		{name: "ipv6", ip: "2001:db8:ffff::1", country: "DE", found: true},
+		{name: "longest ipv6 prefix", ip: "2001:db8:1::1", country: "FR", found: true},
+		{name: "ipv6 with zone", ip: "2001:db8:1::1%eth0", country: "FR", found: true},
+		{name: "ipv4-mapped ipv6", ip: "::ffff:10.1.2.3", country: "JP", found: true},
+		{name: "surrounding whitespace", ip: " 10.1.2.3 ", country: "JP", found: true},
+		{name: "unknown network", ip: "198.51.100.1"},
+		{name: "ipv6 outside the networks", ip: "2001:db9::1"},
+		{name: "invalid address", ip: "not-an-ip"},
+		{name: "empty", ip: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			location, found := db.Lookup(tt.ip)
+			if found != tt.found || location.Country != tt.country {
+				t.Fatalf("Lookup(%q) = %+v, %v; want country %q, %v", tt.ip, location, found, tt.country, tt.found)
+			}
+		})
+	}
+
+	location, _ := db.Lookup("10.1.2.3")
+	if location.Latitude != 35.68 || location.Longitude != 139.69 {
+		t.Fatalf("Lookup() coordinates = %v, %v", location.Latitude, location.Longitude)
+	}
+}
+
+func TestLoadGeoDatabaseGeoLite2Columns(t *testing.T) {
+	path := writeGeoDatabase(t,
+		"network,geoname_id,country_iso_code,latitude,longitude,accuracy_radius",
+		"198.51.100.0/24,3469058,BR,-15.78,-47.93,100",
+		"short,row",
+	)
+	db, err := LoadGeoDatabase(path)
+	if err != nil {
+		t.Fatalf("LoadGeoDatabase(): %v", err)
+	}
+	if location, found := db.Lookup("198.51.100.9"); !found || location.Country != "BR" {
+		t.Fatalf("Lookup() = %+v, %v", location, found)
+	}
+}
+
+func TestLoadGeoDatabaseRejectsInvalidFiles(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+	}{
+		{name: "empty file"},
+		{name: "missing coordinates columns", lines: []string{"network,country", "10.0.0.0/8,BR"}},
+		{name: "invalid network", lines: []string{"network,latitude,longitude", "10.0.0.0/33,1,1"}},
+		{name: "invalid latitude", lines: []string{"network,latitude,longitude", "10.0.0.0/8,north,1"}},
+		{name: "latitude out of range", lines: []string{"network,latitude,longitude", "10.0.0.0/8,91,1"}},
+		{name: "longitude out of range", lines: []string{"network,latitude,longitude", "10.0.0.0/8,1,-180.5"}},
+		{name: "not a number", lines: []string{"network,latitude,longitude", "10.0.0.0/8,NaN,1"}},
+		{name: "infinite", lines: []string{"network,latitude,longitude", "10.0.0.0/8,1,Inf"}},
+		{name: "unterminated quote", lines: []string{"network,latitude,longitude", `"10.0.0.0/8,1,1`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "geoip.csv")
+			if err := os.WriteFile(path, []byte(strings.Join(tt.lines, "\n")), 0o600); err != nil {
+				t.Fatalf("WriteFile: %v", err)
+			}
+			if _, err := LoadGeoDatabase(path); err == nil {
+				t.Fatal("LoadGeoDatabase() succeeded")
+			}
+		})
+	}
+
+	if _, err := LoadGeoDatabase(filepath.Join(t.TempDir(), "missing.csv")); err == nil {
+		t.Fatal("LoadGeoDatabase() of a missing file succeeded")
+	}
+}
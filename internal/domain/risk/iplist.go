@@ -0,0 +1,85 @@
+package risk
+
+import (
+	"bufio"
+	"fmt"
+	"net/netip"
+	"os"
+	"strings"
+)
+
+// IPList é um conjunto de endereços e redes, como as listas públicas de IPs
+// maliciosos
+type IPList struct {
+	prefixes []netip.Prefix
+}
+
+// LoadIPLists lê arquivos com um IP ou CIDR por linha. Linhas vazias e
+// comentários iniciados por # ou ; são ignorados, assim como o texto após o
+// primeiro espaço (formato das listas DROP e FireHOL).
+func LoadIPLists(paths ...string) (*IPList, error) {
+	list := &IPList{}
+	for _, path := range paths {
+		if err := list.load(path); err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+func (l *IPList) load(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open ip list: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		entry := strings.TrimSpace(scanner.Text())
+		if entry == "" || strings.HasPrefix(entry, "#") || strings.HasPrefix(entry, ";") {
+			continue
+		}
+		if fields := strings.Fields(entry); len(fields) > 0 {
+			entry = fields[0]
+		}
+
+		if !strings.Contains(entry, "/") {
+			addr, err := netip.ParseAddr(entry)
+			if err != nil {
+				return fmt.Errorf("invalid address on %s line %d: %w", path, line, err)
+			}
+			addr = addr.Unmap()
+			l.prefixes = append(l.prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return fmt.Errorf("invalid network on %s line %d: %w", path, line, err)
+		}
+		l.prefixes = append(l.prefixes, prefix.Masked())
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read ip list %s: %w", path, err)
+	}
+	return nil
+}
+
+// Contains informa se o endereço pertence a alguma entrada da lista
+func (l *IPList) Contains(ip string) bool {
+	addr, ok := parseAddr(ip)
+	if !ok {
+		return false
+	}
+	for _, prefix := range l.prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Len retorna o número de entradas carregadas
+func (l *IPList) Len() int {
+	return len(l.prefixes)
+}
@@ -0,0 +1,18 @@
+package risk
+
+import (
+	"context"
+	"time"
+)
+
+// Repository guarda o estado dos sinais que aprendem com os logins: os
+// contadores de falhas e o último login de cada usuário
+type Repository interface {
+	// CountFailure incrementa e retorna as falhas da chave dentro da janela
+	CountFailure(ctx context.Context, key string, window time.Duration) (int64, error)
+	// Failures retorna as falhas da chave na janela atual
+	Failures(ctx context.Context, key string) (int64, error)
+	// LastLogin retorna nil quando o usuário não tem login registrado
+	LastLogin(ctx context.Context, userID string) (*LastLogin, error)
+	SaveLastLogin(ctx context.Context, userID string, login *LastLogin, ttl time.Duration) error
+}
@@ -0,0 +1,110 @@
+package risk
+
+import (
+	"context"
+	"log"
+	"strconv"
+
+	"github.com/juanjerrah/go_auth_api/internal/domain/audit"
+)
+
+// Signal é um critério de risco. Evaluate retorna a pontuação da tentativa,
+// zero quando o sinal não se aplica.
+type Signal interface {
+	Name() string
+	Evaluate(ctx context.Context, attempt *Attempt) (int, error)
+}
+
+// Observer é implementado pelos sinais que aprendem com o resultado dos
+// logins (contadores de falhas, último local de acesso)
+type Observer interface {
+	Observe(ctx context.Context, attempt *Attempt, success bool) error
+}
+
+type Service interface {
+	// Assess soma os sinais da tentativa e decide entre permitir, exigir o
+	// segundo fator ou bloquear. Cada decisão é registrada na auditoria.
+	Assess(ctx context.Context, attempt *Attempt) *Assessment
+	// Record informa o resultado da tentativa aos sinais que o acompanham
+	Record(ctx context.Context, attempt *Attempt, success bool)
+}
+
+type service struct {
+	signals        []Signal
+	audit          audit.Service
+	mfaThreshold   int
+	blockThreshold int
+}
+
+// NewService cria o motor de risco com os sinais habilitados. Pontuações a
+// partir de mfaThreshold exigem o segundo fator e a partir de blockThreshold
+// bloqueiam o login; um limite zero desativa a respectiva decisão.
+func NewService(signals []Signal, auditService audit.Service, mfaThreshold, blockThreshold int) Service {
+	return &service{
+		signals:        signals,
+		audit:          auditService,
+		mfaThreshold:   mfaThreshold,
+		blockThreshold: blockThreshold,
+	}
+}
+
+// Assess implements Service. Um sinal que falha não pontua, para que a
+// indisponibilidade de uma fonte não impeça os logins.
+func (s *service) Assess(ctx context.Context, attempt *Attempt) *Assessment {
+	assessment := &Assessment{Decision: DecisionAllow, Signals: map[string]int{}}
+	for _, signal := range s.signals {
+		score, err := signal.Evaluate(ctx, attempt)
+		if err != nil {
+			log.Printf("Warning: risk signal %s failed: %v", signal.Name(), err)
+			continue
+		}
+		if score > 0 {
+			assessment.Signals[signal.Name()] = score
+			assessment.Score += score
+		}
+	}
+
+	switch {
+	case s.blockThreshold > 0 && assessment.Score >= s.blockThreshold:
+		assessment.Decision = DecisionBlock
+	case s.mfaThreshold > 0 && assessment.Score >= s.mfaThreshold:
+		assessment.Decision = DecisionRequireMFA
+	}
+
+	metadata := map[string]string{
+		"email":    attempt.Email,
+		"score":    strconv.Itoa(assessment.Score),
+		"decision": string(assessment.Decision),
+	}
+	for name, score := range assessment.Signals {
+		metadata["signal."+name] = strconv.Itoa(score)
+	}
+	event := &audit.Event{
+		Type:     audit.EventRiskAssessed,
+		ActorID:  attempt.UserID,
+		TargetID: attempt.UserID,
+		Metadata: metadata,
+	}
+	if assessment.Decision == DecisionBlock {
+		event.Outcome = audit.OutcomeFailure
+		event.Reason = "blocked by risk policy"
+	}
+	if err := s.audit.Record(ctx, event); err != nil {
+		log.Printf("Warning: failed to record audit event %s: %v", event.Type, err)
+	}
+
+	return assessment
+}
+
+// Record implements Service.
+func (s *service) Record(ctx context.Context, attempt *Attempt, success bool) {
+	for _, signal := range s.signals {
+		observer, ok := signal.(Observer)
+		if !ok {
+			continue
+		}
+		if err := observer.Observe(ctx, attempt, success); err != nil {
+			log.Printf("Warning: risk signal %s failed to record login: %v", signal.Name(), err)
+		}
+	}
+}
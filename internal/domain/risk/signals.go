@@ -0,0 +1,248 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// failureVelocitySignal pontua tentativas após muitas falhas recentes, contadas
+// por e-mail e por IP para pegar tanto ataques a uma conta quanto varreduras
+type failureVelocitySignal struct {
+	repo   Repository
+	weight int
+	limit  int64
+	window time.Duration
+}
+
+// NewFailureVelocitySignal pontua weight quando o e-mail ou o IP somam limit
+// falhas dentro de window
+func NewFailureVelocitySignal(repo Repository, weight int, limit int64, window time.Duration) Signal {
+	return &failureVelocitySignal{repo: repo, weight: weight, limit: limit, window: window}
+}
+
+func (s *failureVelocitySignal) Name() string { return "failure_velocity" }
+
+func (s *failureVelocitySignal) Evaluate(ctx context.Context, attempt *Attempt) (int, error) {
+	for _, key := range s.keys(attempt) {
+		failures, err := s.repo.Failures(ctx, key)
+		if err != nil {
+			return 0, err
+		}
+		if failures >= s.limit {
+			return s.weight, nil
+		}
+	}
+	return 0, nil
+}
+
+func (s *failureVelocitySignal) Observe(ctx context.Context, attempt *Attempt, success bool) error {
+	if success {
+		return nil
+	}
+	for _, key := range s.keys(attempt) {
+		if _, err := s.repo.CountFailure(ctx, key, s.window); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *failureVelocitySignal) keys(attempt *Attempt) []string {
+	var keys []string
+	if attempt.Email != "" {
+		keys = append(keys, "email:"+strings.ToLower(attempt.Email))
+	}
+	if attempt.IP != "" {
+		keys = append(keys, "ip:"+attempt.IP)
+	}
+	return keys
+}
+
+// DeviceHistory informa se o User-Agent já foi usado pelo usuário
+type DeviceHistory interface {
+	KnownDevice(ctx context.Context, userID, userAgent string) (bool, error)
+}
+
+type deviceSignal struct {
+	history DeviceHistory
+	weight  int
+}
+
+// NewDeviceSignal pontua weight quando o login vem de um dispositivo que o
+// usuário ainda não usou
+func NewDeviceSignal(history DeviceHistory, weight int) Signal {
+	return &deviceSignal{history: history, weight: weight}
+}
+
+func (s *deviceSignal) Name() string { return "new_device" }
+
+func (s *deviceSignal) Evaluate(ctx context.Context, attempt *Attempt) (int, error) {
+	if attempt.UserID == "" {
+		return 0, nil
+	}
+	known, err := s.history.KnownDevice(ctx, attempt.UserID, attempt.UserAgent)
+	if err != nil || known {
+		return 0, err
+	}
+	return s.weight, nil
+}
+
+// impossibleTravelSignal compara a localização da tentativa com a do último
+// login bem-sucedido e pontua deslocamentos mais rápidos que maxSpeed
+type impossibleTravelSignal struct {
+	repo     Repository
+	geo      GeoDatabase
+	weight   int
+	maxSpeed float64
+	ttl      time.Duration
+}
+
+// NewImpossibleTravelSignal pontua weight quando a distância até o último
+// login exigiria velocidade acima de maxSpeedKmh. O último login fica guardado
+// por ttl.
+func NewImpossibleTravelSignal(repo Repository, geo GeoDatabase, weight int, maxSpeedKmh float64, ttl time.Duration) Signal {
+	return &impossibleTravelSignal{repo: repo, geo: geo, weight: weight, maxSpeed: maxSpeedKmh, ttl: ttl}
+}
+
+func (s *impossibleTravelSignal) Name() string { return "impossible_travel" }
+
+func (s *impossibleTravelSignal) Evaluate(ctx context.Context, attempt *Attempt) (int, error) {
+	if attempt.UserID == "" {
+		return 0, nil
+	}
+	location, ok := s.geo.Lookup(attempt.IP)
+	if !ok {
+		return 0, nil
+	}
+	last, err := s.repo.LastLogin(ctx, attempt.UserID)
+	if err != nil || last == nil {
+		return 0, err
+	}
+
+	distance := haversine(last.Location, location)
+	hours := attempt.Time.Sub(last.Time).Hours()
+	// Abaixo de ~100 km a imprecisão da base não permite concluir nada
+	if distance < 100 {
+		return 0, nil
+	}
+	if hours <= 0 || distance/hours > s.maxSpeed {
+		return s.weight, nil
+	}
+	return 0, nil
+}
+
+func (s *impossibleTravelSignal) Observe(ctx context.Context, attempt *Attempt, success bool) error {
+	if !success || attempt.UserID == "" {
+		return nil
+	}
+	location, ok := s.geo.Lookup(attempt.IP)
+	if !ok {
+		return nil
+	}
+	return s.repo.SaveLastLogin(ctx, attempt.UserID, &LastLogin{
+		Location: location,
+		IP:       attempt.IP,
+		Time:     attempt.Time,
+	}, s.ttl)
+}
+
+// haversine retorna a distância em km entre duas localizações
+func haversine(a, b Location) float64 {
+	const earthRadius = 6371.0
+	lat1 := a.Latitude * math.Pi / 180
+	lat2 := b.Latitude * math.Pi / 180
+	dLat := lat2 - lat1
+	dLon := (b.Longitude - a.Longitude) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+type ipListSignal struct {
+	list   *IPList
+	weight int
+}
+
+// NewIPListSignal pontua weight para endereços presentes na lista de IPs
+// maliciosos
+func NewIPListSignal(list *IPList, weight int) Signal {
+	return &ipListSignal{list: list, weight: weight}
+}
+
+func (s *ipListSignal) Name() string { return "bad_ip" }
+
+func (s *ipListSignal) Evaluate(ctx context.Context, attempt *Attempt) (int, error) {
+	if s.list.Contains(attempt.IP) {
+		return s.weight, nil
+	}
+	return 0, nil
+}
+
+// quietHoursSignal pontua logins no horário em que a organização não costuma
+// acessar o sistema
+type quietHoursSignal struct {
+	start    int
+	end      int
+	location *time.Location
+	weight   int
+}
+
+// NewQuietHoursSignal pontua weight para logins entre as horas de spec
+// ("22-6": das 22h às 6h) no fuso timezone
+func NewQuietHoursSignal(spec, timezone string, weight int) (Signal, error) {
+	from, to, ok := strings.Cut(spec, "-")
+	if !ok {
+		return nil, fmt.Errorf("invalid quiet hours %q: expected start-end", spec)
+	}
+	start, err := parseHour(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid quiet hours %q: %w", spec, err)
+	}
+	end, err := parseHour(to)
+	if err != nil {
+		return nil, fmt.Errorf("invalid quiet hours %q: %w", spec, err)
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", timezone, err)
+	}
+	return &quietHoursSignal{start: start, end: end, location: location, weight: weight}, nil
+}
+
+func (s *quietHoursSignal) Name() string { return "quiet_hours" }
+
+func (s *quietHoursSignal) Evaluate(ctx context.Context, attempt *Attempt) (int, error) {
+	hour := attempt.Time.In(s.location).Hour()
+	quiet := hour >= s.start && hour < s.end
+	// Intervalos que passam da meia-noite
+	if s.start > s.end {
+		quiet = hour >= s.start || hour < s.end
+	}
+	if quiet {
+		return s.weight, nil
+	}
+	return 0, nil
+}
+
+func parseHour(value string) (int, error) {
+	hour, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || hour < 0 || hour > 24 {
+		return 0, fmt.Errorf("invalid hour %q", value)
+	}
+	return hour % 24, nil
+}
+
+// parseAddr normaliza o IP da tentativa; endereços IPv4 mapeados em IPv6 são
+// tratados como IPv4
+func parseAddr(ip string) (netip.Addr, bool) {
+	addr, err := netip.ParseAddr(strings.TrimSpace(ip))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
@@ -0,0 +1,114 @@
+package saml
+
+import (
+	"crypto/x509"
+	"encoding/xml"
+	"time"
+)
+
+const (
+	nsAssertion = "urn:oasis:names:tc:SAML:2.0:assertion"
+	nsProtocol  = "urn:oasis:names:tc:SAML:2.0:protocol"
+
+	bindingPOST       = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST"
+	statusSuccess     = "urn:oasis:names:tc:SAML:2.0:status:Success"
+	confirmBearer     = "urn:oasis:names:tc:SAML:2.0:cm:bearer"
+	nameIDEmail       = "urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress"
+	nameIDUnspecified = "urn:oasis:names:tc:SAML:1.1:nameid-format:unspecified"
+)
+
+// ProviderConfig configura um provedor de identidade SAML. Certificates são
+// os certificados aceitos na assinatura das respostas. Os atributos mapeiam a
+// asserção para os campos do usuário; sem EmailAttribute, o e-mail é o NameID.
+// Com RoleAttribute, o usuário é admin quando algum valor do atributo está em
+// AdminValues, e a role é sincronizada a cada login. EmailDomains são os
+// domínios administrados pelo IdP: só e-mails deles são tratados como
+// verificados, permitindo vincular ou criar usuários pelo e-mail.
+type ProviderConfig struct {
+	Name                string
+	DisplayName         string
+	IdPEntityID         string
+	IdPSSOURL           string
+	Certificates        []*x509.Certificate
+	EmailAttribute      string
+	NameAttribute       string
+	DepartmentAttribute string
+	RoleAttribute       string
+	AdminValues         []string
+	EmailDomains        []string
+	AllowIdPInitiated   bool
+}
+
+// PendingRequest é um AuthnRequest emitido e ainda sem resposta. LinkUserID,
+// quando presente, é o usuário autenticado que pediu para vincular a conta
+// externa em vez de fazer login.
+type PendingRequest struct {
+	Provider   string `json:"provider"`
+	LinkUserID string `json:"link_user_id,omitempty"`
+}
+
+// ProviderResponse descreve um provedor disponível para login
+type ProviderResponse struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+	LoginURL    string `json:"login_url"`
+	MetadataURL string `json:"metadata_url"`
+}
+
+// Estruturas lidas do XML já verificado (forma canônica do conteúdo assinado)
+
+type response struct {
+	XMLName      xml.Name    `xml:"urn:oasis:names:tc:SAML:2.0:protocol Response"`
+	ID           string      `xml:"ID,attr"`
+	Destination  string      `xml:"Destination,attr"`
+	InResponseTo string      `xml:"InResponseTo,attr"`
+	Issuer       string      `xml:"urn:oasis:names:tc:SAML:2.0:assertion Issuer"`
+	Status       status      `xml:"urn:oasis:names:tc:SAML:2.0:protocol Status"`
+	Assertions   []assertion `xml:"urn:oasis:names:tc:SAML:2.0:assertion Assertion"`
+}
+
+type status struct {
+	StatusCode struct {
+		Value string `xml:"Value,attr"`
+	} `xml:"urn:oasis:names:tc:SAML:2.0:protocol StatusCode"`
+}
+
+type assertion struct {
+	ID                 string             `xml:"ID,attr"`
+	Issuer             string             `xml:"urn:oasis:names:tc:SAML:2.0:assertion Issuer"`
+	Subject            subject            `xml:"urn:oasis:names:tc:SAML:2.0:assertion Subject"`
+	Conditions         *conditions        `xml:"urn:oasis:names:tc:SAML:2.0:assertion Conditions"`
+	AttributeStatement attributeStatement `xml:"urn:oasis:names:tc:SAML:2.0:assertion AttributeStatement"`
+}
+
+type subject struct {
+	NameID struct {
+		Format string `xml:"Format,attr"`
+		Value  string `xml:",chardata"`
+	} `xml:"urn:oasis:names:tc:SAML:2.0:assertion NameID"`
+	Confirmations []subjectConfirmation `xml:"urn:oasis:names:tc:SAML:2.0:assertion SubjectConfirmation"`
+}
+
+type subjectConfirmation struct {
+	Method string `xml:"Method,attr"`
+	Data   struct {
+		NotOnOrAfter time.Time `xml:"NotOnOrAfter,attr"`
+		Recipient    string    `xml:"Recipient,attr"`
+		InResponseTo string    `xml:"InResponseTo,attr"`
+	} `xml:"urn:oasis:names:tc:SAML:2.0:assertion SubjectConfirmationData"`
+}
+
+type conditions struct {
+	NotBefore            time.Time `xml:"NotBefore,attr"`
+	NotOnOrAfter         time.Time `xml:"NotOnOrAfter,attr"`
+	AudienceRestrictions []struct {
+		Audiences []string `xml:"urn:oasis:names:tc:SAML:2.0:assertion Audience"`
+	} `xml:"urn:oasis:names:tc:SAML:2.0:assertion AudienceRestriction"`
+}
+
+type attributeStatement struct {
+	Attributes []struct {
+		Name   string   `xml:"Name,attr"`
+		Values []string `xml:"urn:oasis:names:tc:SAML:2.0:assertion AttributeValue"`
+	} `xml:"urn:oasis:names:tc:SAML:2.0:assertion Attribute"`
+}
@@ -0,0 +1,19 @@
+package saml
+
+import (
+	"context"
+	"time"
+)
+
+// RequestRepository guarda os AuthnRequests emitidos, para validar o
+// InResponseTo das respostas, e os IDs das asserções já usadas, para impedir
+// que uma resposta seja reapresentada.
+type RequestRepository interface {
+	SaveRequest(ctx context.Context, requestID string, request *PendingRequest, ttl time.Duration) error
+	// ConsumeRequest remove o pedido e o retorna (nil se não existir ou tiver
+	// expirado)
+	ConsumeRequest(ctx context.Context, requestID string) (*PendingRequest, error)
+	// MarkAssertionUsed registra a asserção até expires e informa se ela
+	// ainda não havia sido usada
+	MarkAssertionUsed(ctx context.Context, provider, assertionID string, expires time.Time) (bool, error)
+}
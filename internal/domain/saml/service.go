@@ -0,0 +1,410 @@
+package saml
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/juanjerrah/go_auth_api/internal/domain/user"
+	"github.com/juanjerrah/go_auth_api/pkg/common"
+)
+
+// Diferença de relógio tolerada entre o IdP e a API
+const clockSkew = 2 * time.Minute
+
+// Tamanho máximo aceito para uma resposta SAML decodificada
+const maxResponseSize = 1 << 20
+
+var (
+	ErrUnknownProvider      = errors.New("unknown identity provider")
+	ErrInvalidResponse      = errors.New("invalid SAML response")
+	ErrAuthenticationFailed = errors.New("identity provider rejected the authentication")
+	ErrUnsolicitedResponse  = errors.New("unsolicited SAML response not allowed")
+	ErrReplayedAssertion    = errors.New("SAML assertion already used")
+)
+
+type Service interface {
+	Providers() []*ProviderResponse
+	// Metadata retorna os metadados do SP a serem cadastrados no IdP
+	Metadata(providerName string) ([]byte, error)
+	// Begin inicia o login pelo SP e retorna a URL do IdP com o AuthnRequest
+	Begin(ctx context.Context, providerName string) (string, error)
+	// BeginLink inicia, para o usuário autenticado, o vínculo com sua conta
+	// no IdP e retorna a URL do IdP com o AuthnRequest
+	BeginLink(ctx context.Context, providerName, userID string) (string, error)
+	// Complete valida a resposta recebida no ACS (iniciada pelo SP ou pelo
+	// IdP) e autentica o usuário mapeado a partir da asserção. Se a resposta
+	// é de um pedido de BeginLink, vincula a conta ao usuário que o fez e
+	// retorna linked verdadeiro; nesse caso nenhuma sessão deve ser iniciada.
+	Complete(ctx context.Context, providerName, samlResponse string) (usr *user.User, linked bool, err error)
+}
+
+type service struct {
+	providers      map[string]*ProviderConfig
+	order          []string
+	baseURL        string
+	requests       RequestRepository
+	userService    user.Service
+	tokenGenerator common.TokenGenerator
+	requestTTL     time.Duration
+}
+
+// NewService cria o serviço de login SAML. baseURL é a URL pública das rotas
+// SAML; cada provedor tem um SP próprio em {baseURL}/{nome}, cujo entity ID é
+// a URL dos metadados.
+func NewService(configs []ProviderConfig, baseURL string, requests RequestRepository, userService user.Service, tokenGenerator common.TokenGenerator, requestTTL time.Duration) Service {
+	s := &service{
+		providers:      make(map[string]*ProviderConfig, len(configs)),
+		baseURL:        baseURL,
+		requests:       requests,
+		userService:    userService,
+		tokenGenerator: tokenGenerator,
+		requestTTL:     requestTTL,
+	}
+	for i := range configs {
+		s.providers[configs[i].Name] = &configs[i]
+		s.order = append(s.order, configs[i].Name)
+	}
+	return s
+}
+
+// LoadCertificates lê os certificados PEM dos arquivos informados
+func LoadCertificates(paths ...string) ([]*x509.Certificate, error) {
+	var certificates []*x509.Certificate
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for {
+			var block *pem.Block
+			block, data = pem.Decode(data)
+			if block == nil {
+				break
+			}
+			if block.Type != "CERTIFICATE" {
+				continue
+			}
+			certificate, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			certificates = append(certificates, certificate)
+		}
+	}
+	return certificates, nil
+}
+
+// Providers implements Service.
+func (s *service) Providers() []*ProviderResponse {
+	providers := make([]*ProviderResponse, 0, len(s.order))
+	for _, name := range s.order {
+		displayName := s.providers[name].DisplayName
+		if displayName == "" {
+			displayName = name
+		}
+		providers = append(providers, &ProviderResponse{
+			Name:        name,
+			DisplayName: displayName,
+			LoginURL:    s.providerURL(name) + "/login",
+			MetadataURL: s.entityID(name),
+		})
+	}
+	return providers
+}
+
+// Metadata implements Service.
+func (s *service) Metadata(providerName string) ([]byte, error) {
+	if _, ok := s.providers[providerName]; !ok {
+		return nil, ErrUnknownProvider
+	}
+	return spMetadata(s.entityID(providerName), s.acsURL(providerName))
+}
+
+// Begin implements Service.
+func (s *service) Begin(ctx context.Context, providerName string) (string, error) {
+	return s.begin(ctx, providerName, "")
+}
+
+// BeginLink implements Service.
+func (s *service) BeginLink(ctx context.Context, providerName, userID string) (string, error) {
+	return s.begin(ctx, providerName, userID)
+}
+
+func (s *service) begin(ctx context.Context, providerName, linkUserID string) (string, error) {
+	p, ok := s.providers[providerName]
+	if !ok {
+		return "", ErrUnknownProvider
+	}
+
+	token, err := s.tokenGenerator.Generate()
+	if err != nil {
+		return "", err
+	}
+	// IDs SAML são xs:ID e não podem começar com dígito
+	requestID := "_" + token
+
+	location, err := redirectURL(p.IdPSSOURL, requestID, s.entityID(providerName), s.acsURL(providerName))
+	if err != nil {
+		return "", err
+	}
+
+	request := &PendingRequest{Provider: providerName, LinkUserID: linkUserID}
+	if err := s.requests.SaveRequest(ctx, requestID, request, s.requestTTL); err != nil {
+		return "", err
+	}
+	return location, nil
+}
+
+// Complete implements Service.
+func (s *service) Complete(ctx context.Context, providerName, samlResponse string) (*user.User, bool, error) {
+	p, ok := s.providers[providerName]
+	if !ok {
+		return nil, false, ErrUnknownProvider
+	}
+
+	resp, a, err := s.parseResponse(p, samlResponse)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if resp.Status.StatusCode.Value != statusSuccess {
+		return nil, false, fmt.Errorf("%w: %s", ErrAuthenticationFailed, resp.Status.StatusCode.Value)
+	}
+
+	acsURL := s.acsURL(providerName)
+	if resp.Destination != "" && resp.Destination != acsURL {
+		return nil, false, fmt.Errorf("%w: unexpected destination", ErrInvalidResponse)
+	}
+	if a.Issuer != p.IdPEntityID || (resp.Issuer != "" && resp.Issuer != p.IdPEntityID) {
+		return nil, false, fmt.Errorf("%w: unexpected issuer", ErrInvalidResponse)
+	}
+
+	now := time.Now()
+	if err := s.checkConditions(a, s.entityID(providerName), now); err != nil {
+		return nil, false, err
+	}
+	confirmation, err := bearerConfirmation(a, acsURL, now)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Respostas a um pedido do SP precisam corresponder a um AuthnRequest
+	// ainda pendente; as demais só são aceitas se o provedor permitir
+	var linkUserID string
+	if inResponseTo := confirmation.Data.InResponseTo; inResponseTo != "" {
+		if resp.InResponseTo != "" && resp.InResponseTo != inResponseTo {
+			return nil, false, fmt.Errorf("%w: InResponseTo mismatch", ErrInvalidResponse)
+		}
+		request, err := s.requests.ConsumeRequest(ctx, inResponseTo)
+		if err != nil {
+			return nil, false, err
+		}
+		if request == nil || request.Provider != providerName {
+			return nil, false, fmt.Errorf("%w: unknown or expired request", ErrInvalidResponse)
+		}
+		linkUserID = request.LinkUserID
+	} else if !p.AllowIdPInitiated || resp.InResponseTo != "" {
+		return nil, false, ErrUnsolicitedResponse
+	}
+
+	if a.ID == "" {
+		return nil, false, fmt.Errorf("%w: assertion without ID", ErrInvalidResponse)
+	}
+	expires := confirmation.Data.NotOnOrAfter
+	if a.Conditions.NotOnOrAfter.After(expires) {
+		expires = a.Conditions.NotOnOrAfter
+	}
+	fresh, err := s.requests.MarkAssertionUsed(ctx, providerName, a.ID, expires.Add(clockSkew))
+	if err != nil {
+		return nil, false, err
+	}
+	if !fresh {
+		return nil, false, ErrReplayedAssertion
+	}
+
+	identity, err := mapIdentity(p, a)
+	if err != nil {
+		return nil, false, err
+	}
+	if linkUserID != "" {
+		usr, err := s.userService.LinkFederatedIdentity(ctx, linkUserID, identity)
+		if err != nil {
+			return nil, false, err
+		}
+		return usr, true, nil
+	}
+
+	usr, err := s.userService.AuthenticateFederated(ctx, identity)
+	if err != nil {
+		return nil, false, err
+	}
+	return usr, false, nil
+}
+
+// parseResponse decodifica a resposta e verifica sua assinatura. A asserção
+// retornada vem sempre do conteúdo assinado: da resposta, quando ela é
+// assinada, ou da própria asserção.
+func (s *service) parseResponse(p *ProviderConfig, samlResponse string) (*response, *assertion, error) {
+	data, err := decodeBase64(samlResponse)
+	if err != nil || len(data) > maxResponseSize {
+		return nil, nil, fmt.Errorf("%w: malformed encoding", ErrInvalidResponse)
+	}
+
+	root, err := parseXML(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+	if !root.is(nsProtocol, "Response") {
+		return nil, nil, fmt.Errorf("%w: not a Response", ErrInvalidResponse)
+	}
+	if len(root.elements(nsAssertion, "EncryptedAssertion")) > 0 {
+		return nil, nil, fmt.Errorf("%w: encrypted assertions are not supported", ErrInvalidResponse)
+	}
+	assertions := root.elements(nsAssertion, "Assertion")
+	if len(assertions) != 1 {
+		return nil, nil, fmt.Errorf("%w: expected exactly one assertion", ErrInvalidResponse)
+	}
+
+	var resp response
+	signedResponse, err := verifySignature(root, p.Certificates)
+	switch {
+	case err == nil:
+		if err := xml.Unmarshal(signedResponse, &resp); err != nil || len(resp.Assertions) != 1 {
+			return nil, nil, fmt.Errorf("%w: malformed signed response", ErrInvalidResponse)
+		}
+		return &resp, &resp.Assertions[0], nil
+	case !errors.Is(err, errMissingSignature):
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+
+	signedAssertion, err := verifySignature(assertions[0], p.Certificates)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+
+	// Sem assinatura na resposta, seus campos só servem para recusá-la
+	var a assertion
+	if err := xml.Unmarshal(data, &resp); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+	if err := xml.Unmarshal(signedAssertion, &a); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+	return &resp, &a, nil
+}
+
+// checkConditions valida o período de validade e a audiência da asserção
+func (s *service) checkConditions(a *assertion, entityID string, now time.Time) error {
+	if a.Conditions == nil {
+		return fmt.Errorf("%w: missing conditions", ErrInvalidResponse)
+	}
+	if !a.Conditions.NotBefore.IsZero() && now.Add(clockSkew).Before(a.Conditions.NotBefore) {
+		return fmt.Errorf("%w: assertion not yet valid", ErrInvalidResponse)
+	}
+	if !a.Conditions.NotOnOrAfter.IsZero() && !now.Add(-clockSkew).Before(a.Conditions.NotOnOrAfter) {
+		return fmt.Errorf("%w: assertion expired", ErrInvalidResponse)
+	}
+
+	// Todas as restrições de audiência precisam incluir este SP
+	if len(a.Conditions.AudienceRestrictions) == 0 {
+		return fmt.Errorf("%w: missing audience restriction", ErrInvalidResponse)
+	}
+	for _, restriction := range a.Conditions.AudienceRestrictions {
+		found := false
+		for _, audience := range restriction.Audiences {
+			if strings.TrimSpace(audience) == entityID {
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("%w: audience mismatch", ErrInvalidResponse)
+		}
+	}
+	return nil
+}
+
+// bearerConfirmation retorna a confirmação bearer válida para este ACS
+func bearerConfirmation(a *assertion, acsURL string, now time.Time) (*subjectConfirmation, error) {
+	for i, confirmation := range a.Subject.Confirmations {
+		if confirmation.Method != confirmBearer || confirmation.Data.Recipient != acsURL {
+			continue
+		}
+		if confirmation.Data.NotOnOrAfter.IsZero() || !now.Add(-clockSkew).Before(confirmation.Data.NotOnOrAfter) {
+			continue
+		}
+		return &a.Subject.Confirmations[i], nil
+	}
+	return nil, fmt.Errorf("%w: no valid bearer subject confirmation", ErrInvalidResponse)
+}
+
+// mapIdentity converte o NameID e os atributos da asserção nos dados do
+// usuário. A assinatura prova que a asserção veio do IdP, não que ele
+// administra o e-mail: só e-mails dos domínios configurados para o provedor
+// são tratados como verificados.
+func mapIdentity(p *ProviderConfig, a *assertion) (*user.FederatedIdentity, error) {
+	attributes := map[string][]string{}
+	for _, attribute := range a.AttributeStatement.Attributes {
+		attributes[attribute.Name] = append(attributes[attribute.Name], attribute.Values...)
+	}
+	first := func(name string) string {
+		if values := attributes[name]; name != "" && len(values) > 0 {
+			return strings.TrimSpace(values[0])
+		}
+		return ""
+	}
+
+	nameID := strings.TrimSpace(a.Subject.NameID.Value)
+	if nameID == "" {
+		return nil, fmt.Errorf("%w: missing NameID", ErrInvalidResponse)
+	}
+
+	email := nameID
+	if p.EmailAttribute != "" {
+		email = first(p.EmailAttribute)
+	}
+	if !strings.Contains(email, "@") {
+		return nil, fmt.Errorf("%w: assertion has no email", ErrInvalidResponse)
+	}
+
+	identity := &user.FederatedIdentity{
+		Protocol:      user.AuthProviderSAML,
+		Provider:      p.Name,
+		Subject:       nameID,
+		Email:         email,
+		EmailVerified: user.EmailInDomains(email, p.EmailDomains),
+		Name:          first(p.NameAttribute),
+		Department:    first(p.DepartmentAttribute),
+		SyncProfile:   true,
+	}
+
+	if p.RoleAttribute != "" {
+		identity.Role = user.RoleUser
+		for _, value := range attributes[p.RoleAttribute] {
+			for _, adminValue := range p.AdminValues {
+				if strings.EqualFold(strings.TrimSpace(value), adminValue) {
+					identity.Role = user.RoleAdmin
+				}
+			}
+		}
+	}
+	return identity, nil
+}
+
+func (s *service) providerURL(name string) string {
+	return s.baseURL + "/" + name
+}
+
+func (s *service) entityID(name string) string {
+	return s.providerURL(name) + "/metadata"
+}
+
+func (s *service) acsURL(name string) string {
+	return s.providerURL(name) + "/acs"
+}
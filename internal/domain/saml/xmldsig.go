@@ -0,0 +1,447 @@
+package saml
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+// Verificação de assinaturas XML (XML-DSig) no formato usado por SAML:
+// assinatura enveloped, uma única referência ao elemento assinado pelo seu
+// ID e canonicalização exclusiva (exc-c14n). SHA-1 não é aceito.
+
+const (
+	nsDSig = "http://www.w3.org/2000/09/xmldsig#"
+	nsXML  = "http://www.w3.org/XML/1998/namespace"
+
+	algExcC14N     = "http://www.w3.org/2001/10/xml-exc-c14n#"
+	algEnveloped   = "http://www.w3.org/2000/09/xmldsig#enveloped-signature"
+	algSHA256      = "http://www.w3.org/2001/04/xmlenc#sha256"
+	algSHA512      = "http://www.w3.org/2001/04/xmlenc#sha512"
+	algRSASHA256   = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha256"
+	algRSASHA512   = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha512"
+	algECDSASHA256 = "http://www.w3.org/2001/04/xmldsig-more#ecdsa-sha256"
+)
+
+var (
+	errMissingSignature = errors.New("element is not signed")
+	errInvalidSignature = errors.New("invalid signature")
+)
+
+// xmlElement é um elemento do documento com os namespaces já resolvidos.
+// children contém *xmlElement e xmlText, na ordem do documento.
+type xmlElement struct {
+	prefix   string
+	local    string
+	space    string
+	nsDecls  map[string]string
+	attrs    []xmlAttr
+	parent   *xmlElement
+	children []any
+}
+
+type xmlAttr struct {
+	prefix string
+	local  string
+	space  string
+	value  string
+}
+
+type xmlText string
+
+// parseXML lê o documento em uma árvore. DTDs são recusados, evitando
+// expansão de entidades e referências externas.
+func parseXML(data []byte) (*xmlElement, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+
+	var root, current *xmlElement
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			el := &xmlElement{prefix: t.Name.Space, local: t.Name.Local, parent: current, nsDecls: map[string]string{}}
+			for _, attr := range t.Attr {
+				switch {
+				case attr.Name.Space == "xmlns":
+					el.nsDecls[attr.Name.Local] = attr.Value
+				case attr.Name.Space == "" && attr.Name.Local == "xmlns":
+					el.nsDecls[""] = attr.Value
+				default:
+					el.attrs = append(el.attrs, xmlAttr{prefix: attr.Name.Space, local: attr.Name.Local, value: attr.Value})
+				}
+			}
+
+			var ok bool
+			if el.space, ok = el.lookup(el.prefix); !ok {
+				return nil, fmt.Errorf("undeclared namespace prefix %q", el.prefix)
+			}
+			for i := range el.attrs {
+				// Atributos sem prefixo não pertencem ao namespace padrão
+				if el.attrs[i].prefix == "" {
+					continue
+				}
+				if el.attrs[i].space, ok = el.lookup(el.attrs[i].prefix); !ok {
+					return nil, fmt.Errorf("undeclared namespace prefix %q", el.attrs[i].prefix)
+				}
+			}
+
+			if current == nil {
+				if root != nil {
+					return nil, errors.New("multiple root elements")
+				}
+				root = el
+			} else {
+				current.children = append(current.children, el)
+			}
+			current = el
+		case xml.EndElement:
+			if current == nil || t.Name.Space != current.prefix || t.Name.Local != current.local {
+				return nil, errors.New("mismatched end element")
+			}
+			current = current.parent
+		case xml.CharData:
+			if current != nil {
+				current.children = append(current.children, xmlText(t))
+			}
+		case xml.Directive:
+			return nil, errors.New("DTD is not allowed")
+		}
+	}
+
+	if root == nil || current != nil {
+		return nil, errors.New("incomplete document")
+	}
+	return root, nil
+}
+
+// lookup resolve o prefixo no escopo do elemento
+func (e *xmlElement) lookup(prefix string) (string, bool) {
+	if prefix == "xml" {
+		return nsXML, true
+	}
+	for el := e; el != nil; el = el.parent {
+		if uri, ok := el.nsDecls[prefix]; ok {
+			return uri, true
+		}
+	}
+	// Sem declaração, o namespace padrão é vazio
+	return "", prefix == ""
+}
+
+func (e *xmlElement) is(space, local string) bool {
+	return e.space == space && e.local == local
+}
+
+func (e *xmlElement) attr(local string) string {
+	for _, attr := range e.attrs {
+		if attr.space == "" && attr.local == local {
+			return attr.value
+		}
+	}
+	return ""
+}
+
+func (e *xmlElement) elements(space, local string) []*xmlElement {
+	var found []*xmlElement
+	for _, child := range e.children {
+		if el, ok := child.(*xmlElement); ok && el.is(space, local) {
+			found = append(found, el)
+		}
+	}
+	return found
+}
+
+func (e *xmlElement) element(space, local string) *xmlElement {
+	if found := e.elements(space, local); len(found) == 1 {
+		return found[0]
+	}
+	return nil
+}
+
+func (e *xmlElement) text() string {
+	var builder strings.Builder
+	for _, child := range e.children {
+		if text, ok := child.(xmlText); ok {
+			builder.WriteString(string(text))
+		}
+	}
+	return builder.String()
+}
+
+// verifySignature verifica a assinatura enveloped do elemento com algum dos
+// certificados e retorna a forma canônica do conteúdo assinado. Os dados do
+// elemento devem ser lidos desse retorno, e não do documento original, para
+// que conteúdo não assinado nunca seja usado (signature wrapping).
+func verifySignature(el *xmlElement, certificates []*x509.Certificate) ([]byte, error) {
+	signatures := el.elements(nsDSig, "Signature")
+	if len(signatures) == 0 {
+		return nil, errMissingSignature
+	}
+	if len(signatures) > 1 {
+		return nil, fmt.Errorf("%w: multiple signatures", errInvalidSignature)
+	}
+	signature := signatures[0]
+
+	signedInfo := signature.element(nsDSig, "SignedInfo")
+	if signedInfo == nil {
+		return nil, fmt.Errorf("%w: missing SignedInfo", errInvalidSignature)
+	}
+	canonicalization := signedInfo.element(nsDSig, "CanonicalizationMethod")
+	if canonicalization == nil || canonicalization.attr("Algorithm") != algExcC14N {
+		return nil, fmt.Errorf("%w: unsupported canonicalization", errInvalidSignature)
+	}
+	signatureMethod := signedInfo.element(nsDSig, "SignatureMethod")
+	if signatureMethod == nil {
+		return nil, fmt.Errorf("%w: missing SignatureMethod", errInvalidSignature)
+	}
+
+	reference := signedInfo.element(nsDSig, "Reference")
+	id := el.attr("ID")
+	if reference == nil || id == "" || reference.attr("URI") != "#"+id {
+		return nil, fmt.Errorf("%w: reference does not match signed element", errInvalidSignature)
+	}
+
+	var prefixes []string
+	canonical := false
+	if transforms := reference.element(nsDSig, "Transforms"); transforms != nil {
+		for _, transform := range transforms.elements(nsDSig, "Transform") {
+			switch transform.attr("Algorithm") {
+			case algEnveloped:
+			case algExcC14N:
+				canonical = true
+				prefixes = inclusivePrefixes(transform)
+			default:
+				return nil, fmt.Errorf("%w: unsupported transform %s", errInvalidSignature, transform.attr("Algorithm"))
+			}
+		}
+	}
+	if !canonical {
+		return nil, fmt.Errorf("%w: missing exclusive canonicalization transform", errInvalidSignature)
+	}
+
+	digestMethod := reference.element(nsDSig, "DigestMethod")
+	digestValue := reference.element(nsDSig, "DigestValue")
+	if digestMethod == nil || digestValue == nil {
+		return nil, fmt.Errorf("%w: missing digest", errInvalidSignature)
+	}
+	digestHash, err := digestAlgorithm(digestMethod.attr("Algorithm"))
+	if err != nil {
+		return nil, err
+	}
+	expectedDigest, err := decodeBase64(digestValue.text())
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed digest", errInvalidSignature)
+	}
+
+	signed := canonicalize(el, prefixes, signature)
+	hasher := digestHash.New()
+	hasher.Write(signed)
+	if subtle.ConstantTimeCompare(hasher.Sum(nil), expectedDigest) != 1 {
+		return nil, fmt.Errorf("%w: digest mismatch", errInvalidSignature)
+	}
+
+	signatureValue := signature.element(nsDSig, "SignatureValue")
+	if signatureValue == nil {
+		return nil, fmt.Errorf("%w: missing SignatureValue", errInvalidSignature)
+	}
+	value, err := decodeBase64(signatureValue.text())
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed SignatureValue", errInvalidSignature)
+	}
+
+	signedInfoBytes := canonicalize(signedInfo, inclusivePrefixes(canonicalization), nil)
+	algorithm := signatureMethod.attr("Algorithm")
+	for _, certificate := range certificates {
+		if verifySignatureValue(certificate.PublicKey, algorithm, signedInfoBytes, value) == nil {
+			return signed, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: no trusted certificate matches", errInvalidSignature)
+}
+
+func digestAlgorithm(algorithm string) (crypto.Hash, error) {
+	switch algorithm {
+	case algSHA256:
+		return crypto.SHA256, nil
+	case algSHA512:
+		return crypto.SHA512, nil
+	default:
+		return 0, fmt.Errorf("%w: unsupported digest %s", errInvalidSignature, algorithm)
+	}
+}
+
+func verifySignatureValue(publicKey any, algorithm string, data, value []byte) error {
+	var hash crypto.Hash
+	switch algorithm {
+	case algRSASHA256, algECDSASHA256:
+		hash = crypto.SHA256
+	case algRSASHA512:
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("%w: unsupported signature method %s", errInvalidSignature, algorithm)
+	}
+	hasher := hash.New()
+	hasher.Write(data)
+	digest := hasher.Sum(nil)
+
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		if algorithm == algECDSASHA256 {
+			return errInvalidSignature
+		}
+		return rsa.VerifyPKCS1v15(key, hash, digest, value)
+	case *ecdsa.PublicKey:
+		// XML-DSig codifica a assinatura ECDSA como r || s, sem ASN.1
+		if algorithm != algECDSASHA256 || len(value)%2 != 0 {
+			return errInvalidSignature
+		}
+		r := new(big.Int).SetBytes(value[:len(value)/2])
+		s := new(big.Int).SetBytes(value[len(value)/2:])
+		if !ecdsa.Verify(key, digest, r, s) {
+			return errInvalidSignature
+		}
+		return nil
+	default:
+		return errInvalidSignature
+	}
+}
+
+// inclusivePrefixes lê a lista InclusiveNamespaces de uma transformação exc-c14n
+func inclusivePrefixes(transform *xmlElement) []string {
+	for _, child := range transform.children {
+		if el, ok := child.(*xmlElement); ok && el.is(algExcC14N, "InclusiveNamespaces") {
+			return strings.Fields(el.attr("PrefixList"))
+		}
+	}
+	return nil
+}
+
+func decodeBase64(value string) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(value), ""))
+}
+
+// canonicalize serializa o elemento em Exclusive XML Canonicalization 1.0
+// (sem comentários), omitindo exclude (a assinatura enveloped)
+func canonicalize(el *xmlElement, inclusive []string, exclude *xmlElement) []byte {
+	included := map[string]bool{}
+	for _, prefix := range inclusive {
+		if prefix == "#default" {
+			prefix = ""
+		}
+		included[prefix] = true
+	}
+
+	var buf bytes.Buffer
+	writeCanonical(&buf, el, map[string]string{}, included, exclude)
+	return buf.Bytes()
+}
+
+func writeCanonical(buf *bytes.Buffer, el *xmlElement, rendered map[string]string, inclusive map[string]bool, exclude *xmlElement) {
+	// Declara apenas os namespaces visivelmente usados pelo elemento (ou
+	// listados como inclusivos) que ainda não foram declarados por um ancestral
+	used := map[string]bool{el.prefix: true}
+	for _, attr := range el.attrs {
+		if attr.prefix != "" {
+			used[attr.prefix] = true
+		}
+	}
+	for prefix := range inclusive {
+		if _, ok := el.lookup(prefix); ok {
+			used[prefix] = true
+		}
+	}
+
+	next := rendered
+	var declared []string
+	for prefix := range used {
+		if prefix == "xml" {
+			continue
+		}
+		uri, _ := el.lookup(prefix)
+		if current, ok := rendered[prefix]; (ok && current == uri) || (!ok && prefix == "" && uri == "") {
+			continue
+		}
+		if len(declared) == 0 {
+			next = make(map[string]string, len(rendered)+1)
+			for k, v := range rendered {
+				next[k] = v
+			}
+		}
+		next[prefix] = uri
+		declared = append(declared, prefix)
+	}
+	sort.Strings(declared)
+
+	attrs := append([]xmlAttr(nil), el.attrs...)
+	sort.Slice(attrs, func(i, j int) bool {
+		if attrs[i].space != attrs[j].space {
+			return attrs[i].space < attrs[j].space
+		}
+		return attrs[i].local < attrs[j].local
+	})
+
+	name := qualifiedName(el.prefix, el.local)
+	buf.WriteString("<" + name)
+	for _, prefix := range declared {
+		if prefix == "" {
+			buf.WriteString(` xmlns="`)
+		} else {
+			buf.WriteString(` xmlns:` + prefix + `="`)
+		}
+		buf.WriteString(escapeAttr(next[prefix]) + `"`)
+	}
+	for _, attr := range attrs {
+		buf.WriteString(" " + qualifiedName(attr.prefix, attr.local) + `="` + escapeAttr(attr.value) + `"`)
+	}
+	buf.WriteString(">")
+
+	for _, child := range el.children {
+		switch c := child.(type) {
+		case *xmlElement:
+			if c != exclude {
+				writeCanonical(buf, c, next, inclusive, exclude)
+			}
+		case xmlText:
+			buf.WriteString(escapeText(string(c)))
+		}
+	}
+	buf.WriteString("</" + name + ">")
+}
+
+func qualifiedName(prefix, local string) string {
+	if prefix == "" {
+		return local
+	}
+	return prefix + ":" + local
+}
+
+var (
+	textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\r", "&#xD;")
+	attrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", `"`, "&quot;", "\t", "&#x9;", "\n", "&#xA;", "\r", "&#xD;")
+)
+
+func escapeText(value string) string {
+	return textEscaper.Replace(value)
+}
+
+func escapeAttr(value string) string {
+	return attrEscaper.Replace(value)
+}
@@ -0,0 +1,386 @@
+package saml
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCanonicalize(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		path      []string
+		inclusive []string
+		want      string
+	}{
+		{
+			name:  "attributes sorted and empty elements expanded",
+			input: `<a xmlns="urn:a" b="2" a="1"/>`,
+			want:  `<a xmlns="urn:a" a="1" b="2"></a>`,
+		},
+		{
+			name:  "namespaced attributes sorted by namespace URI",
+			input: `<e xmlns:b="urn:b" xmlns:a="urn:z" b:attr="1" a:attr="2" attr="0"/>`,
+			want:  `<e xmlns:a="urn:z" xmlns:b="urn:b" attr="0" b:attr="1" a:attr="2"></e>`,
+		},
+		{
+			name:  "unused namespaces of ancestors are omitted",
+			input: `<r xmlns:x="urn:x" xmlns:y="urn:y"><x:c/></r>`,
+			path:  []string{"c"},
+			want:  `<x:c xmlns:x="urn:x"></x:c>`,
+		},
+		{
+			name:      "inclusive namespaces are kept",
+			input:     `<r xmlns:x="urn:x" xmlns:y="urn:y"><x:c/></r>`,
+			path:      []string{"c"},
+			inclusive: []string{"y"},
+			want:      `<x:c xmlns:x="urn:x" xmlns:y="urn:y"></x:c>`,
+		},
+		{
+			name:  "redundant declarations are dropped",
+			input: `<r xmlns="urn:a"><c xmlns="urn:a"><d/></c></r>`,
+			want:  `<r xmlns="urn:a"><c><d></d></c></r>`,
+		},
+		{
+			name:  "undeclared default namespace",
+			input: `<r xmlns="urn:a"><c xmlns=""/></r>`,
+			want:  `<r xmlns="urn:a"><c xmlns=""></c></r>`,
+		},
+		{
+			name:  "text and attribute escaping",
+			input: `<e a="&quot;&#9;&lt;">1 &lt; 2 &gt; 0 &amp; "q"</e>`,
+			want:  `<e a="&quot;&#x9;&lt;">1 &lt; 2 &gt; 0 &amp; "q"</e>`,
+		},
+		{
+			name:  "comments removed and CDATA escaped",
+			input: `<e><!-- note --><![CDATA[<x>]]></e>`,
+			want:  `<e>&lt;x&gt;</e>`,
+		},
+		{
+			name:  "xml namespace is never declared",
+			input: `<e xml:lang="pt"/>`,
+			want:  `<e xml:lang="pt"></e>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := parseXML([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("parseXML: %v", err)
+			}
+			el := root
+			for _, local := range tt.path {
+				el = childByLocal(t, el, local)
+			}
+
+			if got := string(canonicalize(el, tt.inclusive, nil)); got != tt.want {
+				t.Fatalf("canonicalize() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseXMLRejectsInvalidDocuments(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"DTD", `<!DOCTYPE r [<!ENTITY e "x">]><r>&e;</r>`},
+		{"undeclared element prefix", `<x:r/>`},
+		{"undeclared attribute prefix", `<r x:a="1"/>`},
+		{"multiple roots", `<r/><s/>`},
+		{"mismatched end element", `<r><a></b></r>`},
+		{"incomplete", `<r><a>`},
+		{"empty", ``},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseXML([]byte(tt.input)); err == nil {
+				t.Fatalf("parseXML(%q) succeeded", tt.input)
+			}
+		})
+	}
+}
+
+// signedResponse descreve uma resposta assinada pelo teste; campos vazios
+// usam algoritmos aceitos e a referência ao próprio Response.
+type signedResponse struct {
+	key             crypto.Signer
+	signatureMethod string
+	digestMethod    string
+	reference       string
+	transforms      string
+}
+
+const (
+	testNSProtocol    = "urn:oasis:names:tc:SAML:2.0:protocol"
+	testNSAssertion   = "urn:oasis:names:tc:SAML:2.0:assertion"
+	defaultTransforms = `<ds:Transform Algorithm="` + algEnveloped + `"/><ds:Transform Algorithm="` + algExcC14N + `"/>`
+)
+
+// sign monta a resposta com a assinatura enveloped logo após o Issuer
+func (r signedResponse) sign(t *testing.T) string {
+	t.Helper()
+
+	if r.signatureMethod == "" {
+		r.signatureMethod = algRSASHA256
+	}
+	if r.digestMethod == "" {
+		r.digestMethod = algSHA256
+	}
+	if r.reference == "" {
+		r.reference = "#_response"
+	}
+	if r.transforms == "" {
+		r.transforms = defaultTransforms
+	}
+
+	head := `<samlp:Response xmlns:samlp="` + testNSProtocol + `" ID="_response" Version="2.0"><saml:Issuer xmlns:saml="` + testNSAssertion + `">https://idp.example.com</saml:Issuer>`
+	tail := `<saml:Assertion xmlns:saml="` + testNSAssertion + `" ID="_assertion"><saml:Subject>ana@example.com</saml:Subject></saml:Assertion></samlp:Response>`
+
+	// A assinatura enveloped é removida antes do digest, então o documento sem
+	// ela tem a mesma forma canônica
+	unsigned, err := parseXML([]byte(head + tail))
+	if err != nil {
+		t.Fatalf("parseXML: %v", err)
+	}
+	hash, err := digestAlgorithm(r.digestMethod)
+	if err != nil {
+		// Algoritmo recusado: o valor do digest não chega a ser conferido
+		hash = crypto.SHA256
+	}
+	hasher := hash.New()
+	hasher.Write(canonicalize(unsigned, nil, nil))
+	digest := base64.StdEncoding.EncodeToString(hasher.Sum(nil))
+
+	signedInfo := `<ds:SignedInfo><ds:CanonicalizationMethod Algorithm="` + algExcC14N + `"/>` +
+		`<ds:SignatureMethod Algorithm="` + r.signatureMethod + `"/>` +
+		`<ds:Reference URI="` + r.reference + `"><ds:Transforms>` + r.transforms + `</ds:Transforms>` +
+		`<ds:DigestMethod Algorithm="` + r.digestMethod + `"/><ds:DigestValue>` + digest + `</ds:DigestValue></ds:Reference></ds:SignedInfo>`
+	signature := `<ds:Signature xmlns:ds="` + nsDSig + `">` + signedInfo + `<ds:SignatureValue>%s</ds:SignatureValue></ds:Signature>`
+
+	document, err := parseXML([]byte(head + strings.Replace(signature, "%s", "", 1) + tail))
+	if err != nil {
+		t.Fatalf("parseXML: %v", err)
+	}
+	info := childByLocal(t, childByLocal(t, document, "Signature"), "SignedInfo")
+	value := signValue(t, r.key, r.signatureMethod, canonicalize(info, nil, nil))
+
+	return head + strings.Replace(signature, "%s", base64.StdEncoding.EncodeToString(value), 1) + tail
+}
+
+func signValue(t *testing.T, key crypto.Signer, algorithm string, data []byte) []byte {
+	t.Helper()
+
+	hash := crypto.SHA256
+	if algorithm == algRSASHA512 {
+		hash = crypto.SHA512
+	}
+	hasher := hash.New()
+	hasher.Write(data)
+	digest := hasher.Sum(nil)
+
+	switch k := key.(type) {
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest)
+		if err != nil {
+			t.Fatalf("ecdsa.Sign: %v", err)
+		}
+		size := (k.Curve.Params().BitSize + 7) / 8
+		value := make([]byte, 2*size)
+		r.FillBytes(value[:size])
+		s.FillBytes(value[size:])
+		return value
+	default:
+		value, err := key.Sign(rand.Reader, digest, hash)
+		if err != nil {
+			t.Fatalf("Sign: %v", err)
+		}
+		return value
+	}
+}
+
+func newCertificate(t *testing.T, key crypto.Signer) *x509.Certificate {
+	t.Helper()
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "idp.example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatalf("CreateCertificate: %v", err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("ParseCertificate: %v", err)
+	}
+	return certificate
+}
+
+func childByLocal(t *testing.T, el *xmlElement, local string) *xmlElement {
+	t.Helper()
+
+	for _, child := range el.children {
+		if c, ok := child.(*xmlElement); ok && c.local == local {
+			return c
+		}
+	}
+	t.Fatalf("element %s has no child %s", el.local, local)
+	return nil
+}
+
+func TestVerifySignature(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	trusted := []*x509.Certificate{newCertificate(t, rsaKey), newCertificate(t, ecKey)}
+
+	tests := []struct {
+		name     string
+		response signedResponse
+		tamper   func(document string) string
+		want     error
+	}{
+		{name: "rsa-sha256", response: signedResponse{key: rsaKey}},
+		{name: "rsa-sha512", response: signedResponse{key: rsaKey, signatureMethod: algRSASHA512, digestMethod: algSHA512}},
+		{name: "ecdsa-sha256", response: signedResponse{key: ecKey, signatureMethod: algECDSASHA256}},
+		{
+			name:     "untrusted certificate",
+			response: signedResponse{key: otherKey},
+			want:     errInvalidSignature,
+		},
+		{
+			name:     "content changed after signing",
+			response: signedResponse{key: rsaKey},
+			tamper: func(document string) string {
+				return strings.Replace(document, "ana@example.com", "eve@example.com", 1)
+			},
+			want: errInvalidSignature,
+		},
+		{
+			name:     "element added after signing",
+			response: signedResponse{key: rsaKey},
+			tamper: func(document string) string {
+				return strings.Replace(document, "</samlp:Response>", `<saml:Assertion xmlns:saml="`+testNSAssertion+`" ID="_evil"/></samlp:Response>`, 1)
+			},
+			want: errInvalidSignature,
+		},
+		{
+			name:     "signature value changed",
+			response: signedResponse{key: rsaKey},
+			tamper: func(document string) string {
+				start := strings.Index(document, "<ds:SignatureValue>") + len("<ds:SignatureValue>")
+				flipped := "A"
+				if document[start] == 'A' {
+					flipped = "B"
+				}
+				return document[:start] + flipped + document[start+1:]
+			},
+			want: errInvalidSignature,
+		},
+		{
+			name:     "missing signature",
+			response: signedResponse{key: rsaKey},
+			tamper: func(document string) string {
+				start := strings.Index(document, "<ds:Signature ")
+				end := strings.Index(document, "</ds:Signature>") + len("</ds:Signature>")
+				return document[:start] + document[end:]
+			},
+			want: errMissingSignature,
+		},
+		{
+			name:     "multiple signatures",
+			response: signedResponse{key: rsaKey},
+			tamper: func(document string) string {
+				start := strings.Index(document, "<ds:Signature ")
+				end := strings.Index(document, "</ds:Signature>") + len("</ds:Signature>")
+				return document[:end] + document[start:end] + document[end:]
+			},
+			want: errInvalidSignature,
+		},
+		{
+			name:     "reference to another element",
+			response: signedResponse{key: rsaKey, reference: "#_assertion"},
+			want:     errInvalidSignature,
+		},
+		{
+			name:     "sha1 digest",
+			response: signedResponse{key: rsaKey, digestMethod: "http://www.w3.org/2000/09/xmldsig#sha1"},
+			want:     errInvalidSignature,
+		},
+		{
+			name:     "rsa-sha1 signature method",
+			response: signedResponse{key: rsaKey, signatureMethod: "http://www.w3.org/2000/09/xmldsig#rsa-sha1"},
+			want:     errInvalidSignature,
+		},
+		{
+			name:     "ecdsa method with rsa key",
+			response: signedResponse{key: rsaKey, signatureMethod: algECDSASHA256},
+			want:     errInvalidSignature,
+		},
+		{
+			name:     "xpath transform",
+			response: signedResponse{key: rsaKey, transforms: defaultTransforms + `<ds:Transform Algorithm="http://www.w3.org/TR/1999/REC-xpath-19991116"/>`},
+			want:     errInvalidSignature,
+		},
+		{
+			name:     "missing exclusive canonicalization",
+			response: signedResponse{key: rsaKey, transforms: `<ds:Transform Algorithm="` + algEnveloped + `"/>`},
+			want:     errInvalidSignature,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			document := tt.response.sign(t)
+			if tt.tamper != nil {
+				document = tt.tamper(document)
+			}
+
+			root, err := parseXML([]byte(document))
+			if err != nil {
+				t.Fatalf("parseXML: %v", err)
+			}
+			signed, err := verifySignature(root, trusted)
+			if tt.want != nil {
+				if !errors.Is(err, tt.want) {
+					t.Fatalf("verifySignature() error = %v, want %v", err, tt.want)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("verifySignature(): %v", err)
+			}
+
+			// O retorno é o conteúdo assinado, sem a própria assinatura
+			if got := string(signed); strings.Contains(got, "Signature") || !strings.Contains(got, "ana@example.com") {
+				t.Fatalf("signed content = %s", got)
+			}
+		})
+	}
+}
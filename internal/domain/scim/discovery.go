@@ -0,0 +1,122 @@
+package scim
+
+// Documentos de descoberta (RFC 7643, seções 5 a 7). São estáticos e
+// descrevem apenas o que a API de fato suporta.
+
+// ServiceProviderConfig descreve os recursos opcionais suportados
+func ServiceProviderConfig(baseURL string) map[string]any {
+	return map[string]any{
+		"schemas":          []string{SchemaServiceProviderConfig},
+		"documentationUri": "https://datatracker.ietf.org/doc/html/rfc7644",
+		"patch":            map[string]any{"supported": true},
+		"bulk":             map[string]any{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":           map[string]any{"supported": true, "maxResults": MaxCount},
+		"changePassword":   map[string]any{"supported": true},
+		"sort":             map[string]any{"supported": false},
+		"etag":             map[string]any{"supported": false},
+		"authenticationSchemes": []map[string]any{{
+			"type":        "oauthbearertoken",
+			"name":        "Bearer Token",
+			"description": "Authentication with a provisioning bearer token",
+			"primary":     true,
+		}},
+		"meta": map[string]any{
+			"resourceType": "ServiceProviderConfig",
+			"location":     baseURL + "/ServiceProviderConfig",
+		},
+	}
+}
+
+// ResourceTypes lista os tipos de recurso expostos
+func ResourceTypes(baseURL string) []map[string]any {
+	return []map[string]any{
+		{
+			"schemas":     []string{SchemaResourceType},
+			"id":          "User",
+			"name":        "User",
+			"endpoint":    "/Users",
+			"description": "User Account",
+			"schema":      SchemaUser,
+			"schemaExtensions": []map[string]any{
+				{"schema": SchemaEnterpriseUser, "required": false},
+			},
+			"meta": map[string]any{"resourceType": "ResourceType", "location": baseURL + "/ResourceTypes/User"},
+		},
+		{
+			"schemas":     []string{SchemaResourceType},
+			"id":          "Group",
+			"name":        "Group",
+			"endpoint":    "/Groups",
+			"description": "Group",
+			"schema":      SchemaGroup,
+			"meta":        map[string]any{"resourceType": "ResourceType", "location": baseURL + "/ResourceTypes/Group"},
+		},
+	}
+}
+
+// Schemas descreve os atributos de cada schema suportado
+func Schemas(baseURL string) []map[string]any {
+	schema := func(id, name string, attributes ...map[string]any) map[string]any {
+		return map[string]any{
+			"schemas":    []string{SchemaSchema},
+			"id":         id,
+			"name":       name,
+			"attributes": attributes,
+			"meta":       map[string]any{"resourceType": "Schema", "location": baseURL + "/Schemas/" + id},
+		}
+	}
+
+	return []map[string]any{
+		schema(SchemaUser, "User",
+			attribute("userName", "string", false, true, "readWrite", "server"),
+			attribute("name", "complex", false, false, "readWrite", "none",
+				attribute("formatted", "string", false, false, "readWrite", "none"),
+				attribute("givenName", "string", false, false, "writeOnly", "none"),
+				attribute("familyName", "string", false, false, "writeOnly", "none"),
+			),
+			attribute("displayName", "string", false, false, "readWrite", "none"),
+			attribute("emails", "complex", true, false, "readOnly", "none",
+				attribute("value", "string", false, false, "readOnly", "none"),
+				attribute("type", "string", false, false, "readOnly", "none"),
+				attribute("primary", "boolean", false, false, "readOnly", "none"),
+			),
+			attribute("active", "boolean", false, false, "readWrite", "none"),
+			attribute("password", "string", false, false, "writeOnly", "none"),
+			attribute("groups", "complex", true, false, "readOnly", "none",
+				attribute("value", "string", false, false, "readOnly", "none"),
+				attribute("$ref", "reference", false, false, "readOnly", "none"),
+				attribute("display", "string", false, false, "readOnly", "none"),
+			),
+		),
+		schema(SchemaGroup, "Group",
+			attribute("displayName", "string", false, true, "readWrite", "server"),
+			attribute("members", "complex", true, false, "readWrite", "none",
+				attribute("value", "string", false, false, "immutable", "none"),
+				attribute("$ref", "reference", false, false, "immutable", "none"),
+			),
+		),
+		schema(SchemaEnterpriseUser, "EnterpriseUser",
+			attribute("department", "string", false, false, "readWrite", "none"),
+		),
+	}
+}
+
+func attribute(name, kind string, multiValued, required bool, mutability, uniqueness string, subAttributes ...map[string]any) map[string]any {
+	attr := map[string]any{
+		"name":        name,
+		"type":        kind,
+		"multiValued": multiValued,
+		"required":    required,
+		"caseExact":   false,
+		"mutability":  mutability,
+		"returned":    "default",
+		"uniqueness":  uniqueness,
+	}
+	if mutability == "writeOnly" {
+		attr["returned"] = "never"
+	}
+	if len(subAttributes) > 0 {
+		attr["subAttributes"] = subAttributes
+	}
+	return attr
+}
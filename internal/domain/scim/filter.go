@@ -0,0 +1,370 @@
+package scim
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var ErrInvalidFilter = errors.New("invalid filter")
+
+// Operadores lógicos e de comparação de filtros (RFC 7644, seção 3.4.2.2)
+const (
+	opAnd = "and"
+	opOr  = "or"
+	opNot = "not"
+	opEq  = "eq"
+	opNe  = "ne"
+	opCo  = "co"
+	opSw  = "sw"
+	opEw  = "ew"
+	opPr  = "pr"
+	opGt  = "gt"
+	opGe  = "ge"
+	opLt  = "lt"
+	opLe  = "le"
+)
+
+var comparisonOperators = map[string]bool{
+	opEq: true, opNe: true, opCo: true, opSw: true, opEw: true,
+	opGt: true, opGe: true, opLt: true, opLe: true,
+}
+
+// Expression é um filtro SCIM já interpretado. Nós lógicos usam Children;
+// comparações usam Path, normalizado em minúsculas e sem o URN do schema
+// principal, e Value.
+type Expression struct {
+	Operator string
+	Path     string
+	Value    any
+	Children []*Expression
+}
+
+// ParseFilter interpreta a expressão do parâmetro filter
+func ParseFilter(filter string) (*Expression, error) {
+	tokens, err := tokenize(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.tokens) {
+		return nil, ErrInvalidFilter
+	}
+	return expr, nil
+}
+
+type token struct {
+	text   string
+	quoted bool
+}
+
+func tokenize(filter string) ([]token, error) {
+	var tokens []token
+	runes := []rune(filter)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')' || r == '[' || r == ']':
+			tokens = append(tokens, token{text: string(r)})
+			i++
+		case r == '"':
+			j := i + 1
+			for ; j < len(runes) && runes[j] != '"'; j++ {
+				if runes[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(runes) {
+				return nil, ErrInvalidFilter
+			}
+			var value string
+			if err := json.Unmarshal([]byte(string(runes[i:j+1])), &value); err != nil {
+				return nil, ErrInvalidFilter
+			}
+			tokens = append(tokens, token{text: value, quoted: true})
+			i = j + 1
+		default:
+			j := i
+			for j < len(runes) && !unicode.IsSpace(runes[j]) && !strings.ContainsRune("()[]\"", runes[j]) {
+				j++
+			}
+			tokens = append(tokens, token{text: string(runes[i:j])})
+			i = j
+		}
+	}
+	if len(tokens) == 0 {
+		return nil, ErrInvalidFilter
+	}
+	return tokens, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() (token, bool) {
+	if p.pos >= len(p.tokens) {
+		return token{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *parser) keyword(word string) bool {
+	tok, ok := p.peek()
+	if ok && !tok.quoted && strings.EqualFold(tok.text, word) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) parseOr() (*Expression, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword(opOr) {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &Expression{Operator: opOr, Children: []*Expression{left, right}}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (*Expression, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword(opAnd) {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &Expression{Operator: opAnd, Children: []*Expression{left, right}}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (*Expression, error) {
+	if p.keyword(opNot) {
+		if !p.keyword("(") {
+			return nil, ErrInvalidFilter
+		}
+		inner, err := p.parseGroup(")")
+		if err != nil {
+			return nil, err
+		}
+		return &Expression{Operator: opNot, Children: []*Expression{inner}}, nil
+	}
+	if p.keyword("(") {
+		return p.parseGroup(")")
+	}
+	return p.parseAttribute()
+}
+
+func (p *parser) parseGroup(closing string) (*Expression, error) {
+	inner, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.keyword(closing) {
+		return nil, ErrInvalidFilter
+	}
+	return inner, nil
+}
+
+func (p *parser) parseAttribute() (*Expression, error) {
+	tok, ok := p.peek()
+	if !ok || tok.quoted {
+		return nil, ErrInvalidFilter
+	}
+	p.pos++
+	path := normalizePath(tok.text)
+
+	// Filtro de valor: emails[type eq "work"]
+	if p.keyword("[") {
+		inner, err := p.parseGroup("]")
+		if err != nil {
+			return nil, err
+		}
+		prefixPaths(inner, path+".")
+		return inner, nil
+	}
+
+	opToken, ok := p.peek()
+	if !ok || opToken.quoted {
+		return nil, ErrInvalidFilter
+	}
+	p.pos++
+	operator := strings.ToLower(opToken.text)
+
+	if operator == opPr {
+		return &Expression{Operator: opPr, Path: path}, nil
+	}
+	if !comparisonOperators[operator] {
+		return nil, ErrInvalidFilter
+	}
+
+	valueToken, ok := p.peek()
+	if !ok {
+		return nil, ErrInvalidFilter
+	}
+	p.pos++
+
+	value, err := parseValue(valueToken)
+	if err != nil {
+		return nil, err
+	}
+	return &Expression{Operator: operator, Path: path, Value: value}, nil
+}
+
+func parseValue(tok token) (any, error) {
+	if tok.quoted {
+		return tok.text, nil
+	}
+	switch strings.ToLower(tok.text) {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	var number float64
+	if err := json.Unmarshal([]byte(tok.text), &number); err != nil {
+		return nil, ErrInvalidFilter
+	}
+	return number, nil
+}
+
+// normalizePath remove o URN dos schemas principais e coloca o caminho em
+// minúsculas, já que nomes de atributos SCIM não diferenciam caixa.
+func normalizePath(path string) string {
+	path = strings.ToLower(path)
+	for _, schema := range []string{SchemaUser, SchemaGroup} {
+		prefix := strings.ToLower(schema) + ":"
+		if strings.HasPrefix(path, prefix) {
+			return strings.TrimPrefix(path, prefix)
+		}
+	}
+	return path
+}
+
+func prefixPaths(expr *Expression, prefix string) {
+	if expr.Path != "" {
+		expr.Path = prefix + expr.Path
+	}
+	for _, child := range expr.Children {
+		prefixPaths(child, prefix)
+	}
+}
+
+// Matches avalia a expressão sobre um conjunto de atributos multivalorados,
+// indexados pelo caminho normalizado. É usada para recursos pequenos o
+// bastante para serem filtrados em memória.
+func (e *Expression) Matches(attributes map[string][]any) bool {
+	switch e.Operator {
+	case opAnd:
+		for _, child := range e.Children {
+			if !child.Matches(attributes) {
+				return false
+			}
+		}
+		return true
+	case opOr:
+		for _, child := range e.Children {
+			if child.Matches(attributes) {
+				return true
+			}
+		}
+		return false
+	case opNot:
+		return !e.Children[0].Matches(attributes)
+	}
+
+	values := attributes[e.Path]
+	if e.Operator == opPr {
+		for _, value := range values {
+			if value != nil && value != "" {
+				return true
+			}
+		}
+		return false
+	}
+	if e.Operator == opNe {
+		return !(&Expression{Operator: opEq, Path: e.Path, Value: e.Value}).Matches(attributes)
+	}
+	for _, value := range values {
+		if compare(e.Operator, value, e.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+func compare(operator string, actual, expected any) bool {
+	switch a := actual.(type) {
+	case string:
+		b, ok := expected.(string)
+		if !ok {
+			return false
+		}
+		a, b = strings.ToLower(a), strings.ToLower(b)
+		switch operator {
+		case opEq:
+			return a == b
+		case opCo:
+			return strings.Contains(a, b)
+		case opSw:
+			return strings.HasPrefix(a, b)
+		case opEw:
+			return strings.HasSuffix(a, b)
+		case opGt:
+			return a > b
+		case opGe:
+			return a >= b
+		case opLt:
+			return a < b
+		case opLe:
+			return a <= b
+		}
+	case bool:
+		b, ok := expected.(bool)
+		return ok && operator == opEq && a == b
+	case time.Time:
+		text, ok := expected.(string)
+		if !ok {
+			return false
+		}
+		b, err := time.Parse(time.RFC3339, text)
+		if err != nil {
+			return false
+		}
+		switch operator {
+		case opEq:
+			return a.Equal(b)
+		case opGt:
+			return a.After(b)
+		case opGe:
+			return !a.Before(b)
+		case opLt:
+			return a.Before(b)
+		case opLe:
+			return !a.After(b)
+		}
+	}
+	return false
+}
@@ -0,0 +1,970 @@
+package scim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/juanjerrah/go_auth_api/internal/domain/audit"
+	"github.com/juanjerrah/go_auth_api/internal/domain/group"
+	"github.com/juanjerrah/go_auth_api/internal/domain/user"
+	"github.com/juanjerrah/go_auth_api/pkg/common"
+)
+
+var (
+	ErrResourceNotFound = errors.New("resource not found")
+	ErrUniqueness       = errors.New("resource already exists")
+	ErrInvalidValue     = errors.New("invalid attribute value")
+	ErrInvalidPath      = errors.New("invalid patch path")
+)
+
+// Caminho do atributo department da extensão enterprise, já normalizado
+var enterpriseDepartmentPath = strings.ToLower(SchemaEnterpriseUser) + ":department"
+
+type Service interface {
+	CreateUser(ctx context.Context, resource *User) (*User, error)
+	GetUser(ctx context.Context, id string) (*User, error)
+	ListUsers(ctx context.Context, req *ListRequest) (*ListResponse, error)
+	ReplaceUser(ctx context.Context, id string, resource *User) (*User, error)
+	PatchUser(ctx context.Context, id string, req *PatchRequest) (*User, error)
+	DeleteUser(ctx context.Context, id string) error
+	CreateGroup(ctx context.Context, resource *Group) (*Group, error)
+	GetGroup(ctx context.Context, id string) (*Group, error)
+	ListGroups(ctx context.Context, req *ListRequest) (*ListResponse, error)
+	ReplaceGroup(ctx context.Context, id string, resource *Group) (*Group, error)
+	PatchGroup(ctx context.Context, id string, req *PatchRequest) (*Group, error)
+	DeleteGroup(ctx context.Context, id string) error
+}
+
+type service struct {
+	userRepo       user.Repository
+	userService    user.Service
+	groupRepo      group.Repository
+	groupService   group.Service
+	hasher         common.PasswordHasher
+	tokenGenerator common.TokenGenerator
+	mongoUtils     common.MongoUtils
+	sessions       user.SessionRevoker
+	audit          audit.Service
+	baseURL        string
+}
+
+// NewService cria o serviço de provisionamento. baseURL é o endereço público
+// de /scim/v2, usado em meta.location e nas referências entre recursos.
+func NewService(userRepo user.Repository, userService user.Service, groupRepo group.Repository, groupService group.Service, hasher common.PasswordHasher, tokenGenerator common.TokenGenerator, mongoUtils common.MongoUtils, sessions user.SessionRevoker, auditService audit.Service, baseURL string) Service {
+	return &service{
+		userRepo:       userRepo,
+		userService:    userService,
+		groupRepo:      groupRepo,
+		groupService:   groupService,
+		hasher:         hasher,
+		tokenGenerator: tokenGenerator,
+		mongoUtils:     mongoUtils,
+		sessions:       sessions,
+		audit:          auditService,
+		baseURL:        strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// CreateUser implements Service. Sem senha no recurso, o usuário recebe uma
+// senha aleatória e só consegue entrar após redefini-la.
+func (s *service) CreateUser(ctx context.Context, resource *User) (*User, error) {
+	now := time.Now().UTC()
+	usr := &user.User{
+		ID:        s.mongoUtils.GenerateObjectID(),
+		Role:      user.RoleUser,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.applyUser(usr, resource); err != nil {
+		return nil, err
+	}
+	if err := s.ensureEmailAvailable(ctx, usr.Email, ""); err != nil {
+		return nil, err
+	}
+
+	password := resource.Password
+	if password == "" {
+		generated, err := s.tokenGenerator.Generate()
+		if err != nil {
+			return nil, err
+		}
+		password = generated
+	}
+	hashedPassword, err := s.hasher.Hash(password)
+	if err != nil {
+		return nil, err
+	}
+	usr.Password = hashedPassword
+
+	if err := s.userRepo.Create(ctx, usr); err != nil {
+		return nil, err
+	}
+
+	s.record(ctx, &audit.Event{
+		Type:     audit.EventUserCreated,
+		TargetID: usr.ID.Hex(),
+		Metadata: map[string]string{"email": usr.Email, "role": string(usr.Role), "source": "scim"},
+	})
+
+	return s.toUser(ctx, usr, true)
+}
+
+// GetUser implements Service.
+func (s *service) GetUser(ctx context.Context, id string) (*User, error) {
+	usr, err := s.userRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, ErrResourceNotFound
+	}
+	return s.toUser(ctx, usr, true)
+}
+
+// ListUsers implements Service. O filtro é traduzido para uma consulta do
+// repositório, então a paginação acontece no banco.
+func (s *service) ListUsers(ctx context.Context, req *ListRequest) (*ListResponse, error) {
+	var filter *user.Filter
+	if req.Filter != "" {
+		expr, err := ParseFilter(req.Filter)
+		if err != nil {
+			return nil, err
+		}
+		if filter, err = toUserFilter(expr); err != nil {
+			return nil, err
+		}
+	}
+
+	startIndex, count := pagination(req)
+
+	// Com count 0 o cliente quer apenas o total
+	limit := max(count, 1)
+	users, total, err := s.userRepo.Search(ctx, filter, startIndex-1, limit)
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		users = nil
+	}
+
+	withGroups := !excludes(req.ExcludedAttributes, "groups")
+	resources := make([]any, 0, len(users))
+	for _, usr := range users {
+		resource, err := s.toUser(ctx, usr, withGroups)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, resource)
+	}
+
+	return listResponse(total, startIndex, resources), nil
+}
+
+// ReplaceUser implements Service.
+func (s *service) ReplaceUser(ctx context.Context, id string, resource *User) (*User, error) {
+	usr, err := s.userRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, ErrResourceNotFound
+	}
+
+	previous := *usr
+	if err := s.applyUser(usr, resource); err != nil {
+		return nil, err
+	}
+	return s.saveUser(ctx, &previous, usr, resource.Password)
+}
+
+// PatchUser implements Service. As operações são aplicadas sobre a
+// representação SCIM atual, que depois é gravada como numa substituição.
+func (s *service) PatchUser(ctx context.Context, id string, req *PatchRequest) (*User, error) {
+	usr, err := s.userRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, ErrResourceNotFound
+	}
+
+	resource, err := s.toUser(ctx, usr, false)
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range req.Operations {
+		if err := patchUser(resource, op); err != nil {
+			return nil, err
+		}
+	}
+
+	previous := *usr
+	if err := s.applyUser(usr, resource); err != nil {
+		return nil, err
+	}
+	return s.saveUser(ctx, &previous, usr, resource.Password)
+}
+
+// DeleteUser implements Service.
+func (s *service) DeleteUser(ctx context.Context, id string) error {
+	if err := s.userService.DeleteUser(ctx, id); err != nil {
+		if err == user.ErrUserNotFound {
+			return ErrResourceNotFound
+		}
+		return err
+	}
+
+	groups, err := s.groupRepo.FindByMember(ctx, id)
+	if err != nil {
+		log.Printf("Warning: failed to list groups of deleted user %s: %v", id, err)
+	}
+	for _, grp := range groups {
+		if err := s.groupService.RemoveMember(ctx, grp.ID.Hex(), id); err != nil {
+			log.Printf("Warning: failed to remove deleted user %s from group %s: %v", id, grp.ID.Hex(), err)
+		}
+	}
+
+	return nil
+}
+
+// CreateGroup implements Service.
+func (s *service) CreateGroup(ctx context.Context, resource *Group) (*Group, error) {
+	if resource.DisplayName == "" {
+		return nil, ErrInvalidValue
+	}
+
+	created, err := s.groupService.CreateGroup(ctx, &group.CreateGroupRequest{Name: resource.DisplayName})
+	if err != nil {
+		return nil, groupError(err)
+	}
+
+	if err := s.syncMembers(ctx, created.ID, nil, memberIDs(resource.Members)); err != nil {
+		return nil, err
+	}
+
+	return s.GetGroup(ctx, created.ID)
+}
+
+// GetGroup implements Service.
+func (s *service) GetGroup(ctx context.Context, id string) (*Group, error) {
+	grp, err := s.groupRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, ErrResourceNotFound
+	}
+	return s.toGroup(grp, true), nil
+}
+
+// ListGroups implements Service. Grupos são poucos, então são filtrados e
+// paginados em memória.
+func (s *service) ListGroups(ctx context.Context, req *ListRequest) (*ListResponse, error) {
+	var expr *Expression
+	if req.Filter != "" {
+		var err error
+		if expr, err = ParseFilter(req.Filter); err != nil {
+			return nil, err
+		}
+	}
+
+	groups, err := s.groupRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []*group.Group
+	for _, grp := range groups {
+		if expr == nil || expr.Matches(groupAttributes(grp)) {
+			matched = append(matched, grp)
+		}
+	}
+
+	startIndex, count := pagination(req)
+	from := min(startIndex-1, len(matched))
+	to := min(from+count, len(matched))
+
+	withMembers := !excludes(req.ExcludedAttributes, "members")
+	resources := make([]any, 0, to-from)
+	for _, grp := range matched[from:to] {
+		resources = append(resources, s.toGroup(grp, withMembers))
+	}
+
+	return listResponse(int64(len(matched)), startIndex, resources), nil
+}
+
+// ReplaceGroup implements Service.
+func (s *service) ReplaceGroup(ctx context.Context, id string, resource *Group) (*Group, error) {
+	grp, err := s.groupRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, ErrResourceNotFound
+	}
+	if resource.DisplayName == "" {
+		return nil, ErrInvalidValue
+	}
+
+	return s.saveGroup(ctx, grp, resource.DisplayName, memberIDs(resource.Members))
+}
+
+// PatchGroup implements Service.
+func (s *service) PatchGroup(ctx context.Context, id string, req *PatchRequest) (*Group, error) {
+	grp, err := s.groupRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, ErrResourceNotFound
+	}
+
+	name := grp.Name
+	members := make([]string, 0, len(grp.MemberIDs))
+	for _, memberID := range grp.MemberIDs {
+		members = append(members, memberID.Hex())
+	}
+
+	for _, op := range req.Operations {
+		if name, members, err = patchGroup(name, members, op); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.saveGroup(ctx, grp, name, members)
+}
+
+// DeleteGroup implements Service.
+func (s *service) DeleteGroup(ctx context.Context, id string) error {
+	if err := s.groupService.DeleteGroup(ctx, id); err != nil {
+		return groupError(err)
+	}
+	return nil
+}
+
+// applyUser copia os atributos do recurso SCIM para o usuário
+func (s *service) applyUser(usr *user.User, resource *User) error {
+	email := strings.TrimSpace(resource.UserName)
+	if email == "" {
+		email = primaryEmail(resource.Emails)
+	}
+	if address, err := mail.ParseAddress(email); err != nil || address.Address != email {
+		return ErrInvalidValue
+	}
+	usr.Email = email
+
+	name := resource.DisplayName
+	if name == "" && resource.Name != nil {
+		name = resource.Name.Formatted
+		if name == "" {
+			name = strings.TrimSpace(resource.Name.GivenName + " " + resource.Name.FamilyName)
+		}
+	}
+	if name != "" {
+		usr.Name = name
+	} else if usr.Name == "" {
+		usr.Name = email
+	}
+
+	usr.ExternalID = resource.ExternalID
+	if resource.Active != nil {
+		usr.Disabled = !*resource.Active
+	}
+	usr.Department = ""
+	if resource.Enterprise != nil {
+		usr.Department = resource.Enterprise.Department
+	}
+
+	return nil
+}
+
+// saveUser grava as alterações de uma substituição ou patch, encerrando as
+// sessões de usuários desativados ou com a senha trocada.
+func (s *service) saveUser(ctx context.Context, previous, usr *user.User, password string) (*User, error) {
+	if usr.Email != previous.Email {
+		if err := s.ensureEmailAvailable(ctx, usr.Email, usr.ID.Hex()); err != nil {
+			return nil, err
+		}
+	}
+
+	deactivated := usr.Disabled && !previous.Disabled
+	if deactivated && usr.Role == user.RoleAdmin {
+		// Contar e gravar sob o bloqueio, como em user.Service
+		unlock, err := s.userRepo.LockAdmins(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer unlock()
+
+		admins, err := s.userRepo.CountByRole(ctx, user.RoleAdmin)
+		if err != nil {
+			return nil, err
+		}
+		if admins <= 1 {
+			return nil, user.ErrLastAdmin
+		}
+	}
+
+	if password != "" {
+		hashedPassword, err := s.hasher.Hash(password)
+		if err != nil {
+			return nil, err
+		}
+		usr.Password = hashedPassword
+	}
+
+	usr.UpdatedAt = time.Now().UTC()
+	if err := s.userRepo.Update(ctx, usr); err != nil {
+		return nil, err
+	}
+
+	if deactivated || password != "" {
+		if err := s.sessions.InvalidateUserTokens(ctx, usr.ID.Hex()); err != nil {
+			log.Printf("Warning: failed to invalidate sessions of user %s: %v", usr.ID.Hex(), err)
+		}
+	}
+
+	changes := userChanges(previous, usr)
+	if password != "" {
+		s.record(ctx, &audit.Event{Type: audit.EventPasswordChanged, TargetID: usr.ID.Hex(), Metadata: map[string]string{"source": "scim"}})
+	}
+	if len(changes) > 0 {
+		changes["source"] = "scim"
+		s.record(ctx, &audit.Event{Type: audit.EventUserUpdated, TargetID: usr.ID.Hex(), Metadata: changes})
+	}
+
+	return s.toUser(ctx, usr, true)
+}
+
+func (s *service) ensureEmailAvailable(ctx context.Context, email, exceptID string) error {
+	users, _, err := s.userRepo.Search(ctx, &user.Filter{Field: "email", Operator: user.FilterEq, Value: email}, 0, 2)
+	if err != nil {
+		return err
+	}
+	for _, usr := range users {
+		if usr.ID.Hex() != exceptID {
+			return ErrUniqueness
+		}
+	}
+	return nil
+}
+
+// saveGroup renomeia o grupo e sincroniza os membros com a lista desejada
+func (s *service) saveGroup(ctx context.Context, grp *group.Group, name string, members []string) (*Group, error) {
+	id := grp.ID.Hex()
+	if name != grp.Name {
+		if _, err := s.groupService.UpdateGroup(ctx, id, &group.UpdateGroupRequest{Name: name}); err != nil {
+			return nil, groupError(err)
+		}
+	}
+
+	current := make([]string, 0, len(grp.MemberIDs))
+	for _, memberID := range grp.MemberIDs {
+		current = append(current, memberID.Hex())
+	}
+	if err := s.syncMembers(ctx, id, current, members); err != nil {
+		return nil, err
+	}
+
+	return s.GetGroup(ctx, id)
+}
+
+func (s *service) syncMembers(ctx context.Context, id string, current, desired []string) error {
+	for _, userID := range desired {
+		if slices.Contains(current, userID) {
+			continue
+		}
+		if err := s.groupService.AddMember(ctx, id, userID); err != nil && err != group.ErrAlreadyGroupMember {
+			return groupError(err)
+		}
+	}
+	for _, userID := range current {
+		if slices.Contains(desired, userID) {
+			continue
+		}
+		if err := s.groupService.RemoveMember(ctx, id, userID); err != nil && err != group.ErrNotGroupMember {
+			return groupError(err)
+		}
+	}
+	return nil
+}
+
+func (s *service) toUser(ctx context.Context, usr *user.User, withGroups bool) (*User, error) {
+	id := usr.ID.Hex()
+	active := !usr.Disabled
+	created, modified := usr.CreatedAt, usr.UpdatedAt
+
+	resource := &User{
+		Schemas:     []string{SchemaUser},
+		ID:          id,
+		ExternalID:  usr.ExternalID,
+		UserName:    usr.Email,
+		Name:        &Name{Formatted: usr.Name},
+		DisplayName: usr.Name,
+		Emails:      []Email{{Value: usr.Email, Type: "work", Primary: true}},
+		Active:      &active,
+		Meta: &Meta{
+			ResourceType: "User",
+			Created:      &created,
+			LastModified: &modified,
+			Location:     s.baseURL + "/Users/" + id,
+		},
+	}
+	if usr.Department != "" {
+		resource.Schemas = append(resource.Schemas, SchemaEnterpriseUser)
+		resource.Enterprise = &EnterpriseUser{Department: usr.Department}
+	}
+
+	if withGroups {
+		groups, err := s.groupRepo.FindByMember(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		for _, grp := range groups {
+			resource.Groups = append(resource.Groups, GroupRef{
+				Value:   grp.ID.Hex(),
+				Ref:     s.baseURL + "/Groups/" + grp.ID.Hex(),
+				Display: grp.Name,
+			})
+		}
+	}
+
+	return resource, nil
+}
+
+func (s *service) toGroup(grp *group.Group, withMembers bool) *Group {
+	id := grp.ID.Hex()
+	created, modified := grp.CreatedAt, grp.UpdatedAt
+
+	resource := &Group{
+		Schemas:     []string{SchemaGroup},
+		ID:          id,
+		DisplayName: grp.Name,
+		Meta: &Meta{
+			ResourceType: "Group",
+			Created:      &created,
+			LastModified: &modified,
+			Location:     s.baseURL + "/Groups/" + id,
+		},
+	}
+	if withMembers {
+		for _, memberID := range grp.MemberIDs {
+			resource.Members = append(resource.Members, Member{
+				Value: memberID.Hex(),
+				Ref:   s.baseURL + "/Users/" + memberID.Hex(),
+			})
+		}
+	}
+	return resource
+}
+
+func (s *service) record(ctx context.Context, event *audit.Event) {
+	if err := s.audit.Record(ctx, event); err != nil {
+		log.Printf("Warning: failed to record audit event %s: %v", event.Type, err)
+	}
+}
+
+// patchUser aplica uma operação de patch à representação SCIM do usuário
+func patchUser(resource *User, op PatchOperation) error {
+	operation := strings.ToLower(op.Op)
+	if operation != "add" && operation != "replace" && operation != "remove" {
+		return ErrInvalidValue
+	}
+
+	if op.Path == "" {
+		values, ok := op.Value.(map[string]any)
+		if !ok || operation == "remove" {
+			return ErrInvalidValue
+		}
+		for path, value := range values {
+			if enterprise, ok := value.(map[string]any); ok && strings.EqualFold(path, SchemaEnterpriseUser) {
+				for attribute, v := range enterprise {
+					if err := setUserAttribute(resource, strings.ToLower(SchemaEnterpriseUser+":"+attribute), v); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			if err := setUserAttribute(resource, normalizePath(path), value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	path := normalizePath(op.Path)
+	if operation == "remove" {
+		switch {
+		case isEmailPath(path):
+		case path == "externalid":
+			resource.ExternalID = ""
+		case path == enterpriseDepartmentPath:
+			resource.Enterprise = nil
+		default:
+			return ErrInvalidPath
+		}
+		return nil
+	}
+	return setUserAttribute(resource, path, op.Value)
+}
+
+func setUserAttribute(resource *User, path string, value any) error {
+	// E-mails acompanham o userName, que é o atributo autoritativo
+	if isEmailPath(path) {
+		return nil
+	}
+
+	if path == "active" {
+		active, err := toBool(value)
+		if err != nil {
+			return err
+		}
+		resource.Active = &active
+		return nil
+	}
+
+	if path == "name" {
+		name, ok := value.(map[string]any)
+		if !ok {
+			return ErrInvalidValue
+		}
+		for attribute, v := range name {
+			if err := setUserAttribute(resource, "name."+strings.ToLower(attribute), v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	text, ok := value.(string)
+	if !ok {
+		return ErrInvalidValue
+	}
+
+	if resource.Name == nil {
+		resource.Name = &Name{}
+	}
+	switch path {
+	case "username":
+		resource.UserName = text
+	case "displayname":
+		resource.DisplayName = text
+	case "name.formatted":
+		resource.Name.Formatted = text
+		resource.DisplayName = text
+	case "name.givenname":
+		resource.Name.GivenName = text
+		resource.DisplayName = strings.TrimSpace(text + " " + resource.Name.FamilyName)
+	case "name.familyname":
+		resource.Name.FamilyName = text
+		resource.DisplayName = strings.TrimSpace(resource.Name.GivenName + " " + text)
+	case "externalid":
+		resource.ExternalID = text
+	case "password":
+		resource.Password = text
+	case enterpriseDepartmentPath:
+		resource.Enterprise = &EnterpriseUser{Department: text}
+	default:
+		return ErrInvalidPath
+	}
+	return nil
+}
+
+// patchGroup aplica uma operação de patch ao nome e à lista de membros
+func patchGroup(name string, members []string, op PatchOperation) (string, []string, error) {
+	operation := strings.ToLower(op.Op)
+	if operation != "add" && operation != "replace" && operation != "remove" {
+		return "", nil, ErrInvalidValue
+	}
+
+	if op.Path == "" {
+		values, ok := op.Value.(map[string]any)
+		if !ok || operation == "remove" {
+			return "", nil, ErrInvalidValue
+		}
+		for path, value := range values {
+			var err error
+			name, members, err = patchGroup(name, members, PatchOperation{Op: operation, Path: path, Value: value})
+			if err != nil {
+				return "", nil, err
+			}
+		}
+		return name, members, nil
+	}
+
+	attribute, valueFilter, err := parsePath(op.Path)
+	if err != nil {
+		return "", nil, err
+	}
+
+	switch attribute {
+	case "displayname":
+		text, ok := op.Value.(string)
+		if !ok || text == "" || operation == "remove" {
+			return "", nil, ErrInvalidValue
+		}
+		return text, members, nil
+
+	case "members":
+		switch {
+		case operation == "remove" && valueFilter != nil:
+			members = slices.DeleteFunc(members, func(id string) bool {
+				return valueFilter.Matches(map[string][]any{"value": {id}})
+			})
+		case operation == "remove" && op.Value == nil:
+			members = []string{}
+		default:
+			ids, err := memberValues(op.Value)
+			if err != nil {
+				return "", nil, err
+			}
+			switch operation {
+			case "add":
+				for _, id := range ids {
+					if !slices.Contains(members, id) {
+						members = append(members, id)
+					}
+				}
+			case "replace":
+				members = ids
+			case "remove":
+				members = slices.DeleteFunc(members, func(id string) bool {
+					return slices.Contains(ids, id)
+				})
+			}
+		}
+		return name, members, nil
+	}
+
+	return "", nil, ErrInvalidPath
+}
+
+// parsePath separa o atributo de um caminho de patch do filtro de valor
+// opcional, como em members[value eq "id"].
+func parsePath(path string) (string, *Expression, error) {
+	open := strings.Index(path, "[")
+	if open < 0 {
+		return normalizePath(path), nil, nil
+	}
+	if !strings.HasSuffix(path, "]") {
+		return "", nil, ErrInvalidPath
+	}
+	valueFilter, err := ParseFilter(path[open+1 : len(path)-1])
+	if err != nil {
+		return "", nil, ErrInvalidPath
+	}
+	return normalizePath(path[:open]), valueFilter, nil
+}
+
+func memberValues(value any) ([]string, error) {
+	items, ok := value.([]any)
+	if !ok {
+		return nil, ErrInvalidValue
+	}
+	ids := make([]string, 0, len(items))
+	for _, item := range items {
+		member, ok := item.(map[string]any)
+		if !ok {
+			return nil, ErrInvalidValue
+		}
+		id, ok := member["value"].(string)
+		if !ok || id == "" {
+			return nil, ErrInvalidValue
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func memberIDs(members []Member) []string {
+	ids := make([]string, 0, len(members))
+	for _, member := range members {
+		if member.Value != "" && !slices.Contains(ids, member.Value) {
+			ids = append(ids, member.Value)
+		}
+	}
+	return ids
+}
+
+func groupAttributes(grp *group.Group) map[string][]any {
+	members := make([]any, 0, len(grp.MemberIDs))
+	for _, memberID := range grp.MemberIDs {
+		members = append(members, memberID.Hex())
+	}
+	return map[string][]any{
+		"id":                {grp.ID.Hex()},
+		"displayname":       {grp.Name},
+		"members":           members,
+		"members.value":     members,
+		"meta.created":      {grp.CreatedAt},
+		"meta.lastmodified": {grp.UpdatedAt},
+	}
+}
+
+// toUserFilter traduz a expressão SCIM para um filtro sobre os campos do
+// usuário. Atributos sem correspondência tornam o filtro inválido.
+func toUserFilter(expr *Expression) (*user.Filter, error) {
+	switch expr.Operator {
+	case opAnd, opOr:
+		children := make([]*user.Filter, 0, len(expr.Children))
+		for _, child := range expr.Children {
+			filter, err := toUserFilter(child)
+			if err != nil {
+				return nil, err
+			}
+			children = append(children, filter)
+		}
+		if expr.Operator == opAnd {
+			return &user.Filter{And: children}, nil
+		}
+		return &user.Filter{Or: children}, nil
+	case opNot:
+		filter, err := toUserFilter(expr.Children[0])
+		if err != nil {
+			return nil, err
+		}
+		return &user.Filter{Not: filter}, nil
+	}
+
+	operator := user.FilterOperator(expr.Operator)
+
+	if expr.Path == "active" {
+		// active é o inverso de disabled, que pode estar ausente nos documentos
+		if operator == user.FilterPresent {
+			return &user.Filter{Field: "_id", Operator: user.FilterPresent}, nil
+		}
+		active, ok := expr.Value.(bool)
+		if !ok || (operator != user.FilterEq && operator != user.FilterNe) {
+			return nil, ErrInvalidFilter
+		}
+		if operator == user.FilterNe {
+			active = !active
+		}
+		if active {
+			return &user.Filter{Field: "disabled", Operator: user.FilterNe, Value: true}, nil
+		}
+		return &user.Filter{Field: "disabled", Operator: user.FilterEq, Value: true}, nil
+	}
+
+	var field string
+	switch expr.Path {
+	case "id":
+		field = "_id"
+	case "username", "emails", "emails.value":
+		field = "email"
+	case "displayname", "name.formatted":
+		field = "name"
+	case "externalid":
+		field = "external_id"
+	case enterpriseDepartmentPath:
+		field = "department"
+	case "meta.created", "meta.lastmodified":
+		field = "created_at"
+		if expr.Path == "meta.lastmodified" {
+			field = "updated_at"
+		}
+		if operator == user.FilterPresent {
+			return &user.Filter{Field: field, Operator: operator}, nil
+		}
+		text, ok := expr.Value.(string)
+		if !ok {
+			return nil, ErrInvalidFilter
+		}
+		value, err := time.Parse(time.RFC3339, text)
+		if err != nil {
+			return nil, ErrInvalidFilter
+		}
+		return &user.Filter{Field: field, Operator: operator, Value: value.UTC()}, nil
+	default:
+		return nil, ErrInvalidFilter
+	}
+
+	if operator == user.FilterPresent {
+		return &user.Filter{Field: field, Operator: operator}, nil
+	}
+	if _, ok := expr.Value.(string); !ok {
+		return nil, ErrInvalidFilter
+	}
+	return &user.Filter{Field: field, Operator: operator, Value: expr.Value}, nil
+}
+
+func userChanges(previous, current *user.User) map[string]string {
+	changes := map[string]string{}
+	if previous.Name != current.Name {
+		changes["name"] = current.Name
+	}
+	if previous.Email != current.Email {
+		changes["email"] = current.Email
+	}
+	if previous.Department != current.Department {
+		changes["department"] = current.Department
+	}
+	if previous.ExternalID != current.ExternalID {
+		changes["external_id"] = current.ExternalID
+	}
+	if previous.Disabled != current.Disabled {
+		changes["active"] = fmt.Sprint(!current.Disabled)
+	}
+	return changes
+}
+
+// groupError converte os erros do serviço de grupos para erros SCIM
+func groupError(err error) error {
+	switch err {
+	case group.ErrGroupNotFound:
+		return ErrResourceNotFound
+	case group.ErrGroupNameInUse:
+		return ErrUniqueness
+	case group.ErrGroupMemberNotFound:
+		return ErrInvalidValue
+	}
+	return err
+}
+
+func pagination(req *ListRequest) (int, int) {
+	startIndex := max(req.StartIndex, 1)
+	count := DefaultCount
+	if req.Count != nil {
+		count = min(max(*req.Count, 0), MaxCount)
+	}
+	return startIndex, count
+}
+
+func listResponse(total int64, startIndex int, resources []any) *ListResponse {
+	return &ListResponse{
+		Schemas:      []string{MessageListResponse},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	}
+}
+
+func excludes(excludedAttributes, attribute string) bool {
+	for _, excluded := range strings.Split(excludedAttributes, ",") {
+		if strings.EqualFold(strings.TrimSpace(excluded), attribute) {
+			return true
+		}
+	}
+	return false
+}
+
+func isEmailPath(path string) bool {
+	return path == "emails" || strings.HasPrefix(path, "emails[") || strings.HasPrefix(path, "emails.")
+}
+
+func primaryEmail(emails []Email) string {
+	for _, email := range emails {
+		if email.Primary {
+			return email.Value
+		}
+	}
+	if len(emails) > 0 {
+		return emails[0].Value
+	}
+	return ""
+}
+
+func toBool(value any) (bool, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case string:
+		// Alguns provedores enviam booleanos como texto ("True")
+		switch strings.ToLower(v) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+	}
+	return false, ErrInvalidValue
+}
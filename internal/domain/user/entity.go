@@ -1,6 +1,7 @@
 package user
 
 import (
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -13,32 +14,176 @@ const (
 	RoleUser  Role = "user"
 )
 
+// Origem das credenciais do usuário (User.AuthProvider); senhas locais não
+// têm provedor
+const (
+	AuthProviderLocal = ""
+	AuthProviderLDAP  = "ldap"
+	AuthProviderOIDC  = "oidc"
+	AuthProviderSAML  = "saml"
+)
+
 type User struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Name      string             `bson:"name" json:"name"`
-	Email     string             `bson:"email" json:"email"`
-	Password  string             `bson:"password" json:"-"`
-	Role      Role               `bson:"role" json:"role"`
-	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
-	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+	ID                  primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	Name                string               `bson:"name" json:"name"`
+	Email               string               `bson:"email" json:"email"`
+	Password            string               `bson:"password" json:"-"`
+	Role                Role                 `bson:"role" json:"role"`
+	Department          string               `bson:"department" json:"department,omitempty"`
+	ExternalID          string               `bson:"external_id" json:"external_id,omitempty"`
+	Disabled            bool                 `bson:"disabled" json:"disabled,omitempty"`
+	MustResetPassword   bool                 `bson:"must_reset_password" json:"must_reset_password,omitempty"`
+	AuthProvider        string               `bson:"auth_provider" json:"auth_provider,omitempty"`
+	Identities          []LinkedIdentity     `bson:"identities,omitempty" json:"identities,omitempty"`
+	WebAuthnCredentials []WebAuthnCredential `bson:"webauthn_credentials" json:"-"`
+	OTPFactors          []OTPFactor          `bson:"otp_factors" json:"-"`
+	TrustedDevices      []TrustedDevice      `bson:"trusted_devices" json:"-"`
+	CreatedAt           time.Time            `bson:"created_at" json:"created_at"`
+	UpdatedAt           time.Time            `bson:"updated_at" json:"updated_at"`
+}
+
+// LinkedIdentity é uma conta de um provedor de identidade externo vinculada
+// ao usuário. O par Provider/Subject identifica a conta de forma única.
+type LinkedIdentity struct {
+	Provider string    `bson:"provider" json:"provider"`
+	Subject  string    `bson:"subject" json:"subject"`
+	Email    string    `bson:"email" json:"email"`
+	LinkedAt time.Time `bson:"linked_at" json:"linked_at"`
+}
+
+// WebAuthnCredential é uma chave de acesso (passkey) registrada pelo usuário.
+// PublicKey é a chave COSE da credencial; SignCount é o último contador de
+// assinaturas visto, usado para detectar autenticadores clonados. O campo
+// no usuário não tem omitempty para que remover a última credencial o limpe.
+type WebAuthnCredential struct {
+	ID                string     `bson:"id" json:"id"`
+	Name              string     `bson:"name" json:"name"`
+	PublicKey         []byte     `bson:"public_key" json:"-"`
+	SignCount         uint32     `bson:"sign_count" json:"sign_count"`
+	AAGUID            string     `bson:"aaguid" json:"aaguid"`
+	AttestationFormat string     `bson:"attestation_format" json:"attestation_format"`
+	Transports        []string   `bson:"transports,omitempty" json:"transports,omitempty"`
+	CreatedAt         time.Time  `bson:"created_at" json:"created_at"`
+	LastUsedAt        *time.Time `bson:"last_used_at,omitempty" json:"last_used_at,omitempty"`
+}
+
+// OTPFactor é um canal (email ou sms) cadastrado para receber códigos de uso
+// único no login. Destination é o endereço ou telefone confirmado no
+// cadastro. Como nas passkeys, o campo no usuário não tem omitempty.
+type OTPFactor struct {
+	Channel     string     `bson:"channel" json:"channel"`
+	Destination string     `bson:"destination" json:"destination"`
+	CreatedAt   time.Time  `bson:"created_at" json:"created_at"`
+	LastUsedAt  *time.Time `bson:"last_used_at,omitempty" json:"last_used_at,omitempty"`
+}
+
+// TrustedDevice é um navegador em que o usuário dispensou o segundo fator
+// até ExpiresAt. TokenHash é o hash do segredo guardado no cookie do
+// dispositivo e FingerprintHash o do User-Agent a que ele foi vinculado. Como
+// nas passkeys, o campo no usuário não tem omitempty.
+type TrustedDevice struct {
+	ID              string    `bson:"id" json:"id"`
+	Name            string    `bson:"name" json:"name"`
+	TokenHash       string    `bson:"token_hash" json:"-"`
+	FingerprintHash string    `bson:"fingerprint_hash" json:"-"`
+	IPAddress       string    `bson:"ip_address,omitempty" json:"ip_address,omitempty"`
+	FirstSeenAt     time.Time `bson:"first_seen_at" json:"first_seen_at"`
+	LastSeenAt      time.Time `bson:"last_seen_at" json:"last_seen_at"`
+	ExpiresAt       time.Time `bson:"expires_at" json:"expires_at"`
+}
+
+// DirectoryIdentity são os dados de um usuário autenticado por um diretório
+// externo, usados para criar ou atualizar o registro local.
+type DirectoryIdentity struct {
+	Name       string
+	Email      string
+	Department string
+	Role       Role
+}
+
+// FederatedIdentity são os dados de um usuário autenticado por um provedor
+// de identidade externo. Protocol é o AuthProvider dado aos usuários criados
+// no primeiro login. Com SyncProfile, nome, departamento e role do usuário
+// são atualizados a cada login; valores vazios mantêm os atuais.
+type FederatedIdentity struct {
+	Protocol      string
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Department    string
+	Role          Role
+	SyncProfile   bool
+}
+
+// EmailInDomains informa se o domínio do e-mail está na lista. Provedores só
+// podem atestar e-mails dos domínios que administram.
+func EmailInDomains(email string, domains []string) bool {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+	domain := email[at+1:]
+	for _, candidate := range domains {
+		if strings.EqualFold(candidate, domain) {
+			return true
+		}
+	}
+	return false
+}
+
+type FilterOperator string
+
+const (
+	FilterEq         FilterOperator = "eq"
+	FilterNe         FilterOperator = "ne"
+	FilterContains   FilterOperator = "co"
+	FilterStartsWith FilterOperator = "sw"
+	FilterEndsWith   FilterOperator = "ew"
+	FilterPresent    FilterOperator = "pr"
+	FilterGt         FilterOperator = "gt"
+	FilterGe         FilterOperator = "ge"
+	FilterLt         FilterOperator = "lt"
+	FilterLe         FilterOperator = "le"
+)
+
+// Filter é uma expressão de busca sobre os campos (nomes bson) do usuário.
+// Folhas comparam Field com Value; And e Or combinam subexpressões e Not as
+// nega. Comparações de texto não diferenciam maiúsculas de minúsculas.
+type Filter struct {
+	Field    string
+	Operator FilterOperator
+	Value    any
+	And      []*Filter
+	Or       []*Filter
+	Not      *Filter
 }
 
 type CreateUserRequest struct {
-	Name     string `json:"name" binding:"required"`
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=6"`
-	Role     Role   `json:"role" binding:"required,oneof=admin user"`
+	Name       string `json:"name" binding:"required"`
+	Email      string `json:"email" binding:"required,email"`
+	Password   string `json:"password" binding:"required,min=6"`
+	Role       Role   `json:"role" binding:"omitempty,oneof=admin user"`
+	Department string `json:"department"`
 }
 
 type UpdateUserRequest struct {
-	Name  string `json:"name"`
-	Email string `json:"email" binding:"email"`
-	Role  Role   `json:"role" binding:"required,oneof=admin user"`
+	Name       string `json:"name"`
+	Email      string `json:"email" binding:"omitempty,email"`
+	Role       Role   `json:"role" binding:"omitempty,oneof=admin user"`
+	Department string `json:"department"`
+}
+
+type ChangeRoleRequest struct {
+	Role Role `json:"role" binding:"required,oneof=admin user"`
 }
 
 type LoginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required"`
+	// OrganizationID seleciona a organização ativa da sessão (opcional)
+	OrganizationID string `json:"organization_id"`
 }
 
 type ChangePasswordRequest struct {
@@ -48,10 +193,14 @@ type ChangePasswordRequest struct {
 }
 
 type UserResponse struct {
-	ID        string    `json:"id"`
-	Name      string    `json:"name"`
-	Email     string    `json:"email"`
-	Role      string    `json:"role"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-}
\ No newline at end of file
+	ID           string           `json:"id"`
+	Name         string           `json:"name"`
+	Email        string           `json:"email"`
+	Role         string           `json:"role"`
+	Department   string           `json:"department,omitempty"`
+	Disabled     bool             `json:"disabled,omitempty"`
+	AuthProvider string           `json:"auth_provider,omitempty"`
+	Identities   []LinkedIdentity `json:"identities,omitempty"`
+	CreatedAt    time.Time        `json:"created_at"`
+	UpdatedAt    time.Time        `json:"updated_at"`
+}
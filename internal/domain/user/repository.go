@@ -9,7 +9,22 @@ type Repository interface {
 	Create(ctx context.Context, user *User) error
 	FindByID(ctx context.Context, id string) (*User, error)
 	FindByEmail(ctx context.Context, email string) (*User, error)
+	// FindByIdentity busca o usuário vinculado à conta externa
+	FindByIdentity(ctx context.Context, provider, subject string) (*User, error)
+	// FindByWebAuthnCredential busca o dono da credencial WebAuthn
+	FindByWebAuthnCredential(ctx context.Context, credentialID string) (*User, error)
 	Update(ctx context.Context, user *User) error
 	Delete(ctx context.Context, id string) error
 	ExistsByEmail(ctx context.Context, email string) (bool, error)
+	CountByRole(ctx context.Context, role Role) (int64, error)
+	// LockAdmins serializa as operações que podem deixar o sistema sem
+	// administradores, para que a contagem e a alteração não se intercalem
+	// com outra remoção; a função retornada libera o bloqueio
+	LockAdmins(ctx context.Context) (func(), error)
+	// Consultas restritas aos membros de uma organização (tenant)
+	FindByIDInTenant(ctx context.Context, tenantID, id string) (*User, error)
+	ListByTenant(ctx context.Context, tenantID string, page, limit int) ([]*User, int64, error)
+	// Search retorna os usuários que satisfazem o filtro (nil para todos),
+	// ordenados por criação, e o total de resultados
+	Search(ctx context.Context, filter *Filter, offset, limit int) ([]*User, int64, error)
 }
\ No newline at end of file
@@ -3,18 +3,45 @@ package user
 import (
 	"context"
 	"errors"
+	"log"
+	"strconv"
 	"time"
 
+	"github.com/juanjerrah/go_auth_api/internal/domain/audit"
 	"github.com/juanjerrah/go_auth_api/pkg/common"
 )
 
 var (
-	ErrUserNotFound      = errors.New("user not found")
-	ErrInvalidEmail      = errors.New("invalid email")
-	ErrInvalidPassword   = errors.New("invalid password")
-	ErrEmailAlreadyInUse = errors.New("email already in use")
+	ErrUserNotFound         = errors.New("user not found")
+	ErrInvalidEmail         = errors.New("invalid email")
+	ErrInvalidPassword      = errors.New("invalid password")
+	ErrEmailAlreadyInUse    = errors.New("email already in use")
+	ErrLastAdmin            = errors.New("cannot remove the last admin")
+	ErrUserDisabled         = errors.New("user is disabled")
+	ErrExternalPassword     = errors.New("password is managed by an external directory")
+	ErrDirectoryUnavailable = errors.New("directory unavailable")
+	ErrEmailNotVerified     = errors.New("email not verified by identity provider")
+	ErrIdentityConflict     = errors.New("user already linked to another account of this provider")
+	ErrIdentityInUse        = errors.New("external account already linked to another user")
+	ErrLinkRequiresSignIn   = errors.New("existing account must be linked while signed in")
+	ErrMustResetPassword    = errors.New("password reset required")
 )
 
+// SessionRevoker invalida as sessões ativas de um usuário. É usado para que
+// mudanças de role e remoções tenham efeito imediato.
+type SessionRevoker interface {
+	InvalidateUserTokens(ctx context.Context, userID string) error
+}
+
+// DirectoryAuthenticator verifica credenciais em um diretório externo (LDAP).
+// Authenticate deve retornar ErrInvalidPassword para credenciais recusadas.
+type DirectoryAuthenticator interface {
+	// Handles informa se o e-mail, ou o usuário local já existente (que pode
+	// ser nil), deve ser autenticado pelo diretório
+	Handles(email string, user *User) bool
+	Authenticate(ctx context.Context, email, password string) (*DirectoryIdentity, error)
+}
+
 type Service interface {
 	CreateUser(ctx context.Context, req *CreateUserRequest) (*UserResponse, error)
 	GetUserByID(ctx context.Context, id string) (*UserResponse, error)
@@ -22,20 +49,43 @@ type Service interface {
 	UpdateUser(ctx context.Context, id string, req *UpdateUserRequest) error
 	DeleteUser(ctx context.Context, id string) error
 	Authenticate(ctx context.Context, email, password string) (*User, error)
+	AuthenticateFederated(ctx context.Context, identity *FederatedIdentity) (*User, error)
+	// LinkFederatedIdentity vincula a conta externa ao usuário autenticado,
+	// que provou o controle das duas contas; o e-mail não precisa coincidir
+	LinkFederatedIdentity(ctx context.Context, id string, identity *FederatedIdentity) (*User, error)
 	ChangePassword(ctx context.Context, id, oldPassword, newPassword string) error
+	ChangeRole(ctx context.Context, id string, role Role) error
+	ForceLogout(ctx context.Context, id string) error
+	// ResetMFA remove todos os segundos fatores do usuário e encerra suas
+	// sessões, para quem perdeu o autenticador
+	ResetMFA(ctx context.Context, id string) error
+	// RequirePasswordReset bloqueia o login por senha até que ela seja
+	// redefinida, para contas com suspeita de senha vazada
+	RequirePasswordReset(ctx context.Context, id string) error
+	// ResetPassword define a nova senha sem exigir a anterior e encerra as
+	// sessões do usuário. Quem chama já confirmou a identidade por outro meio.
+	ResetPassword(ctx context.Context, id, newPassword string) error
 }
 
 type service struct {
 	repo       Repository
 	hasher     common.PasswordHasher
 	mongoUtils common.MongoUtils
+	audit      audit.Service
+	sessions   SessionRevoker
+	directory  DirectoryAuthenticator
 }
 
-func NewService(repo Repository, hasher common.PasswordHasher, mongoUtils common.MongoUtils) Service {
+// NewService cria o serviço de usuários. directory é opcional; sem ele todas
+// as senhas são verificadas localmente.
+func NewService(repo Repository, hasher common.PasswordHasher, mongoUtils common.MongoUtils, auditService audit.Service, sessions SessionRevoker, directory DirectoryAuthenticator) Service {
 	return &service{
 		repo:       repo,
 		hasher:     hasher,
 		mongoUtils: mongoUtils,
+		audit:      auditService,
+		sessions:   sessions,
+		directory:  directory,
 	}
 }
 
@@ -44,10 +94,17 @@ func (s *service) ChangePassword(ctx context.Context, id string, oldPassword str
 
 	user, err := s.repo.FindByID(ctx, id)
 	if err != nil {
+		s.recordFailure(ctx, audit.EventPasswordChanged, id, "user not found")
 		return ErrUserNotFound
 	}
 
+	if user.AuthProvider != AuthProviderLocal {
+		s.recordFailure(ctx, audit.EventPasswordChanged, id, "password managed by "+user.AuthProvider)
+		return ErrExternalPassword
+	}
+
 	if err := s.hasher.Verify(oldPassword, user.Password); err != nil {
+		s.recordFailure(ctx, audit.EventPasswordChanged, id, "invalid password")
 		return ErrInvalidPassword
 	}
 
@@ -57,26 +114,302 @@ func (s *service) ChangePassword(ctx context.Context, id string, oldPassword str
 	}
 
 	user.Password = hashedPassword
+	user.MustResetPassword = false
 	user.UpdatedAt = time.Now().UTC()
 
 	if err := s.repo.Update(ctx, user); err != nil {
 		return err
 	}
 
+	s.record(ctx, &audit.Event{Type: audit.EventPasswordChanged, TargetID: id})
+
 	return nil
 }
 
-// Authenticate implements Service.
+// Authenticate implements Service. Usuários atendidos pelo diretório externo
+// têm a senha verificada lá e o registro local criado ou atualizado a cada
+// login.
 func (s *service) Authenticate(ctx context.Context, email string, password string) (*User, error) {
 	user, err := s.repo.FindByEmail(ctx, email)
 	if err != nil {
+		user = nil
+	}
+
+	if s.directory != nil && s.directory.Handles(email, user) {
+		return s.authenticateDirectory(ctx, email, password, user)
+	}
+
+	if user == nil {
 		return nil, ErrInvalidEmail
 	}
+	if user.AuthProvider != AuthProviderLocal {
+		// O diretório deixou de ser configurado; a senha local não é válida
+		return nil, ErrInvalidPassword
+	}
 
 	if err := s.hasher.Verify(password, user.Password); err != nil {
 		return nil, ErrInvalidPassword
 	}
 
+	if user.Disabled {
+		return nil, ErrUserDisabled
+	}
+
+	// Só é informado a quem acertou a senha, para não revelar o estado da conta
+	if user.MustResetPassword {
+		return nil, ErrMustResetPassword
+	}
+
+	return user, nil
+}
+
+// authenticateDirectory verifica as credenciais no diretório e sincroniza o
+// registro local com os dados retornados (criação just-in-time).
+func (s *service) authenticateDirectory(ctx context.Context, email, password string, user *User) (*User, error) {
+	identity, err := s.directory.Authenticate(ctx, email, password)
+	if err == ErrInvalidPassword {
+		return nil, err
+	}
+	if err != nil {
+		log.Printf("Warning: directory authentication failed for %s: %v", email, err)
+		return nil, ErrDirectoryUnavailable
+	}
+
+	now := time.Now().UTC()
+	if user == nil {
+		user = &User{
+			ID:           s.mongoUtils.GenerateObjectID(),
+			Name:         identity.Name,
+			Email:        identity.Email,
+			Role:         identity.Role,
+			Department:   identity.Department,
+			AuthProvider: AuthProviderLDAP,
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		}
+		if err := s.repo.Create(ctx, user); err != nil {
+			return nil, err
+		}
+
+		s.record(ctx, &audit.Event{
+			Type:     audit.EventUserCreated,
+			TargetID: user.ID.Hex(),
+			Metadata: map[string]string{"email": user.Email, "role": string(user.Role), "source": AuthProviderLDAP},
+		})
+		return user, nil
+	}
+
+	if user.Disabled {
+		return nil, ErrUserDisabled
+	}
+
+	changes := map[string]string{}
+	if identity.Name != "" && identity.Name != user.Name {
+		user.Name = identity.Name
+		changes["name"] = identity.Name
+	}
+	if identity.Department != user.Department {
+		user.Department = identity.Department
+		changes["department"] = identity.Department
+	}
+	if user.AuthProvider != AuthProviderLDAP {
+		// Senhas locais deixam de valer quando o usuário passa ao diretório
+		user.AuthProvider = AuthProviderLDAP
+		user.Password = ""
+		changes["auth_provider"] = AuthProviderLDAP
+	}
+
+	return s.applyExternalChanges(ctx, user, changes, identity.Role, AuthProviderLDAP)
+}
+
+// applyExternalChanges persiste as alterações vindas de uma fonte externa
+// (diretório ou provedor de identidade) e aplica a role informada; role vazia
+// mantém a atual. A troca é ignorada se deixaria o sistema sem administradores.
+func (s *service) applyExternalChanges(ctx context.Context, user *User, changes map[string]string, role Role, source string) (*User, error) {
+	previousRole := user.Role
+	if role != "" && role != user.Role {
+		unlock, err := s.ensureNotLastAdmin(ctx, user)
+		if err != nil {
+			log.Printf("Warning: keeping role %s of user %s from %s: %v", user.Role, user.ID.Hex(), source, err)
+		} else {
+			defer unlock()
+			user.Role = role
+		}
+	}
+
+	if len(changes) == 0 && previousRole == user.Role {
+		return user, nil
+	}
+
+	user.UpdatedAt = time.Now().UTC()
+	if err := s.repo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	if len(changes) > 0 {
+		changes["source"] = source
+		s.record(ctx, &audit.Event{Type: audit.EventUserUpdated, TargetID: user.ID.Hex(), Metadata: changes})
+	}
+	if previousRole != user.Role {
+		// Sessões abertas carregam a role anterior
+		if err := s.sessions.InvalidateUserTokens(ctx, user.ID.Hex()); err != nil {
+			log.Printf("Warning: failed to invalidate sessions after role change of user %s: %v", user.ID.Hex(), err)
+		}
+		s.record(ctx, &audit.Event{
+			Type:     audit.EventRoleChanged,
+			TargetID: user.ID.Hex(),
+			Metadata: map[string]string{"from": string(previousRole), "to": string(user.Role), "source": source},
+		})
+	}
+
+	return user, nil
+}
+
+// AuthenticateFederated implements Service. Contas externas já vinculadas
+// autenticam o próprio usuário; as demais são vinculadas ao usuário com o
+// mesmo e-mail, desde que verificado pelo provedor, ou criam um novo usuário.
+func (s *service) AuthenticateFederated(ctx context.Context, identity *FederatedIdentity) (*User, error) {
+	user, err := s.repo.FindByIdentity(ctx, identity.Provider, identity.Subject)
+	if err != nil {
+		user, err = s.linkIdentity(ctx, identity)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if user.Disabled {
+		return nil, ErrUserDisabled
+	}
+	if !identity.SyncProfile {
+		return user, nil
+	}
+
+	changes := map[string]string{}
+	if identity.Name != "" && identity.Name != user.Name {
+		user.Name = identity.Name
+		changes["name"] = identity.Name
+	}
+	if identity.Department != "" && identity.Department != user.Department {
+		user.Department = identity.Department
+		changes["department"] = identity.Department
+	}
+	return s.applyExternalChanges(ctx, user, changes, identity.Role, identity.Protocol)
+}
+
+// linkIdentity vincula a conta externa ao usuário com o mesmo e-mail ou cria
+// o usuário (just-in-time) com a conta já vinculada. Contas com senha local ou
+// de administradores não são vinculadas automaticamente: um e-mail atestado
+// por engano pelo provedor bastaria para assumi-las. O dono as vincula
+// autenticado, por LinkFederatedIdentity.
+func (s *service) linkIdentity(ctx context.Context, identity *FederatedIdentity) (*User, error) {
+	// Sem e-mail verificado, vincular permitiria assumir a conta de outro usuário
+	if identity.Email == "" || !identity.EmailVerified {
+		return nil, ErrEmailNotVerified
+	}
+
+	user, err := s.repo.FindByEmail(ctx, identity.Email)
+	if err != nil {
+		user = nil
+	}
+
+	now := time.Now().UTC()
+	link := LinkedIdentity{
+		Provider: identity.Provider,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+		LinkedAt: now,
+	}
+
+	if user == nil {
+		name := identity.Name
+		if name == "" {
+			name = identity.Email
+		}
+		role := identity.Role
+		if role == "" {
+			role = RoleUser
+		}
+		user = &User{
+			ID:           s.mongoUtils.GenerateObjectID(),
+			Name:         name,
+			Email:        identity.Email,
+			Role:         role,
+			Department:   identity.Department,
+			AuthProvider: identity.Protocol,
+			Identities:   []LinkedIdentity{link},
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		}
+		if err := s.repo.Create(ctx, user); err != nil {
+			return nil, err
+		}
+
+		s.record(ctx, &audit.Event{
+			Type:     audit.EventUserCreated,
+			TargetID: user.ID.Hex(),
+			Metadata: map[string]string{"email": user.Email, "role": string(user.Role), "source": identity.Protocol, "provider": identity.Provider},
+		})
+		return user, nil
+	}
+
+	if user.Disabled {
+		return nil, ErrUserDisabled
+	}
+	if user.Password != "" || user.Role == RoleAdmin {
+		s.recordFailure(ctx, audit.EventIdentityLinked, user.ID.Hex(), ErrLinkRequiresSignIn.Error())
+		return nil, ErrLinkRequiresSignIn
+	}
+
+	return s.addIdentity(ctx, user, link)
+}
+
+// LinkFederatedIdentity implements Service.
+func (s *service) LinkFederatedIdentity(ctx context.Context, id string, identity *FederatedIdentity) (*User, error) {
+	user, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	if user.Disabled {
+		return nil, ErrUserDisabled
+	}
+
+	if owner, err := s.repo.FindByIdentity(ctx, identity.Provider, identity.Subject); err == nil {
+		if owner.ID == user.ID {
+			return user, nil
+		}
+		s.recordFailure(ctx, audit.EventIdentityLinked, user.ID.Hex(), ErrIdentityInUse.Error())
+		return nil, ErrIdentityInUse
+	}
+
+	return s.addIdentity(ctx, user, LinkedIdentity{
+		Provider: identity.Provider,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+		LinkedAt: time.Now().UTC(),
+	})
+}
+
+// addIdentity grava o vínculo, limitado a uma conta por provedor
+func (s *service) addIdentity(ctx context.Context, user *User, link LinkedIdentity) (*User, error) {
+	for _, existing := range user.Identities {
+		if existing.Provider == link.Provider {
+			s.recordFailure(ctx, audit.EventIdentityLinked, user.ID.Hex(), ErrIdentityConflict.Error())
+			return nil, ErrIdentityConflict
+		}
+	}
+
+	user.Identities = append(user.Identities, link)
+	user.UpdatedAt = link.LinkedAt
+	if err := s.repo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	s.record(ctx, &audit.Event{
+		Type:     audit.EventIdentityLinked,
+		TargetID: user.ID.Hex(),
+		Metadata: map[string]string{"provider": link.Provider, "subject": link.Subject, "email": link.Email},
+	})
+
 	return user, nil
 }
 
@@ -95,30 +428,59 @@ func (s *service) CreateUser(ctx context.Context, req *CreateUserRequest) (*User
 		return nil, err
 	}
 
+	if req.Role == "" {
+		req.Role = RoleUser
+	}
+
 	var user = &User{
-		ID:        s.mongoUtils.GenerateObjectID(),
-		Name:      req.Name,
-		Email:     req.Email,
-		Password:  hashedPassword,
-		Role:      req.Role,
-		CreatedAt: time.Now().UTC(),
-		UpdatedAt: time.Now().UTC(),
+		ID:         s.mongoUtils.GenerateObjectID(),
+		Name:       req.Name,
+		Email:      req.Email,
+		Password:   hashedPassword,
+		Role:       req.Role,
+		Department: req.Department,
+		CreatedAt:  time.Now().UTC(),
+		UpdatedAt:  time.Now().UTC(),
 	}
 
 	if err := s.repo.Create(ctx, user); err != nil {
 		return nil, err
 	}
 
+	s.record(ctx, &audit.Event{
+		Type:     audit.EventUserCreated,
+		TargetID: user.ID.Hex(),
+		Metadata: map[string]string{"email": user.Email, "role": string(user.Role)},
+	})
+
 	return s.toResponse(user), nil
 }
 
 // DeleteUser implements Service.
 func (s *service) DeleteUser(ctx context.Context, id string) error {
+	user, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return ErrUserNotFound
+	}
+
+	unlock, err := s.ensureNotLastAdmin(ctx, user)
+	if err != nil {
+		s.recordFailure(ctx, audit.EventUserDeleted, id, err.Error())
+		return err
+	}
+	defer unlock()
 
 	if err := s.repo.Delete(ctx, id); err != nil {
+		s.recordFailure(ctx, audit.EventUserDeleted, id, err.Error())
 		return err
 	}
 
+	if err := s.sessions.InvalidateUserTokens(ctx, id); err != nil {
+		log.Printf("Warning: failed to invalidate sessions of deleted user %s: %v", id, err)
+	}
+
+	s.record(ctx, &audit.Event{Type: audit.EventUserDeleted, TargetID: id})
+
 	return nil
 }
 
@@ -147,28 +509,259 @@ func (s *service) UpdateUser(ctx context.Context, id string, req *UpdateUserRequ
 		return ErrUserNotFound
 	}
 
-	if req.Name != "" {
+	// Verificar o e-mail antes de qualquer alteração, para não trocar a role
+	// e então falhar
+	if req.Email != "" && req.Email != user.Email {
+		exist, err := s.repo.ExistsByEmail(ctx, req.Email)
+		if err != nil {
+			return err
+		}
+		if exist {
+			return ErrEmailAlreadyInUse
+		}
+	}
+
+	if req.Role != "" && req.Role != user.Role {
+		if err := s.ChangeRole(ctx, id, req.Role); err != nil {
+			return err
+		}
+		// ChangeRole gravou o usuário com a nova role
+		user.Role = req.Role
+	}
+
+	changes := map[string]string{}
+	if req.Name != "" && req.Name != user.Name {
 		user.Name = req.Name
+		changes["name"] = req.Name
 	}
-	if req.Email != "" {
+	if req.Email != "" && req.Email != user.Email {
+		changes["email"] = req.Email
 		user.Email = req.Email
 	}
+	if req.Department != "" && req.Department != user.Department {
+		changes["department"] = req.Department
+		user.Department = req.Department
+	}
+	user.UpdatedAt = time.Now().UTC()
+
+	if err := s.repo.Update(ctx, user); err != nil {
+		return err
+	}
+
+	s.record(ctx, &audit.Event{Type: audit.EventUserUpdated, TargetID: id, Metadata: changes})
+
+	return nil
+}
+
+// ChangeRole implements Service.
+func (s *service) ChangeRole(ctx context.Context, id string, role Role) error {
+	user, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return ErrUserNotFound
+	}
+
+	if user.Role == role {
+		return nil
+	}
+
+	unlock, err := s.ensureNotLastAdmin(ctx, user)
+	if err != nil {
+		s.recordFailure(ctx, audit.EventRoleChanged, id, err.Error())
+		return err
+	}
+	defer unlock()
+
+	previousRole := user.Role
+	user.Role = role
+	user.UpdatedAt = time.Now().UTC()
+
+	if err := s.repo.Update(ctx, user); err != nil {
+		return err
+	}
+
+	// As permissões ficam gravadas na sessão; invalidar força um novo login
+	// para que a nova role tenha efeito imediato.
+	if err := s.sessions.InvalidateUserTokens(ctx, id); err != nil {
+		log.Printf("Warning: failed to invalidate sessions after role change of user %s: %v", id, err)
+	}
+
+	s.record(ctx, &audit.Event{
+		Type:     audit.EventRoleChanged,
+		TargetID: id,
+		Metadata: map[string]string{"from": string(previousRole), "to": string(role)},
+	})
+
+	return nil
+}
+
+// ForceLogout implements Service.
+func (s *service) ForceLogout(ctx context.Context, id string) error {
+	if _, err := s.repo.FindByID(ctx, id); err != nil {
+		return ErrUserNotFound
+	}
+
+	if err := s.sessions.InvalidateUserTokens(ctx, id); err != nil {
+		s.recordFailure(ctx, audit.EventForceLogout, id, err.Error())
+		return err
+	}
+
+	s.record(ctx, &audit.Event{Type: audit.EventForceLogout, TargetID: id})
+
+	return nil
+}
+
+// ResetMFA implements Service.
+func (s *service) ResetMFA(ctx context.Context, id string) error {
+	user, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return ErrUserNotFound
+	}
+
+	removedCredentials := len(user.WebAuthnCredentials)
+	removedFactors := len(user.OTPFactors)
+	removedDevices := len(user.TrustedDevices)
+	user.WebAuthnCredentials = nil
+	user.OTPFactors = nil
+	// Dispositivos confiáveis dispensariam o segundo fator que acabou de ser removido
+	user.TrustedDevices = nil
+	user.UpdatedAt = time.Now().UTC()
+	if err := s.repo.Update(ctx, user); err != nil {
+		s.recordFailure(ctx, audit.EventMFAReset, id, err.Error())
+		return err
+	}
+
+	// Uma sessão aberta com o fator perdido não deve sobreviver à troca
+	if err := s.sessions.InvalidateUserTokens(ctx, id); err != nil {
+		log.Printf("Warning: failed to invalidate sessions of user %s after mfa reset: %v", id, err)
+	}
+
+	s.record(ctx, &audit.Event{
+		Type:     audit.EventMFAReset,
+		TargetID: id,
+		Metadata: map[string]string{
+			"webauthn_credentials": strconv.Itoa(removedCredentials),
+			"otp_factors":          strconv.Itoa(removedFactors),
+			"trusted_devices":      strconv.Itoa(removedDevices),
+		},
+	})
+
+	return nil
+}
+
+// RequirePasswordReset implements Service.
+func (s *service) RequirePasswordReset(ctx context.Context, id string) error {
+	user, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	if user.AuthProvider != AuthProviderLocal {
+		return ErrExternalPassword
+	}
+	if user.MustResetPassword {
+		return nil
+	}
+
+	user.MustResetPassword = true
 	user.UpdatedAt = time.Now().UTC()
+	if err := s.repo.Update(ctx, user); err != nil {
+		return err
+	}
+
+	s.record(ctx, &audit.Event{Type: audit.EventPasswordResetRequired, TargetID: id})
+	return nil
+}
+
+// ResetPassword implements Service.
+func (s *service) ResetPassword(ctx context.Context, id, newPassword string) error {
+	user, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		s.recordFailure(ctx, audit.EventPasswordChanged, id, "user not found")
+		return ErrUserNotFound
+	}
+	if user.AuthProvider != AuthProviderLocal {
+		s.recordFailure(ctx, audit.EventPasswordChanged, id, "password managed by "+user.AuthProvider)
+		return ErrExternalPassword
+	}
 
+	hashedPassword, err := s.hasher.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+
+	user.Password = hashedPassword
+	user.MustResetPassword = false
+	user.UpdatedAt = time.Now().UTC()
 	if err := s.repo.Update(ctx, user); err != nil {
 		return err
 	}
 
+	// Sessões abertas com a senha antiga não devem sobreviver à redefinição
+	if err := s.sessions.InvalidateUserTokens(ctx, id); err != nil {
+		log.Printf("Warning: failed to invalidate sessions of user %s after password reset: %v", id, err)
+	}
+
+	s.record(ctx, &audit.Event{
+		Type:     audit.EventPasswordChanged,
+		TargetID: id,
+		Metadata: map[string]string{"reset": "true"},
+	})
 	return nil
 }
 
+// ensureNotLastAdmin impede que a remoção ou rebaixamento de um admin deixe o
+// sistema sem nenhum administrador. Para um admin, a contagem é feita sob o
+// bloqueio das remoções de administradores, que quem chama mantém até gravar
+// a alteração e libera com a função retornada; assim duas remoções
+// simultâneas não veem ambas o outro admin.
+func (s *service) ensureNotLastAdmin(ctx context.Context, user *User) (func(), error) {
+	if user.Role != RoleAdmin {
+		return func() {}, nil
+	}
+
+	unlock, err := s.repo.LockAdmins(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	admins, err := s.repo.CountByRole(ctx, RoleAdmin)
+	if err != nil {
+		unlock()
+		return nil, err
+	}
+	if admins <= 1 {
+		unlock()
+		return nil, ErrLastAdmin
+	}
+
+	return unlock, nil
+}
+
+func (s *service) record(ctx context.Context, event *audit.Event) {
+	if err := s.audit.Record(ctx, event); err != nil {
+		log.Printf("Warning: failed to record audit event %s: %v", event.Type, err)
+	}
+}
+
+func (s *service) recordFailure(ctx context.Context, eventType audit.EventType, targetID, reason string) {
+	s.record(ctx, &audit.Event{
+		Type:     eventType,
+		TargetID: targetID,
+		Outcome:  audit.OutcomeFailure,
+		Reason:   reason,
+	})
+}
+
 func (s *service) toResponse(user *User) *UserResponse {
 	return &UserResponse{
-		ID:        user.ID.Hex(),
-		Name:      user.Name,
-		Email:     user.Email,
-		Role:      string(user.Role),
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
+		ID:           user.ID.Hex(),
+		Name:         user.Name,
+		Email:        user.Email,
+		Role:         string(user.Role),
+		Department:   user.Department,
+		Disabled:     user.Disabled,
+		AuthProvider: user.AuthProvider,
+		Identities:   user.Identities,
+		CreatedAt:    user.CreatedAt,
+		UpdatedAt:    user.UpdatedAt,
 	}
 }
@@ -0,0 +1,218 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Flags dos dados do autenticador
+const (
+	flagUserPresent      = 0x01
+	flagUserVerified     = 0x04
+	flagAttestedCredData = 0x40
+	flagExtensionData    = 0x80
+)
+
+// Formatos de atestação aceitos no registro
+const (
+	formatNone   = "none"
+	formatPacked = "packed"
+)
+
+// Extensão dos certificados de atestação FIDO com o AAGUID do autenticador
+var oidFIDOAAGUID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 45724, 1, 1, 4}
+
+var errInvalidAttestation = errors.New("invalid attestation")
+
+// authenticatorData são os dados assinados pelo autenticador (WebAuthn §6.1)
+type authenticatorData struct {
+	raw       []byte
+	rpIDHash  []byte
+	flags     byte
+	signCount uint32
+	// Presentes apenas no registro (flag AT)
+	aaguid       []byte
+	credentialID []byte
+	publicKey    []byte
+}
+
+func (a *authenticatorData) userPresent() bool  { return a.flags&flagUserPresent != 0 }
+func (a *authenticatorData) userVerified() bool { return a.flags&flagUserVerified != 0 }
+
+func parseAuthenticatorData(raw []byte) (*authenticatorData, error) {
+	if len(raw) < 37 {
+		return nil, fmt.Errorf("%w: authenticator data too short", errInvalidAttestation)
+	}
+	data := &authenticatorData{
+		raw:       raw,
+		rpIDHash:  raw[:32],
+		flags:     raw[32],
+		signCount: binary.BigEndian.Uint32(raw[33:37]),
+	}
+
+	rest := raw[37:]
+	if data.flags&flagAttestedCredData != 0 {
+		if len(rest) < 18 {
+			return nil, fmt.Errorf("%w: attested credential data too short", errInvalidAttestation)
+		}
+		data.aaguid = rest[:16]
+		length := int(binary.BigEndian.Uint16(rest[16:18]))
+		rest = rest[18:]
+		if length == 0 || length > 1023 || len(rest) < length {
+			return nil, fmt.Errorf("%w: invalid credential id length", errInvalidAttestation)
+		}
+		data.credentialID = rest[:length]
+		rest = rest[length:]
+
+		// A chave é um item CBOR seguido, opcionalmente, pelas extensões
+		_, n, err := decodeCBOR(rest)
+		if err != nil {
+			return nil, fmt.Errorf("%w: credential public key: %v", errInvalidAttestation, err)
+		}
+		data.publicKey = rest[:n]
+		rest = rest[n:]
+	}
+	if data.flags&flagExtensionData != 0 {
+		_, n, err := decodeCBOR(rest)
+		if err != nil {
+			return nil, fmt.Errorf("%w: extensions: %v", errInvalidAttestation, err)
+		}
+		rest = rest[n:]
+	}
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("%w: trailing authenticator data", errInvalidAttestation)
+	}
+	return data, nil
+}
+
+// attestationObject é o objeto CBOR devolvido pelo autenticador no registro
+type attestationObject struct {
+	format    string
+	statement map[any]any
+	authData  *authenticatorData
+}
+
+func parseAttestationObject(raw []byte) (*attestationObject, error) {
+	decoded, n, err := decodeCBOR(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidAttestation, err)
+	}
+	object, ok := decoded.(map[any]any)
+	if !ok || n != len(raw) {
+		return nil, fmt.Errorf("%w: malformed attestation object", errInvalidAttestation)
+	}
+
+	format, _ := object["fmt"].(string)
+	statement, ok := object["attStmt"].(map[any]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: missing attestation statement", errInvalidAttestation)
+	}
+	rawAuthData, ok := object["authData"].([]byte)
+	if !ok {
+		return nil, fmt.Errorf("%w: missing authenticator data", errInvalidAttestation)
+	}
+
+	authData, err := parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+	if authData.credentialID == nil {
+		return nil, fmt.Errorf("%w: missing attested credential data", errInvalidAttestation)
+	}
+	return &attestationObject{format: format, statement: statement, authData: authData}, nil
+}
+
+// verify confere a declaração de atestação (WebAuthn §8). Atestações packed
+// com certificado são validadas quanto à assinatura e ao formato do
+// certificado; a cadeia não é verificada contra raízes de fabricantes.
+func (o *attestationObject) verify(credentialKey *publicKey, clientDataHash []byte) error {
+	switch o.format {
+	case formatNone:
+		if len(o.statement) != 0 {
+			return fmt.Errorf("%w: none attestation with statement", errInvalidAttestation)
+		}
+		return nil
+	case formatPacked:
+		return o.verifyPacked(credentialKey, clientDataHash)
+	}
+	return fmt.Errorf("%w: unsupported format %q", errInvalidAttestation, o.format)
+}
+
+func (o *attestationObject) verifyPacked(credentialKey *publicKey, clientDataHash []byte) error {
+	algorithm, ok := o.statement["alg"].(int64)
+	if !ok {
+		return fmt.Errorf("%w: packed attestation without alg", errInvalidAttestation)
+	}
+	signature, ok := o.statement["sig"].([]byte)
+	if !ok {
+		return fmt.Errorf("%w: packed attestation without sig", errInvalidAttestation)
+	}
+	signed := append(append([]byte(nil), o.authData.raw...), clientDataHash...)
+
+	chain, hasChain := o.statement["x5c"].([]any)
+	if !hasChain {
+		// Autoatestação: assinada com a própria chave da credencial
+		if algorithm != credentialKey.algorithm {
+			return fmt.Errorf("%w: self attestation algorithm mismatch", errInvalidAttestation)
+		}
+		if err := credentialKey.verify(signed, signature); err != nil {
+			return fmt.Errorf("%w: %v", errInvalidAttestation, err)
+		}
+		return nil
+	}
+
+	if len(chain) == 0 {
+		return fmt.Errorf("%w: empty certificate chain", errInvalidAttestation)
+	}
+	der, ok := chain[0].([]byte)
+	if !ok {
+		return fmt.Errorf("%w: malformed certificate chain", errInvalidAttestation)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errInvalidAttestation, err)
+	}
+	if err := checkAttestationCertificate(certificate, o.authData.aaguid); err != nil {
+		return err
+	}
+	if err := verifySignature(algorithm, certificate.PublicKey, signed, signature); err != nil {
+		return fmt.Errorf("%w: %v", errInvalidAttestation, err)
+	}
+	return nil
+}
+
+// checkAttestationCertificate aplica os requisitos do certificado de
+// atestação packed (WebAuthn §8.2.1)
+func checkAttestationCertificate(certificate *x509.Certificate, aaguid []byte) error {
+	if certificate.Version != 3 {
+		return fmt.Errorf("%w: attestation certificate must be version 3", errInvalidAttestation)
+	}
+	subject := certificate.Subject
+	if len(subject.Country) == 0 || len(subject.Organization) == 0 || subject.CommonName == "" {
+		return fmt.Errorf("%w: incomplete attestation certificate subject", errInvalidAttestation)
+	}
+	if len(subject.OrganizationalUnit) != 1 || subject.OrganizationalUnit[0] != "Authenticator Attestation" {
+		return fmt.Errorf("%w: invalid attestation certificate unit", errInvalidAttestation)
+	}
+	if certificate.IsCA {
+		return fmt.Errorf("%w: attestation certificate must not be a CA", errInvalidAttestation)
+	}
+
+	for _, extension := range certificate.Extensions {
+		if !extension.Id.Equal(oidFIDOAAGUID) {
+			continue
+		}
+		var value []byte
+		if _, err := asn1.Unmarshal(extension.Value, &value); err != nil || extension.Critical {
+			return fmt.Errorf("%w: invalid aaguid extension", errInvalidAttestation)
+		}
+		if !bytes.Equal(value, aaguid) {
+			return fmt.Errorf("%w: aaguid mismatch", errInvalidAttestation)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package webauthn
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Os vetores vêm do apêndice A da RFC 8949
+func TestDecodeCBOR(t *testing.T) {
+	tests := []struct {
+		hex  string
+		want any
+	}{
+		{"00", int64(0)},
+		{"17", int64(23)},
+		{"1818", int64(24)},
+		{"1903e8", int64(1000)},
+		{"1a000f4240", int64(1000000)},
+		{"1b000000e8d4a51000", int64(1000000000000)},
+		{"1b7fffffffffffffff", int64(1<<63 - 1)},
+		{"20", int64(-1)},
+		{"3863", int64(-100)},
+		{"3b7fffffffffffffff", int64(-1 << 63)},
+		{"40", []byte(nil)},
+		{"4401020304", []byte{1, 2, 3, 4}},
+		{"60", ""},
+		{"6449455446", "IETF"},
+		{"62c3bc", "ü"},
+		{"f4", false},
+		{"f5", true},
+		{"f6", nil},
+		{"80", []any{}},
+		{"83010203", []any{int64(1), int64(2), int64(3)}},
+		{"8301820203820405", []any{int64(1), []any{int64(2), int64(3)}, []any{int64(4), int64(5)}}},
+		{"a0", map[any]any{}},
+		{"a201020304", map[any]any{int64(1): int64(2), int64(3): int64(4)}},
+		{"a26161016162820203", map[any]any{"a": int64(1), "b": []any{int64(2), int64(3)}}},
+		{"c074323031332d30332d32315432303a30343a30305a", "2013-03-21T20:04:00Z"},
+		{"d82076687474703a2f2f7777772e6578616d706c652e636f6d", "http://www.example.com"},
+		{"f93c00", nil},
+		{"fb3ff199999999999a", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.hex, func(t *testing.T) {
+			data, err := hex.DecodeString(tt.hex)
+			if err != nil {
+				t.Fatalf("hex: %v", err)
+			}
+			got, n, err := decodeCBOR(data)
+			if err != nil {
+				t.Fatalf("decodeCBOR(): %v", err)
+			}
+			if n != len(data) {
+				t.Errorf("decodeCBOR() consumed %d of %d bytes", n, len(data))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decodeCBOR() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeCBORReportsConsumedBytes(t *testing.T) {
+	data := []byte{0x82, 0x01, 0x02, 0xff, 0xff}
+	_, n, err := decodeCBOR(data)
+	if err != nil {
+		t.Fatalf("decodeCBOR(): %v", err)
+	}
+	if n != 3 {
+		t.Fatalf("decodeCBOR() consumed %d bytes, want 3", n)
+	}
+}
+
+func TestDecodeCBORRejectsMalformedInput(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"truncated argument", []byte{0x19, 0x01}},
+		{"truncated byte string", []byte{0x44, 0x01, 0x02}},
+		{"truncated text string", []byte{0x64, 'I', 'E'}},
+		{"byte string longer than data", []byte{0x5b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+		{"array longer than data", []byte{0x9b, 0x7f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+		{"map longer than data", []byte{0xba, 0xff, 0xff, 0xff, 0xff}},
+		{"truncated array", []byte{0x83, 0x01, 0x02}},
+		{"map without value", []byte{0xa1, 0x01}},
+		{"unsigned overflow", []byte{0x1b, 0x80, 0, 0, 0, 0, 0, 0, 0}},
+		{"negative overflow", []byte{0x3b, 0x80, 0, 0, 0, 0, 0, 0, 0}},
+		{"indefinite length", []byte{0x5f, 0x41, 0x01, 0xff}},
+		{"reserved additional information", []byte{0x1c}},
+		{"unsupported simple value", []byte{0xf0}},
+		{"truncated float", []byte{0xfb, 0x3f, 0xf1}},
+		{"array map key", []byte{0xa1, 0x80, 0x01}},
+		{"duplicate map key", []byte{0xa2, 0x01, 0x02, 0x01, 0x03}},
+		{"nesting too deep", append(bytes.Repeat([]byte{0x81}, maxCBORDepth+1), 0x00)},
+		{"tags nested too deep", append(bytes.Repeat([]byte{0xc0}, maxCBORDepth+1), 0x00)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := decodeCBOR(tt.data); !errors.Is(err, errInvalidCBOR) {
+				t.Fatalf("decodeCBOR(%x) error = %v, want %v", tt.data, err, errInvalidCBOR)
+			}
+		})
+	}
+}
+
+// Todo prefixo de um item válido deve resultar em erro, nunca em panic
+func TestDecodeCBORTruncated(t *testing.T) {
+	data, _ := hex.DecodeString(strings.Join([]string{
+		"a5",   // mapa com 5 pares
+		"0102", // kty: EC2
+		"0326", // alg: ES256
+		"2001", // crv: P-256
+		"2158200102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f20",
+		"2258200102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f20",
+	}, ""))
+
+	if _, _, err := decodeCBOR(data); err != nil {
+		t.Fatalf("decodeCBOR(): %v", err)
+	}
+	for i := range len(data) {
+		if _, _, err := decodeCBOR(data[:i]); !errors.Is(err, errInvalidCBOR) {
+			t.Fatalf("decodeCBOR() of %d bytes error = %v, want %v", i, err, errInvalidCBOR)
+		}
+	}
+}
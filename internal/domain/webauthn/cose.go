@@ -0,0 +1,175 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// Algoritmos COSE (RFC 9053) aceitos nas credenciais, em ordem de preferência
+const (
+	algES256 int64 = -7
+	algEdDSA int64 = -8
+	algES384 int64 = -35
+	algRS256 int64 = -257
+)
+
+var supportedAlgorithms = []int64{algES256, algEdDSA, algES384, algRS256}
+
+// Parâmetros das chaves COSE
+const (
+	coseKeyType   = 1
+	coseAlgorithm = 3
+	coseCurve     = -1 // crv (EC2/OKP) ou n (RSA)
+	coseX         = -2 // x (EC2/OKP) ou e (RSA)
+	coseY         = -3
+
+	keyTypeOKP = 1
+	keyTypeEC2 = 2
+	keyTypeRSA = 3
+
+	curveP256    = 1
+	curveP384    = 2
+	curveEd25519 = 6
+)
+
+var (
+	errUnsupportedKey = errors.New("unsupported credential public key")
+	errBadSignature   = errors.New("signature verification failed")
+)
+
+// publicKey é a chave pública de uma credencial com o algoritmo declarado
+type publicKey struct {
+	algorithm int64
+	key       crypto.PublicKey
+}
+
+// parsePublicKey lê uma chave COSE codificada em CBOR
+func parsePublicKey(raw []byte) (*publicKey, error) {
+	decoded, n, err := decodeCBOR(raw)
+	if err != nil {
+		return nil, err
+	}
+	if n != len(raw) {
+		return nil, fmt.Errorf("%w: trailing data", errUnsupportedKey)
+	}
+	return coseKey(decoded)
+}
+
+func coseKey(decoded any) (*publicKey, error) {
+	params, ok := decoded.(map[any]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: not a map", errUnsupportedKey)
+	}
+	keyType, _ := params[int64(coseKeyType)].(int64)
+	algorithm, _ := params[int64(coseAlgorithm)].(int64)
+
+	switch keyType {
+	case keyTypeEC2:
+		curve, _ := params[int64(coseCurve)].(int64)
+		x, _ := params[int64(coseX)].([]byte)
+		y, _ := params[int64(coseY)].([]byte)
+
+		var ellipticCurve elliptic.Curve
+		switch {
+		case algorithm == algES256 && curve == curveP256:
+			ellipticCurve = elliptic.P256()
+		case algorithm == algES384 && curve == curveP384:
+			ellipticCurve = elliptic.P384()
+		default:
+			return nil, fmt.Errorf("%w: ec2 algorithm %d with curve %d", errUnsupportedKey, algorithm, curve)
+		}
+		size := (ellipticCurve.Params().BitSize + 7) / 8
+		if len(x) != size || len(y) != size {
+			return nil, fmt.Errorf("%w: invalid ec2 coordinates", errUnsupportedKey)
+		}
+		key := &ecdsa.PublicKey{Curve: ellipticCurve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !ellipticCurve.IsOnCurve(key.X, key.Y) {
+			return nil, fmt.Errorf("%w: point not on curve", errUnsupportedKey)
+		}
+		return &publicKey{algorithm: algorithm, key: key}, nil
+
+	case keyTypeOKP:
+		curve, _ := params[int64(coseCurve)].(int64)
+		x, _ := params[int64(coseX)].([]byte)
+		if algorithm != algEdDSA || curve != curveEd25519 || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("%w: okp algorithm %d with curve %d", errUnsupportedKey, algorithm, curve)
+		}
+		return &publicKey{algorithm: algorithm, key: ed25519.PublicKey(x)}, nil
+
+	case keyTypeRSA:
+		n, _ := params[int64(coseCurve)].([]byte)
+		e, _ := params[int64(coseX)].([]byte)
+		if algorithm != algRS256 || len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("%w: rsa algorithm %d", errUnsupportedKey, algorithm)
+		}
+		// Zeros à esquerda não contam para o tamanho do módulo; o expoente deve
+		// ser ímpar e caber em um int de 32 bits
+		modulus := new(big.Int).SetBytes(n)
+		exponent := new(big.Int).SetBytes(e).Int64()
+		if modulus.BitLen() < 2048 || exponent < 3 || exponent > 1<<31-1 || exponent%2 == 0 {
+			return nil, fmt.Errorf("%w: invalid rsa parameters", errUnsupportedKey)
+		}
+		return &publicKey{algorithm: algorithm, key: &rsa.PublicKey{N: modulus, E: int(exponent)}}, nil
+	}
+	return nil, fmt.Errorf("%w: key type %d", errUnsupportedKey, keyType)
+}
+
+// verify confere a assinatura de data com a chave, usando o algoritmo
+// declarado na credencial
+func (k *publicKey) verify(data, signature []byte) error {
+	return verifySignature(k.algorithm, k.key, data, signature)
+}
+
+// verifySignature confere uma assinatura COSE com uma chave qualquer, também
+// usada para os certificados de atestação
+func verifySignature(algorithm int64, key crypto.PublicKey, data, signature []byte) error {
+	switch algorithm {
+	case algES256, algES384:
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: key does not match algorithm", errBadSignature)
+		}
+		var digest []byte
+		if algorithm == algES256 {
+			sum := sha256.Sum256(data)
+			digest = sum[:]
+		} else {
+			sum := sha512.Sum384(data)
+			digest = sum[:]
+		}
+		if !ecdsa.VerifyASN1(ecKey, digest, signature) {
+			return errBadSignature
+		}
+		return nil
+
+	case algEdDSA:
+		edKey, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: key does not match algorithm", errBadSignature)
+		}
+		if !ed25519.Verify(edKey, data, signature) {
+			return errBadSignature
+		}
+		return nil
+
+	case algRS256:
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: key does not match algorithm", errBadSignature)
+		}
+		digest := sha256.Sum256(data)
+		if err := rsa.VerifyPKCS1v15(rsaKey, crypto.SHA256, digest[:], signature); err != nil {
+			return errBadSignature
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: unsupported algorithm %d", errBadSignature, algorithm)
+}
@@ -0,0 +1,276 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+// encodeCBOR codifica os tipos usados nas chaves COSE de teste
+func encodeCBOR(value any) []byte {
+	header := func(major byte, argument uint64) []byte {
+		switch {
+		case argument < 24:
+			return []byte{major<<5 | byte(argument)}
+		case argument <= 0xff:
+			return []byte{major<<5 | 24, byte(argument)}
+		case argument <= 0xffff:
+			return binary.BigEndian.AppendUint16([]byte{major<<5 | 25}, uint16(argument))
+		case argument <= 0xffffffff:
+			return binary.BigEndian.AppendUint32([]byte{major<<5 | 26}, uint32(argument))
+		default:
+			return binary.BigEndian.AppendUint64([]byte{major<<5 | 27}, argument)
+		}
+	}
+
+	switch v := value.(type) {
+	case int:
+		if v < 0 {
+			return header(1, uint64(-1-v))
+		}
+		return header(0, uint64(v))
+	case []byte:
+		return append(header(2, uint64(len(v))), v...)
+	case string:
+		return append(header(3, uint64(len(v))), v...)
+	case map[int]any:
+		encoded := header(5, uint64(len(v)))
+		for key, item := range v {
+			encoded = append(encoded, encodeCBOR(key)...)
+			encoded = append(encoded, encodeCBOR(item)...)
+		}
+		return encoded
+	}
+	panic("unsupported cbor value")
+}
+
+func ec2Key(algorithm int, curve int, key *ecdsa.PublicKey) map[int]any {
+	size := (key.Curve.Params().BitSize + 7) / 8
+	return map[int]any{
+		coseKeyType:   keyTypeEC2,
+		coseAlgorithm: algorithm,
+		coseCurve:     curve,
+		coseX:         key.X.FillBytes(make([]byte, size)),
+		coseY:         key.Y.FillBytes(make([]byte, size)),
+	}
+}
+
+func rsaKey(key *rsa.PublicKey) map[int]any {
+	return map[int]any{
+		coseKeyType:   keyTypeRSA,
+		coseAlgorithm: int(algRS256),
+		coseCurve:     key.N.Bytes(),
+		coseX:         big.NewInt(int64(key.E)).Bytes(),
+	}
+}
+
+type testKeys struct {
+	p256    *ecdsa.PrivateKey
+	p384    *ecdsa.PrivateKey
+	ed25519 ed25519.PrivateKey
+	rsa     *rsa.PrivateKey
+}
+
+func generateTestKeys(t *testing.T) *testKeys {
+	t.Helper()
+
+	p256, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	p384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	rsaPrivate, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	return &testKeys{p256: p256, p384: p384, ed25519: edKey, rsa: rsaPrivate}
+}
+
+func TestParsePublicKey(t *testing.T) {
+	keys := generateTestKeys(t)
+	edPublic := keys.ed25519.Public().(ed25519.PublicKey)
+	smallRSA, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+
+	offCurve := ec2Key(int(algES256), curveP256, &keys.p256.PublicKey)
+	offCurve[coseY] = make([]byte, 32)
+
+	shortX := ec2Key(int(algES256), curveP256, &keys.p256.PublicKey)
+	shortX[coseX] = shortX[coseX].([]byte)[1:]
+
+	// Módulo de 1024 bits preenchido com zeros até 256 bytes
+	paddedRSA := rsaKey(&smallRSA.PublicKey)
+	paddedRSA[coseCurve] = smallRSA.N.FillBytes(make([]byte, 256))
+
+	evenExponent := rsaKey(&keys.rsa.PublicKey)
+	evenExponent[coseX] = []byte{0x01, 0x00, 0x00}
+
+	unitExponent := rsaKey(&keys.rsa.PublicKey)
+	unitExponent[coseX] = []byte{0x01}
+
+	hugeExponent := rsaKey(&keys.rsa.PublicKey)
+	hugeExponent[coseX] = []byte{0xff, 0xff, 0xff, 0xff}
+
+	tests := []struct {
+		name      string
+		raw       []byte
+		algorithm int64
+		wantErr   bool
+	}{
+		{name: "es256", raw: encodeCBOR(ec2Key(int(algES256), curveP256, &keys.p256.PublicKey)), algorithm: algES256},
+		{name: "es384", raw: encodeCBOR(ec2Key(int(algES384), curveP384, &keys.p384.PublicKey)), algorithm: algES384},
+		{
+			name:      "eddsa",
+			raw:       encodeCBOR(map[int]any{coseKeyType: keyTypeOKP, coseAlgorithm: int(algEdDSA), coseCurve: curveEd25519, coseX: []byte(edPublic)}),
+			algorithm: algEdDSA,
+		},
+		{name: "rs256", raw: encodeCBOR(rsaKey(&keys.rsa.PublicKey)), algorithm: algRS256},
+		{name: "es256 on p-384", raw: encodeCBOR(ec2Key(int(algES256), curveP384, &keys.p384.PublicKey)), wantErr: true},
+		{name: "es384 on p-256", raw: encodeCBOR(ec2Key(int(algES384), curveP256, &keys.p256.PublicKey)), wantErr: true},
+		{name: "point not on curve", raw: encodeCBOR(offCurve), wantErr: true},
+		{name: "short coordinate", raw: encodeCBOR(shortX), wantErr: true},
+		{
+			name:    "eddsa on another curve",
+			raw:     encodeCBOR(map[int]any{coseKeyType: keyTypeOKP, coseAlgorithm: int(algEdDSA), coseCurve: 4, coseX: []byte(edPublic)}),
+			wantErr: true,
+		},
+		{
+			name:    "short ed25519 key",
+			raw:     encodeCBOR(map[int]any{coseKeyType: keyTypeOKP, coseAlgorithm: int(algEdDSA), coseCurve: curveEd25519, coseX: []byte(edPublic[1:])}),
+			wantErr: true,
+		},
+		{name: "rsa 1024", raw: encodeCBOR(rsaKey(&smallRSA.PublicKey)), wantErr: true},
+		{name: "rsa 1024 padded to 2048", raw: encodeCBOR(paddedRSA), wantErr: true},
+		{name: "rsa even exponent", raw: encodeCBOR(evenExponent), wantErr: true},
+		{name: "rsa exponent 1", raw: encodeCBOR(unitExponent), wantErr: true},
+		{name: "rsa exponent overflow", raw: encodeCBOR(hugeExponent), wantErr: true},
+		{name: "rsa with another algorithm", raw: encodeCBOR(map[int]any{coseKeyType: keyTypeRSA, coseAlgorithm: -37, coseCurve: keys.rsa.N.Bytes(), coseX: []byte{1, 0, 1}}), wantErr: true},
+		{name: "unknown key type", raw: encodeCBOR(map[int]any{coseKeyType: 4, coseAlgorithm: int(algES256)}), wantErr: true},
+		{name: "not a map", raw: encodeCBOR([]byte{1, 2, 3}), wantErr: true},
+		{name: "trailing data", raw: append(encodeCBOR(ec2Key(int(algES256), curveP256, &keys.p256.PublicKey)), 0x00), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := parsePublicKey(tt.raw)
+			if tt.wantErr {
+				if !errors.Is(err, errUnsupportedKey) {
+					t.Fatalf("parsePublicKey() error = %v, want %v", err, errUnsupportedKey)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parsePublicKey(): %v", err)
+			}
+			if key.algorithm != tt.algorithm {
+				t.Fatalf("algorithm = %d, want %d", key.algorithm, tt.algorithm)
+			}
+		})
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	keys := generateTestKeys(t)
+	data := []byte("authenticator data || client data hash")
+
+	sha256Digest := sha256.Sum256(data)
+	sha384Digest := sha512.Sum384(data)
+	es256, err := ecdsa.SignASN1(rand.Reader, keys.p256, sha256Digest[:])
+	if err != nil {
+		t.Fatalf("SignASN1: %v", err)
+	}
+	es384, err := ecdsa.SignASN1(rand.Reader, keys.p384, sha384Digest[:])
+	if err != nil {
+		t.Fatalf("SignASN1: %v", err)
+	}
+	rs256, err := rsa.SignPKCS1v15(rand.Reader, keys.rsa, crypto.SHA256, sha256Digest[:])
+	if err != nil {
+		t.Fatalf("SignPKCS1v15: %v", err)
+	}
+	eddsa := ed25519.Sign(keys.ed25519, data)
+
+	// ES256 exige a assinatura em DER, não r || s
+	r, s, err := ecdsa.Sign(rand.Reader, keys.p256, sha256Digest[:])
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	rawES256 := append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+
+	tests := []struct {
+		name      string
+		algorithm int64
+		key       crypto.PublicKey
+		data      []byte
+		signature []byte
+		wantErr   bool
+	}{
+		{name: "es256", algorithm: algES256, key: &keys.p256.PublicKey, data: data, signature: es256},
+		{name: "es384", algorithm: algES384, key: &keys.p384.PublicKey, data: data, signature: es384},
+		{name: "eddsa", algorithm: algEdDSA, key: keys.ed25519.Public(), data: data, signature: eddsa},
+		{name: "rs256", algorithm: algRS256, key: &keys.rsa.PublicKey, data: data, signature: rs256},
+		{name: "es256 altered data", algorithm: algES256, key: &keys.p256.PublicKey, data: []byte("other"), signature: es256, wantErr: true},
+		{name: "eddsa altered data", algorithm: algEdDSA, key: keys.ed25519.Public(), data: []byte("other"), signature: eddsa, wantErr: true},
+		{name: "rs256 altered data", algorithm: algRS256, key: &keys.rsa.PublicKey, data: []byte("other"), signature: rs256, wantErr: true},
+		{name: "es256 raw signature", algorithm: algES256, key: &keys.p256.PublicKey, data: data, signature: rawES256, wantErr: true},
+		{name: "es256 signature as es384", algorithm: algES384, key: &keys.p256.PublicKey, data: data, signature: es256, wantErr: true},
+		{name: "ecdsa algorithm with rsa key", algorithm: algES256, key: &keys.rsa.PublicKey, data: data, signature: rs256, wantErr: true},
+		{name: "rsa algorithm with ecdsa key", algorithm: algRS256, key: &keys.p256.PublicKey, data: data, signature: es256, wantErr: true},
+		{name: "eddsa algorithm with ecdsa key", algorithm: algEdDSA, key: &keys.p256.PublicKey, data: data, signature: es256, wantErr: true},
+		{name: "unsupported algorithm", algorithm: -36, key: &keys.p256.PublicKey, data: data, signature: es256, wantErr: true},
+		{name: "empty signature", algorithm: algES256, key: &keys.p256.PublicKey, data: data, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifySignature(tt.algorithm, tt.key, tt.data, tt.signature)
+			if tt.wantErr {
+				if !errors.Is(err, errBadSignature) {
+					t.Fatalf("verifySignature() error = %v, want %v", err, errBadSignature)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("verifySignature(): %v", err)
+			}
+		})
+	}
+}
+
+func TestPublicKeyVerify(t *testing.T) {
+	keys := generateTestKeys(t)
+	data := []byte("signed data")
+	digest := sha256.Sum256(data)
+	signature, err := ecdsa.SignASN1(rand.Reader, keys.p256, digest[:])
+	if err != nil {
+		t.Fatalf("SignASN1: %v", err)
+	}
+
+	key, err := parsePublicKey(encodeCBOR(ec2Key(int(algES256), curveP256, &keys.p256.PublicKey)))
+	if err != nil {
+		t.Fatalf("parsePublicKey(): %v", err)
+	}
+	if err := key.verify(data, signature); err != nil {
+		t.Fatalf("verify(): %v", err)
+	}
+	if err := key.verify([]byte("other data"), signature); !errors.Is(err, errBadSignature) {
+		t.Fatalf("verify() of other data error = %v, want %v", err, errBadSignature)
+	}
+}
@@ -0,0 +1,156 @@
+package webauthn
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// Config identifica o relying party. Origins são as origens (esquema, host e
+// porta) de onde as cerimônias podem partir. Attestation é a preferência de
+// atestação pedida no registro (none, indirect ou direct).
+type Config struct {
+	RPID        string
+	RPName      string
+	Origins     []string
+	Attestation string
+	Timeout     time.Duration
+}
+
+// Tipos de cerimônia guardados junto com o desafio
+const (
+	ceremonyRegistration   = "webauthn.create"
+	ceremonyAuthentication = "webauthn.get"
+)
+
+// Requisitos de verificação do usuário (UV)
+const (
+	verificationRequired  = "required"
+	verificationPreferred = "preferred"
+)
+
+// Ceremony é uma cerimônia em andamento, guardada pelo desafio. Sem UserID,
+// é um login sem senha em que o usuário é descoberto pela credencial.
+type Ceremony struct {
+	Challenge        string    `json:"challenge"`
+	Type             string    `json:"type"`
+	UserID           string    `json:"user_id,omitempty"`
+	UserVerification string    `json:"user_verification"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// Base64URL são dados binários trafegados em base64url, como na API
+// WebAuthn do navegador. Também aceita valores com padding.
+type Base64URL []byte
+
+func (b Base64URL) MarshalJSON() ([]byte, error) {
+	return json.Marshal(base64.RawURLEncoding.EncodeToString(b))
+}
+
+func (b *Base64URL) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+	if err != nil {
+		return err
+	}
+	*b = decoded
+	return nil
+}
+
+func (b Base64URL) String() string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+type RelyingParty struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type UserEntity struct {
+	ID          Base64URL `json:"id"`
+	Name        string    `json:"name"`
+	DisplayName string    `json:"displayName"`
+}
+
+type CredentialParameter struct {
+	Type      string `json:"type"`
+	Algorithm int64  `json:"alg"`
+}
+
+type CredentialDescriptor struct {
+	Type       string    `json:"type"`
+	ID         Base64URL `json:"id"`
+	Transports []string  `json:"transports,omitempty"`
+}
+
+type AuthenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+// CreationOptions são as opções de navigator.credentials.create()
+type CreationOptions struct {
+	Challenge              Base64URL              `json:"challenge"`
+	RelyingParty           RelyingParty           `json:"rp"`
+	User                   UserEntity             `json:"user"`
+	Parameters             []CredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                  `json:"timeout"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection AuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+}
+
+// RequestOptions são as opções de navigator.credentials.get()
+type RequestOptions struct {
+	Challenge        Base64URL              `json:"challenge"`
+	RPID             string                 `json:"rpId"`
+	Timeout          int64                  `json:"timeout"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                 `json:"userVerification"`
+}
+
+// AttestationCredential é a credencial criada pelo navegador no registro
+type AttestationCredential struct {
+	ID       string    `json:"id" binding:"required"`
+	RawID    Base64URL `json:"rawId" binding:"required"`
+	Type     string    `json:"type" binding:"required"`
+	Response struct {
+		ClientDataJSON    Base64URL `json:"clientDataJSON" binding:"required"`
+		AttestationObject Base64URL `json:"attestationObject" binding:"required"`
+		Transports        []string  `json:"transports"`
+	} `json:"response" binding:"required"`
+}
+
+// AssertionCredential é a resposta do navegador numa autenticação
+type AssertionCredential struct {
+	ID       string    `json:"id" binding:"required"`
+	RawID    Base64URL `json:"rawId" binding:"required"`
+	Type     string    `json:"type" binding:"required"`
+	Response struct {
+		ClientDataJSON    Base64URL `json:"clientDataJSON" binding:"required"`
+		AuthenticatorData Base64URL `json:"authenticatorData" binding:"required"`
+		Signature         Base64URL `json:"signature" binding:"required"`
+		UserHandle        Base64URL `json:"userHandle"`
+	} `json:"response" binding:"required"`
+}
+
+type RegistrationRequest struct {
+	// Name identifica a credencial na lista do usuário (ex.: "Notebook")
+	Name       string                `json:"name"`
+	Credential AttestationCredential `json:"credential" binding:"required"`
+}
+
+type LoginRequest struct {
+	Credential AssertionCredential `json:"credential" binding:"required"`
+}
+
+// clientData é o clientDataJSON assinado indiretamente pelo autenticador
+type clientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
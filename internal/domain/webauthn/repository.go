@@ -0,0 +1,13 @@
+package webauthn
+
+import (
+	"context"
+	"time"
+)
+
+// ChallengeRepository guarda as cerimônias em andamento pelo desafio. Consume
+// remove o registro, garantindo que cada desafio seja usado uma única vez.
+type ChallengeRepository interface {
+	Save(ctx context.Context, ceremony *Ceremony, ttl time.Duration) error
+	Consume(ctx context.Context, challenge string) (*Ceremony, error)
+}
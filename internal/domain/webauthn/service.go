@@ -0,0 +1,431 @@
+package webauthn
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/juanjerrah/go_auth_api/internal/domain/audit"
+	"github.com/juanjerrah/go_auth_api/internal/domain/user"
+	"github.com/juanjerrah/go_auth_api/pkg/common"
+)
+
+const credentialType = "public-key"
+
+var (
+	ErrInvalidChallenge    = errors.New("invalid or expired webauthn challenge")
+	ErrInvalidCredential   = errors.New("invalid webauthn credential")
+	ErrCredentialNotFound  = errors.New("webauthn credential not found")
+	ErrCredentialExists    = errors.New("webauthn credential already registered")
+	ErrClonedAuthenticator = errors.New("webauthn signature counter did not increase")
+)
+
+type Service interface {
+	BeginRegistration(ctx context.Context, userID string) (*CreationOptions, error)
+	FinishRegistration(ctx context.Context, userID string, req *RegistrationRequest) (*user.WebAuthnCredential, error)
+	// BeginLogin inicia uma autenticação. Com userID, a chave de acesso é um
+	// segundo fator do usuário; sem ele, é um login sem senha com credenciais
+	// descobertas pelo autenticador, que exige verificação do usuário.
+	BeginLogin(ctx context.Context, userID string) (*RequestOptions, error)
+	FinishLogin(ctx context.Context, userID string, credential *AssertionCredential) (*user.User, error)
+	ListCredentials(ctx context.Context, userID string) ([]user.WebAuthnCredential, error)
+	RemoveCredential(ctx context.Context, userID, credentialID string) error
+}
+
+type service struct {
+	config     Config
+	rpIDHash   []byte
+	challenges ChallengeRepository
+	userRepo   user.Repository
+	tokens     common.TokenGenerator
+	audit      audit.Service
+}
+
+func NewService(config Config, challenges ChallengeRepository, userRepo user.Repository, tokens common.TokenGenerator, auditService audit.Service) Service {
+	rpIDHash := sha256.Sum256([]byte(config.RPID))
+	return &service{
+		config:     config,
+		rpIDHash:   rpIDHash[:],
+		challenges: challenges,
+		userRepo:   userRepo,
+		tokens:     tokens,
+		audit:      auditService,
+	}
+}
+
+// BeginRegistration implements Service.
+func (s *service) BeginRegistration(ctx context.Context, userID string) (*CreationOptions, error) {
+	usr, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, user.ErrUserNotFound
+	}
+
+	challenge, err := s.newCeremony(ctx, ceremonyRegistration, userID, verificationPreferred)
+	if err != nil {
+		return nil, err
+	}
+
+	parameters := make([]CredentialParameter, 0, len(supportedAlgorithms))
+	for _, algorithm := range supportedAlgorithms {
+		parameters = append(parameters, CredentialParameter{Type: credentialType, Algorithm: algorithm})
+	}
+
+	return &CreationOptions{
+		Challenge:    challenge,
+		RelyingParty: RelyingParty{ID: s.config.RPID, Name: s.config.RPName},
+		// O identificador do usuário no autenticador é o ObjectID, sem dados pessoais
+		User:               UserEntity{ID: usr.ID[:], Name: usr.Email, DisplayName: usr.Name},
+		Parameters:         parameters,
+		Timeout:            s.config.Timeout.Milliseconds(),
+		ExcludeCredentials: descriptors(usr.WebAuthnCredentials),
+		AuthenticatorSelection: AuthenticatorSelection{
+			ResidentKey:      "preferred",
+			UserVerification: verificationPreferred,
+		},
+		Attestation: s.config.Attestation,
+	}, nil
+}
+
+// FinishRegistration implements Service.
+func (s *service) FinishRegistration(ctx context.Context, userID string, req *RegistrationRequest) (*user.WebAuthnCredential, error) {
+	credential := &req.Credential
+	if err := checkCredentialID(credential.Type, credential.ID, credential.RawID); err != nil {
+		return nil, err
+	}
+
+	ceremony, err := s.consumeCeremony(ctx, credential.Response.ClientDataJSON, ceremonyRegistration)
+	if err != nil {
+		return nil, err
+	}
+	if ceremony.UserID != userID {
+		return nil, ErrInvalidChallenge
+	}
+
+	attestation, err := parseAttestationObject(credential.Response.AttestationObject)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredential, err)
+	}
+	if err := s.checkAuthenticatorData(attestation.authData, ceremony); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(attestation.authData.credentialID, credential.RawID) {
+		return nil, fmt.Errorf("%w: credential id mismatch", ErrInvalidCredential)
+	}
+
+	key, err := parsePublicKey(attestation.authData.publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredential, err)
+	}
+	clientDataHash := sha256.Sum256(credential.Response.ClientDataJSON)
+	if err := attestation.verify(key, clientDataHash[:]); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredential, err)
+	}
+
+	// Uma credencial pertence a um único usuário
+	credentialID := credential.RawID.String()
+	if _, err := s.userRepo.FindByWebAuthnCredential(ctx, credentialID); err == nil {
+		return nil, ErrCredentialExists
+	}
+
+	usr, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, user.ErrUserNotFound
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = fmt.Sprintf("Passkey %d", len(usr.WebAuthnCredentials)+1)
+	}
+
+	now := time.Now().UTC()
+	registered := user.WebAuthnCredential{
+		ID:                credentialID,
+		Name:              name,
+		PublicKey:         attestation.authData.publicKey,
+		SignCount:         attestation.authData.signCount,
+		AAGUID:            hex.EncodeToString(attestation.authData.aaguid),
+		AttestationFormat: attestation.format,
+		Transports:        credential.Response.Transports,
+		CreatedAt:         now,
+	}
+	usr.WebAuthnCredentials = append(usr.WebAuthnCredentials, registered)
+	usr.UpdatedAt = now
+	if err := s.userRepo.Update(ctx, usr); err != nil {
+		return nil, err
+	}
+
+	s.record(ctx, &audit.Event{
+		Type:     audit.EventWebAuthnRegistered,
+		TargetID: userID,
+		Metadata: map[string]string{
+			"credential_id": credentialID,
+			"name":          name,
+			"format":        attestation.format,
+			"aaguid":        registered.AAGUID,
+		},
+	})
+
+	return &registered, nil
+}
+
+// BeginLogin implements Service.
+func (s *service) BeginLogin(ctx context.Context, userID string) (*RequestOptions, error) {
+	verification := verificationRequired
+	allowed := []CredentialDescriptor{}
+	if userID != "" {
+		usr, err := s.userRepo.FindByID(ctx, userID)
+		if err != nil {
+			return nil, user.ErrUserNotFound
+		}
+		if len(usr.WebAuthnCredentials) == 0 {
+			return nil, ErrCredentialNotFound
+		}
+		// Como segundo fator, a senha já foi verificada; basta a presença
+		verification = verificationPreferred
+		allowed = descriptors(usr.WebAuthnCredentials)
+	}
+
+	challenge, err := s.newCeremony(ctx, ceremonyAuthentication, userID, verification)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RequestOptions{
+		Challenge:        challenge,
+		RPID:             s.config.RPID,
+		Timeout:          s.config.Timeout.Milliseconds(),
+		AllowCredentials: allowed,
+		UserVerification: verification,
+	}, nil
+}
+
+// FinishLogin implements Service.
+func (s *service) FinishLogin(ctx context.Context, userID string, credential *AssertionCredential) (*user.User, error) {
+	if err := checkCredentialID(credential.Type, credential.ID, credential.RawID); err != nil {
+		return nil, err
+	}
+
+	ceremony, err := s.consumeCeremony(ctx, credential.Response.ClientDataJSON, ceremonyAuthentication)
+	if err != nil {
+		return nil, err
+	}
+	if ceremony.UserID != userID {
+		return nil, ErrInvalidChallenge
+	}
+
+	credentialID := credential.RawID.String()
+	usr, err := s.userRepo.FindByWebAuthnCredential(ctx, credentialID)
+	if err != nil {
+		return nil, ErrCredentialNotFound
+	}
+	// No segundo fator a credencial precisa ser do usuário que digitou a senha;
+	// no login sem senha, do usuário indicado pelo autenticador
+	if userID != "" && usr.ID.Hex() != userID {
+		return nil, ErrCredentialNotFound
+	}
+	if len(credential.Response.UserHandle) > 0 && !bytes.Equal(credential.Response.UserHandle, usr.ID[:]) {
+		return nil, fmt.Errorf("%w: user handle mismatch", ErrInvalidCredential)
+	}
+
+	index := -1
+	for i := range usr.WebAuthnCredentials {
+		if usr.WebAuthnCredentials[i].ID == credentialID {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return nil, ErrCredentialNotFound
+	}
+	stored := &usr.WebAuthnCredentials[index]
+
+	authData, err := parseAuthenticatorData(credential.Response.AuthenticatorData)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredential, err)
+	}
+	if err := s.checkAuthenticatorData(authData, ceremony); err != nil {
+		return nil, err
+	}
+
+	key, err := parsePublicKey(stored.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	clientDataHash := sha256.Sum256(credential.Response.ClientDataJSON)
+	signed := append(append([]byte(nil), authData.raw...), clientDataHash[:]...)
+	if err := key.verify(signed, credential.Response.Signature); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredential, err)
+	}
+
+	if usr.Disabled {
+		return nil, user.ErrUserDisabled
+	}
+
+	// Autenticadores que contam assinaturas devem sempre avançar o contador;
+	// um valor que não avança indica uma possível cópia da credencial
+	if (authData.signCount != 0 || stored.SignCount != 0) && authData.signCount <= stored.SignCount {
+		return nil, ErrClonedAuthenticator
+	}
+
+	now := time.Now().UTC()
+	stored.SignCount = authData.signCount
+	stored.LastUsedAt = &now
+	if err := s.userRepo.Update(ctx, usr); err != nil {
+		return nil, err
+	}
+
+	return usr, nil
+}
+
+// ListCredentials implements Service.
+func (s *service) ListCredentials(ctx context.Context, userID string) ([]user.WebAuthnCredential, error) {
+	usr, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, user.ErrUserNotFound
+	}
+	if usr.WebAuthnCredentials == nil {
+		return []user.WebAuthnCredential{}, nil
+	}
+	return usr.WebAuthnCredentials, nil
+}
+
+// RemoveCredential implements Service.
+func (s *service) RemoveCredential(ctx context.Context, userID, credentialID string) error {
+	usr, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return user.ErrUserNotFound
+	}
+
+	remaining := make([]user.WebAuthnCredential, 0, len(usr.WebAuthnCredentials))
+	var removed *user.WebAuthnCredential
+	for i, credential := range usr.WebAuthnCredentials {
+		if credential.ID == credentialID {
+			removed = &usr.WebAuthnCredentials[i]
+			continue
+		}
+		remaining = append(remaining, credential)
+	}
+	if removed == nil {
+		return ErrCredentialNotFound
+	}
+
+	usr.WebAuthnCredentials = remaining
+	usr.UpdatedAt = time.Now().UTC()
+	if err := s.userRepo.Update(ctx, usr); err != nil {
+		return err
+	}
+
+	s.record(ctx, &audit.Event{
+		Type:     audit.EventWebAuthnRemoved,
+		TargetID: userID,
+		Metadata: map[string]string{"credential_id": credentialID, "name": removed.Name},
+	})
+	return nil
+}
+
+// newCeremony gera um desafio e guarda a cerimônia até o timeout das opções
+func (s *service) newCeremony(ctx context.Context, ceremonyType, userID, verification string) (Base64URL, error) {
+	token, err := s.tokens.Generate()
+	if err != nil {
+		return nil, err
+	}
+	challenge, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, err
+	}
+
+	ceremony := &Ceremony{
+		Challenge:        token,
+		Type:             ceremonyType,
+		UserID:           userID,
+		UserVerification: verification,
+		CreatedAt:        time.Now().UTC(),
+	}
+	if err := s.challenges.Save(ctx, ceremony, s.config.Timeout); err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+
+// consumeCeremony valida o clientDataJSON (WebAuthn §7.1 e §7.2) e consome a
+// cerimônia do desafio que ele carrega
+func (s *service) consumeCeremony(ctx context.Context, rawClientData []byte, ceremonyType string) (*Ceremony, error) {
+	var data clientData
+	if err := json.Unmarshal(rawClientData, &data); err != nil {
+		return nil, fmt.Errorf("%w: malformed client data", ErrInvalidCredential)
+	}
+	if data.Type != ceremonyType {
+		return nil, fmt.Errorf("%w: unexpected client data type", ErrInvalidCredential)
+	}
+	if !s.allowedOrigin(data.Origin) {
+		return nil, fmt.Errorf("%w: origin %q not allowed", ErrInvalidCredential, data.Origin)
+	}
+	if data.Challenge == "" {
+		return nil, ErrInvalidChallenge
+	}
+
+	ceremony, err := s.challenges.Consume(ctx, data.Challenge)
+	if err != nil {
+		return nil, err
+	}
+	if ceremony == nil || ceremony.Type != ceremonyType {
+		return nil, ErrInvalidChallenge
+	}
+	return ceremony, nil
+}
+
+func (s *service) checkAuthenticatorData(authData *authenticatorData, ceremony *Ceremony) error {
+	if !bytes.Equal(authData.rpIDHash, s.rpIDHash) {
+		return fmt.Errorf("%w: relying party mismatch", ErrInvalidCredential)
+	}
+	if !authData.userPresent() {
+		return fmt.Errorf("%w: user not present", ErrInvalidCredential)
+	}
+	if ceremony.UserVerification == verificationRequired && !authData.userVerified() {
+		return fmt.Errorf("%w: user not verified", ErrInvalidCredential)
+	}
+	return nil
+}
+
+func (s *service) allowedOrigin(origin string) bool {
+	for _, allowed := range s.config.Origins {
+		if origin == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *service) record(ctx context.Context, event *audit.Event) {
+	if err := s.audit.Record(ctx, event); err != nil {
+		log.Printf("Warning: failed to record audit event %s: %v", event.Type, err)
+	}
+}
+
+// checkCredentialID confere se o id textual corresponde ao rawId
+func checkCredentialID(kind, id string, rawID []byte) error {
+	if kind != credentialType || len(rawID) == 0 || id != base64.RawURLEncoding.EncodeToString(rawID) {
+		return fmt.Errorf("%w: malformed credential", ErrInvalidCredential)
+	}
+	return nil
+}
+
+func descriptors(credentials []user.WebAuthnCredential) []CredentialDescriptor {
+	result := make([]CredentialDescriptor, 0, len(credentials))
+	for _, credential := range credentials {
+		id, err := base64.RawURLEncoding.DecodeString(credential.ID)
+		if err != nil {
+			continue
+		}
+		result = append(result, CredentialDescriptor{Type: credentialType, ID: id, Transports: credential.Transports})
+	}
+	return result
+}
@@ -0,0 +1,121 @@
+package ldap
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/juanjerrah/go_auth_api/internal/config"
+	"github.com/juanjerrah/go_auth_api/internal/domain/user"
+)
+
+// Authenticator autentica usuários em um servidor LDAP: localiza a entrada
+// pelo e-mail com a conta de serviço e faz bind com as credenciais do próprio
+// usuário.
+type Authenticator struct {
+	cfg       *config.LDAPConfig
+	tlsConfig *tls.Config
+}
+
+func NewAuthenticator(cfg *config.LDAPConfig) user.DirectoryAuthenticator {
+	return &Authenticator{
+		cfg:       cfg,
+		tlsConfig: &tls.Config{MinVersion: tls.VersionTLS12},
+	}
+}
+
+// Handles implements user.DirectoryAuthenticator.
+func (a *Authenticator) Handles(email string, usr *user.User) bool {
+	if usr != nil && usr.AuthProvider == user.AuthProviderLDAP {
+		return true
+	}
+
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+	domain := email[at+1:]
+	for _, candidate := range a.cfg.Domains {
+		if strings.EqualFold(candidate, domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// Authenticate implements user.DirectoryAuthenticator.
+func (a *Authenticator) Authenticate(ctx context.Context, email, password string) (*user.DirectoryIdentity, error) {
+	// Um bind com senha vazia seria um bind anônimo aceito pelo servidor
+	if password == "" {
+		return nil, user.ErrInvalidPassword
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, a.cfg.Timeout)
+	defer cancel()
+
+	c, err := dial(ctx, a.cfg.URL, a.cfg.StartTLS, a.tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+	defer c.close()
+
+	if err := c.bind(a.cfg.BindDN, a.cfg.BindPassword); err != nil {
+		return nil, fmt.Errorf("service bind: %w", err)
+	}
+
+	attributes := []string{a.cfg.EmailAttribute, a.cfg.NameAttribute, a.cfg.DepartmentAttribute, a.cfg.GroupAttribute}
+	entries, err := c.searchEquality(a.cfg.BaseDN, a.cfg.EmailAttribute, email, attributes, 2)
+	if err != nil {
+		return nil, err
+	}
+	// E-mail ausente ou ambíguo no diretório é tratado como credencial inválida
+	if len(entries) != 1 {
+		return nil, user.ErrInvalidPassword
+	}
+	found := entries[0]
+
+	if err := c.bind(found.dn, password); err != nil {
+		if errors.Is(err, errInvalidCredentials) {
+			return nil, user.ErrInvalidPassword
+		}
+		return nil, err
+	}
+
+	identity := &user.DirectoryIdentity{
+		Name:       found.first(a.cfg.NameAttribute),
+		Email:      email,
+		Department: found.first(a.cfg.DepartmentAttribute),
+		Role:       user.RoleUser,
+	}
+	if identity.Name == "" {
+		identity.Name = email
+	}
+	if a.isAdmin(found.attributes[strings.ToLower(a.cfg.GroupAttribute)]) {
+		identity.Role = user.RoleAdmin
+	}
+	return identity, nil
+}
+
+// isAdmin informa se algum dos grupos do usuário está entre os grupos de
+// administradores configurados
+func (a *Authenticator) isAdmin(groups []string) bool {
+	for _, group := range groups {
+		for _, adminGroup := range a.cfg.AdminGroups {
+			if normalizeDN(group) == normalizeDN(adminGroup) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// normalizeDN permite comparar DNs ignorando caixa e espaços entre os RDNs
+func normalizeDN(dn string) string {
+	parts := strings.Split(dn, ",")
+	for i, part := range parts {
+		parts[i] = strings.ToLower(strings.TrimSpace(part))
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,179 @@
+package ldap
+
+import (
+	"bufio"
+	"errors"
+	"io"
+)
+
+// Subconjunto da codificação BER (X.690) usado pelo protocolo LDAPv3: tags de
+// um byte e comprimentos de até 4 bytes.
+
+var errMalformedPacket = errors.New("ldap: malformed packet")
+
+// Tags universais
+const (
+	tagBoolean     = 0x01
+	tagInteger     = 0x02
+	tagOctetString = 0x04
+	tagEnumerated  = 0x0a
+	tagSequence    = 0x30
+	tagSet         = 0x31
+)
+
+// Tamanho máximo aceito para uma mensagem do servidor
+const maxPacketSize = 16 << 20
+
+type element struct {
+	tag     byte
+	content []byte
+}
+
+func encode(tag byte, content ...[]byte) []byte {
+	length := 0
+	for _, part := range content {
+		length += len(part)
+	}
+
+	packet := []byte{tag}
+	switch {
+	case length < 0x80:
+		packet = append(packet, byte(length))
+	case length <= 0xff:
+		packet = append(packet, 0x81, byte(length))
+	case length <= 0xffff:
+		packet = append(packet, 0x82, byte(length>>8), byte(length))
+	default:
+		packet = append(packet, 0x84, byte(length>>24), byte(length>>16), byte(length>>8), byte(length))
+	}
+	for _, part := range content {
+		packet = append(packet, part...)
+	}
+	return packet
+}
+
+func encodeString(tag byte, value string) []byte {
+	return encode(tag, []byte(value))
+}
+
+func encodeInt(tag byte, value int) []byte {
+	// Complemento de dois com o menor número de bytes
+	var content []byte
+	for {
+		content = append([]byte{byte(value)}, content...)
+		value >>= 8
+		if (value == 0 && content[0]&0x80 == 0) || (value == -1 && content[0]&0x80 != 0) {
+			break
+		}
+	}
+	return encode(tag, content)
+}
+
+func encodeBool(value bool) []byte {
+	if value {
+		return encode(tagBoolean, []byte{0xff})
+	}
+	return encode(tagBoolean, []byte{0x00})
+}
+
+// readElement lê um elemento completo da conexão
+func readElement(reader *bufio.Reader) (*element, error) {
+	tag, err := reader.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	length, err := readLength(reader)
+	if err != nil {
+		return nil, err
+	}
+	if length > maxPacketSize {
+		return nil, errMalformedPacket
+	}
+
+	content := make([]byte, length)
+	if _, err := io.ReadFull(reader, content); err != nil {
+		return nil, err
+	}
+	return &element{tag: tag, content: content}, nil
+}
+
+func readLength(reader io.ByteReader) (int, error) {
+	first, err := reader.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	if first&0x80 == 0 {
+		return int(first), nil
+	}
+
+	octets := int(first & 0x7f)
+	if octets == 0 || octets > 4 {
+		return 0, errMalformedPacket
+	}
+	length := 0
+	for range octets {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		length = length<<8 | int(b)
+	}
+	// Com int de 32 bits, 4 bytes de comprimento podem estourar para negativo
+	if length < 0 {
+		return 0, errMalformedPacket
+	}
+	return length, nil
+}
+
+// children decodifica os elementos contidos em um elemento construído
+func (e *element) children() ([]*element, error) {
+	var children []*element
+	data := e.content
+	for len(data) > 0 {
+		if len(data) < 2 {
+			return nil, errMalformedPacket
+		}
+		tag := data[0]
+		reader := &byteReader{data: data[1:]}
+		length, err := readLength(reader)
+		if err != nil {
+			return nil, errMalformedPacket
+		}
+		offset := 1 + reader.pos
+		if length > len(data)-offset {
+			return nil, errMalformedPacket
+		}
+		children = append(children, &element{tag: tag, content: data[offset : offset+length]})
+		data = data[offset+length:]
+	}
+	return children, nil
+}
+
+func (e *element) int() int {
+	value := 0
+	for i, b := range e.content {
+		if i == 0 && b&0x80 != 0 {
+			value = -1
+		}
+		value = value<<8 | int(b)
+	}
+	return value
+}
+
+func (e *element) string() string {
+	return string(e.content)
+}
+
+type byteReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *byteReader) ReadByte() (byte, error) {
+	if r.pos >= len(r.data) {
+		return 0, io.ErrUnexpectedEOF
+	}
+	b := r.data[r.pos]
+	r.pos++
+	return b, nil
+}
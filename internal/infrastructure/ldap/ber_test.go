@@ -0,0 +1,173 @@
+package ldap
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestEncodeLength(t *testing.T) {
+	tests := []struct {
+		name   string
+		length int
+		header []byte
+	}{
+		{"empty", 0, []byte{tagOctetString, 0x00}},
+		{"short form", 0x7f, []byte{tagOctetString, 0x7f}},
+		{"one length octet", 0x80, []byte{tagOctetString, 0x81, 0x80}},
+		{"one length octet max", 0xff, []byte{tagOctetString, 0x81, 0xff}},
+		{"two length octets", 0x100, []byte{tagOctetString, 0x82, 0x01, 0x00}},
+		{"four length octets", 0x10000, []byte{tagOctetString, 0x84, 0x00, 0x01, 0x00, 0x00}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := bytes.Repeat([]byte{'a'}, tt.length)
+			packet := encode(tagOctetString, content)
+			if !bytes.HasPrefix(packet, tt.header) {
+				t.Fatalf("header = %x, want %x", packet[:len(tt.header)], tt.header)
+			}
+
+			decoded, err := readElement(bufio.NewReader(bytes.NewReader(packet)))
+			if err != nil {
+				t.Fatalf("readElement: %v", err)
+			}
+			if decoded.tag != tagOctetString || !bytes.Equal(decoded.content, content) {
+				t.Fatalf("decoded tag %x with %d bytes, want %x with %d bytes", decoded.tag, len(decoded.content), tagOctetString, tt.length)
+			}
+		})
+	}
+}
+
+func TestEncodeInt(t *testing.T) {
+	tests := []struct {
+		value   int
+		content []byte
+	}{
+		{0, []byte{0x00}},
+		{1, []byte{0x01}},
+		{127, []byte{0x7f}},
+		{128, []byte{0x00, 0x80}},
+		{256, []byte{0x01, 0x00}},
+		{-1, []byte{0xff}},
+		{-128, []byte{0x80}},
+		{-129, []byte{0xff, 0x7f}},
+		{1 << 24, []byte{0x01, 0x00, 0x00, 0x00}},
+	}
+
+	for _, tt := range tests {
+		packet := encodeInt(tagInteger, tt.value)
+		want := append([]byte{tagInteger, byte(len(tt.content))}, tt.content...)
+		if !bytes.Equal(packet, want) {
+			t.Errorf("encodeInt(%d) = %x, want %x", tt.value, packet, want)
+		}
+
+		decoded := &element{tag: tagInteger, content: tt.content}
+		if got := decoded.int(); got != tt.value {
+			t.Errorf("int() of %x = %d, want %d", tt.content, got, tt.value)
+		}
+	}
+}
+
+func TestReadElementRejectsMalformedInput(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+		want  error
+	}{
+		{"empty", nil, io.EOF},
+		{"tag only", []byte{tagSequence}, io.EOF},
+		{"indefinite length", []byte{tagSequence, 0x80}, errMalformedPacket},
+		{"too many length octets", []byte{tagSequence, 0x85, 0, 0, 0, 0, 1}, errMalformedPacket},
+		{"truncated length octets", []byte{tagSequence, 0x82, 0x01}, io.EOF},
+		{"larger than limit", []byte{tagSequence, 0x84, 0x7f, 0xff, 0xff, 0xff}, errMalformedPacket},
+		{"truncated content", []byte{tagSequence, 0x05, 0x02, 0x01}, io.ErrUnexpectedEOF},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readElement(bufio.NewReader(bytes.NewReader(tt.input)))
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("readElement() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestChildren(t *testing.T) {
+	tests := []struct {
+		name    string
+		content []byte
+		tags    []byte
+		wantErr bool
+	}{
+		{"empty", nil, nil, false},
+		{"two children", append(encodeInt(tagInteger, 7), encodeString(tagOctetString, "x")...), []byte{tagInteger, tagOctetString}, false},
+		{"long form child", encode(tagOctetString, bytes.Repeat([]byte{'a'}, 200)), []byte{tagOctetString}, false},
+		{"lone tag", []byte{tagInteger}, nil, true},
+		{"child past end", []byte{tagOctetString, 0x05, 'a', 'b'}, nil, true},
+		{"truncated long length", []byte{tagOctetString, 0x82, 0x01}, nil, true},
+		{"indefinite length", []byte{tagSequence, 0x80, 0x00, 0x00}, nil, true},
+		{"too many length octets", []byte{tagOctetString, 0x88, 0, 0, 0, 0, 0, 0, 0, 1, 'a'}, nil, true},
+		{"huge length", []byte{tagOctetString, 0x84, 0xff, 0xff, 0xff, 0xff, 'a'}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			children, err := (&element{tag: tagSequence, content: tt.content}).children()
+			if tt.wantErr {
+				if !errors.Is(err, errMalformedPacket) {
+					t.Fatalf("children() error = %v, want %v", err, errMalformedPacket)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("children(): %v", err)
+			}
+			if len(children) != len(tt.tags) {
+				t.Fatalf("got %d children, want %d", len(children), len(tt.tags))
+			}
+			for i, child := range children {
+				if child.tag != tt.tags[i] {
+					t.Errorf("child %d tag = %x, want %x", i, child.tag, tt.tags[i])
+				}
+			}
+		})
+	}
+}
+
+// Toda mensagem truncada deve resultar em erro, nunca em panic
+func TestTruncatedMessagesDoNotPanic(t *testing.T) {
+	message := ldapMessage(1, searchEntry("uid=ana,dc=example,dc=com", map[string][]string{
+		"mail":     {"ana@example.com"},
+		"memberOf": {"cn=admins,dc=example,dc=com", "cn=staff,dc=example,dc=com"},
+	}))
+
+	for i := range len(message) {
+		if _, err := readElement(bufio.NewReader(bytes.NewReader(message[:i]))); err == nil {
+			t.Fatalf("readElement accepted %d of %d bytes", i, len(message))
+		}
+	}
+
+	// Conteúdo truncado dentro de um envelope válido percorre os decodificadores
+	// internos
+	parsed, err := readElement(bufio.NewReader(bytes.NewReader(message)))
+	if err != nil {
+		t.Fatalf("readElement: %v", err)
+	}
+	parts, err := parsed.children()
+	if err != nil || len(parts) != 2 {
+		t.Fatalf("children() = %d parts, %v", len(parts), err)
+	}
+	op := parts[1]
+	for i := range len(op.content) {
+		truncated := &element{tag: op.tag, content: op.content[:i]}
+		parseEntry(truncated)
+		checkResult(truncated)
+	}
+	if _, err := parseEntry(op); err != nil {
+		t.Fatalf("parseEntry of complete entry: %v", err)
+	}
+}
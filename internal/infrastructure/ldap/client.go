@@ -0,0 +1,312 @@
+package ldap
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Tags de aplicação das operações LDAPv3 (RFC 4511)
+const (
+	opBindRequest       = 0x60
+	opBindResponse      = 0x61
+	opUnbindRequest     = 0x42
+	opSearchRequest     = 0x63
+	opSearchEntry       = 0x64
+	opSearchDone        = 0x65
+	opSearchReference   = 0x73
+	opExtendedRequest   = 0x77
+	opExtendedResponse  = 0x78
+	tagSimpleAuth       = 0x80
+	tagExtendedName     = 0x80
+	filterEqualityMatch = 0xa3
+)
+
+// Códigos de resultado relevantes
+const (
+	resultSuccess            = 0
+	resultSizeLimitExceeded  = 4
+	resultInvalidCredentials = 49
+)
+
+const (
+	scopeWholeSubtree = 2
+	derefNever        = 0
+	startTLSOID       = "1.3.6.1.4.1.1466.20037"
+)
+
+var errInvalidCredentials = errors.New("ldap: invalid credentials")
+
+// resultError é uma resposta LDAP com código de erro
+type resultError struct {
+	code    int
+	message string
+}
+
+func (e *resultError) Error() string {
+	return fmt.Sprintf("ldap: result code %d: %s", e.code, e.message)
+}
+
+type entry struct {
+	dn         string
+	attributes map[string][]string
+}
+
+// first retorna o primeiro valor do atributo (nomes não diferenciam caixa)
+func (e *entry) first(attribute string) string {
+	if values := e.attributes[strings.ToLower(attribute)]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// conn é uma conexão LDAPv3 síncrona: cada operação espera sua resposta
+// antes da próxima.
+type conn struct {
+	netConn   net.Conn
+	reader    *bufio.Reader
+	messageID int
+}
+
+// dial conecta ao servidor de rawURL (ldap:// ou ldaps://), opcionalmente
+// negociando StartTLS. O prazo do contexto vale para toda a conexão.
+func dial(ctx context.Context, rawURL string, startTLS bool, tlsConfig *tls.Config) (*conn, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	host := parsed.Host
+	if parsed.Port() == "" {
+		port := "389"
+		if parsed.Scheme == "ldaps" {
+			port = "636"
+		}
+		host = net.JoinHostPort(parsed.Hostname(), port)
+	}
+
+	config := tlsConfig.Clone()
+	if config == nil {
+		config = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	if config.ServerName == "" {
+		config.ServerName = parsed.Hostname()
+	}
+
+	var netConn net.Conn
+	dialer := &net.Dialer{}
+	switch parsed.Scheme {
+	case "ldap":
+		netConn, err = dialer.DialContext(ctx, "tcp", host)
+	case "ldaps":
+		netConn, err = (&tls.Dialer{NetDialer: dialer, Config: config}).DialContext(ctx, "tcp", host)
+	default:
+		return nil, fmt.Errorf("ldap: unsupported scheme %q", parsed.Scheme)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		netConn.SetDeadline(deadline)
+	} else {
+		netConn.SetDeadline(time.Now().Add(30 * time.Second))
+	}
+
+	c := &conn{netConn: netConn, reader: bufio.NewReader(netConn)}
+	if startTLS && parsed.Scheme == "ldap" {
+		if err := c.startTLS(config); err != nil {
+			c.netConn.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (c *conn) startTLS(config *tls.Config) error {
+	response, err := c.request(encode(opExtendedRequest, encodeString(tagExtendedName, startTLSOID)), opExtendedResponse)
+	if err != nil {
+		return err
+	}
+	if err := checkResult(response); err != nil {
+		return err
+	}
+
+	tlsConn := tls.Client(c.netConn, config)
+	if err := tlsConn.Handshake(); err != nil {
+		return err
+	}
+	c.netConn = tlsConn
+	c.reader = bufio.NewReader(tlsConn)
+	return nil
+}
+
+// bind autentica a conexão com DN e senha (simple bind). Senhas vazias são
+// recusadas, já que o servidor as trataria como bind anônimo bem-sucedido.
+func (c *conn) bind(dn, password string) error {
+	if password == "" && dn != "" {
+		return errInvalidCredentials
+	}
+
+	request := encode(opBindRequest,
+		encodeInt(tagInteger, 3),
+		encodeString(tagOctetString, dn),
+		encodeString(tagSimpleAuth, password),
+	)
+	response, err := c.request(request, opBindResponse)
+	if err != nil {
+		return err
+	}
+
+	err = checkResult(response)
+	var result *resultError
+	if errors.As(err, &result) && result.code == resultInvalidCredentials {
+		return errInvalidCredentials
+	}
+	return err
+}
+
+// searchEquality busca, na subárvore de baseDN, as entradas em que attribute
+// é igual a value. O valor vai codificado no filtro, sem risco de injeção.
+func (c *conn) searchEquality(baseDN, attribute, value string, attributes []string, sizeLimit int) ([]*entry, error) {
+	filter := encode(filterEqualityMatch,
+		encodeString(tagOctetString, attribute),
+		encodeString(tagOctetString, value),
+	)
+
+	var requested [][]byte
+	for _, attr := range attributes {
+		requested = append(requested, encodeString(tagOctetString, attr))
+	}
+
+	request := encode(opSearchRequest,
+		encodeString(tagOctetString, baseDN),
+		encodeInt(tagEnumerated, scopeWholeSubtree),
+		encodeInt(tagEnumerated, derefNever),
+		encodeInt(tagInteger, sizeLimit),
+		encodeInt(tagInteger, 0),
+		encodeBool(false),
+		filter,
+		encode(tagSequence, requested...),
+	)
+
+	messageID := c.send(request)
+	var entries []*entry
+	for {
+		op, err := c.receive(messageID)
+		if err != nil {
+			return nil, err
+		}
+
+		switch op.tag {
+		case opSearchEntry:
+			parsed, err := parseEntry(op)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, parsed)
+		case opSearchReference:
+			// Referências a outros servidores não são seguidas
+		case opSearchDone:
+			err := checkResult(op)
+			var result *resultError
+			if errors.As(err, &result) && result.code == resultSizeLimitExceeded {
+				return entries, nil
+			}
+			return entries, err
+		default:
+			return nil, errMalformedPacket
+		}
+	}
+}
+
+func (c *conn) close() {
+	c.send(encode(opUnbindRequest))
+	c.netConn.Close()
+}
+
+func (c *conn) request(op []byte, responseTag byte) (*element, error) {
+	messageID := c.send(op)
+	response, err := c.receive(messageID)
+	if err != nil {
+		return nil, err
+	}
+	if response.tag != responseTag {
+		return nil, errMalformedPacket
+	}
+	return response, nil
+}
+
+// send escreve a operação em um LDAPMessage e retorna o ID da mensagem. Erros
+// de escrita aparecem na leitura da resposta.
+func (c *conn) send(op []byte) int {
+	c.messageID++
+	c.netConn.Write(encode(tagSequence, encodeInt(tagInteger, c.messageID), op))
+	return c.messageID
+}
+
+// receive lê a próxima resposta da mensagem, descartando notificações
+func (c *conn) receive(messageID int) (*element, error) {
+	for {
+		message, err := readElement(c.reader)
+		if err != nil {
+			return nil, err
+		}
+		if message.tag != tagSequence {
+			return nil, errMalformedPacket
+		}
+		parts, err := message.children()
+		if err != nil || len(parts) < 2 {
+			return nil, errMalformedPacket
+		}
+		if parts[0].int() == messageID {
+			return parts[1], nil
+		}
+	}
+}
+
+func checkResult(op *element) error {
+	parts, err := op.children()
+	if err != nil || len(parts) < 3 {
+		return errMalformedPacket
+	}
+	if code := parts[0].int(); code != resultSuccess {
+		return &resultError{code: code, message: parts[2].string()}
+	}
+	return nil
+}
+
+func parseEntry(op *element) (*entry, error) {
+	parts, err := op.children()
+	if err != nil || len(parts) < 2 {
+		return nil, errMalformedPacket
+	}
+
+	attributes, err := parts[1].children()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &entry{dn: parts[0].string(), attributes: map[string][]string{}}
+	for _, attribute := range attributes {
+		fields, err := attribute.children()
+		if err != nil || len(fields) < 2 {
+			return nil, errMalformedPacket
+		}
+		values, err := fields[1].children()
+		if err != nil {
+			return nil, err
+		}
+		name := strings.ToLower(fields[0].string())
+		for _, value := range values {
+			result.attributes[name] = append(result.attributes[name], value.string())
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,504 @@
+package ldap
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/juanjerrah/go_auth_api/internal/config"
+	"github.com/juanjerrah/go_auth_api/internal/domain/user"
+)
+
+// stubServer é um servidor LDAP de teste: decodifica cada LDAPMessage
+// recebida e escreve os bytes devolvidos por handle. Um retorno nil encerra a
+// conexão.
+type stubServer struct {
+	listener net.Listener
+	handle   func(messageID int, op *element) []byte
+
+	mu       sync.Mutex
+	requests []*element
+}
+
+func startStubServer(t *testing.T, handle func(messageID int, op *element) []byte) *stubServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	server := &stubServer{listener: listener, handle: handle}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			netConn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(netConn)
+		}
+	}()
+	return server
+}
+
+func (s *stubServer) serve(netConn net.Conn) {
+	defer netConn.Close()
+	reader := bufio.NewReader(netConn)
+	for {
+		message, err := readElement(reader)
+		if err != nil {
+			return
+		}
+		parts, err := message.children()
+		if err != nil || len(parts) < 2 {
+			return
+		}
+		op := parts[1]
+		if op.tag == opUnbindRequest {
+			return
+		}
+
+		s.mu.Lock()
+		s.requests = append(s.requests, op)
+		s.mu.Unlock()
+
+		response := s.handle(parts[0].int(), op)
+		if response == nil {
+			return
+		}
+		if _, err := netConn.Write(response); err != nil {
+			return
+		}
+	}
+}
+
+func (s *stubServer) url() string {
+	return "ldap://" + s.listener.Addr().String()
+}
+
+func (s *stubServer) received() []*element {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*element(nil), s.requests...)
+}
+
+func ldapMessage(messageID int, op []byte) []byte {
+	return encode(tagSequence, encodeInt(tagInteger, messageID), op)
+}
+
+func ldapResult(tag byte, code int, message string) []byte {
+	return encode(tag,
+		encodeInt(tagEnumerated, code),
+		encodeString(tagOctetString, ""),
+		encodeString(tagOctetString, message),
+	)
+}
+
+func searchEntry(dn string, attributes map[string][]string) []byte {
+	names := make([]string, 0, len(attributes))
+	for name := range attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var encoded [][]byte
+	for _, name := range names {
+		var values [][]byte
+		for _, value := range attributes[name] {
+			values = append(values, encodeString(tagOctetString, value))
+		}
+		encoded = append(encoded, encode(tagSequence,
+			encodeString(tagOctetString, name),
+			encode(tagSet, values...),
+		))
+	}
+	return encode(opSearchEntry, encodeString(tagOctetString, dn), encode(tagSequence, encoded...))
+}
+
+func searchReference(uri string) []byte {
+	return encode(opSearchReference, encodeString(tagOctetString, uri))
+}
+
+func dialStub(t *testing.T, server *stubServer) *conn {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	c, err := dial(ctx, server.url(), false, &tls.Config{})
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(c.close)
+	return c
+}
+
+func TestBind(t *testing.T) {
+	tests := []struct {
+		name     string
+		dn       string
+		password string
+		response func(messageID int) []byte
+		want     error
+		wantCode int
+		sent     bool
+	}{
+		{
+			name: "success", dn: "cn=svc,dc=example,dc=com", password: "secret", sent: true,
+			response: func(id int) []byte { return ldapMessage(id, ldapResult(opBindResponse, resultSuccess, "")) },
+		},
+		{
+			name: "invalid credentials", dn: "cn=svc,dc=example,dc=com", password: "wrong", sent: true,
+			response: func(id int) []byte {
+				return ldapMessage(id, ldapResult(opBindResponse, resultInvalidCredentials, "invalid"))
+			},
+			want: errInvalidCredentials,
+		},
+		{
+			name: "other result code", dn: "cn=svc,dc=example,dc=com", password: "secret", sent: true,
+			response: func(id int) []byte {
+				return ldapMessage(id, ldapResult(opBindResponse, 53, "unwilling to perform"))
+			},
+			wantCode: 53,
+		},
+		{
+			name: "empty password is not sent", dn: "cn=svc,dc=example,dc=com", password: "",
+			want: errInvalidCredentials,
+		},
+		{
+			name: "unexpected response operation", dn: "cn=svc,dc=example,dc=com", password: "secret", sent: true,
+			response: func(id int) []byte {
+				return ldapMessage(id, ldapResult(opSearchDone, resultSuccess, ""))
+			},
+			want: errMalformedPacket,
+		},
+		{
+			name: "result with missing fields", dn: "cn=svc,dc=example,dc=com", password: "secret", sent: true,
+			response: func(id int) []byte {
+				return ldapMessage(id, encode(opBindResponse, encodeInt(tagEnumerated, resultSuccess)))
+			},
+			want: errMalformedPacket,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := startStubServer(t, func(messageID int, op *element) []byte {
+				return tt.response(messageID)
+			})
+			c := dialStub(t, server)
+
+			err := c.bind(tt.dn, tt.password)
+			switch {
+			case tt.wantCode != 0:
+				var result *resultError
+				if !errors.As(err, &result) || result.code != tt.wantCode {
+					t.Fatalf("bind() error = %v, want result code %d", err, tt.wantCode)
+				}
+			case !errors.Is(err, tt.want):
+				t.Fatalf("bind() error = %v, want %v", err, tt.want)
+			}
+
+			requests := server.received()
+			if !tt.sent {
+				if len(requests) != 0 {
+					t.Fatalf("server received %d requests, want none", len(requests))
+				}
+				return
+			}
+			if len(requests) != 1 || requests[0].tag != opBindRequest {
+				t.Fatalf("server received %d requests, want one bind", len(requests))
+			}
+			fields, err := requests[0].children()
+			if err != nil || len(fields) != 3 {
+				t.Fatalf("bind request fields = %d, %v", len(fields), err)
+			}
+			if fields[0].int() != 3 || fields[1].string() != tt.dn || fields[2].tag != tagSimpleAuth || fields[2].string() != tt.password {
+				t.Fatalf("bind request = version %d, dn %q, password %q", fields[0].int(), fields[1].string(), fields[2].string())
+			}
+		})
+	}
+}
+
+func TestSearchEquality(t *testing.T) {
+	ana := searchEntry("uid=ana,dc=example,dc=com", map[string][]string{
+		"mail":     {"ana@example.com"},
+		"memberOf": {"cn=admins,dc=example,dc=com", "cn=staff,dc=example,dc=com"},
+	})
+	bob := searchEntry("uid=bob,dc=example,dc=com", map[string][]string{"mail": {"bob@example.com"}})
+
+	tests := []struct {
+		name     string
+		response func(messageID int) []byte
+		dns      []string
+		want     error
+		wantCode int
+	}{
+		{
+			name: "entries",
+			response: func(id int) []byte {
+				return concat(ldapMessage(id, ana), ldapMessage(id, bob), ldapMessage(id, ldapResult(opSearchDone, resultSuccess, "")))
+			},
+			dns: []string{"uid=ana,dc=example,dc=com", "uid=bob,dc=example,dc=com"},
+		},
+		{
+			name: "references are not followed",
+			response: func(id int) []byte {
+				return concat(
+					ldapMessage(id, searchReference("ldap://other.example.com/dc=example,dc=com")),
+					ldapMessage(id, ana),
+					ldapMessage(id, ldapResult(opSearchDone, resultSuccess, "")),
+				)
+			},
+			dns: []string{"uid=ana,dc=example,dc=com"},
+		},
+		{
+			name: "referral result",
+			response: func(id int) []byte {
+				return ldapMessage(id, ldapResult(opSearchDone, 10, "referral"))
+			},
+			wantCode: 10,
+		},
+		{
+			name: "size limit exceeded keeps entries",
+			response: func(id int) []byte {
+				return concat(ldapMessage(id, ana), ldapMessage(id, ldapResult(opSearchDone, resultSizeLimitExceeded, "")))
+			},
+			dns: []string{"uid=ana,dc=example,dc=com"},
+		},
+		{
+			name: "notifications of other messages are skipped",
+			response: func(id int) []byte {
+				return concat(
+					ldapMessage(0, encode(opExtendedResponse, encodeInt(tagEnumerated, 0))),
+					ldapMessage(id+100, ana),
+					ldapMessage(id, ldapResult(opSearchDone, resultSuccess, "")),
+				)
+			},
+		},
+		{
+			name: "malformed entry",
+			response: func(id int) []byte {
+				return ldapMessage(id, encode(opSearchEntry, encodeString(tagOctetString, "uid=ana"), encode(tagSequence, []byte{tagSequence, 0x05, 0x04})))
+			},
+			want: errMalformedPacket,
+		},
+		{
+			name: "unexpected operation",
+			response: func(id int) []byte {
+				return ldapMessage(id, ldapResult(opBindResponse, resultSuccess, ""))
+			},
+			want: errMalformedPacket,
+		},
+		{
+			name: "message that is not a sequence",
+			response: func(id int) []byte {
+				return encodeString(tagOctetString, "garbage")
+			},
+			want: errMalformedPacket,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := startStubServer(t, func(messageID int, op *element) []byte {
+				return tt.response(messageID)
+			})
+			c := dialStub(t, server)
+
+			entries, err := c.searchEquality("dc=example,dc=com", "mail", "ana@example.com", []string{"mail", "memberOf"}, 2)
+			switch {
+			case tt.wantCode != 0:
+				var result *resultError
+				if !errors.As(err, &result) || result.code != tt.wantCode {
+					t.Fatalf("searchEquality() error = %v, want result code %d", err, tt.wantCode)
+				}
+				return
+			case tt.want != nil:
+				if !errors.Is(err, tt.want) {
+					t.Fatalf("searchEquality() error = %v, want %v", err, tt.want)
+				}
+				return
+			case err != nil:
+				t.Fatalf("searchEquality(): %v", err)
+			}
+
+			if len(entries) != len(tt.dns) {
+				t.Fatalf("got %d entries, want %d", len(entries), len(tt.dns))
+			}
+			for i, found := range entries {
+				if found.dn != tt.dns[i] {
+					t.Errorf("entry %d dn = %q, want %q", i, found.dn, tt.dns[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSearchEqualityEncodesFilterValue(t *testing.T) {
+	server := startStubServer(t, func(messageID int, op *element) []byte {
+		return ldapMessage(messageID, ldapResult(opSearchDone, resultSuccess, ""))
+	})
+	c := dialStub(t, server)
+
+	value := "*)(uid=*"
+	if _, err := c.searchEquality("dc=example,dc=com", "mail", value, []string{"mail"}, 2); err != nil {
+		t.Fatalf("searchEquality(): %v", err)
+	}
+
+	requests := server.received()
+	if len(requests) != 1 || requests[0].tag != opSearchRequest {
+		t.Fatalf("server received %d requests, want one search", len(requests))
+	}
+	fields, err := requests[0].children()
+	if err != nil || len(fields) != 8 {
+		t.Fatalf("search request fields = %d, %v", len(fields), err)
+	}
+	if fields[0].string() != "dc=example,dc=com" || fields[3].int() != 2 {
+		t.Fatalf("search request base %q, size limit %d", fields[0].string(), fields[3].int())
+	}
+	filter := fields[6]
+	assertion, err := filter.children()
+	if filter.tag != filterEqualityMatch || err != nil || len(assertion) != 2 {
+		t.Fatalf("filter tag %x with %d fields, %v", filter.tag, len(assertion), err)
+	}
+	if assertion[0].string() != "mail" || assertion[1].string() != value {
+		t.Fatalf("filter = (%s=%s), want (mail=%s)", assertion[0].string(), assertion[1].string(), value)
+	}
+}
+
+func TestTruncatedResponsesReturnErrors(t *testing.T) {
+	response := ldapMessage(1, ldapResult(opBindResponse, resultSuccess, "ok"))
+
+	for i := 1; i < len(response); i++ {
+		client, server := net.Pipe()
+		c := &conn{netConn: client, reader: bufio.NewReader(client)}
+
+		// O servidor encerra a conexão após a resposta parcial
+		go func() {
+			defer server.Close()
+			if _, err := readElement(bufio.NewReader(server)); err != nil {
+				return
+			}
+			server.Write(response[:i])
+		}()
+
+		if err := c.bind("cn=svc,dc=example,dc=com", "secret"); err == nil {
+			t.Fatalf("bind accepted %d of %d response bytes", i, len(response))
+		}
+		client.Close()
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	const (
+		serviceDN = "cn=svc,dc=example,dc=com"
+		userDN    = "uid=ana,ou=people,dc=example,dc=com"
+	)
+
+	tests := []struct {
+		name     string
+		password string
+		entries  []map[string][]string
+		want     error
+		role     user.Role
+	}{
+		{
+			name:     "admin by group",
+			password: "user-secret",
+			entries: []map[string][]string{{
+				"mail":     {"ana@example.com"},
+				"cn":       {"Ana"},
+				"memberOf": {"CN=Admins, DC=example, DC=com"},
+			}},
+			role: user.RoleAdmin,
+		},
+		{
+			name:     "regular user",
+			password: "user-secret",
+			entries:  []map[string][]string{{"mail": {"ana@example.com"}, "cn": {"Ana"}}},
+			role:     user.RoleUser,
+		},
+		{
+			name:     "wrong password",
+			password: "wrong",
+			entries:  []map[string][]string{{"mail": {"ana@example.com"}}},
+			want:     user.ErrInvalidPassword,
+		},
+		{
+			name:     "not in directory",
+			password: "user-secret",
+			want:     user.ErrInvalidPassword,
+		},
+		{
+			name:     "ambiguous email",
+			password: "user-secret",
+			entries:  []map[string][]string{{"mail": {"ana@example.com"}}, {"mail": {"ana@example.com"}}},
+			want:     user.ErrInvalidPassword,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := startStubServer(t, func(messageID int, op *element) []byte {
+				switch op.tag {
+				case opBindRequest:
+					fields, _ := op.children()
+					code := resultInvalidCredentials
+					if (fields[1].string() == serviceDN && fields[2].string() == "svc-secret") ||
+						(fields[1].string() == userDN && fields[2].string() == "user-secret") {
+						code = resultSuccess
+					}
+					return ldapMessage(messageID, ldapResult(opBindResponse, code, ""))
+				case opSearchRequest:
+					var response []byte
+					for _, attributes := range tt.entries {
+						response = append(response, ldapMessage(messageID, searchEntry(userDN, attributes))...)
+					}
+					return append(response, ldapMessage(messageID, ldapResult(opSearchDone, resultSuccess, ""))...)
+				}
+				return nil
+			})
+
+			authenticator := NewAuthenticator(&config.LDAPConfig{
+				URL:            server.url(),
+				BindDN:         serviceDN,
+				BindPassword:   "svc-secret",
+				BaseDN:         "dc=example,dc=com",
+				EmailAttribute: "mail",
+				NameAttribute:  "cn",
+				GroupAttribute: "memberOf",
+				AdminGroups:    []string{"cn=admins,dc=example,dc=com"},
+				Timeout:        5 * time.Second,
+			})
+
+			identity, err := authenticator.Authenticate(context.Background(), "ana@example.com", tt.password)
+			if tt.want != nil {
+				if !errors.Is(err, tt.want) {
+					t.Fatalf("Authenticate() error = %v, want %v", err, tt.want)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Authenticate(): %v", err)
+			}
+			if identity.Role != tt.role || identity.Name != "Ana" || identity.Email != "ana@example.com" {
+				t.Fatalf("identity = %+v, want role %s", identity, tt.role)
+			}
+		})
+	}
+}
+
+func concat(parts ...[]byte) []byte {
+	var result []byte
+	for _, part := range parts {
+		result = append(result, part...)
+	}
+	return result
+}
@@ -0,0 +1,22 @@
+package mailer
+
+import (
+	"context"
+	"log"
+
+	"github.com/juanjerrah/go_auth_api/pkg/common"
+)
+
+// LogMailer escreve as mensagens no log em vez de enviá-las. Útil em
+// desenvolvimento, onde não há servidor SMTP disponível.
+type LogMailer struct{}
+
+func NewLogMailer() common.Mailer {
+	return &LogMailer{}
+}
+
+// Send implements common.Mailer.
+func (m *LogMailer) Send(ctx context.Context, message *common.EmailMessage) error {
+	log.Printf("Mail to=%s subject=%q\n%s", message.To, message.Subject, message.Body)
+	return nil
+}
@@ -0,0 +1,20 @@
+package mailer
+
+import (
+	"fmt"
+
+	"github.com/juanjerrah/go_auth_api/internal/config"
+	"github.com/juanjerrah/go_auth_api/pkg/common"
+)
+
+// NewMailer escolhe a implementação de envio de e-mail a partir da configuração.
+func NewMailer(cfg *config.MailerConfig) (common.Mailer, error) {
+	switch cfg.Driver {
+	case "log", "":
+		return NewLogMailer(), nil
+	case "smtp":
+		return NewSMTPMailer(cfg), nil
+	default:
+		return nil, fmt.Errorf("unknown mailer driver: %s", cfg.Driver)
+	}
+}
@@ -0,0 +1,139 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/juanjerrah/go_auth_api/internal/domain/audit"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type AuditRepository struct {
+	collection *mongo.Collection
+}
+
+func NewAuditRepository(db *mongo.Database) audit.Repository {
+	collection := db.Collection("audit_events")
+
+	// O índice único de sequência impede que duas gravações concorrentes
+	// ocupem a mesma posição da cadeia de hashes.
+	_, err := collection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{Keys: bson.D{{Key: "sequence", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "actor_id", Value: 1}, {Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: "target_id", Value: 1}, {Key: "timestamp", Value: -1}}},
+	})
+	if err != nil {
+		log.Printf("Warning: failed to create audit indexes: %v", err)
+	}
+
+	return &AuditRepository{
+		collection: collection,
+	}
+}
+
+// Append implements audit.Repository.
+func (a *AuditRepository) Append(ctx context.Context, event *audit.Event) error {
+	result, err := a.collection.InsertOne(ctx, event)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return audit.ErrSequenceConflict
+		}
+		return err
+	}
+
+	if id, ok := result.InsertedID.(primitive.ObjectID); ok {
+		event.ID = id
+	}
+	return nil
+}
+
+// Last implements audit.Repository.
+func (a *AuditRepository) Last(ctx context.Context) (*audit.Event, error) {
+	opts := options.FindOne().SetSort(bson.D{{Key: "sequence", Value: -1}})
+
+	var event audit.Event
+	err := a.collection.FindOne(ctx, bson.M{}, opts).Decode(&event)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &event, nil
+}
+
+// Find implements audit.Repository.
+func (a *AuditRepository) Find(ctx context.Context, filter *audit.Filter) ([]*audit.Event, int64, error) {
+	query := bson.M{}
+	if filter.ActorID != "" {
+		query["actor_id"] = filter.ActorID
+	}
+	if filter.TargetID != "" {
+		query["target_id"] = filter.TargetID
+	}
+	if filter.SubjectID != "" {
+		query["$or"] = bson.A{
+			bson.M{"actor_id": filter.SubjectID},
+			bson.M{"target_id": filter.SubjectID},
+		}
+	}
+	if filter.Type != "" {
+		query["type"] = filter.Type
+	}
+	if filter.Outcome != "" {
+		query["outcome"] = filter.Outcome
+	}
+	if !filter.From.IsZero() || !filter.To.IsZero() {
+		timestamp := bson.M{}
+		if !filter.From.IsZero() {
+			timestamp["$gte"] = filter.From
+		}
+		if !filter.To.IsZero() {
+			timestamp["$lte"] = filter.To
+		}
+		query["timestamp"] = timestamp
+	}
+
+	total, err := a.collection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "sequence", Value: -1}}).
+		SetSkip(int64((filter.Page - 1) * filter.Limit)).
+		SetLimit(int64(filter.Limit))
+
+	events, err := a.find(ctx, query, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	return events, total, nil
+}
+
+// FindAfter implements audit.Repository.
+func (a *AuditRepository) FindAfter(ctx context.Context, sequence int64, limit int) ([]*audit.Event, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "sequence", Value: 1}}).
+		SetLimit(int64(limit))
+
+	return a.find(ctx, bson.M{"sequence": bson.M{"$gt": sequence}}, opts)
+}
+
+func (a *AuditRepository) find(ctx context.Context, query bson.M, opts *options.FindOptions) ([]*audit.Event, error) {
+	cursor, err := a.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	events := []*audit.Event{}
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
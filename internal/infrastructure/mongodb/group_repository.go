@@ -0,0 +1,164 @@
+package mongodb
+
+import (
+	"context"
+	"log"
+
+	"github.com/juanjerrah/go_auth_api/internal/domain/group"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type GroupRepository struct {
+	collection *mongo.Collection
+}
+
+func NewGroupRepository(db *mongo.Database) group.Repository {
+	collection := db.Collection("groups")
+
+	_, err := collection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "name", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "member_ids", Value: 1}}},
+		{Keys: bson.D{{Key: "parent_ids", Value: 1}}},
+	})
+	if err != nil {
+		log.Printf("Warning: failed to create group indexes: %v", err)
+	}
+
+	return &GroupRepository{
+		collection: collection,
+	}
+}
+
+// Create implements group.Repository.
+func (g *GroupRepository) Create(ctx context.Context, grp *group.Group) error {
+	_, err := g.collection.InsertOne(ctx, grp)
+	return err
+}
+
+// FindByID implements group.Repository.
+func (g *GroupRepository) FindByID(ctx context.Context, id string) (*group.Group, error) {
+	groupID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var grp group.Group
+	err = g.collection.FindOne(ctx, bson.M{"_id": groupID}).Decode(&grp)
+	if err != nil {
+		return nil, err
+	}
+	return &grp, nil
+}
+
+// FindByIDs implements group.Repository.
+func (g *GroupRepository) FindByIDs(ctx context.Context, ids []string) ([]*group.Group, error) {
+	return g.find(ctx, bson.M{"_id": bson.M{"$in": toObjectIDs(ids)}})
+}
+
+// List implements group.Repository.
+func (g *GroupRepository) List(ctx context.Context) ([]*group.Group, error) {
+	return g.find(ctx, bson.M{})
+}
+
+// ExistsByName implements group.Repository.
+func (g *GroupRepository) ExistsByName(ctx context.Context, name string) (bool, error) {
+	count, err := g.collection.CountDocuments(ctx, bson.M{"name": name})
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// Update implements group.Repository. Os membros são alterados apenas por
+// AddMember/RemoveMember para não sobrescrever alterações concorrentes.
+func (g *GroupRepository) Update(ctx context.Context, grp *group.Group) error {
+	_, err := g.collection.UpdateOne(ctx, bson.M{"_id": grp.ID}, bson.M{"$set": bson.M{
+		"name":        grp.Name,
+		"description": grp.Description,
+		"permissions": grp.Permissions,
+		"parent_ids":  grp.ParentIDs,
+		"updated_at":  grp.UpdatedAt,
+	}})
+	return err
+}
+
+// Delete implements group.Repository.
+func (g *GroupRepository) Delete(ctx context.Context, id string) error {
+	groupID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+	_, err = g.collection.DeleteOne(ctx, bson.M{"_id": groupID})
+	return err
+}
+
+// FindByMember implements group.Repository.
+func (g *GroupRepository) FindByMember(ctx context.Context, userID string) ([]*group.Group, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, err
+	}
+	return g.find(ctx, bson.M{"member_ids": uid})
+}
+
+// FindChildren implements group.Repository.
+func (g *GroupRepository) FindChildren(ctx context.Context, id string) ([]*group.Group, error) {
+	groupID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	return g.find(ctx, bson.M{"parent_ids": groupID})
+}
+
+// AddMember implements group.Repository.
+func (g *GroupRepository) AddMember(ctx context.Context, id, userID string) error {
+	return g.updateMembers(ctx, id, userID, "$addToSet")
+}
+
+// RemoveMember implements group.Repository.
+func (g *GroupRepository) RemoveMember(ctx context.Context, id, userID string) error {
+	return g.updateMembers(ctx, id, userID, "$pull")
+}
+
+// RemoveParent implements group.Repository.
+func (g *GroupRepository) RemoveParent(ctx context.Context, parentID string) error {
+	pid, err := primitive.ObjectIDFromHex(parentID)
+	if err != nil {
+		return err
+	}
+	_, err = g.collection.UpdateMany(ctx, bson.M{"parent_ids": pid}, bson.M{"$pull": bson.M{"parent_ids": pid}})
+	return err
+}
+
+func (g *GroupRepository) updateMembers(ctx context.Context, id, userID, operator string) error {
+	groupID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return err
+	}
+	_, err = g.collection.UpdateOne(ctx, bson.M{"_id": groupID}, bson.M{operator: bson.M{"member_ids": uid}})
+	return err
+}
+
+func (g *GroupRepository) find(ctx context.Context, filter bson.M) ([]*group.Group, error) {
+	cursor, err := g.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	groups := []*group.Group{}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
@@ -0,0 +1,104 @@
+package mongodb
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/juanjerrah/go_auth_api/internal/domain/organization"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type InvitationRepository struct {
+	collection *mongo.Collection
+}
+
+func NewInvitationRepository(db *mongo.Database) organization.InvitationRepository {
+	collection := db.Collection("invitations")
+
+	_, err := collection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{Keys: bson.D{{Key: "token_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "organization_id", Value: 1}, {Key: "email", Value: 1}}},
+	})
+	if err != nil {
+		log.Printf("Warning: failed to create invitation indexes: %v", err)
+	}
+
+	return &InvitationRepository{
+		collection: collection,
+	}
+}
+
+// Create implements organization.InvitationRepository.
+func (i *InvitationRepository) Create(ctx context.Context, invitation *organization.Invitation) error {
+	_, err := i.collection.InsertOne(ctx, invitation)
+	return err
+}
+
+// FindByID implements organization.InvitationRepository.
+func (i *InvitationRepository) FindByID(ctx context.Context, id string) (*organization.Invitation, error) {
+	invitationID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	return i.findOne(ctx, bson.M{"_id": invitationID})
+}
+
+// FindByTokenHash implements organization.InvitationRepository.
+func (i *InvitationRepository) FindByTokenHash(ctx context.Context, tokenHash string) (*organization.Invitation, error) {
+	return i.findOne(ctx, bson.M{"token_hash": tokenHash})
+}
+
+// FindPendingByEmail implements organization.InvitationRepository.
+func (i *InvitationRepository) FindPendingByEmail(ctx context.Context, organizationID, email string) (*organization.Invitation, error) {
+	orgID, err := primitive.ObjectIDFromHex(organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	return i.findOne(ctx, bson.M{
+		"organization_id": orgID,
+		"email":           email,
+		"accepted_at":     nil,
+		"revoked_at":      nil,
+		"expires_at":      bson.M{"$gt": time.Now().UTC()},
+	})
+}
+
+// ListByOrganization implements organization.InvitationRepository.
+func (i *InvitationRepository) ListByOrganization(ctx context.Context, organizationID string) ([]*organization.Invitation, error) {
+	orgID, err := primitive.ObjectIDFromHex(organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := i.collection.Find(ctx, bson.M{"organization_id": orgID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	invitations := []*organization.Invitation{}
+	if err := cursor.All(ctx, &invitations); err != nil {
+		return nil, err
+	}
+	return invitations, nil
+}
+
+// Update implements organization.InvitationRepository.
+func (i *InvitationRepository) Update(ctx context.Context, invitation *organization.Invitation) error {
+	_, err := i.collection.UpdateOne(ctx, bson.M{"_id": invitation.ID}, bson.M{"$set": invitation})
+	return err
+}
+
+func (i *InvitationRepository) findOne(ctx context.Context, filter bson.M) (*organization.Invitation, error) {
+	var invitation organization.Invitation
+	if err := i.collection.FindOne(ctx, filter).Decode(&invitation); err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
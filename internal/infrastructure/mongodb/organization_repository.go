@@ -0,0 +1,203 @@
+package mongodb
+
+import (
+	"context"
+	"log"
+
+	"github.com/juanjerrah/go_auth_api/internal/domain/organization"
+	"github.com/juanjerrah/go_auth_api/internal/domain/user"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type OrganizationRepository struct {
+	collection *mongo.Collection
+}
+
+func NewOrganizationRepository(db *mongo.Database) organization.Repository {
+	collection := db.Collection("organizations")
+
+	_, err := collection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "slug", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		log.Printf("Warning: failed to create organization indexes: %v", err)
+	}
+
+	return &OrganizationRepository{
+		collection: collection,
+	}
+}
+
+// Create implements organization.Repository.
+func (o *OrganizationRepository) Create(ctx context.Context, org *organization.Organization) error {
+	_, err := o.collection.InsertOne(ctx, org)
+	return err
+}
+
+// FindByID implements organization.Repository.
+func (o *OrganizationRepository) FindByID(ctx context.Context, id string) (*organization.Organization, error) {
+	orgID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var org organization.Organization
+	err = o.collection.FindOne(ctx, bson.M{"_id": orgID}).Decode(&org)
+	if err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+// FindByIDs implements organization.Repository.
+func (o *OrganizationRepository) FindByIDs(ctx context.Context, ids []string) ([]*organization.Organization, error) {
+	orgIDs := toObjectIDs(ids)
+
+	cursor, err := o.collection.Find(ctx, bson.M{"_id": bson.M{"$in": orgIDs}}, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	orgs := []*organization.Organization{}
+	if err := cursor.All(ctx, &orgs); err != nil {
+		return nil, err
+	}
+	return orgs, nil
+}
+
+// ExistsBySlug implements organization.Repository.
+func (o *OrganizationRepository) ExistsBySlug(ctx context.Context, slug string) (bool, error) {
+	count, err := o.collection.CountDocuments(ctx, bson.M{"slug": slug})
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+type MembershipRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMembershipRepository(db *mongo.Database) organization.MembershipRepository {
+	collection := db.Collection(membershipsCollection)
+
+	_, err := collection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "organization_id", Value: 1}, {Key: "user_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+	})
+	if err != nil {
+		log.Printf("Warning: failed to create membership indexes: %v", err)
+	}
+
+	return &MembershipRepository{
+		collection: collection,
+	}
+}
+
+// Create implements organization.MembershipRepository.
+func (m *MembershipRepository) Create(ctx context.Context, membership *organization.Membership) error {
+	_, err := m.collection.InsertOne(ctx, membership)
+	return err
+}
+
+// Find implements organization.MembershipRepository.
+func (m *MembershipRepository) Find(ctx context.Context, organizationID, userID string) (*organization.Membership, error) {
+	filter, err := membershipFilter(organizationID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var membership organization.Membership
+	err = m.collection.FindOne(ctx, filter).Decode(&membership)
+	if err != nil {
+		return nil, err
+	}
+	return &membership, nil
+}
+
+// ListByOrganization implements organization.MembershipRepository.
+func (m *MembershipRepository) ListByOrganization(ctx context.Context, organizationID string) ([]*organization.Membership, error) {
+	orgID, err := primitive.ObjectIDFromHex(organizationID)
+	if err != nil {
+		return nil, err
+	}
+	return m.find(ctx, bson.M{"organization_id": orgID})
+}
+
+// ListByUser implements organization.MembershipRepository.
+func (m *MembershipRepository) ListByUser(ctx context.Context, userID string) ([]*organization.Membership, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, err
+	}
+	return m.find(ctx, bson.M{"user_id": uid})
+}
+
+// Update implements organization.MembershipRepository.
+func (m *MembershipRepository) Update(ctx context.Context, membership *organization.Membership) error {
+	_, err := m.collection.UpdateOne(ctx, bson.M{"_id": membership.ID}, bson.M{"$set": membership})
+	return err
+}
+
+// Delete implements organization.MembershipRepository.
+func (m *MembershipRepository) Delete(ctx context.Context, organizationID, userID string) error {
+	filter, err := membershipFilter(organizationID, userID)
+	if err != nil {
+		return err
+	}
+	_, err = m.collection.DeleteOne(ctx, filter)
+	return err
+}
+
+// CountByRole implements organization.MembershipRepository.
+func (m *MembershipRepository) CountByRole(ctx context.Context, organizationID string, role user.Role) (int64, error) {
+	orgID, err := primitive.ObjectIDFromHex(organizationID)
+	if err != nil {
+		return 0, err
+	}
+	return m.collection.CountDocuments(ctx, bson.M{"organization_id": orgID, "role": role})
+}
+
+func (m *MembershipRepository) find(ctx context.Context, filter bson.M) ([]*organization.Membership, error) {
+	cursor, err := m.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	memberships := []*organization.Membership{}
+	if err := cursor.All(ctx, &memberships); err != nil {
+		return nil, err
+	}
+	return memberships, nil
+}
+
+func membershipFilter(organizationID, userID string) (bson.M, error) {
+	orgID, err := primitive.ObjectIDFromHex(organizationID)
+	if err != nil {
+		return nil, err
+	}
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, err
+	}
+	return bson.M{"organization_id": orgID, "user_id": uid}, nil
+}
+
+func toObjectIDs(ids []string) []primitive.ObjectID {
+	objectIDs := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		if objectID, err := primitive.ObjectIDFromHex(id); err == nil {
+			objectIDs = append(objectIDs, objectID)
+		}
+	}
+	return objectIDs
+}
@@ -0,0 +1,63 @@
+package mongodb
+
+import (
+	"context"
+
+	"github.com/juanjerrah/go_auth_api/internal/domain/policy"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type PolicyRepository struct {
+	collection *mongo.Collection
+}
+
+func NewPolicyRepository(db *mongo.Database) policy.Repository {
+	return &PolicyRepository{
+		collection: db.Collection("policies"),
+	}
+}
+
+// Create implements policy.Repository.
+func (p *PolicyRepository) Create(ctx context.Context, pol *policy.Policy) error {
+	_, err := p.collection.InsertOne(ctx, pol)
+	return err
+}
+
+// FindByID implements policy.Repository.
+func (p *PolicyRepository) FindByID(ctx context.Context, id string) (*policy.Policy, error) {
+	var pol policy.Policy
+	err := p.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&pol)
+	if err != nil {
+		return nil, err
+	}
+	return &pol, nil
+}
+
+// List implements policy.Repository.
+func (p *PolicyRepository) List(ctx context.Context) ([]*policy.Policy, error) {
+	cursor, err := p.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	policies := []*policy.Policy{}
+	if err := cursor.All(ctx, &policies); err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+// Update implements policy.Repository.
+func (p *PolicyRepository) Update(ctx context.Context, pol *policy.Policy) error {
+	_, err := p.collection.ReplaceOne(ctx, bson.M{"_id": pol.ID}, pol)
+	return err
+}
+
+// Delete implements policy.Repository.
+func (p *PolicyRepository) Delete(ctx context.Context, id string) error {
+	_, err := p.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
@@ -0,0 +1,175 @@
+package mongodb
+
+import (
+	"context"
+	"log"
+
+	"github.com/juanjerrah/go_auth_api/internal/domain/relation"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Documento único que guarda a revisão global das tuplas
+const relationRevisionID = "relation_tuples"
+
+type RelationRepository struct {
+	collection *mongo.Collection
+	revisions  *mongo.Collection
+}
+
+func NewRelationRepository(db *mongo.Database) relation.Repository {
+	collection := db.Collection("relation_tuples")
+
+	_, err := collection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "namespace", Value: 1},
+				{Key: "object_id", Value: 1},
+				{Key: "relation", Value: 1},
+				{Key: "subject.namespace", Value: 1},
+				{Key: "subject.object_id", Value: 1},
+				{Key: "subject.relation", Value: 1},
+			},
+			Options: options.Index().SetUnique(true),
+		},
+	})
+	if err != nil {
+		log.Printf("Warning: failed to create relation tuple indexes: %v", err)
+	}
+
+	return &RelationRepository{
+		collection: collection,
+		revisions:  db.Collection("revisions"),
+	}
+}
+
+// Write implements relation.Repository.
+func (r *RelationRepository) Write(ctx context.Context, tuple *relation.Tuple) error {
+	_, err := r.collection.UpdateOne(ctx, tupleFilter(tuple), bson.M{"$setOnInsert": tuple}, options.Update().SetUpsert(true))
+	return err
+}
+
+// Delete implements relation.Repository.
+func (r *RelationRepository) Delete(ctx context.Context, tuple *relation.Tuple) error {
+	_, err := r.collection.DeleteOne(ctx, tupleFilter(tuple))
+	return err
+}
+
+// Find implements relation.Repository.
+func (r *RelationRepository) Find(ctx context.Context, namespace, objectID, relationName string) ([]*relation.Tuple, error) {
+	filter := bson.M{"namespace": namespace}
+	if objectID != "" {
+		filter["object_id"] = objectID
+	}
+	if relationName != "" {
+		filter["relation"] = relationName
+	}
+
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	tuples := []*relation.Tuple{}
+	if err := cursor.All(ctx, &tuples); err != nil {
+		return nil, err
+	}
+	return tuples, nil
+}
+
+// ObjectIDs implements relation.Repository.
+func (r *RelationRepository) ObjectIDs(ctx context.Context, namespace string) ([]string, error) {
+	values, err := r.collection.Distinct(ctx, "object_id", bson.M{"namespace": namespace})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(values))
+	for _, value := range values {
+		if id, ok := value.(string); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// NextRevision implements relation.Repository.
+func (r *RelationRepository) NextRevision(ctx context.Context) (int64, error) {
+	var doc struct {
+		Revision int64 `bson:"revision"`
+	}
+	err := r.revisions.FindOneAndUpdate(ctx,
+		bson.M{"_id": relationRevisionID},
+		bson.M{"$inc": bson.M{"revision": int64(1)}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&doc)
+	if err != nil {
+		return 0, err
+	}
+	return doc.Revision, nil
+}
+
+// CurrentRevision implements relation.Repository.
+func (r *RelationRepository) CurrentRevision(ctx context.Context) (int64, error) {
+	var doc struct {
+		Revision int64 `bson:"revision"`
+	}
+	err := r.revisions.FindOne(ctx, bson.M{"_id": relationRevisionID}).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return doc.Revision, nil
+}
+
+func tupleFilter(tuple *relation.Tuple) bson.M {
+	return bson.M{
+		"namespace":         tuple.Namespace,
+		"object_id":         tuple.ObjectID,
+		"relation":          tuple.Relation,
+		"subject.namespace": tuple.Subject.Namespace,
+		"subject.object_id": tuple.Subject.ObjectID,
+		"subject.relation":  tuple.Subject.Relation,
+	}
+}
+
+type NamespaceRepository struct {
+	collection *mongo.Collection
+}
+
+func NewNamespaceRepository(db *mongo.Database) relation.NamespaceRepository {
+	return &NamespaceRepository{
+		collection: db.Collection("relation_namespaces"),
+	}
+}
+
+// Save implements relation.NamespaceRepository.
+func (n *NamespaceRepository) Save(ctx context.Context, config *relation.NamespaceConfig) error {
+	_, err := n.collection.ReplaceOne(ctx, bson.M{"_id": config.Name}, config, options.Replace().SetUpsert(true))
+	return err
+}
+
+// FindAll implements relation.NamespaceRepository.
+func (n *NamespaceRepository) FindAll(ctx context.Context) ([]*relation.NamespaceConfig, error) {
+	cursor, err := n.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	configs := []*relation.NamespaceConfig{}
+	if err := cursor.All(ctx, &configs); err != nil {
+		return nil, err
+	}
+	return configs, nil
+}
+
+// Delete implements relation.NamespaceRepository.
+func (n *NamespaceRepository) Delete(ctx context.Context, name string) error {
+	_, err := n.collection.DeleteOne(ctx, bson.M{"_id": name})
+	return err
+}
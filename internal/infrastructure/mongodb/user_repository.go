@@ -2,20 +2,39 @@ package mongodb
 
 import (
 	"context"
+	"log"
+	"regexp"
+	"time"
 
 	"github.com/juanjerrah/go_auth_api/internal/domain/user"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const membershipsCollection = "memberships"
+
+const (
+	// adminsLockID identifica o bloqueio das remoções de administradores
+	adminsLockID = "admins"
+	// adminsLockTTL libera o bloqueio de uma instância que caiu sem liberá-lo
+	adminsLockTTL = 10 * time.Second
+	// adminsLockRetry é o intervalo entre as tentativas de obter o bloqueio
+	adminsLockRetry = 50 * time.Millisecond
 )
 
 type UserRepository struct {
-	collection *mongo.Collection
+	collection  *mongo.Collection
+	memberships *mongo.Collection
+	locks       *mongo.Collection
 }
 
 func NewUserRepository(db *mongo.Database) user.Repository {
 	return &UserRepository{
-		collection: db.Collection("users"),
+		collection:  db.Collection("users"),
+		memberships: db.Collection(membershipsCollection),
+		locks:       db.Collection("locks"),
 	}
 }
 
@@ -45,6 +64,48 @@ func (u *UserRepository) ExistsByEmail(ctx context.Context, email string) (bool,
 	return count > 0, nil
 }
 
+// CountByRole implements user.Repository.
+func (u *UserRepository) CountByRole(ctx context.Context, role user.Role) (int64, error) {
+	return u.collection.CountDocuments(ctx, bson.M{"role": role})
+}
+
+// LockAdmins implements user.Repository. O bloqueio é um documento em locks:
+// o upsert só o obtém se ele não existe ou já expirou; enquanto outra
+// operação o detém, o upsert colide com o _id existente e a tentativa se
+// repete até ctx ser cancelado.
+func (u *UserRepository) LockAdmins(ctx context.Context) (func(), error) {
+	owner := primitive.NewObjectID()
+	for {
+		now := time.Now().UTC()
+		_, err := u.locks.UpdateOne(ctx,
+			bson.M{"_id": adminsLockID, "expires_at": bson.M{"$lt": now}},
+			bson.M{"$set": bson.M{"owner": owner, "expires_at": now.Add(adminsLockTTL)}},
+			options.Update().SetUpsert(true),
+		)
+		if err == nil {
+			break
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(adminsLockRetry):
+		}
+	}
+
+	unlock := func() {
+		// Liberar mesmo que ctx já tenha sido cancelado; o owner evita
+		// liberar um bloqueio que expirou e foi obtido por outra operação
+		if _, err := u.locks.DeleteOne(context.Background(), bson.M{"_id": adminsLockID, "owner": owner}); err != nil {
+			log.Printf("Warning: failed to release admins lock: %v", err)
+		}
+	}
+	return unlock, nil
+}
+
 // FindByEmail implements user.Repository.
 func (u *UserRepository) FindByEmail(ctx context.Context, email string) (*user.User, error) {
 	var usr user.User
@@ -55,6 +116,26 @@ func (u *UserRepository) FindByEmail(ctx context.Context, email string) (*user.U
 	return &usr, nil
 }
 
+// FindByIdentity implements user.Repository.
+func (u *UserRepository) FindByIdentity(ctx context.Context, provider, subject string) (*user.User, error) {
+	filter := bson.M{"identities": bson.M{"$elemMatch": bson.M{"provider": provider, "subject": subject}}}
+
+	var usr user.User
+	if err := u.collection.FindOne(ctx, filter).Decode(&usr); err != nil {
+		return nil, err
+	}
+	return &usr, nil
+}
+
+// FindByWebAuthnCredential implements user.Repository.
+func (u *UserRepository) FindByWebAuthnCredential(ctx context.Context, credentialID string) (*user.User, error) {
+	var usr user.User
+	if err := u.collection.FindOne(ctx, bson.M{"webauthn_credentials.id": credentialID}).Decode(&usr); err != nil {
+		return nil, err
+	}
+	return &usr, nil
+}
+
 // FindByID implements user.Repository.
 func (u *UserRepository) FindByID(ctx context.Context, id string) (*user.User, error) {
 	userID, err := primitive.ObjectIDFromHex(id)
@@ -76,3 +157,154 @@ func (u *UserRepository) Update(ctx context.Context, user *user.User) error {
 	return err
 }
 
+// FindByIDInTenant implements user.Repository.
+func (u *UserRepository) FindByIDInTenant(ctx context.Context, tenantID, id string) (*user.User, error) {
+	orgID, err := primitive.ObjectIDFromHex(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	userID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := u.memberships.CountDocuments(ctx, bson.M{"organization_id": orgID, "user_id": userID})
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, mongo.ErrNoDocuments
+	}
+
+	return u.FindByID(ctx, id)
+}
+
+// ListByTenant implements user.Repository.
+func (u *UserRepository) ListByTenant(ctx context.Context, tenantID string, page, limit int) ([]*user.User, int64, error) {
+	orgID, err := primitive.ObjectIDFromHex(tenantID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	userIDs, err := u.memberships.Distinct(ctx, "user_id", bson.M{"organization_id": orgID})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	filter := bson.M{"_id": bson.M{"$in": userIDs}}
+	total, err := u.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "name", Value: 1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+
+	cursor, err := u.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	users := []*user.User{}
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+// Search implements user.Repository.
+func (u *UserRepository) Search(ctx context.Context, filter *user.Filter, offset, limit int) ([]*user.User, int64, error) {
+	query := bson.M{}
+	if filter != nil {
+		query = userFilterQuery(filter)
+	}
+
+	total, err := u.collection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetSkip(int64(offset)).
+		SetLimit(int64(limit))
+
+	cursor, err := u.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	users := []*user.User{}
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+// userFilterQuery traduz um user.Filter para uma consulta do MongoDB
+func userFilterQuery(filter *user.Filter) bson.M {
+	switch {
+	case len(filter.And) > 0:
+		return bson.M{"$and": userFilterQueries(filter.And)}
+	case len(filter.Or) > 0:
+		return bson.M{"$or": userFilterQueries(filter.Or)}
+	case filter.Not != nil:
+		return bson.M{"$nor": bson.A{userFilterQuery(filter.Not)}}
+	}
+
+	text, isText := filter.Value.(string)
+	if filter.Field == "_id" && isText {
+		// IDs inválidos não correspondem a nenhum usuário
+		id, _ := primitive.ObjectIDFromHex(text)
+		filter = &user.Filter{Field: "_id", Operator: filter.Operator, Value: id}
+		isText = false
+	}
+
+	switch filter.Operator {
+	case user.FilterPresent:
+		return bson.M{filter.Field: bson.M{"$exists": true, "$nin": bson.A{nil, ""}}}
+	case user.FilterEq:
+		if isText {
+			return bson.M{filter.Field: textPattern("^" + regexp.QuoteMeta(text) + "$")}
+		}
+		return bson.M{filter.Field: filter.Value}
+	case user.FilterNe:
+		if isText {
+			return bson.M{filter.Field: bson.M{"$not": textPattern("^" + regexp.QuoteMeta(text) + "$")}}
+		}
+		return bson.M{filter.Field: bson.M{"$ne": filter.Value}}
+	case user.FilterContains:
+		return bson.M{filter.Field: textPattern(regexp.QuoteMeta(text))}
+	case user.FilterStartsWith:
+		return bson.M{filter.Field: textPattern("^" + regexp.QuoteMeta(text))}
+	case user.FilterEndsWith:
+		return bson.M{filter.Field: textPattern(regexp.QuoteMeta(text) + "$")}
+	case user.FilterGt:
+		return bson.M{filter.Field: bson.M{"$gt": filter.Value}}
+	case user.FilterGe:
+		return bson.M{filter.Field: bson.M{"$gte": filter.Value}}
+	case user.FilterLt:
+		return bson.M{filter.Field: bson.M{"$lt": filter.Value}}
+	case user.FilterLe:
+		return bson.M{filter.Field: bson.M{"$lte": filter.Value}}
+	}
+
+	// Operador desconhecido: não corresponde a nenhum documento
+	return bson.M{"_id": bson.M{"$exists": false}}
+}
+
+func userFilterQueries(filters []*user.Filter) bson.A {
+	queries := bson.A{}
+	for _, f := range filters {
+		queries = append(queries, userFilterQuery(f))
+	}
+	return queries
+}
+
+func textPattern(pattern string) primitive.Regex {
+	return primitive.Regex{Pattern: pattern, Options: "i"}
+}
@@ -0,0 +1,144 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/juanjerrah/go_auth_api/internal/domain/loginalert"
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisLoginAlertRepository guarda o histórico de login de cada usuário em
+// sorted sets (membro: dispositivo ou rede; score: último acesso), além dos
+// avisos enviados e das redefinições de senha pendentes
+type RedisLoginAlertRepository struct {
+	client        *redis.Client
+	devicePrefix  string
+	networkPrefix string
+	alertPrefix   string
+	resetPrefix   string
+}
+
+func NewLoginAlertRepository(client *redis.Client) loginalert.Repository {
+	return &RedisLoginAlertRepository{
+		client:        client,
+		devicePrefix:  "login_devices:",
+		networkPrefix: "login_networks:",
+		alertPrefix:   "login_alert:",
+		resetPrefix:   "password_reset:",
+	}
+}
+
+// RecordLogin implements loginalert.Repository. Uma rede vazia (IP
+// desconhecido) não é registrada nem considerada nova.
+func (r *RedisLoginAlertRepository) RecordLogin(ctx context.Context, userID, device, network string, retention time.Duration) (*loginalert.Sighting, error) {
+	devicesKey := r.devicePrefix + userID
+	networksKey := r.networkPrefix + userID
+	now := time.Now()
+	cutoff := "(" + strconv.FormatInt(now.Add(-retention).Unix(), 10)
+
+	pipe := r.client.TxPipeline()
+	pipe.ZRemRangeByScore(ctx, devicesKey, "-inf", cutoff)
+	pipe.ZRemRangeByScore(ctx, networksKey, "-inf", cutoff)
+	history := pipe.Exists(ctx, devicesKey, networksKey)
+	deviceSeen := pipe.ZScore(ctx, devicesKey, device)
+	networkSeen := pipe.ZScore(ctx, networksKey, network)
+	pipe.ZAdd(ctx, devicesKey, redis.Z{Score: float64(now.Unix()), Member: device})
+	if network != "" {
+		pipe.ZAdd(ctx, networksKey, redis.Z{Score: float64(now.Unix()), Member: network})
+	}
+	pipe.Expire(ctx, devicesKey, retention)
+	pipe.Expire(ctx, networksKey, retention)
+	// ZSCORE de um membro ausente responde nil, o que não é uma falha
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to record login history in redis: %w", err)
+	}
+
+	return &loginalert.Sighting{
+		NewDevice:  deviceSeen.Err() == redis.Nil,
+		NewNetwork: network != "" && networkSeen.Err() == redis.Nil,
+		FirstLogin: history.Val() == 0,
+	}, nil
+}
+
+// KnownDevice implements loginalert.Repository. Entradas mais antigas que
+// retention ainda não removidas por RecordLogin não contam como conhecidas.
+func (r *RedisLoginAlertRepository) KnownDevice(ctx context.Context, userID, device string, retention time.Duration) (bool, bool, error) {
+	devicesKey := r.devicePrefix + userID
+
+	pipe := r.client.Pipeline()
+	seen := pipe.ZScore(ctx, devicesKey, device)
+	history := pipe.Exists(ctx, devicesKey, r.networkPrefix+userID)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return false, false, fmt.Errorf("failed to read login history from redis: %w", err)
+	}
+
+	cutoff := time.Now().Add(-retention).Unix()
+	known := seen.Err() == nil && int64(seen.Val()) >= cutoff
+	return known, history.Val() > 0, nil
+}
+
+// SaveAlert implements loginalert.Repository.
+func (r *RedisLoginAlertRepository) SaveAlert(ctx context.Context, tokenHash string, alert *loginalert.Alert, ttl time.Duration) error {
+	data, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("failed to marshal login alert: %w", err)
+	}
+
+	if err := r.client.Set(ctx, r.alertPrefix+tokenHash, data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to store login alert in redis: %w", err)
+	}
+	return nil
+}
+
+// ConsumeAlert implements loginalert.Repository. Retorna nil quando o aviso
+// não existe, já foi usado ou expirou.
+func (r *RedisLoginAlertRepository) ConsumeAlert(ctx context.Context, tokenHash string) (*loginalert.Alert, error) {
+	data, err := r.client.GetDel(ctx, r.alertPrefix+tokenHash).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to consume login alert from redis: %w", err)
+	}
+
+	var alert loginalert.Alert
+	if err := json.Unmarshal(data, &alert); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal login alert: %w", err)
+	}
+	return &alert, nil
+}
+
+// SaveReset implements loginalert.Repository.
+func (r *RedisLoginAlertRepository) SaveReset(ctx context.Context, tokenHash string, reset *loginalert.Reset, ttl time.Duration) error {
+	data, err := json.Marshal(reset)
+	if err != nil {
+		return fmt.Errorf("failed to marshal password reset: %w", err)
+	}
+
+	if err := r.client.Set(ctx, r.resetPrefix+tokenHash, data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to store password reset in redis: %w", err)
+	}
+	return nil
+}
+
+// ConsumeReset implements loginalert.Repository. Retorna nil quando a
+// redefinição não existe, já foi usada ou expirou.
+func (r *RedisLoginAlertRepository) ConsumeReset(ctx context.Context, tokenHash string) (*loginalert.Reset, error) {
+	data, err := r.client.GetDel(ctx, r.resetPrefix+tokenHash).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to consume password reset from redis: %w", err)
+	}
+
+	var reset loginalert.Reset
+	if err := json.Unmarshal(data, &reset); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal password reset: %w", err)
+	}
+	return &reset, nil
+}
@@ -0,0 +1,73 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/juanjerrah/go_auth_api/internal/domain/magiclink"
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisMagicLinkRepository guarda os links de login pendentes e os contadores
+// de envio por endereço, ambos com expiração
+type RedisMagicLinkRepository struct {
+	client     *redis.Client
+	linkPrefix string
+	ratePrefix string
+}
+
+func NewMagicLinkRepository(client *redis.Client) magiclink.Repository {
+	return &RedisMagicLinkRepository{
+		client:     client,
+		linkPrefix: "magic_link:",
+		ratePrefix: "magic_link_rate:",
+	}
+}
+
+// Save implements magiclink.Repository.
+func (r *RedisMagicLinkRepository) Save(ctx context.Context, tokenHash string, link *magiclink.Link, ttl time.Duration) error {
+	data, err := json.Marshal(link)
+	if err != nil {
+		return fmt.Errorf("failed to marshal magic link: %w", err)
+	}
+
+	if err := r.client.Set(ctx, r.linkPrefix+tokenHash, data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to store magic link in redis: %w", err)
+	}
+	return nil
+}
+
+// Consume implements magiclink.Repository. Retorna nil quando o link não
+// existe, já foi usado ou expirou.
+func (r *RedisMagicLinkRepository) Consume(ctx context.Context, tokenHash string) (*magiclink.Link, error) {
+	// GETDEL lê e remove numa única operação, impedindo o uso concorrente do mesmo link
+	data, err := r.client.GetDel(ctx, r.linkPrefix+tokenHash).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to consume magic link from redis: %w", err)
+	}
+
+	var link magiclink.Link
+	if err := json.Unmarshal(data, &link); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal magic link: %w", err)
+	}
+	return &link, nil
+}
+
+// CountRequest implements magiclink.Repository. A janela é fixa: começa no
+// primeiro pedido e expira junto com o contador.
+func (r *RedisMagicLinkRepository) CountRequest(ctx context.Context, email string, window time.Duration) (int64, error) {
+	key := r.ratePrefix + email
+
+	pipe := r.client.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.ExpireNX(ctx, key, window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("failed to count magic link requests in redis: %w", err)
+	}
+	return incr.Val(), nil
+}
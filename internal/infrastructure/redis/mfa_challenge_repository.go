@@ -0,0 +1,72 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/juanjerrah/go_auth_api/internal/domain/mfa"
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisMFAChallengeRepository guarda os logins que aguardam o segundo fator
+// com expiração
+type RedisMFAChallengeRepository struct {
+	client *redis.Client
+	prefix string
+}
+
+func NewMFAChallengeRepository(client *redis.Client) mfa.ChallengeRepository {
+	return &RedisMFAChallengeRepository{
+		client: client,
+		prefix: "mfa_challenge:",
+	}
+}
+
+// Save implements mfa.ChallengeRepository.
+func (r *RedisMFAChallengeRepository) Save(ctx context.Context, tokenHash string, challenge *mfa.Challenge, ttl time.Duration) error {
+	data, err := json.Marshal(challenge)
+	if err != nil {
+		return fmt.Errorf("failed to marshal mfa challenge: %w", err)
+	}
+
+	if err := r.client.Set(ctx, r.prefix+tokenHash, data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to store mfa challenge in redis: %w", err)
+	}
+	return nil
+}
+
+// Find implements mfa.ChallengeRepository. Retorna nil quando o desafio não
+// existe ou já expirou.
+func (r *RedisMFAChallengeRepository) Find(ctx context.Context, tokenHash string) (*mfa.Challenge, error) {
+	data, err := r.client.Get(ctx, r.prefix+tokenHash).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get mfa challenge from redis: %w", err)
+	}
+	return r.decode(data)
+}
+
+// Consume implements mfa.ChallengeRepository.
+func (r *RedisMFAChallengeRepository) Consume(ctx context.Context, tokenHash string) (*mfa.Challenge, error) {
+	// GETDEL garante que apenas uma requisição conclua o desafio
+	data, err := r.client.GetDel(ctx, r.prefix+tokenHash).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to consume mfa challenge from redis: %w", err)
+	}
+	return r.decode(data)
+}
+
+func (r *RedisMFAChallengeRepository) decode(data []byte) (*mfa.Challenge, error) {
+	var challenge mfa.Challenge
+	if err := json.Unmarshal(data, &challenge); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal mfa challenge: %w", err)
+	}
+	return &challenge, nil
+}
@@ -0,0 +1,56 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/juanjerrah/go_auth_api/internal/domain/oidc"
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisOIDCStateRepository guarda os logins OIDC em andamento com expiração
+type RedisOIDCStateRepository struct {
+	client *redis.Client
+	prefix string
+}
+
+func NewOIDCStateRepository(client *redis.Client) oidc.StateRepository {
+	return &RedisOIDCStateRepository{
+		client: client,
+		prefix: "oidc_state:",
+	}
+}
+
+// Save implements oidc.StateRepository.
+func (r *RedisOIDCStateRepository) Save(ctx context.Context, request *oidc.AuthRequest, ttl time.Duration) error {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("failed to marshal oidc state: %w", err)
+	}
+
+	if err := r.client.Set(ctx, r.prefix+request.State, data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to store oidc state in redis: %w", err)
+	}
+	return nil
+}
+
+// Consume implements oidc.StateRepository. Retorna nil quando o state não
+// existe ou já expirou.
+func (r *RedisOIDCStateRepository) Consume(ctx context.Context, state string) (*oidc.AuthRequest, error) {
+	// GETDEL lê e remove numa única operação, impedindo o uso concorrente do mesmo state
+	data, err := r.client.GetDel(ctx, r.prefix+state).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to consume oidc state from redis: %w", err)
+	}
+
+	var request oidc.AuthRequest
+	if err := json.Unmarshal(data, &request); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal oidc state: %w", err)
+	}
+	return &request, nil
+}
@@ -0,0 +1,103 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/juanjerrah/go_auth_api/internal/domain/otp"
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisOTPRepository guarda os códigos de uso único pendentes, as tentativas
+// de verificação de cada um e os contadores de envio por usuário
+type RedisOTPRepository struct {
+	client        *redis.Client
+	codePrefix    string
+	attemptPrefix string
+	ratePrefix    string
+}
+
+func NewOTPRepository(client *redis.Client) otp.Repository {
+	return &RedisOTPRepository{
+		client:        client,
+		codePrefix:    "otp:",
+		attemptPrefix: "otp_attempts:",
+		ratePrefix:    "otp_rate:",
+	}
+}
+
+// Save implements otp.Repository.
+func (r *RedisOTPRepository) Save(ctx context.Context, key string, code *otp.Code, ttl time.Duration) error {
+	data, err := json.Marshal(code)
+	if err != nil {
+		return fmt.Errorf("failed to marshal otp code: %w", err)
+	}
+
+	// O novo código substitui o anterior e começa sem tentativas
+	pipe := r.client.TxPipeline()
+	pipe.Set(ctx, r.codePrefix+key, data, ttl)
+	pipe.Del(ctx, r.attemptPrefix+key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to store otp code in redis: %w", err)
+	}
+	return nil
+}
+
+// Find implements otp.Repository. Retorna nil quando o código não existe ou
+// já expirou.
+func (r *RedisOTPRepository) Find(ctx context.Context, key string) (*otp.Code, error) {
+	data, err := r.client.Get(ctx, r.codePrefix+key).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get otp code from redis: %w", err)
+	}
+
+	var code otp.Code
+	if err := json.Unmarshal(data, &code); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal otp code: %w", err)
+	}
+	return &code, nil
+}
+
+// CountAttempt implements otp.Repository. O contador expira junto com o
+// código a que se refere.
+func (r *RedisOTPRepository) CountAttempt(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	attemptKey := r.attemptPrefix + key
+
+	pipe := r.client.TxPipeline()
+	incr := pipe.Incr(ctx, attemptKey)
+	pipe.ExpireNX(ctx, attemptKey, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("failed to count otp attempts in redis: %w", err)
+	}
+	return incr.Val(), nil
+}
+
+// Delete implements otp.Repository.
+func (r *RedisOTPRepository) Delete(ctx context.Context, key string) (bool, error) {
+	pipe := r.client.TxPipeline()
+	deleted := pipe.Del(ctx, r.codePrefix+key)
+	pipe.Del(ctx, r.attemptPrefix+key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, fmt.Errorf("failed to delete otp code from redis: %w", err)
+	}
+	return deleted.Val() == 1, nil
+}
+
+// CountSend implements otp.Repository. A janela é fixa: começa no primeiro
+// envio e expira junto com o contador.
+func (r *RedisOTPRepository) CountSend(ctx context.Context, userID string, window time.Duration) (int64, error) {
+	key := r.ratePrefix + userID
+
+	pipe := r.client.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.ExpireNX(ctx, key, window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("failed to count otp requests in redis: %w", err)
+	}
+	return incr.Val(), nil
+}
@@ -0,0 +1,84 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/juanjerrah/go_auth_api/internal/domain/auth"
+	"github.com/juanjerrah/go_auth_api/pkg/types"
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisPermissionCache guarda as permissões efetivas de cada usuário num hash
+// indexado pela role, já que a mesma conta pode ter a role alterada.
+type RedisPermissionCache struct {
+	client *redis.Client
+	prefix string
+	ttl    time.Duration
+}
+
+func NewPermissionCache(client *redis.Client, ttl time.Duration) *RedisPermissionCache {
+	return &RedisPermissionCache{
+		client: client,
+		prefix: "user_permissions:",
+		ttl:    ttl,
+	}
+}
+
+var _ auth.PermissionCache = (*RedisPermissionCache)(nil)
+
+// Get implements auth.PermissionCache.
+func (r *RedisPermissionCache) Get(ctx context.Context, userID string, role types.Role) ([]types.Permission, bool, error) {
+	data, err := r.client.HGet(ctx, r.getKey(userID), string(role)).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("failed to get permissions from redis: %w", err)
+	}
+
+	var permissions []types.Permission
+	if err := json.Unmarshal(data, &permissions); err != nil {
+		return nil, false, fmt.Errorf("failed to unmarshal permissions: %w", err)
+	}
+	return permissions, true, nil
+}
+
+// Set implements auth.PermissionCache.
+func (r *RedisPermissionCache) Set(ctx context.Context, userID string, role types.Role, permissions []types.Permission) error {
+	data, err := json.Marshal(permissions)
+	if err != nil {
+		return fmt.Errorf("failed to marshal permissions: %w", err)
+	}
+
+	key := r.getKey(userID)
+	pipe := r.client.TxPipeline()
+	pipe.HSet(ctx, key, string(role), data)
+	pipe.Expire(ctx, key, r.ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to store permissions in redis: %w", err)
+	}
+	return nil
+}
+
+// Invalidate implements auth.PermissionCache.
+func (r *RedisPermissionCache) Invalidate(ctx context.Context, userIDs ...string) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(userIDs))
+	for _, userID := range userIDs {
+		keys = append(keys, r.getKey(userID))
+	}
+	if err := r.client.Del(ctx, keys...).Err(); err != nil {
+		return fmt.Errorf("failed to invalidate permissions: %w", err)
+	}
+	return nil
+}
+
+func (r *RedisPermissionCache) getKey(userID string) string {
+	return r.prefix + userID
+}
@@ -0,0 +1,80 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/juanjerrah/go_auth_api/internal/domain/risk"
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisRiskRepository guarda os contadores de falhas (por e-mail e por IP) e o
+// último login localizado de cada usuário
+type RedisRiskRepository struct {
+	client          *redis.Client
+	failurePrefix   string
+	lastLoginPrefix string
+}
+
+func NewRiskRepository(client *redis.Client) risk.Repository {
+	return &RedisRiskRepository{
+		client:          client,
+		failurePrefix:   "risk_failures:",
+		lastLoginPrefix: "risk_last_login:",
+	}
+}
+
+// CountFailure implements risk.Repository. A janela é fixa: começa na
+// primeira falha e expira junto com o contador.
+func (r *RedisRiskRepository) CountFailure(ctx context.Context, key string, window time.Duration) (int64, error) {
+	key = r.failurePrefix + key
+
+	pipe := r.client.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.ExpireNX(ctx, key, window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("failed to count login failures in redis: %w", err)
+	}
+	return incr.Val(), nil
+}
+
+func (r *RedisRiskRepository) Failures(ctx context.Context, key string) (int64, error) {
+	failures, err := r.client.Get(ctx, r.failurePrefix+key).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get login failures from redis: %w", err)
+	}
+	return failures, nil
+}
+
+func (r *RedisRiskRepository) LastLogin(ctx context.Context, userID string) (*risk.LastLogin, error) {
+	data, err := r.client.Get(ctx, r.lastLoginPrefix+userID).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last login from redis: %w", err)
+	}
+
+	var login risk.LastLogin
+	if err := json.Unmarshal([]byte(data), &login); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal last login: %w", err)
+	}
+	return &login, nil
+}
+
+func (r *RedisRiskRepository) SaveLastLogin(ctx context.Context, userID string, login *risk.LastLogin, ttl time.Duration) error {
+	data, err := json.Marshal(login)
+	if err != nil {
+		return fmt.Errorf("failed to marshal last login: %w", err)
+	}
+
+	if err := r.client.Set(ctx, r.lastLoginPrefix+userID, data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to store last login in redis: %w", err)
+	}
+	return nil
+}
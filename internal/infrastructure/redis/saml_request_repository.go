@@ -0,0 +1,77 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/juanjerrah/go_auth_api/internal/domain/saml"
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisSAMLRequestRepository guarda os AuthnRequests pendentes e as asserções
+// já consumidas, ambos com expiração
+type RedisSAMLRequestRepository struct {
+	client          *redis.Client
+	requestPrefix   string
+	assertionPrefix string
+}
+
+func NewSAMLRequestRepository(client *redis.Client) saml.RequestRepository {
+	return &RedisSAMLRequestRepository{
+		client:          client,
+		requestPrefix:   "saml_request:",
+		assertionPrefix: "saml_assertion:",
+	}
+}
+
+// SaveRequest implements saml.RequestRepository.
+func (r *RedisSAMLRequestRepository) SaveRequest(ctx context.Context, requestID string, request *saml.PendingRequest, ttl time.Duration) error {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("failed to marshal saml request: %w", err)
+	}
+
+	if err := r.client.Set(ctx, r.requestPrefix+requestID, data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to store saml request in redis: %w", err)
+	}
+	return nil
+}
+
+// ConsumeRequest implements saml.RequestRepository. Pedidos gravados antes
+// guardavam apenas o nome do provedor.
+func (r *RedisSAMLRequestRepository) ConsumeRequest(ctx context.Context, requestID string) (*saml.PendingRequest, error) {
+	data, err := r.client.GetDel(ctx, r.requestPrefix+requestID).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to consume saml request from redis: %w", err)
+	}
+
+	if !strings.HasPrefix(data, "{") {
+		return &saml.PendingRequest{Provider: data}, nil
+	}
+	var request saml.PendingRequest
+	if err := json.Unmarshal([]byte(data), &request); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal saml request: %w", err)
+	}
+	return &request, nil
+}
+
+// MarkAssertionUsed implements saml.RequestRepository.
+func (r *RedisSAMLRequestRepository) MarkAssertionUsed(ctx context.Context, provider, assertionID string, expires time.Time) (bool, error) {
+	ttl := time.Until(expires)
+	if ttl <= 0 {
+		ttl = time.Second
+	}
+
+	// SETNX garante que, entre requisições concorrentes, apenas uma use a asserção
+	fresh, err := r.client.SetNX(ctx, r.assertionPrefix+provider+":"+assertionID, 1, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to record saml assertion in redis: %w", err)
+	}
+	return fresh, nil
+}
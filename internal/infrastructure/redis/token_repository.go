@@ -2,57 +2,303 @@ package redis
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"time"
 
-	"github.com/redis/go-redis/v9"
 	"github.com/juanjerrah/go_auth_api/internal/domain/auth"
+	"github.com/redis/go-redis/v9"
 )
 
+// convertUserTokensIndex abre todos os scripts: índices gravados antes do
+// sorted set são sets simples e são convertidos, com a expiração atual de cada
+// sessão como score. KEYS[2] é o índice e ARGV[1] o instante atual em ms.
+const convertUserTokensIndex = `
+local index = KEYS[2]
+local now = tonumber(ARGV[1])
+
+if redis.call('TYPE', index).ok == 'set' then
+	local members = redis.call('SMEMBERS', index)
+	redis.call('DEL', index)
+	for _, member in ipairs(members) do
+		local ttl = redis.call('PTTL', member)
+		if ttl > 0 then
+			redis.call('ZADD', index, now + ttl, member)
+		end
+	end
+end
+`
+
+// storeTokenScript grava a sessão (KEYS[1]) e a inclui no índice do usuário,
+// um sorted set com o fim absoluto de cada sessão como score. Remove do índice
+// as sessões cujo fim já passou e, havendo limite (ARGV[5]), também as que
+// expiraram por inatividade; no limite, retorna -1 ou, com ARGV[6] = 1,
+// encerra as sessões mais antigas. Com KEYS[3], a sessão nova substitui essa
+// outra, que não conta para o limite; se ela não existir mais, retorna -2.
+var storeTokenScript = redis.NewScript(convertUserTokensIndex + `
+local replaced = KEYS[3]
+if replaced and redis.call('EXISTS', replaced) == 0 then
+	return -2
+end
+
+redis.call('ZREMRANGEBYSCORE', index, '-inf', now)
+
+local max = tonumber(ARGV[5])
+if max > 0 then
+	for _, member in ipairs(redis.call('ZRANGE', index, 0, -1)) do
+		if redis.call('EXISTS', member) == 0 then
+			redis.call('ZREM', index, member)
+		end
+	end
+	local count = redis.call('ZCARD', index)
+	if replaced and redis.call('ZSCORE', index, replaced) then
+		count = count - 1
+	end
+	if count >= max then
+		if ARGV[6] ~= '1' then
+			return -1
+		end
+		local evict = count - max + 1
+		for _, member in ipairs(redis.call('ZRANGE', index, 0, -1)) do
+			if evict == 0 then
+				break
+			end
+			if member ~= replaced then
+				redis.call('DEL', member)
+				redis.call('ZREM', index, member)
+				evict = evict - 1
+			end
+		end
+	end
+end
+
+if replaced then
+	redis.call('DEL', replaced)
+	redis.call('ZREM', index, replaced)
+end
+
+redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+redis.call('ZADD', index, ARGV[4], KEYS[1])
+if redis.call('PTTL', index) < tonumber(ARGV[7]) then
+	redis.call('PEXPIRE', index, ARGV[7])
+end
+return 0
+`)
+
+// deleteTokenScript remove a sessão (KEYS[1]) e sua entrada no índice
+var deleteTokenScript = redis.NewScript(convertUserTokensIndex + `
+redis.call('DEL', KEYS[1])
+redis.call('ZREM', index, KEYS[1])
+return 0
+`)
+
+// invalidateUserTokensScript remove todas as sessões do índice do usuário e
+// das personificações que ele iniciou (KEYS[3]), além dos próprios índices;
+// KEYS[1] não é usado
+var invalidateUserTokensScript = redis.NewScript(convertUserTokensIndex + `
+local removed = 0
+for _, key in ipairs({index, KEYS[3]}) do
+	local members = redis.call('ZRANGE', key, 0, -1)
+	for _, member in ipairs(members) do
+		redis.call('DEL', member)
+	end
+	redis.call('DEL', key)
+	removed = removed + #members
+end
+return removed
+`)
+
+// pruneUserTokensScript remove do índice as sessões cujo fim já passou e as
+// que expiraram por inatividade, retornando quantas removeu; KEYS[1] não é
+// usado
+var pruneUserTokensScript = redis.NewScript(convertUserTokensIndex + `
+local removed = redis.call('ZREMRANGEBYSCORE', index, '-inf', now)
+for _, member in ipairs(redis.call('ZRANGE', index, 0, -1)) do
+	if redis.call('EXISTS', member) == 0 then
+		redis.call('ZREM', index, member)
+		removed = removed + 1
+	end
+end
+return removed
+`)
 
+// reindexTokenScript inclui no índice uma sessão existente (KEYS[1]) que está
+// fora dele, retornando 1 quando a incluiu. ARGV[2] é o fim absoluto da
+// sessão, ou 0 para usar a expiração atual.
+var reindexTokenScript = redis.NewScript(convertUserTokensIndex + `
+local ttl = redis.call('PTTL', KEYS[1])
+if ttl <= 0 then
+	return 0
+end
+local score = tonumber(ARGV[2])
+if score == 0 then
+	score = now + ttl
+end
+if redis.call('ZADD', index, 'NX', score, KEYS[1]) == 0 then
+	return 0
+end
+local indexTTL = score - now + tonumber(ARGV[3])
+if redis.call('PTTL', index) < indexTTL then
+	redis.call('PEXPIRE', index, indexTTL)
+end
+return 1
+`)
 
+// migrateSessionKeyScript renomeia a sessão gravada com o token na chave
+// (KEYS[1]) para a chave pelo ID da sessão (KEYS[3]), mantendo o TTL e a
+// posição no índice. Retorna 1 quando renomeou.
+var migrateSessionKeyScript = redis.NewScript(convertUserTokensIndex + `
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return 0
+end
+redis.call('RENAME', KEYS[1], KEYS[3])
+local score = redis.call('ZSCORE', index, KEYS[1])
+if score then
+	redis.call('ZREM', index, KEYS[1])
+	redis.call('ZADD', index, score, KEYS[3])
+end
+return 1
+`)
+
+// touchTokenScript troca apenas last_active_at da sessão (KEYS[1]) e renova seu
+// TTL, para não sobrescrever o que outra requisição (como a reautenticação)
+// gravou nesse meio-tempo. Nenhum outro valor serializado contém a sequência
+// "last_active_at":" sem escapes. Retorna 0 se a sessão não existe mais.
+var touchTokenScript = redis.NewScript(`
+local data = redis.call('GET', KEYS[1])
+if not data then
+	return 0
+end
+data = string.gsub(data, '"last_active_at":"[^"]*"', '"last_active_at":"' .. ARGV[1] .. '"', 1)
+redis.call('SET', KEYS[1], data, 'PX', ARGV[2])
+return 1
+`)
+
+// RedisTokenRepository guarda cada sessão em token:<id>, em que o ID é um
+// HMAC do token: quem lê o Redis não obtém tokens utilizáveis. Mantém por
+// usuário o índice user_tokens:<id do usuário>; sessões de personificação
+// ficam em impersonation_tokens:<id do admin>, fora do índice do usuário
+// personificado, para não contarem no seu limite de sessões nem serem
+// encerradas por ele. Toda alteração que envolve a sessão e o índice é feita
+// em um script, de forma atômica.
 type RedisTokenRepository struct {
-	client *redis.Client
-	prefix string
+	client                   *redis.Client
+	secret                   []byte
+	prefix                   string
+	indexPrefix              string
+	impersonationIndexPrefix string
+	// indexGrace mantém o índice além do fim da sessão mais longa, para que
+	// o janitor ainda encontre as entradas
+	indexGrace time.Duration
 }
 
-func NewTokenRepository(client *redis.Client) auth.TokenRepository {
+// NewTokenRepository usa secret para derivar o ID das sessões; trocá-lo
+// torna inacessíveis as sessões existentes
+func NewTokenRepository(client *redis.Client, secret string) auth.TokenRepository {
 	return &RedisTokenRepository{
-		client: client,
-		prefix: "token:",
+		client:                   client,
+		secret:                   []byte(secret),
+		prefix:                   "token:",
+		indexPrefix:              "user_tokens:",
+		impersonationIndexPrefix: "impersonation_tokens:",
+		indexGrace:               24 * time.Hour,
 	}
 }
 
-func (r *RedisTokenRepository) StoreToken(ctx context.Context, token string, authCtx *auth.AuthContext, expiration time.Duration) error {
-	key := r.getKey(token)
-	
+// StoreToken implements auth.TokenRepository. A sessão, o índice do usuário e
+// o limite de sessões são tratados em um único script, para que logins
+// simultâneos não ultrapassem o limite.
+func (r *RedisTokenRepository) StoreToken(ctx context.Context, token string, authCtx *auth.AuthContext, expiration time.Duration, limit auth.SessionLimit) error {
+	return r.storeToken(ctx, []string{r.getKey(token), r.getIndexKey(authCtx)}, authCtx, expiration, limit)
+}
+
+// ReplaceToken implements auth.TokenRepository. O script de StoreToken remove
+// a sessão antiga junto com a gravação da nova.
+func (r *RedisTokenRepository) ReplaceToken(ctx context.Context, oldToken, token string, authCtx *auth.AuthContext, expiration time.Duration, limit auth.SessionLimit) error {
+	return r.storeToken(ctx, []string{r.getKey(token), r.getIndexKey(authCtx), r.getKey(oldToken)}, authCtx, expiration, limit)
+}
+
+func (r *RedisTokenRepository) storeToken(ctx context.Context, keys []string, authCtx *auth.AuthContext, expiration time.Duration, limit auth.SessionLimit) error {
 	authCtxBytes, err := json.Marshal(authCtx)
 	if err != nil {
 		return fmt.Errorf("failed to marshal auth context: %w", err)
 	}
 
-	err = r.client.Set(ctx, key, authCtxBytes, expiration).Err()
+	// O índice é ordenado pelo fim absoluto da sessão, que segue a ordem dos
+	// logins; sessões sem fim absoluto usam a expiração atual
+	now := time.Now()
+	expiresAt := authCtx.ExpiresAt
+	if expiresAt.IsZero() {
+		expiresAt = now.Add(expiration)
+	}
+	indexExpiration := expiresAt.Sub(now) + r.indexGrace
+
+	evictOldest := 0
+	if limit.EvictOldest {
+		evictOldest = 1
+	}
+	result, err := storeTokenScript.Run(ctx, r.client, keys,
+		now.UnixMilli(), authCtxBytes, expiration.Milliseconds(), expiresAt.UnixMilli(),
+		limit.Max, evictOldest, indexExpiration.Milliseconds(),
+	).Int()
 	if err != nil {
 		return fmt.Errorf("failed to store token in redis: %w", err)
 	}
+	switch result {
+	case -1:
+		return auth.ErrTooManySessions
+	case -2:
+		return auth.ErrSessionNotFound
+	}
 
-	// Também armazenar relação user -> tokens para invalidação em massa
-	userTokensKey := r.getUserTokensKey(authCtx.UserID)
-	err = r.client.SAdd(ctx, userTokensKey, key).Err()
+	return nil
+}
+
+// UpdateToken regrava a sessão mantendo o TTL atual. A sessão precisa
+// existir; uma sessão revogada nesse meio-tempo não é recriada.
+func (r *RedisTokenRepository) UpdateToken(ctx context.Context, token string, authCtx *auth.AuthContext) error {
+	authCtxBytes, err := json.Marshal(authCtx)
 	if err != nil {
-		return fmt.Errorf("failed to store user token relation: %w", err)
+		return fmt.Errorf("failed to marshal auth context: %w", err)
 	}
 
-	// Definir expiração para o conjunto de tokens do usuário
-	r.client.Expire(ctx, userTokensKey, expiration+time.Hour*24)
+	err = r.client.SetArgs(ctx, r.getKey(token), authCtxBytes, redis.SetArgs{Mode: "XX", KeepTTL: true}).Err()
+	if err != nil {
+		if err == redis.Nil {
+			return fmt.Errorf("token not found")
+		}
+		return fmt.Errorf("failed to update token in redis: %w", err)
+	}
+
+	return nil
+}
+
+// TouchToken implements auth.TokenRepository. Como em UpdateToken, uma sessão
+// revogada nesse meio-tempo não é recriada. O score no índice é o fim absoluto
+// da sessão e não muda.
+func (r *RedisTokenRepository) TouchToken(ctx context.Context, token string, lastActiveAt time.Time, expiration time.Duration) error {
+	touched, err := touchTokenScript.Run(ctx, r.client,
+		[]string{r.getKey(token)},
+		lastActiveAt.Format(time.RFC3339Nano), expiration.Milliseconds(),
+	).Int()
+	if err != nil {
+		return fmt.Errorf("failed to touch token in redis: %w", err)
+	}
+	if touched == 0 {
+		return fmt.Errorf("token not found")
+	}
 
 	return nil
 }
 
 func (r *RedisTokenRepository) GetToken(ctx context.Context, token string) (*auth.AuthContext, error) {
-	key := r.getKey(token)
-	
+	return r.getSession(ctx, r.getKey(token))
+}
+
+func (r *RedisTokenRepository) getSession(ctx context.Context, key string) (*auth.AuthContext, error) {
 	data, err := r.client.Get(ctx, key).Bytes()
 	if err != nil {
 		if err == redis.Nil {
@@ -71,18 +317,23 @@ func (r *RedisTokenRepository) GetToken(ctx context.Context, token string) (*aut
 }
 
 func (r *RedisTokenRepository) DeleteToken(ctx context.Context, token string) error {
-	key := r.getKey(token)
-	
-	// Primeiro obter o auth context para remover da lista de tokens do usuário
-	authCtx, err := r.GetToken(ctx, token)
+	return r.DeleteSession(ctx, r.SessionID(token))
+}
+
+// DeleteSession implements auth.TokenRepository.
+func (r *RedisTokenRepository) DeleteSession(ctx context.Context, sessionID string) error {
+	key := r.prefix + sessionID
+
+	// Primeiro obter o auth context para localizar o índice da sessão
+	authCtx, err := r.getSession(ctx, key)
 	if err != nil {
 		return err
 	}
 
-	userTokensKey := r.getUserTokensKey(authCtx.UserID)
-	r.client.SRem(ctx, userTokensKey, key)
-
-	err = r.client.Del(ctx, key).Err()
+	err = deleteTokenScript.Run(ctx, r.client,
+		[]string{key, r.getIndexKey(authCtx)},
+		time.Now().UnixMilli(),
+	).Err()
 	if err != nil {
 		return fmt.Errorf("failed to delete token from redis: %w", err)
 	}
@@ -90,35 +341,25 @@ func (r *RedisTokenRepository) DeleteToken(ctx context.Context, token string) er
 	return nil
 }
 
+// InvalidateUserTokens implements auth.TokenRepository. Encerra também as
+// personificações iniciadas pelo usuário: um admin desativado ou rebaixado
+// não continua agindo como outro usuário.
 func (r *RedisTokenRepository) InvalidateUserTokens(ctx context.Context, userID string) error {
-	userTokensKey := r.getUserTokensKey(userID)
-	
-	tokens, err := r.client.SMembers(ctx, userTokensKey).Result()
-	if err != nil {
-		return fmt.Errorf("failed to get user tokens: %w", err)
-	}
-
-	if len(tokens) <= 0 {
-		return nil
-	}
-	err = r.client.Del(ctx, tokens...).Err()
+	// Sessões gravadas durante a invalidação entram antes ou depois do
+	// script, nunca ficam fora do índice
+	err := invalidateUserTokensScript.Run(ctx, r.client,
+		[]string{"", r.getUserTokensKey(userID), r.impersonationIndexPrefix + userID},
+		time.Now().UnixMilli(),
+	).Err()
 	if err != nil {
 		return fmt.Errorf("failed to delete user tokens: %w", err)
 	}
 
-	// Remover o conjunto de tokens do usuário
-	err = r.client.Del(ctx, userTokensKey).Err()
-	if err != nil {
-		return fmt.Errorf("failed to delete user tokens set: %w", err)
-	}
-
 	return nil
 }
 
 func (r *RedisTokenRepository) TokenExists(ctx context.Context, token string) (bool, error) {
-	key := r.getKey(token)
-	
-	exists, err := r.client.Exists(ctx, key).Result()
+	exists, err := r.client.Exists(ctx, r.getKey(token)).Result()
 	if err != nil {
 		return false, fmt.Errorf("failed to check token existence: %w", err)
 	}
@@ -126,10 +367,127 @@ func (r *RedisTokenRepository) TokenExists(ctx context.Context, token string) (b
 	return exists > 0, nil
 }
 
+// ReconcileSessions implements auth.TokenRepository. Percorre os índices,
+// removendo entradas de sessões que não existem mais, e depois as sessões,
+// incluindo no índice do usuário as que estão fora dele (gravadas antes dos
+// scripts atômicos ou cujo índice expirou), para que o logout de todos os
+// dispositivos as alcance.
+func (r *RedisTokenRepository) ReconcileSessions(ctx context.Context) (int, int, error) {
+	pruned := 0
+	for _, prefix := range []string{r.indexPrefix, r.impersonationIndexPrefix} {
+		indexes := r.client.Scan(ctx, 0, prefix+"*", 100).Iterator()
+		for indexes.Next(ctx) {
+			removed, err := pruneUserTokensScript.Run(ctx, r.client,
+				[]string{"", indexes.Val()},
+				time.Now().UnixMilli(),
+			).Int()
+			if err != nil {
+				return pruned, 0, fmt.Errorf("failed to prune user tokens index: %w", err)
+			}
+			pruned += removed
+		}
+		if err := indexes.Err(); err != nil {
+			return pruned, 0, fmt.Errorf("failed to scan user tokens indexes: %w", err)
+		}
+	}
+
+	reindexed := 0
+	sessions := r.client.Scan(ctx, 0, r.prefix+"*", 100).Iterator()
+	for sessions.Next(ctx) {
+		key := sessions.Val()
+		data, err := r.client.Get(ctx, key).Bytes()
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			return pruned, reindexed, fmt.Errorf("failed to get token from redis: %w", err)
+		}
+		var authCtx auth.AuthContext
+		if err := json.Unmarshal(data, &authCtx); err != nil || authCtx.UserID == "" {
+			continue
+		}
+
+		var expiresAt int64
+		if !authCtx.ExpiresAt.IsZero() {
+			expiresAt = authCtx.ExpiresAt.UnixMilli()
+		}
+		added, err := reindexTokenScript.Run(ctx, r.client,
+			[]string{key, r.getIndexKey(&authCtx)},
+			time.Now().UnixMilli(), expiresAt, r.indexGrace.Milliseconds(),
+		).Int()
+		if err != nil {
+			return pruned, reindexed, fmt.Errorf("failed to reindex token: %w", err)
+		}
+		reindexed += added
+	}
+	if err := sessions.Err(); err != nil {
+		return pruned, reindexed, fmt.Errorf("failed to scan tokens: %w", err)
+	}
+
+	return pruned, reindexed, nil
+}
+
+// MigrateSessionKeys implements auth.TokenRepository. Sessões gravadas antes
+// do ID usavam o próprio token na chave; o ID é um hash hexadecimal e nunca
+// coincide com um JWT, que contém pontos.
+func (r *RedisTokenRepository) MigrateSessionKeys(ctx context.Context) (int, error) {
+	migrated := 0
+	sessions := r.client.Scan(ctx, 0, r.prefix+"*", 100).Iterator()
+	for sessions.Next(ctx) {
+		key := sessions.Val()
+		token := key[len(r.prefix):]
+		if isSessionID(token) {
+			continue
+		}
+
+		authCtx, err := r.getSession(ctx, key)
+		if err != nil {
+			// Expirou durante a varredura ou não é uma sessão válida
+			continue
+		}
+		renamed, err := migrateSessionKeyScript.Run(ctx, r.client,
+			[]string{key, r.getIndexKey(authCtx), r.getKey(token)},
+			time.Now().UnixMilli(),
+		).Int()
+		if err != nil {
+			return migrated, fmt.Errorf("failed to migrate session key: %w", err)
+		}
+		migrated += renamed
+	}
+	if err := sessions.Err(); err != nil {
+		return migrated, fmt.Errorf("failed to scan tokens: %w", err)
+	}
+
+	return migrated, nil
+}
+
+// SessionID implements auth.TokenRepository.
+func (r *RedisTokenRepository) SessionID(token string) string {
+	mac := hmac.New(sha256.New, r.secret)
+	mac.Write([]byte("session." + token))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func isSessionID(value string) bool {
+	if len(value) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(value)
+	return err == nil
+}
+
 func (r *RedisTokenRepository) getKey(token string) string {
-	return r.prefix + token
+	return r.prefix + r.SessionID(token)
 }
 
 func (r *RedisTokenRepository) getUserTokensKey(userID string) string {
-	return "user_tokens:" + userID
-}
\ No newline at end of file
+	return r.indexPrefix + userID
+}
+
+// getIndexKey retorna o índice em que a sessão é registrada
+func (r *RedisTokenRepository) getIndexKey(authCtx *auth.AuthContext) string {
+	if authCtx.IsImpersonated() {
+		return r.impersonationIndexPrefix + authCtx.ImpersonatorID
+	}
+	return r.getUserTokensKey(authCtx.UserID)
+}
@@ -0,0 +1,57 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/juanjerrah/go_auth_api/internal/domain/webauthn"
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisWebAuthnChallengeRepository guarda as cerimônias WebAuthn em andamento
+// com expiração
+type RedisWebAuthnChallengeRepository struct {
+	client *redis.Client
+	prefix string
+}
+
+func NewWebAuthnChallengeRepository(client *redis.Client) webauthn.ChallengeRepository {
+	return &RedisWebAuthnChallengeRepository{
+		client: client,
+		prefix: "webauthn_challenge:",
+	}
+}
+
+// Save implements webauthn.ChallengeRepository.
+func (r *RedisWebAuthnChallengeRepository) Save(ctx context.Context, ceremony *webauthn.Ceremony, ttl time.Duration) error {
+	data, err := json.Marshal(ceremony)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webauthn challenge: %w", err)
+	}
+
+	if err := r.client.Set(ctx, r.prefix+ceremony.Challenge, data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to store webauthn challenge in redis: %w", err)
+	}
+	return nil
+}
+
+// Consume implements webauthn.ChallengeRepository. Retorna nil quando o
+// desafio não existe, já foi usado ou expirou.
+func (r *RedisWebAuthnChallengeRepository) Consume(ctx context.Context, challenge string) (*webauthn.Ceremony, error) {
+	// GETDEL lê e remove numa única operação, impedindo o uso concorrente do mesmo desafio
+	data, err := r.client.GetDel(ctx, r.prefix+challenge).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to consume webauthn challenge from redis: %w", err)
+	}
+
+	var ceremony webauthn.Ceremony
+	if err := json.Unmarshal(data, &ceremony); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal webauthn challenge: %w", err)
+	}
+	return &ceremony, nil
+}
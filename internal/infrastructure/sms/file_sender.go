@@ -0,0 +1,49 @@
+package sms
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/juanjerrah/go_auth_api/pkg/common"
+)
+
+// FileSender acrescenta cada mensagem, em uma linha JSON, ao arquivo
+// configurado. Permite que testes de ponta a ponta leiam os códigos enviados.
+type FileSender struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileSender(path string) common.SMSSender {
+	return &FileSender{path: path}
+}
+
+// Send implements common.SMSSender.
+func (s *FileSender) Send(ctx context.Context, message *common.SMSMessage) error {
+	line, err := json.Marshal(map[string]string{
+		"to":      message.To,
+		"body":    message.Body,
+		"sent_at": time.Now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal sms: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open sms file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write sms file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package sms
+
+import (
+	"context"
+	"log"
+
+	"github.com/juanjerrah/go_auth_api/pkg/common"
+)
+
+// LogSender escreve as mensagens no log em vez de enviá-las. Útil em
+// desenvolvimento, onde não há provedor de SMS contratado.
+type LogSender struct{}
+
+func NewLogSender() common.SMSSender {
+	return &LogSender{}
+}
+
+// Send implements common.SMSSender.
+func (s *LogSender) Send(ctx context.Context, message *common.SMSMessage) error {
+	log.Printf("SMS to=%s\n%s", message.To, message.Body)
+	return nil
+}
@@ -0,0 +1,24 @@
+package sms
+
+import (
+	"fmt"
+
+	"github.com/juanjerrah/go_auth_api/internal/config"
+	"github.com/juanjerrah/go_auth_api/pkg/common"
+)
+
+// NewSender escolhe a implementação de envio de SMS a partir da configuração.
+// Provedores reais implementam common.SMSSender e entram aqui como novos drivers.
+func NewSender(cfg *config.SMSConfig) (common.SMSSender, error) {
+	switch cfg.Driver {
+	case "log", "":
+		return NewLogSender(), nil
+	case "file":
+		if cfg.FilePath == "" {
+			return nil, fmt.Errorf("sms file driver requires SMS_FILE_PATH")
+		}
+		return NewFileSender(cfg.FilePath), nil
+	default:
+		return nil, fmt.Errorf("unknown sms driver: %s", cfg.Driver)
+	}
+}
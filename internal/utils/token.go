@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+
+	"github.com/juanjerrah/go_auth_api/pkg/common"
+)
+
+type secureTokenGenerator struct {
+	size int
+}
+
+func NewSecureTokenGenerator(size int) common.TokenGenerator {
+	return &secureTokenGenerator{size: size}
+}
+
+// Generate implements TokenGenerator.
+func (g *secureTokenGenerator) Generate() (string, error) {
+	bytes := make([]byte, g.size)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+// Hash implements TokenGenerator.
+func (g *secureTokenGenerator) Hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -1,6 +1,10 @@
 package common
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
 
 type PasswordHasher interface {
 	Hash(password string) (string, error)
@@ -11,3 +15,30 @@ type MongoUtils interface {
 	ToObjectID(id string) primitive.ObjectID
 	GenerateObjectID() primitive.ObjectID
 }
+
+type EmailMessage struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(ctx context.Context, message *EmailMessage) error
+}
+
+type SMSMessage struct {
+	To   string
+	Body string
+}
+
+// SMSSender entrega mensagens de texto. To é um número no formato E.164.
+type SMSSender interface {
+	Send(ctx context.Context, message *SMSMessage) error
+}
+
+// TokenGenerator gera tokens opacos de uso único e o hash que deve ser
+// persistido no lugar do valor original.
+type TokenGenerator interface {
+	Generate() (string, error)
+	Hash(token string) string
+}
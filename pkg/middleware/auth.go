@@ -1,28 +1,38 @@
 package middleware
 
 import (
+	"log"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/juanjerrah/go_auth_api/internal/domain/audit"
 	"github.com/juanjerrah/go_auth_api/internal/domain/auth"
 	"github.com/juanjerrah/go_auth_api/internal/domain/user"
 )
 
-func AuthMiddleware(jwtManager *auth.JWTManager, authService auth.AuthService) gin.HandlerFunc {
+// AuthMiddleware autentica pelo cabeçalho Authorization ou, no modo de sessão
+// por cookie, pelo cookie de sessão. O cabeçalho tem precedência; sessões
+// lidas do cookie exigem o token CSRF nas requisições que alteram estado.
+func AuthMiddleware(jwtManager *auth.JWTManager, authService auth.AuthService, cookies *SessionCookies) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
+		tokenString := cookies.Token(c)
+		fromCookie := authHeader == "" && tokenString != ""
+		if authHeader == "" && !fromCookie {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
 			c.Abort()
 			return
 		}
 
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-		if tokenString == authHeader {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Bearer token required"})
-			c.Abort()
-			return
+		if !fromCookie {
+			tokenString = strings.TrimPrefix(authHeader, "Bearer ")
+			if tokenString == authHeader {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Bearer token required"})
+				c.Abort()
+				return
+			}
 		}
 
 		// Verificar assinatura do token
@@ -32,6 +42,14 @@ func AuthMiddleware(jwtManager *auth.JWTManager, authService auth.AuthService) g
 			return
 		}
 
+		// O navegador envia o cookie em requisições de outros sites; só o
+		// front-end da aplicação conhece o token CSRF
+		if fromCookie && !cookies.ValidCSRF(c, tokenString) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Invalid CSRF token"})
+			c.Abort()
+			return
+		}
+
 		// Validar token no Redis
 		authCtx, err := authService.ValidateToken(c.Request.Context(), tokenString)
 		if err != nil {
@@ -40,8 +58,27 @@ func AuthMiddleware(jwtManager *auth.JWTManager, authService auth.AuthService) g
 			return
 		}
 
+		// Registrar a atividade antes de alterar o contexto, estendendo o prazo
+		// de inatividade; uma falha aqui não impede a requisição
+		if err := authService.TouchToken(c.Request.Context(), tokenString, authCtx); err != nil {
+			log.Printf("Warning: failed to touch session of user %s: %v", authCtx.UserID, err)
+		}
+
+		// Recalcular as permissões efetivas (cacheadas) para que alterações em
+		// grupos valham sem exigir novo login
+		if permissions, err := authService.GetUserPermissions(c.Request.Context(), authCtx.UserID, authCtx.Role); err == nil {
+			authCtx.Permissions = permissions
+		}
+
 		// Adicionar informações de autenticação ao contexto
 		c.Set("authContext", authCtx)
+		c.Set("jwtToken", tokenString)
+
+		ctx := audit.WithActor(c.Request.Context(), authCtx.UserID)
+		if authCtx.IsImpersonated() {
+			ctx = audit.WithImpersonator(ctx, authCtx.ImpersonatorID)
+		}
+		c.Request = c.Request.WithContext(ctx)
 
 		c.Next()
 	}
@@ -77,7 +114,7 @@ func PermissionMiddleware(requiredPermission auth.Permission) gin.HandlerFunc {
 		}
 
 		authCtx := authContext.(*auth.AuthContext)
-		if !auth.HasPermission(authCtx.Role, requiredPermission) {
+		if !authCtx.HasPermission(requiredPermission) {
 			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
 			c.Abort()
 			return
@@ -86,3 +123,58 @@ func PermissionMiddleware(requiredPermission auth.Permission) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// DenyImpersonation bloqueia operações sensíveis quando a sessão pertence a um
+// admin personificando outro usuário.
+func DenyImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authContext, exists := c.Get("authContext")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+			c.Abort()
+			return
+		}
+
+		authCtx := authContext.(*auth.AuthContext)
+		if authCtx.IsImpersonated() {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Operation not allowed during impersonation"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireRecentAuth exige que o usuário tenha provado sua identidade na
+// sessão há no máximo maxAge e, se methods for informado, com ao menos um
+// desses métodos (valores amr). Caso contrário responde 403 com
+// reauthentication_required, indicando que o cliente deve chamar
+// /auth/reauthenticate e repetir a requisição.
+func RequireRecentAuth(maxAge time.Duration, methods ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authContext, exists := c.Get("authContext")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+			c.Abort()
+			return
+		}
+
+		authCtx := authContext.(*auth.AuthContext)
+		if !authCtx.AuthenticatedWithin(maxAge) || (len(methods) > 0 && !authCtx.AuthenticatedWith(methods...)) {
+			response := gin.H{
+				"error":                     "Recent authentication required",
+				"reauthentication_required": true,
+				"max_age":                   int(maxAge.Seconds()),
+			}
+			if len(methods) > 0 {
+				response["methods"] = methods
+			}
+			c.JSON(http.StatusForbidden, response)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/juanjerrah/go_auth_api/internal/domain/auth"
+	"github.com/juanjerrah/go_auth_api/internal/domain/policy"
+)
+
+// ResourceResolver monta os atributos do recurso alvo da requisição
+type ResourceResolver func(c *gin.Context) (policy.Attributes, error)
+
+// PolicyMiddleware consulta o motor de políticas para a ação informada. Sem
+// resolver, o recurso é descrito apenas pelo seu tipo.
+func PolicyMiddleware(policyService policy.Service, action, resourceType string, resolve ResourceResolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authContext, exists := c.Get("authContext")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+			c.Abort()
+			return
+		}
+
+		resource := policy.Attributes{}
+		if resolve != nil {
+			attrs, err := resolve(c)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to evaluate authorization policies"})
+				c.Abort()
+				return
+			}
+			resource = attrs
+		}
+		resource["type"] = resourceType
+
+		authCtx := authContext.(*auth.AuthContext)
+		decision, err := policyService.Evaluate(c.Request.Context(), &policy.Request{
+			Subject:  policy.SubjectFromAuthContext(authCtx),
+			Action:   action,
+			Resource: resource,
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to evaluate authorization policies"})
+			c.Abort()
+			return
+		}
+
+		if !decision.Allowed {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+			c.Abort()
+			return
+		}
+
+		// Os handlers conferem a ação autorizada e negam quando a rota não
+		// passou pelo motor de políticas
+		c.Set("policyAction", action)
+		c.Next()
+	}
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/juanjerrah/go_auth_api/internal/domain/audit"
+	"github.com/juanjerrah/go_auth_api/internal/domain/scim"
+)
+
+// SCIMActorID identifica o cliente de provisionamento nos eventos de auditoria
+const SCIMActorID = "scim"
+
+// SCIMAuthMiddleware autentica o cliente de provisionamento por um dos bearer
+// tokens configurados. Sem tokens configurados, todas as requisições são
+// recusadas.
+func SCIMAuthMiddleware(tokens []string) gin.HandlerFunc {
+	digests := make([][32]byte, 0, len(tokens))
+	for _, token := range tokens {
+		digests = append(digests, sha256.Sum256([]byte(token)))
+	}
+
+	return func(c *gin.Context) {
+		tokenString := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if tokenString == "" || tokenString == c.GetHeader("Authorization") {
+			abortSCIM(c, http.StatusUnauthorized, "Bearer token required")
+			return
+		}
+
+		// Comparar os hashes em tempo constante evita vazar o token por timing
+		digest := sha256.Sum256([]byte(tokenString))
+		authorized := false
+		for _, expected := range digests {
+			if subtle.ConstantTimeCompare(digest[:], expected[:]) == 1 {
+				authorized = true
+			}
+		}
+		if !authorized {
+			abortSCIM(c, http.StatusUnauthorized, "Invalid provisioning token")
+			return
+		}
+
+		c.Request = c.Request.WithContext(audit.WithActor(c.Request.Context(), SCIMActorID))
+
+		c.Next()
+	}
+}
+
+func abortSCIM(c *gin.Context, status int, detail string) {
+	c.Header("Content-Type", "application/scim+json")
+	c.AbortWithStatusJSON(status, scim.NewError(status, "", detail))
+}
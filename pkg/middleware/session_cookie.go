@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CSRFHeader é o cabeçalho em que o front-end devolve o token CSRF
+const CSRFHeader = "X-CSRF-Token"
+
+// SessionCookies implementa o modo de sessão por cookie: o token fica em um
+// cookie HttpOnly, fora do alcance do JavaScript, e as requisições que alteram
+// estado precisam repetir no cabeçalho X-CSRF-Token o valor do cookie CSRF
+// (double submit). O token CSRF é um HMAC do token de sessão, então não
+// precisa ser guardado e não serve para outra sessão. Um *SessionCookies nil
+// desativa o modo.
+type SessionCookies struct {
+	name     string
+	csrfName string
+	domain   string
+	secure   bool
+	sameSite http.SameSite
+	secret   []byte
+}
+
+// NewSessionCookies configura os cookies de sessão. O cookie CSRF usa o nome
+// da sessão com o sufixo _csrf.
+func NewSessionCookies(name, domain string, secure bool, sameSite http.SameSite, secret string) *SessionCookies {
+	return &SessionCookies{
+		name:     name,
+		csrfName: name + "_csrf",
+		domain:   domain,
+		secure:   secure,
+		sameSite: sameSite,
+		secret:   []byte(secret),
+	}
+}
+
+// ParseSameSite converte "strict", "lax" ou "none"; outros valores usam lax
+func ParseSameSite(value string) http.SameSite {
+	switch value {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteLaxMode
+	}
+}
+
+// Set grava o token de sessão e o token CSRF, que também segue no cabeçalho
+// X-CSRF-Token da resposta para front-ends em outro domínio
+func (s *SessionCookies) Set(c *gin.Context, token string, ttl time.Duration) {
+	if s == nil {
+		return
+	}
+	expires := time.Now().Add(ttl)
+	csrf := s.csrfToken(token)
+
+	http.SetCookie(c.Writer, s.cookie(s.name, token, expires, true))
+	http.SetCookie(c.Writer, s.cookie(s.csrfName, csrf, expires, false))
+	c.Header(CSRFHeader, csrf)
+}
+
+// Clear remove os cookies de sessão
+func (s *SessionCookies) Clear(c *gin.Context) {
+	if s == nil {
+		return
+	}
+	http.SetCookie(c.Writer, s.cookie(s.name, "", time.Unix(0, 0), true))
+	http.SetCookie(c.Writer, s.cookie(s.csrfName, "", time.Unix(0, 0), false))
+}
+
+// Token retorna o token de sessão do cookie, vazio quando ausente
+func (s *SessionCookies) Token(c *gin.Context) string {
+	if s == nil {
+		return ""
+	}
+	token, err := c.Cookie(s.name)
+	if err != nil {
+		return ""
+	}
+	return token
+}
+
+// ValidCSRF confere o cabeçalho X-CSRF-Token com o token da sessão. Métodos
+// seguros (GET, HEAD, OPTIONS) não alteram estado e dispensam a verificação.
+func (s *SessionCookies) ValidCSRF(c *gin.Context, token string) bool {
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	header := c.GetHeader(CSRFHeader)
+	return header != "" && hmac.Equal([]byte(header), []byte(s.csrfToken(token)))
+}
+
+func (s *SessionCookies) csrfToken(token string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte("csrf." + token))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (s *SessionCookies) cookie(name, value string, expires time.Time, httpOnly bool) *http.Cookie {
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		Domain:   s.domain,
+		Expires:  expires,
+		HttpOnly: httpOnly,
+		Secure:   s.secure,
+		SameSite: s.sameSite,
+	}
+	if value == "" {
+		cookie.MaxAge = -1
+	}
+	return cookie
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/juanjerrah/go_auth_api/internal/domain/auth"
+	"github.com/juanjerrah/go_auth_api/internal/domain/user"
+)
+
+// TenantMiddleware rejeita requisições cujo tenant, lido do parâmetro de rota
+// informado, difere da organização ativa na sessão. Admins globais operam a
+// plataforma e têm acesso a todas as organizações.
+func TenantMiddleware(param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authContext, exists := c.Get("authContext")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+			c.Abort()
+			return
+		}
+
+		authCtx := authContext.(*auth.AuthContext)
+		if authCtx.Role == user.RoleAdmin {
+			c.Next()
+			return
+		}
+
+		if authCtx.TenantID == "" || authCtx.TenantID != c.Param(param) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Cross-tenant access denied"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// TenantRoleMiddleware exige a role informada na organização ativa da sessão.
+func TenantRoleMiddleware(requiredRole user.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authContext, exists := c.Get("authContext")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+			c.Abort()
+			return
+		}
+
+		authCtx := authContext.(*auth.AuthContext)
+		if authCtx.Role != user.RoleAdmin && authCtx.TenantRole != requiredRole {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient organization permissions"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -1,9 +1,10 @@
 package types
 
 import (
-
-	"slices"
+	"encoding/json"
 	"github.com/juanjerrah/go_auth_api/internal/domain/user"
+	"slices"
+	"time"
 )
 
 type Role = user.Role
@@ -16,6 +17,34 @@ const (
 	PermissionUserDelete Permission = "user:delete"
 	PermissionAdminRead  Permission = "admin:read"
 	PermissionAdminWrite Permission = "admin:write"
+	// Consulta e escrita de tuplas de relacionamento (/relations)
+	PermissionRelationRead  Permission = "relation:read"
+	PermissionRelationWrite Permission = "relation:write"
+	// Consulta de decisões de autorização por outros serviços (/authz)
+	PermissionAuthzCheck Permission = "authz:check"
+)
+
+// AllPermissions lista as permissões conhecidas, usadas para validar grants
+var AllPermissions = []Permission{
+	PermissionUserRead,
+	PermissionUserWrite,
+	PermissionUserDelete,
+	PermissionAdminRead,
+	PermissionAdminWrite,
+	PermissionRelationRead,
+	PermissionRelationWrite,
+	PermissionAuthzCheck,
+}
+
+// Métodos de autenticação registrados na sessão (amr, RFC 8176)
+const (
+	AMRPassword    = "pwd"
+	AMROTP         = "otp"
+	AMRSMS         = "sms"
+	AMRHardwareKey = "hwk"
+	AMRMultiFactor = "mfa"
+	// AMRFederated não é registrado na RFC; indica um provedor externo
+	AMRFederated = "fed"
 )
 
 type AuthContext struct {
@@ -23,6 +52,75 @@ type AuthContext struct {
 	Email       string
 	Role        Role
 	Permissions []Permission
+	// ImpersonatorID é o admin que está personificando o usuário, se houver
+	ImpersonatorID string `json:"impersonator_id,omitempty"`
+	// TenantID é a organização ativa da sessão e TenantRole a role do
+	// usuário nela; a role global continua em Role
+	TenantID   string `json:"tenant_id,omitempty"`
+	TenantRole Role   `json:"tenant_role,omitempty"`
+	// AuthTime é o momento em que o usuário provou sua identidade pela última
+	// vez na sessão e AMR os métodos usados; ambos são renovados por
+	// /auth/reauthenticate
+	AuthTime time.Time `json:"auth_time"`
+	AMR      []string  `json:"amr,omitempty"`
+	// ExpiresAt é o fim absoluto da sessão, mantido ao renovar o token;
+	// LastActiveAt o último uso registrado e IdleTimeout o tempo sem uso
+	// após o qual a sessão expira (zero quando não há limite de inatividade)
+	ExpiresAt    time.Time     `json:"expires_at"`
+	LastActiveAt time.Time     `json:"last_active_at"`
+	IdleTimeout  time.Duration `json:"idle_timeout,omitempty"`
+}
+
+// UnmarshalJSON aceita também os nomes sem tag com que ImpersonatorID,
+// TenantID e TenantRole eram gravados, para que as sessões abertas antes
+// continuem personificadas e na mesma organização
+func (a *AuthContext) UnmarshalJSON(data []byte) error {
+	type authContext AuthContext
+	var stored struct {
+		authContext
+		LegacyImpersonatorID string `json:"ImpersonatorID"`
+		LegacyTenantID       string `json:"TenantID"`
+		LegacyTenantRole     Role   `json:"TenantRole"`
+	}
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return err
+	}
+
+	*a = AuthContext(stored.authContext)
+	if a.ImpersonatorID == "" {
+		a.ImpersonatorID = stored.LegacyImpersonatorID
+	}
+	if a.TenantID == "" {
+		a.TenantID = stored.LegacyTenantID
+		a.TenantRole = stored.LegacyTenantRole
+	}
+	return nil
+}
+
+func (a *AuthContext) IsImpersonated() bool {
+	return a.ImpersonatorID != ""
+}
+
+// AuthenticatedWithin informa se a última autenticação da sessão ocorreu há
+// no máximo maxAge. Sessões sem AuthTime nunca são recentes.
+func (a *AuthContext) AuthenticatedWithin(maxAge time.Duration) bool {
+	return !a.AuthTime.IsZero() && time.Since(a.AuthTime) <= maxAge
+}
+
+// AuthenticatedWith informa se a sessão usou ao menos um dos métodos
+func (a *AuthContext) AuthenticatedWith(methods ...string) bool {
+	for _, method := range methods {
+		if slices.Contains(a.AMR, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPermission verifica as permissões efetivas da sessão, que incluem as
+// concedidas por grupos além das da role.
+func (a *AuthContext) HasPermission(permission Permission) bool {
+	return slices.Contains(a.Permissions, permission)
 }
 
 // Mapa de permissões por role (agora em types para evitar cycle)
@@ -36,6 +134,9 @@ var RolePermissionMap = map[Role][]Permission{
 		PermissionUserDelete,
 		PermissionAdminRead,
 		PermissionAdminWrite,
+		PermissionRelationRead,
+		PermissionRelationWrite,
+		PermissionAuthzCheck,
 	},
 }
 
@@ -46,4 +147,8 @@ func HasPermission(role Role, permission Permission) bool {
 	}
 
 	return slices.Contains(permissions, permission)
-}
\ No newline at end of file
+}
+
+func IsValidPermission(permission Permission) bool {
+	return slices.Contains(AllPermissions, permission)
+}